@@ -3,6 +3,7 @@ package application
 import (
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/pagination"
 
 	uuid "github.com/satori/go.uuid"
 	"golang.org/x/net/context"
@@ -28,5 +29,9 @@ type TrackerQueryRepository interface {
 
 // SearchRepository encapsulates searching of woritems,users,etc
 type SearchRepository interface {
-	SearchFullText(ctx context.Context, searchStr string, start *int, length *int) ([]*app.WorkItem, uint64, error)
+	SearchFullText(ctx context.Context, searchStr string, currentUser *uuid.UUID, start *int, length *int) ([]*app.SearchHit, uint64, error)
+	SearchFullTextPage(ctx context.Context, searchStr string, currentUser *uuid.UUID, keyset pagination.Keyset) ([]*app.SearchHit, *pagination.Page, error)
+	SearchFacets(ctx context.Context, searchStr string, currentUser *uuid.UUID) (map[string][]*app.SearchFacetValue, error)
+	SearchDuplicates(ctx context.Context, spaceID uuid.UUID, title string, description string, limit int) ([]*app.DuplicateHit, error)
+	Reindex(ctx context.Context, workItemTypeID *uuid.UUID) (int64, error)
 }
@@ -33,3 +33,32 @@ func Transactional(db DB, todo func(f Application) error) error {
 
 	return tx.Commit()
 }
+
+// A Savepointer is a transaction which can roll back the changes made since a savepoint
+type Savepointer interface {
+	Savepoint(name string) error
+	RollbackToSavepoint(name string) error
+	ReleaseSavepoint(name string) error
+}
+
+// Savepoint executes the given function within a savepoint of the transaction appl. If todo returns an error,
+// the changes it made are rolled back and the transaction can go on. appl must be a Savepointer
+func Savepoint(appl Application, name string, todo func() error) error {
+	tx, ok := appl.(Savepointer)
+	if !ok {
+		return errors.Errorf("%T doesn't support savepoints", appl)
+	}
+	if err := tx.Savepoint(name); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := todo(); err != nil {
+		log.Debug(nil, map[string]interface{}{"savepoint": name}, "Rolling back to the savepoint...")
+		if rollbackErr := tx.RollbackToSavepoint(name); rollbackErr != nil {
+			log.Error(nil, map[string]interface{}{
+				"err": rollbackErr,
+			}, "rolling back to the savepoint failed!")
+		}
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(tx.ReleaseSavepoint(name))
+}
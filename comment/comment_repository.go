@@ -2,11 +2,14 @@ package comment
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/rendering"
+	"github.com/almighty/almighty-core/workitem"
 	"github.com/goadesign/goa"
 	"github.com/jinzhu/gorm"
 
@@ -20,6 +23,7 @@ type Repository interface {
 	Save(ctx context.Context, comment *Comment, modifier uuid.UUID) error
 	Delete(ctx context.Context, commentID uuid.UUID, suppressor uuid.UUID) error
 	List(ctx context.Context, parent string, start *int, limit *int) ([]*Comment, uint64, error)
+	ListPage(ctx context.Context, parent string, keyset pagination.Keyset) ([]*Comment, *pagination.Page, error)
 	Load(ctx context.Context, id uuid.UUID) (*Comment, error)
 	Count(ctx context.Context, parent string) (int, error)
 }
@@ -59,6 +63,7 @@ func (m *GormCommentRepository) Create(ctx context.Context, comment *Comment, cr
 	if err := m.revisionRepository.Create(ctx, creatorID, RevisionTypeCreate, *comment); err != nil {
 		return errs.WithStack(err)
 	}
+	m.notifyParentChanged(ctx, comment.ParentID)
 	log.Debug(ctx, map[string]interface{}{
 		"commentID": comment.ID,
 	}, "Comment created!")
@@ -101,6 +106,10 @@ func (m *GormCommentRepository) Save(ctx context.Context, comment *Comment, modi
 	if err := m.revisionRepository.Create(ctx, modifierID, RevisionTypeUpdate, *comment); err != nil {
 		return errs.WithStack(err)
 	}
+	m.notifyParentChanged(ctx, comment.ParentID)
+	if c.ParentID != comment.ParentID {
+		m.notifyParentChanged(ctx, c.ParentID)
+	}
 	log.Debug(ctx, map[string]interface{}{
 		"commentID": comment.ID,
 	}, "Comment updated!")
@@ -126,9 +135,18 @@ func (m *GormCommentRepository) Delete(ctx context.Context, commentID uuid.UUID,
 	if err := m.revisionRepository.Create(ctx, suppressorID, RevisionTypeDelete, c); err != nil {
 		return errs.WithStack(err)
 	}
+	m.notifyParentChanged(ctx, c.ParentID)
 	return nil
 }
 
+// notifyParentChanged notifies the work item change listeners about the work item the comments belong to, as
+// the comments are part of its searchable text. Comments of other parents are ignored.
+func (m *GormCommentRepository) notifyParentChanged(ctx context.Context, parentID string) {
+	if id, err := strconv.ParseUint(parentID, 10, 64); err == nil {
+		workitem.NotifyChanged(ctx, m.db, id)
+	}
+}
+
 // List all comments related to a single item
 func (m *GormCommentRepository) List(ctx context.Context, parent string, start *int, limit *int) ([]*Comment, uint64, error) {
 	defer goa.MeasureSince([]string{"goa", "db", "comment", "query"}, time.Now())
@@ -147,7 +165,7 @@ func (m *GormCommentRepository) List(ctx context.Context, parent string, start *
 		}
 		db = db.Limit(*limit)
 	}
-	db = db.Select("count(*) over () as cnt2 , *").Order("created_at desc")
+	db = db.Select("count(*) over () as cnt2 , *").Order(pagination.Keyset{}.OrderBy(commentKeys))
 
 	rows, err := db.Rows()
 	if err != nil {
@@ -232,3 +250,43 @@ func (m *GormCommentRepository) Load(ctx context.Context, id uuid.UUID) (*Commen
 	}
 	return &obj, nil
 }
+
+// commentKeys orders comments from the newest to the oldest
+var commentKeys = []pagination.Key{
+	{Expression: "created_at", Descending: true},
+	{Expression: "id", Descending: true},
+}
+
+// ListPage returns the comments related to a single item on the page described by the keyset,
+// newest comments first. The page also tells the total number of comments.
+func (m *GormCommentRepository) ListPage(ctx context.Context, parent string, keyset pagination.Keyset) ([]*Comment, *pagination.Page, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "comment", "query"}, time.Now())
+	if err := keyset.Validate(commentKeys); err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	var count uint64
+	if err := m.db.Model(&Comment{}).Where("parent_id = ?", parent).Count(&count).Error; err != nil {
+		return nil, nil, errors.NewInternalError(err.Error())
+	}
+	condition, parameters := keyset.Condition(commentKeys)
+	db := m.db.Model(&Comment{}).Where("parent_id = ?", parent).Where(condition, parameters...)
+	db = db.Order(keyset.OrderBy(commentKeys)).Limit(keyset.Limit + 1).Select("*, " + pagination.Columns(commentKeys))
+	rows, err := db.Rows()
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	defer rows.Close()
+	result := []*Comment{}
+	page, n, err := keyset.ScanPage(rows, commentKeys, count, func() error {
+		value := &Comment{}
+		if err := db.ScanRows(rows, value); err != nil {
+			return err
+		}
+		result = append(result, value)
+		return nil
+	}, func(i, j int) { result[i], result[j] = result[j], result[i] })
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	return result[:n], page, nil
+}
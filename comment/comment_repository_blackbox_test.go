@@ -13,6 +13,7 @@ import (
 	"github.com/almighty/almighty-core/gormsupport/cleaner"
 	"github.com/almighty/almighty-core/migration"
 	"github.com/almighty/almighty-core/models"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/resource"
 	testsupport "github.com/almighty/almighty-core/test"
@@ -221,6 +222,44 @@ func (s *TestCommentRepository) TestListCommentsWrongLimit() {
 	assert.NotNil(s.T(), err)
 }
 
+func (s *TestCommentRepository) TestListCommentsPage() {
+	// given
+	parentID := uuid.NewV4().String()
+	comments := []*comment.Comment{
+		newComment(parentID, "Test A", rendering.SystemMarkupMarkdown),
+		newComment(parentID, "Test B", rendering.SystemMarkupMarkdown),
+		newComment(parentID, "Test C", rendering.SystemMarkupMarkdown),
+	}
+	s.createComments(comments, s.testIdentity.ID)
+	// when
+	page1, page, err := s.repo.ListPage(s.ctx, parentID, pagination.Keyset{Limit: 2})
+	// then
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 2, len(page1))
+	assert.Equal(s.T(), uint64(3), page.TotalCount)
+	assert.True(s.T(), page.HasNext)
+	// when
+	page2, page, err := s.repo.ListPage(s.ctx, parentID, pagination.Keyset{Cursor: page.Last, Limit: 2})
+	// then
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 1, len(page2))
+	assert.False(s.T(), page.HasNext)
+	assert.True(s.T(), page.HasPrev)
+	// the pages hold every comment exactly once
+	ids := map[uuid.UUID]bool{}
+	for _, c := range append(page1, page2...) {
+		ids[c.ID] = true
+	}
+	assert.Len(s.T(), ids, 3)
+	// when
+	previous, _, err := s.repo.ListPage(s.ctx, parentID, pagination.Keyset{Cursor: page.First, Direction: pagination.Before, Limit: 2})
+	// then
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), 2, len(previous))
+	assert.Equal(s.T(), page1[0].ID, previous[0].ID)
+	assert.Equal(s.T(), page1[1].ID, previous[1].ID)
+}
+
 func (s *TestCommentRepository) TestLoadComment() {
 	// given
 	comment := newComment("A", "Test A", rendering.SystemMarkupMarkdown)
@@ -43,6 +43,10 @@ const (
 	varPostgresConnectionMaxIdle    = "postgres.connection.maxidle"
 	varPostgresConnectionMaxOpen    = "postgres.connection.maxopen"
 	varPopulateCommonTypes          = "populate.commontypes"
+	varSearchEngine                 = "search.engine"
+	varSearchIndexPath              = "search.index.path"
+	varSearchKnownURLs              = "search.knownurls"
+	varBulkMaxOperations            = "bulk.maxoperations"
 	varHTTPAddress                  = "http.address"
 	varDeveloperModeEnabled         = "developer.mode.enabled"
 	varGithubAuthToken              = "github.auth.token"
@@ -149,6 +153,14 @@ func (c *ConfigurationData) setConfigDefaults() {
 
 	c.v.SetDefault(varPopulateCommonTypes, true)
 
+	// Search with the full text search of the database, "embedded" keeps an index in search.index.path
+	c.v.SetDefault(varSearchEngine, "postgres")
+	c.v.SetDefault(varSearchIndexPath, "search.index")
+	c.v.SetDefault(varSearchKnownURLs, defaultSearchKnownURLs)
+
+	// The number of operations a bulk request may run in its single transaction
+	c.v.SetDefault(varBulkMaxOperations, 100)
+
 	// Auth-related defaults
 	c.v.SetDefault(varTokenPublicKey, defaultTokenPublicKey)
 	c.v.SetDefault(varTokenPrivateKey, defaultTokenPrivateKey)
@@ -233,6 +245,31 @@ func (c *ConfigurationData) GetPopulateCommonTypes() bool {
 	return c.v.GetBool(varPopulateCommonTypes)
 }
 
+// GetSearchEngine returns the name of the search engine, "postgres" or "embedded" (as set via default,
+// config file, or environment variable). The embedded engine supports a single instance of the service only.
+func (c *ConfigurationData) GetSearchEngine() string {
+	return c.v.GetString(varSearchEngine)
+}
+
+// GetSearchIndexPath returns the path of the file the embedded search engine keeps its index in (as set via
+// default, config file, or environment variable)
+func (c *ConfigurationData) GetSearchIndexPath() string {
+	return c.v.GetString(varSearchIndexPath)
+}
+
+// GetSearchKnownURLs returns the patterns of the URLs the search resolves to the work items they refer to,
+// mapped by their names (as set via default or config file). The patterns don't include the protocol and
+// have the named groups domain, path and id.
+func (c *ConfigurationData) GetSearchKnownURLs() map[string]string {
+	return c.v.GetStringMapString(varSearchKnownURLs)
+}
+
+// GetBulkMaxOperations returns the maximum number of operations of a bulk request of work items (as set via
+// default, config file, or environment variable)
+func (c *ConfigurationData) GetBulkMaxOperations() int {
+	return c.v.GetInt(varBulkMaxOperations)
+}
+
 // GetHTTPAddress returns the HTTP address (as set via default, config file, or environment variable)
 // that the alm server binds to (e.g. "0.0.0.0:8080")
 func (c *ConfigurationData) GetHTTPAddress() string {
@@ -414,6 +451,12 @@ var defaultKeycloakSecret = "08a8bcd1-f362-446a-9d2b-d34b8d464185"
 var defaultKeycloakDomainPrefix = "sso"
 var defaultKeycloakRealm = "fabric8"
 
+// The work item URLs of the demo deployment, the search resolves them to the work items they refer to
+var defaultSearchKnownURLs = map[string]string{
+	"demo-work-item-list-details":  `(?P<domain>demo.almighty.io)(?P<path>/work-item/list/detail/)(?P<id>\d*)`,
+	"demo-work-item-board-details": `(?P<domain>demo.almighty.io)(?P<path>/work-item/board/detail/)(?P<id>\d*)`,
+}
+
 // Github does not allow committing actual OAuth tokens no matter how less privilege the token has
 var camouflagedAccessToken = "751e16a8b39c0985066-AccessToken-4871777f2c13b32be8550"
 
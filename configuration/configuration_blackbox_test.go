@@ -202,3 +202,33 @@ func TestGetTokenPublicKeyFromConfigFile(t *testing.T) {
 func generateEnvKey(yamlKey string) string {
 	return "ALMIGHTY_" + strings.ToUpper(strings.Replace(yamlKey, ".", "_", -1))
 }
+
+func TestGetSearchKnownURLs(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+
+	defaults, err := configuration.NewConfigurationData(defaultValuesConfigFilePath)
+	require.Nil(t, err)
+	known := defaults.GetSearchKnownURLs()
+	require.Len(t, known, 2)
+	assert.Equal(t, `(?P<domain>demo.almighty.io)(?P<path>/work-item/list/detail/)(?P<id>\d*)`, known["demo-work-item-list-details"])
+
+	// the config file holds the same patterns
+	fromFile, err := configuration.NewConfigurationData(defaultConfigFilePath)
+	require.Nil(t, err)
+	assert.Equal(t, known, fromFile.GetSearchKnownURLs())
+}
+
+func TestGetBulkMaxOperations(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	t.Parallel()
+
+	defaults, err := configuration.NewConfigurationData(defaultValuesConfigFilePath)
+	require.Nil(t, err)
+	assert.Equal(t, 100, defaults.GetBulkMaxOperations())
+
+	// the config file holds the same maximum
+	fromFile, err := configuration.NewConfigurationData(defaultConfigFilePath)
+	require.Nil(t, err)
+	assert.Equal(t, defaults.GetBulkMaxOperations(), fromFile.GetBulkMaxOperations())
+}
@@ -73,7 +73,7 @@ func (s *CommentsSuite) unsecuredController() (*goa.Service, *CommentsController
 func (s *CommentsSuite) securedControllers(identity account.Identity) (*goa.Service, *WorkitemController, *WorkItemCommentsController, *CommentsController) {
 	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
 	svc := testsupport.ServiceAsUser("Comment-Service", almtoken.NewManagerWithPrivateKey(priv), identity)
-	workitemCtrl := NewWorkitemController(svc, s.db)
+	workitemCtrl := NewWorkitemController(svc, s.db, wibConfiguration)
 	workitemCommentsCtrl := NewWorkItemCommentsController(svc, s.db)
 	commentsCtrl := NewCommentsController(svc, s.db)
 	return svc, workitemCtrl, workitemCommentsCtrl, commentsCtrl
@@ -102,7 +102,7 @@ func (s *CommentsSuite) createWorkItem(identity account.Identity) string {
 		},
 	}
 	userSvc, workitemCtrl, _, _ := s.securedControllers(identity)
-	_, wi := test.CreateWorkitemCreated(s.T(), userSvc.Context, userSvc, workitemCtrl, &createWorkitemPayload)
+	_, wi := test.CreateWorkitemCreated(s.T(), userSvc.Context, userSvc, workitemCtrl, nil, &createWorkitemPayload)
 	workitemId := *wi.Data.ID
 	s.T().Log(fmt.Sprintf("Created workitem with id %v", workitemId))
 	return workitemId
@@ -1,7 +1,13 @@
 package controller
 
 import (
+	"fmt"
+
 	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/query"
 	"github.com/almighty/almighty-core/rest"
 	"github.com/goadesign/goa"
 )
@@ -9,11 +15,12 @@ import (
 // FilterController implements the filter resource.
 type FilterController struct {
 	*goa.Controller
+	db application.DB
 }
 
 // NewFilterController creates a filter controller.
-func NewFilterController(service *goa.Service) *FilterController {
-	return &FilterController{Controller: service.NewController("FilterController")}
+func NewFilterController(service *goa.Service, db application.DB) *FilterController {
+	return &FilterController{Controller: service.NewController("FilterController"), db: db}
 }
 
 // List runs the list action.
@@ -65,6 +72,30 @@ func (c *FilterController) List(ctx *app.ListFilterContext) error {
 			Type: "filters",
 		},
 	)
+	// the saved queries of the current user are offered as filters as well
+	if currentUser, err := login.ContextIdentity(ctx); err == nil {
+		err = application.Transactional(c.db, func(appl application.Application) error {
+			queries, err := appl.Queries().List(ctx, currentUser, nil)
+			if err != nil {
+				return err
+			}
+			for _, q := range queries {
+				arr = append(arr, &app.Filters{
+					Attributes: &app.FilterAttributes{
+						Title:       q.Title,
+						Query:       fmt.Sprintf("filter[query]=%s", q.ID),
+						Description: q.Description,
+						Type:        query.APIStringTypeQueries,
+					},
+					Type: "filters",
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+	}
 	result := &app.FilterList{
 		Data: arr,
 	}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/rest"
 	"github.com/goadesign/goa"
 	errs "github.com/pkg/errors"
@@ -47,14 +49,16 @@ func computePagingLimts(offsetParam *string, limitParam *int) (offset int, limit
 	return offset, limit
 }
 
-func setPagingLinks(links *app.PagingLinks, path string, resultLen, offset, limit, count int, additionalQuery ...string) {
-
-	format := func(additional []string) string {
-		if len(additional) > 0 {
-			return "&" + strings.Join(additional, "&")
-		}
-		return ""
+// formatAdditionalQuery returns the given query parameters to be appended to a paging link
+func formatAdditionalQuery(additional []string) string {
+	if len(additional) > 0 {
+		return "&" + strings.Join(additional, "&")
 	}
+	return ""
+}
+
+func setPagingLinks(links *app.PagingLinks, path string, resultLen, offset, limit, count int, additionalQuery ...string) {
+	format := formatAdditionalQuery
 
 	// prev link
 	if offset > 0 && count > 0 {
@@ -114,6 +118,50 @@ func setPagingLinks(links *app.PagingLinks, path string, resultLen, offset, limi
 	links.Last = &last
 }
 
+// computeKeyset returns the keyset for cursor based paging if one of the page[after] and page[before]
+// parameters is given and nil otherwise, in which case offset based paging applies. An empty cursor
+// refers to the start of the list for page[after] and to its end for page[before].
+// returns BadParameterError for malformed cursors or if both parameters are given
+func computeKeyset(afterParam *string, beforeParam *string, limitParam *int) (*pagination.Keyset, error) {
+	if afterParam == nil && beforeParam == nil {
+		return nil, nil
+	}
+	if afterParam != nil && beforeParam != nil {
+		return nil, errors.NewBadParameterError("page[before]", *beforeParam)
+	}
+	_, limit := computePagingLimts(nil, limitParam)
+	keyset := pagination.Keyset{Direction: pagination.After, Limit: limit}
+	cursor := afterParam
+	if beforeParam != nil {
+		keyset.Direction = pagination.Before
+		cursor = beforeParam
+	}
+	var err error
+	keyset.Cursor, err = pagination.Decode(*cursor)
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+	return &keyset, nil
+}
+
+// setCursorPagingLinks sets the links for a page fetched with cursor based paging.
+// Next and prev links are only present if there are items after or before the page.
+func setCursorPagingLinks(links *app.PagingLinks, path string, limit int, page *pagination.Page, additionalQuery ...string) {
+	format := formatAdditionalQuery
+	if page.HasPrev && page.First != nil {
+		prev := fmt.Sprintf("%s?page[before]=%s&page[limit]=%d%s", path, page.First.Encode(), limit, format(additionalQuery))
+		links.Prev = &prev
+	}
+	if page.HasNext && page.Last != nil {
+		next := fmt.Sprintf("%s?page[after]=%s&page[limit]=%d%s", path, page.Last.Encode(), limit, format(additionalQuery))
+		links.Next = &next
+	}
+	first := fmt.Sprintf("%s?page[after]=&page[limit]=%d%s", path, limit, format(additionalQuery))
+	links.First = &first
+	last := fmt.Sprintf("%s?page[before]=&page[limit]=%d%s", path, limit, format(additionalQuery))
+	links.Last = &last
+}
+
 func buildAbsoluteURL(req *goa.RequestData) string {
 	return rest.AbsoluteURL(req, req.URL.Path)
 }
@@ -0,0 +1,295 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/query"
+	"github.com/almighty/almighty-core/rest"
+	"github.com/almighty/almighty-core/space"
+	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// QueryController implements the query resource.
+type QueryController struct {
+	*goa.Controller
+	db application.DB
+}
+
+// NewQueryController creates a query controller.
+func NewQueryController(service *goa.Service, db application.DB) *QueryController {
+	return &QueryController{Controller: service.NewController("QueryController"), db: db}
+}
+
+// List runs the list action.
+func (c *QueryController) List(ctx *app.ListQueryContext) error {
+	currentUser, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		queries, err := appl.Queries().List(ctx, currentUser, ctx.FilterSpace)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		return ctx.OK(&app.QueryList{
+			Data: ConvertQueries(ctx.RequestData, queries),
+		})
+	})
+}
+
+// Show runs the show action.
+func (c *QueryController) Show(ctx *app.ShowQueryContext) error {
+	currentUser, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	id, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("query", ctx.ID))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		q, err := loadVisibleQuery(ctx, appl, id, currentUser)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		return ctx.OK(&app.QuerySingle{
+			Data: ConvertQuery(ctx.RequestData, q),
+		})
+	})
+}
+
+// Create runs the create action.
+func (c *QueryController) Create(ctx *app.CreateQueryContext) error {
+	currentUser, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	if err := validateCreateQuery(ctx); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		q := query.Query{OwnerID: *currentUser}
+		convertQueryAttributes(ctx.Payload.Data, &q)
+		if err := checkSharingSpace(ctx, appl, &q, nil, *currentUser); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		created, err := appl.Queries().Create(ctx, &q)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		res := &app.QuerySingle{
+			Data: ConvertQuery(ctx.RequestData, created),
+		}
+		ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.QueryHref(created.ID)))
+		return ctx.Created(res)
+	})
+}
+
+// Update runs the update action.
+func (c *QueryController) Update(ctx *app.UpdateQueryContext) error {
+	currentUser, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	id, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("query", ctx.ID))
+	}
+	if err := validateUpdateQuery(ctx); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		q, err := loadOwnedQuery(ctx, appl, id, *currentUser)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		shared := q.SpaceID
+		q.Version = *ctx.Payload.Data.Attributes.Version
+		convertQueryAttributes(ctx.Payload.Data, q)
+		if err := checkSharingSpace(ctx, appl, q, shared, *currentUser); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		q, err = appl.Queries().Save(ctx, q)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		return ctx.OK(&app.QuerySingle{
+			Data: ConvertQuery(ctx.RequestData, q),
+		})
+	})
+}
+
+// Delete runs the delete action.
+func (c *QueryController) Delete(ctx *app.DeleteQueryContext) error {
+	currentUser, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	id, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("query", ctx.ID))
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		if _, err := loadOwnedQuery(ctx, appl, id, *currentUser); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		if err := appl.Queries().Delete(ctx, id); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		return ctx.OK([]byte{})
+	})
+}
+
+// loadVisibleQuery loads a saved query the given identity is allowed to see, other
+// queries are reported as not found so that their existence isn't disclosed
+func loadVisibleQuery(ctx context.Context, appl application.Application, id uuid.UUID, identityID *uuid.UUID) (*query.Query, error) {
+	q, err := appl.Queries().Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !q.VisibleTo(identityID) {
+		return nil, errors.NewNotFoundError("query", id.String())
+	}
+	return q, nil
+}
+
+// loadOwnedQuery loads a saved query for modification, which only its owner may do
+func loadOwnedQuery(ctx context.Context, appl application.Application, id uuid.UUID, identityID uuid.UUID) (*query.Query, error) {
+	q, err := loadVisibleQuery(ctx, appl, id, &identityID)
+	if err != nil {
+		return nil, err
+	}
+	if !uuid.Equal(q.OwnerID, identityID) {
+		log.Error(ctx, map[string]interface{}{"currentUser": identityID, "owner": q.OwnerID}, "Current user is not owner")
+		return nil, goa.NewErrorClass("forbidden", 403)("User is not the query owner")
+	}
+	return q, nil
+}
+
+// checkSharingSpace checks that the identity may share the query with its space, which only the owner of the
+// space may do. A query which stays shared with the previous space isn't checked again.
+func checkSharingSpace(ctx context.Context, appl application.Application, q *query.Query, previous *uuid.UUID, identityID uuid.UUID) error {
+	if q.SpaceID == nil || (previous != nil && uuid.Equal(*previous, *q.SpaceID)) {
+		return nil
+	}
+	s, err := appl.Spaces().Load(ctx, *q.SpaceID)
+	if err != nil {
+		if _, notFound := errs.Cause(err).(errors.NotFoundError); notFound {
+			return errors.NewBadParameterError("space", q.SpaceID.String()).Expected("existing space")
+		}
+		return err
+	}
+	if !uuid.Equal(identityID, s.OwnerId) {
+		log.Error(ctx, map[string]interface{}{"currentUser": identityID, "owner": s.OwnerId}, "Current user is not owner")
+		return goa.NewErrorClass("forbidden", 403)("User is not the space owner")
+	}
+	return nil
+}
+
+func validateCreateQuery(ctx *app.CreateQueryContext) error {
+	if ctx.Payload.Data == nil {
+		return errors.NewBadParameterError("data", nil).Expected("not nil")
+	}
+	if ctx.Payload.Data.Attributes == nil {
+		return errors.NewBadParameterError("data.attributes", nil).Expected("not nil")
+	}
+	if ctx.Payload.Data.Attributes.Title == nil {
+		return errors.NewBadParameterError("data.attributes.title", nil).Expected("not nil")
+	}
+	return nil
+}
+
+func validateUpdateQuery(ctx *app.UpdateQueryContext) error {
+	if ctx.Payload.Data == nil {
+		return errors.NewBadParameterError("data", nil).Expected("not nil")
+	}
+	if ctx.Payload.Data.Attributes == nil {
+		return errors.NewBadParameterError("data.attributes", nil).Expected("not nil")
+	}
+	if ctx.Payload.Data.Attributes.Version == nil {
+		return errors.NewBadParameterError("data.attributes.version", nil).Expected("not nil")
+	}
+	return nil
+}
+
+// convertQueryAttributes copies the attributes and the space relationship given in the request into the saved query
+func convertQueryAttributes(source *app.Query, target *query.Query) {
+	attributes := source.Attributes
+	if attributes.Title != nil {
+		target.Title = *attributes.Title
+	}
+	if attributes.Description != nil {
+		target.Description = *attributes.Description
+	}
+	if attributes.Filter != nil {
+		target.Filter = *attributes.Filter
+	}
+	if attributes.Sort != nil {
+		target.Sort = *attributes.Sort
+	}
+	if source.Relationships != nil && source.Relationships.Space != nil {
+		if source.Relationships.Space.Data != nil {
+			target.SpaceID = source.Relationships.Space.Data.ID
+		} else {
+			// an empty relationship makes the query private again
+			target.SpaceID = nil
+		}
+	}
+}
+
+// ConvertQueries converts between internal and external REST representation
+func ConvertQueries(request *goa.RequestData, queries []*query.Query) []*app.Query {
+	result := []*app.Query{}
+	for _, q := range queries {
+		result = append(result, ConvertQuery(request, q))
+	}
+	return result
+}
+
+// ConvertQuery converts between internal and external REST representation
+func ConvertQuery(request *goa.RequestData, q *query.Query) *app.Query {
+	selfURL := rest.AbsoluteURL(request, app.QueryHref(q.ID))
+	workItemsURL := rest.AbsoluteURL(request, fmt.Sprintf("%s?filter[query]=%s", app.WorkitemHref(), q.ID))
+	result := &app.Query{
+		ID:   &q.ID,
+		Type: query.APIStringTypeQueries,
+		Attributes: &app.QueryAttributes{
+			Title:       &q.Title,
+			Description: &q.Description,
+			Filter:      &q.Filter,
+			Sort:        &q.Sort,
+			Version:     &q.Version,
+			CreatedAt:   &q.CreatedAt,
+			UpdatedAt:   &q.UpdatedAt,
+		},
+		Links: &app.GenericLinks{
+			Self: &selfURL,
+		},
+		Relationships: &app.QueryRelationships{
+			OwnedBy: &app.QueryOwnedBy{
+				Data: &app.IdentityRelationData{
+					Type: APIStringTypeUser,
+					ID:   &q.OwnerID,
+				},
+			},
+			Workitems: &app.RelationGeneric{
+				Links: &app.GenericLinks{
+					Related: &workItemsURL,
+				},
+			},
+		},
+	}
+	if q.SpaceID != nil {
+		result.Relationships.Space = space.NewSpaceRelation(*q.SpaceID, rest.AbsoluteURL(request, app.SpaceHref(q.SpaceID.String())))
+	}
+	return result
+}
@@ -0,0 +1,244 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/account"
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/migration"
+	"github.com/almighty/almighty-core/models"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+type TestQueryREST struct {
+	gormsupport.DBTestSuite
+
+	db    *gormapplication.GormDB
+	clean func()
+}
+
+func TestRunQueryREST(t *testing.T) {
+	suite.Run(t, &TestQueryREST{DBTestSuite: gormsupport.NewDBTestSuite("../config.yaml")})
+}
+
+// SetupSuite makes sure the work item types the saved queries refer to exist
+func (rest *TestQueryREST) SetupSuite() {
+	rest.DBTestSuite.SetupSuite()
+	if wibConfiguration.GetPopulateCommonTypes() {
+		if err := models.Transactional(rest.DB, func(tx *gorm.DB) error {
+			ctx := migration.NewMigrationContext(context.Background())
+			return migration.PopulateCommonTypes(ctx, tx, workitem.NewWorkItemTypeRepository(tx))
+		}); err != nil {
+			panic(err.Error())
+		}
+	}
+}
+
+func (rest *TestQueryREST) SetupTest() {
+	rest.db = gormapplication.NewGormDB(rest.DB)
+	rest.clean = cleaner.DeleteCreatedEntities(rest.DB)
+}
+
+func (rest *TestQueryREST) TearDownTest() {
+	rest.clean()
+}
+
+func (rest *TestQueryREST) SecuredController(identity account.Identity) (*goa.Service, *QueryController) {
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+
+	svc := testsupport.ServiceAsUser("Query-Service", almtoken.NewManagerWithPrivateKey(priv), identity)
+	return svc, NewQueryController(svc, rest.db)
+}
+
+func (rest *TestQueryREST) UnSecuredController() (*goa.Service, *QueryController) {
+	svc := goa.New("Query-Service")
+	return svc, NewQueryController(svc, rest.db)
+}
+
+func (rest *TestQueryREST) SecuredWorkitemController(identity account.Identity) (*goa.Service, *WorkitemController) {
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+
+	svc := testsupport.ServiceAsUser("Workitem-Service", almtoken.NewManagerWithPrivateKey(priv), identity)
+	return svc, NewWorkitemController(svc, rest.db, wibConfiguration)
+}
+
+// createSpace creates a space owned by testsupport.TestIdentity
+func (rest *TestQueryREST) createSpace() *space.Space {
+	s, err := space.NewRepository(rest.DB).Create(context.Background(), &space.Space{Name: "query-test-" + uuid.NewV4().String(), OwnerId: testsupport.TestIdentity.ID})
+	require.Nil(rest.T(), err)
+	return s
+}
+
+func createQueryPayload(title, filter string, spaceID *uuid.UUID) *app.CreateQueryPayload {
+	payload := &app.CreateQueryPayload{
+		Data: &app.Query{
+			Type: "queries",
+			Attributes: &app.QueryAttributes{
+				Title:  &title,
+				Filter: &filter,
+			},
+		},
+	}
+	if spaceID != nil {
+		payload.Data.Relationships = &app.QueryRelationships{
+			Space: space.NewSpaceRelation(*spaceID, ""),
+		}
+	}
+	return payload
+}
+
+func (rest *TestQueryREST) TestCreateQueryUnauthorized() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+
+	svc, ctrl := rest.UnSecuredController()
+	test.CreateQueryUnauthorized(t, svc.Context, svc, ctrl, createQueryPayload("My bugs", "assignee = me", nil))
+}
+
+func (rest *TestQueryREST) TestCreateQueryBadRequest() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	test.CreateQueryBadRequest(t, svc.Context, svc, ctrl, createQueryPayload("", "assignee = me", nil))
+	test.CreateQueryBadRequest(t, svc.Context, svc, ctrl, createQueryPayload("Broken", "assignee = ", nil))
+}
+
+func (rest *TestQueryREST) TestCreateAndShowQuery() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateQueryCreated(t, svc.Context, svc, ctrl, createQueryPayload("My bugs", "assignee = me", nil))
+	require.NotNil(t, created.Data.ID)
+	assert.Equal(t, "My bugs", *created.Data.Attributes.Title)
+	assert.Equal(t, "assignee = me", *created.Data.Attributes.Filter)
+	assert.Equal(t, testsupport.TestIdentity.ID, *created.Data.Relationships.OwnedBy.Data.ID)
+	assert.Nil(t, created.Data.Relationships.Space)
+	assert.NotNil(t, created.Data.Links.Self)
+
+	_, shown := test.ShowQueryOK(t, svc.Context, svc, ctrl, created.Data.ID.String())
+	assert.Equal(t, *created.Data.ID, *shown.Data.ID)
+
+	// private queries are hidden from other users
+	svc2, ctrl2 := rest.SecuredController(testsupport.TestIdentity2)
+	test.ShowQueryNotFound(t, svc2.Context, svc2, ctrl2, created.Data.ID.String())
+}
+
+func (rest *TestQueryREST) TestListQueries() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+	sp := rest.createSpace()
+
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	test.CreateQueryCreated(t, svc.Context, svc, ctrl, createQueryPayload("private", "assignee = me", nil))
+	test.CreateQueryCreated(t, svc.Context, svc, ctrl, createQueryPayload("shared", "assignee = me", &sp.ID))
+
+	svc2, ctrl2 := rest.SecuredController(testsupport.TestIdentity2)
+	_, list := test.ListQueryOK(t, svc2.Context, svc2, ctrl2, nil)
+	assert.Empty(t, list.Data)
+	_, list = test.ListQueryOK(t, svc2.Context, svc2, ctrl2, &sp.ID)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "shared", *list.Data[0].Attributes.Title)
+
+	_, list = test.ListQueryOK(t, svc.Context, svc, ctrl, nil)
+	assert.Len(t, list.Data, 2)
+}
+
+func (rest *TestQueryREST) TestShareQueryForbidden() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+	sp := rest.createSpace()
+
+	// only the owner of the space may share queries with it
+	svc2, ctrl2 := rest.SecuredController(testsupport.TestIdentity2)
+	test.CreateQueryForbidden(t, svc2.Context, svc2, ctrl2, createQueryPayload("shared", "assignee = me", &sp.ID))
+	_, created := test.CreateQueryCreated(t, svc2.Context, svc2, ctrl2, createQueryPayload("private", "assignee = me", nil))
+	payload := &app.UpdateQueryPayload{
+		Data: &app.Query{
+			Type: "queries",
+			Attributes: &app.QueryAttributes{
+				Version: created.Data.Attributes.Version,
+			},
+			Relationships: &app.QueryRelationships{
+				Space: space.NewSpaceRelation(sp.ID, ""),
+			},
+		},
+	}
+	test.UpdateQueryForbidden(t, svc2.Context, svc2, ctrl2, created.Data.ID.String(), payload)
+	// unknown spaces are rejected
+	unknown := uuid.NewV4()
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	test.CreateQueryBadRequest(t, svc.Context, svc, ctrl, createQueryPayload("shared", "assignee = me", &unknown))
+}
+
+func (rest *TestQueryREST) TestUpdateAndDeleteQuery() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+	sp := rest.createSpace()
+
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateQueryCreated(t, svc.Context, svc, ctrl, createQueryPayload("shared", "assignee = me", &sp.ID))
+	id := created.Data.ID.String()
+
+	title := "renamed"
+	payload := &app.UpdateQueryPayload{
+		Data: &app.Query{
+			Type: "queries",
+			Attributes: &app.QueryAttributes{
+				Title:   &title,
+				Version: created.Data.Attributes.Version,
+			},
+		},
+	}
+	// only the owner may change a shared query
+	svc2, ctrl2 := rest.SecuredController(testsupport.TestIdentity2)
+	test.UpdateQueryForbidden(t, svc2.Context, svc2, ctrl2, id, payload)
+	test.DeleteQueryForbidden(t, svc2.Context, svc2, ctrl2, id)
+
+	_, updated := test.UpdateQueryOK(t, svc.Context, svc, ctrl, id, payload)
+	assert.Equal(t, "renamed", *updated.Data.Attributes.Title)
+	assert.Equal(t, "assignee = me", *updated.Data.Attributes.Filter)
+	assert.Equal(t, *created.Data.Attributes.Version+1, *updated.Data.Attributes.Version)
+	// the version is stale now
+	test.UpdateQueryBadRequest(t, svc.Context, svc, ctrl, id, payload)
+
+	test.DeleteQueryOK(t, svc.Context, svc, ctrl, id)
+	test.ShowQueryNotFound(t, svc.Context, svc, ctrl, id)
+	test.DeleteQueryNotFound(t, svc.Context, svc, ctrl, id)
+}
+
+func (rest *TestQueryREST) TestListWorkItemsBySavedQuery() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+
+	svc, ctrl := rest.SecuredController(testsupport.TestIdentity)
+	_, created := test.CreateQueryCreated(t, svc.Context, svc, ctrl, createQueryPayload("nothing", `title = "`+uuid.NewV4().String()+`"`, nil))
+
+	wiSvc, wiCtrl := rest.SecuredWorkitemController(testsupport.TestIdentity)
+	_, result := test.ListWorkitemOK(t, wiSvc.Context, wiSvc, wiCtrl, nil, nil, nil, nil, created.Data.ID, nil, nil, nil, nil, nil, nil, nil)
+	assert.Empty(t, result.Data)
+	assert.Contains(t, *result.Links.First, "filter[query]="+created.Data.ID.String())
+
+	// private queries of other users can't be used
+	wiSvc2, wiCtrl2 := rest.SecuredWorkitemController(testsupport.TestIdentity2)
+	test.ListWorkitemNotFound(t, wiSvc2.Context, wiSvc2, wiCtrl2, nil, nil, nil, nil, created.Data.ID, nil, nil, nil, nil, nil, nil, nil)
+	unknown := uuid.NewV4()
+	test.ListWorkitemNotFound(t, wiSvc.Context, wiSvc, wiCtrl, nil, nil, nil, nil, &unknown, nil, nil, nil, nil, nil, nil, nil)
+}
@@ -2,16 +2,21 @@ package controller
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/search"
 	"github.com/almighty/almighty-core/space"
 	"github.com/goadesign/goa"
 	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
 )
 
 type searchConfiguration interface {
@@ -50,9 +55,27 @@ func (c *SearchController) Show(ctx *app.ShowSearchContext) error {
 	urlRegexString = fmt.Sprintf("(?P<domain>%s)(?P<path>/work-item/board/detail/)(?P<id>\\d*)", hostString)
 	search.RegisterAsKnownURL(search.HostRegistrationKeyForBoardWI, urlRegexString)
 
+	keyset, err := computeKeyset(ctx.PageAfter, ctx.PageBefore, ctx.PageLimit)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	var currentUserIdentityID *uuid.UUID
+	if strings.Contains(strings.ToLower(ctx.Q), ":me") {
+		// searching is allowed for anonymous users, the identity is only needed for qualifiers like assignee:me
+		currentUserIdentityID, _ = login.ContextIdentity(ctx)
+	}
+
 	return application.Transactional(c.db, func(appl application.Application) error {
 		//return transaction.Do(c.ts, func() error {
-		result, c, err := appl.SearchItems().SearchFullText(ctx.Context, ctx.Q, &offset, &limit)
+		var result []*app.SearchHit
+		var page *pagination.Page
+		var c uint64
+		var err error
+		if keyset != nil {
+			result, page, err = appl.SearchItems().SearchFullTextPage(ctx.Context, ctx.Q, currentUserIdentityID, *keyset)
+		} else {
+			result, c, err = appl.SearchItems().SearchFullText(ctx.Context, ctx.Q, currentUserIdentityID, &offset, &limit)
+		}
 		count := int(c)
 		if err != nil {
 			cause := errs.Cause(err)
@@ -69,17 +92,51 @@ func (c *SearchController) Show(ctx *app.ShowSearchContext) error {
 			}
 		}
 
+		if page != nil {
+			count = int(page.TotalCount)
+		}
 		response := app.SearchWorkItemList{
 			Links: &app.PagingLinks{},
-			Meta:  &app.WorkItemListResponseMeta{TotalCount: count},
-			Data:  ConvertWorkItems(ctx.RequestData, result),
+			Meta:  &app.SearchWorkItemListMeta{TotalCount: count},
+			Data:  ConvertSearchHits(ctx.RequestData, result),
+		}
+		if ctx.Facets != nil && *ctx.Facets {
+			response.Meta.Facets, err = appl.SearchItems().SearchFacets(ctx.Context, ctx.Q, currentUserIdentityID)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
 		}
 
-		setPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), len(result), offset, limit, count, "q="+ctx.Q)
+		additionalQuery := []string{"q=" + ctx.Q}
+		if ctx.Facets != nil {
+			additionalQuery = append(additionalQuery, fmt.Sprintf("facets=%t", *ctx.Facets))
+		}
+		if page != nil {
+			setCursorPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), keyset.Limit, page, additionalQuery...)
+		} else {
+			setPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), len(result), offset, limit, count, additionalQuery...)
+		}
 		return ctx.OK(&response)
 	})
 }
 
+// ConvertSearchHits converts the work items found by a search, the highlighted snippets of a hit are
+// added to the meta object of its work item
+func ConvertSearchHits(request *goa.RequestData, hits []*app.SearchHit) []*app.WorkItem2 {
+	result := make([]*app.WorkItem2, len(hits))
+	for index, hit := range hits {
+		result[index] = ConvertWorkItem(request, hit.WorkItem)
+		if len(hit.Highlights) == 0 {
+			continue
+		}
+		if result[index].Meta == nil {
+			result[index].Meta = map[string]interface{}{}
+		}
+		result[index].Meta["highlights"] = hit.Highlights
+	}
+	return result
+}
+
 // Spaces runs the space search action.
 func (c *SearchController) Spaces(ctx *app.SpacesSearchContext) error {
 	q := ctx.Q
@@ -125,3 +182,25 @@ func (c *SearchController) Spaces(ctx *app.SpacesSearchContext) error {
 		return ctx.OK(&response)
 	})
 }
+
+// KnownUrls runs the known_urls action.
+func (c *SearchController) KnownUrls(ctx *app.KnownUrlsSearchContext) error {
+	known := search.GetAllRegisteredURLs()
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	response := app.KnownURLList{Data: make([]*app.KnownURL, len(names))}
+	for i, name := range names {
+		response.Data[i] = &app.KnownURL{
+			Type: "knownurls",
+			ID:   name,
+			Attributes: &app.KnownURLAttributes{
+				Pattern: known[name].URLRegex,
+				Groups:  known[name].GroupNames(),
+			},
+		}
+	}
+	return ctx.OK(&response)
+}
@@ -102,13 +102,76 @@ func (s *searchBlackBoxTest) TestSearchWorkItems() {
 	require.Nil(s.T(), err)
 	// when
 	q := "specialwordforsearch"
-	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, q)
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
 	// then
 	require.NotEmpty(s.T(), sr.Data)
 	r := sr.Data[0]
 	assert.Equal(s.T(), "specialwordforsearch", r.Attributes[workitem.SystemTitle])
 }
 
+func (s *searchBlackBoxTest) TestSearchFacets() {
+	// given
+	for _, state := range []string{workitem.SystemStateNew, workitem.SystemStateNew, workitem.SystemStateClosed} {
+		_, err := s.wiRepo.Create(
+			s.ctx,
+			space.SystemSpace,
+			workitem.SystemBug,
+			map[string]interface{}{
+				workitem.SystemTitle: "facetedwordforsearch",
+				workitem.SystemState: state,
+			},
+			s.testIdentity.ID)
+		require.Nil(s.T(), err)
+	}
+	q := "facetedwordforsearch"
+	// when
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
+	// then
+	assert.Nil(s.T(), sr.Meta.Facets)
+	// when
+	facets := true
+	limit := 1
+	_, sr = test.ShowSearchOK(s.T(), nil, nil, s.controller, &facets, nil, nil, &limit, nil, q)
+	// then the facets are computed over all matching work items, not just the page
+	require.Len(s.T(), sr.Data, 1)
+	require.NotNil(s.T(), sr.Meta.Facets)
+	states := sr.Meta.Facets[search.FacetState]
+	require.Len(s.T(), states, 2)
+	assert.Equal(s.T(), workitem.SystemStateNew, *states[0].Value)
+	assert.Equal(s.T(), 2, states[0].Count)
+	assert.Equal(s.T(), workitem.SystemStateClosed, *states[1].Value)
+	assert.Equal(s.T(), 1, states[1].Count)
+	require.Len(s.T(), sr.Meta.Facets[search.FacetAssignee], 1)
+	assert.Nil(s.T(), sr.Meta.Facets[search.FacetAssignee][0].Value)
+	assert.Equal(s.T(), 3, sr.Meta.Facets[search.FacetAssignee][0].Count)
+	assert.Contains(s.T(), *sr.Links.Next, "facets=true")
+}
+
+func (s *searchBlackBoxTest) TestSearchHighlights() {
+	// given
+	_, err := s.wiRepo.Create(
+		s.ctx,
+		space.SystemSpace,
+		workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle:       "Tom & Jerry's highlightedwordforsearch",
+			workitem.SystemDescription: rendering.NewMarkupContentFromLegacy("the description mentions highlightedwordforsearch too"),
+			workitem.SystemState:       workitem.SystemStateNew,
+		},
+		s.testIdentity.ID)
+	require.Nil(s.T(), err)
+	// when
+	q := "highlightedwordforsearch"
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
+	// then
+	require.Len(s.T(), sr.Data, 1)
+	require.NotNil(s.T(), sr.Data[0].Meta)
+	highlights := sr.Data[0].Meta["highlights"].(map[string]string)
+	// the title is escaped
+	assert.Equal(s.T(), "Tom &amp; Jerry&#39;s <mark>highlightedwordforsearch</mark>", highlights[workitem.SystemTitle])
+	assert.Contains(s.T(), highlights[workitem.SystemDescription], "mentions <mark>highlightedwordforsearch</mark> too")
+}
+
 func (s *searchBlackBoxTest) TestSearchPagination() {
 	// given
 	_, err := s.wiRepo.Create(
@@ -125,7 +188,7 @@ func (s *searchBlackBoxTest) TestSearchPagination() {
 	require.Nil(s.T(), err)
 	// when
 	q := "specialwordforsearch2"
-	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, q)
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
 	// then
 	// defaults in paging.go is 'pageSizeDefault = 20'
 	assert.Equal(s.T(), "http:///api/search?page[offset]=0&page[limit]=20&q=specialwordforsearch2", *sr.Links.First)
@@ -150,7 +213,7 @@ func (s *searchBlackBoxTest) TestSearchWithEmptyValue() {
 	require.Nil(s.T(), err)
 	// when
 	q := ""
-	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, q)
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
 	// then
 	require.NotNil(s.T(), sr.Data)
 	assert.Empty(s.T(), sr.Data)
@@ -172,7 +235,7 @@ func (s *searchBlackBoxTest) TestSearchWithDomainPortCombination() {
 	require.Nil(s.T(), err)
 	// when
 	q := `"http://localhost:8080/detail/154687364529310"`
-	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, q)
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
 	// then
 	require.NotEmpty(s.T(), sr.Data)
 	r := sr.Data[0]
@@ -196,7 +259,7 @@ func (s *searchBlackBoxTest) TestSearchURLWithoutPort() {
 	require.Nil(s.T(), err)
 	// when
 	q := `"http://localhost/detail/876394"`
-	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, q)
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
 	// then
 	require.NotEmpty(s.T(), sr.Data)
 	r := sr.Data[0]
@@ -220,7 +283,7 @@ func (s *searchBlackBoxTest) TestUnregisteredURLWithPort() {
 	require.Nil(s.T(), err)
 	// when
 	q := `http://some-other-domain:8080/different-path/`
-	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, q)
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
 	// then
 	require.NotEmpty(s.T(), sr.Data)
 	r := sr.Data[0]
@@ -245,7 +308,7 @@ func (s *searchBlackBoxTest) TestUnwantedCharactersRelatedToSearchLogic() {
 	// when
 	// add url: in the query, that is not expected by the code hence need to make sure it gives expected result.
 	q := `http://url:some-random-other-domain:8080/different-path/`
-	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, q)
+	_, sr := test.ShowSearchOK(s.T(), nil, nil, s.controller, nil, nil, nil, nil, nil, q)
 	// then
 	require.NotNil(s.T(), sr.Data)
 	assert.Empty(s.T(), sr.Data)
@@ -330,10 +393,10 @@ func (s *searchBlackBoxTest) verifySearchByKnownURLs(wi *app.WorkItem2Single, ho
 // Uses helper functions verifySearchByKnownURLs, searchByURL, getWICreatePayload
 func (s *searchBlackBoxTest) TestAutoRegisterHostURL() {
 	// service := getServiceAsUser(s.testIdentity)
-	wiCtrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB))
+	wiCtrl := NewWorkitemController(s.svc, gormapplication.NewGormDB(s.DB), wibConfiguration)
 	// create a WI, search by `list view URL` of newly created item
 	newWI := s.getWICreatePayload()
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, wiCtrl, newWI)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, wiCtrl, nil, newWI)
 	require.NotNil(s.T(), wi)
 	customHost := "own.domain.one"
 	queryString := fmt.Sprintf("http://%s/work-item/list/detail/%s", customHost, *wi.Data.ID)
@@ -344,3 +407,23 @@ func (s *searchBlackBoxTest) TestAutoRegisterHostURL() {
 	queryString2 := fmt.Sprintf("http://%s/work-item/board/detail/%s", customHost2, *wi.Data.ID)
 	s.verifySearchByKnownURLs(wi, customHost2, queryString2)
 }
+
+func (s *searchBlackBoxTest) TestKnownURLs() {
+	// given
+	urlRegex := `(?P<domain>known.urls.io)(?P<path>/work-item/list/detail/)(?P<id>\d*)`
+	require.Nil(s.T(), search.RegisterKnownURLs(map[string]string{"test-known-urls": urlRegex}))
+	defer search.UnregisterKnownURL("test-known-urls")
+	// when
+	_, list := test.KnownUrlsSearchOK(s.T(), nil, nil, s.controller)
+	// then
+	require.NotNil(s.T(), list)
+	var found *app.KnownURL
+	for _, known := range list.Data {
+		if known.ID == "test-known-urls" {
+			found = known
+		}
+	}
+	require.NotNil(s.T(), found)
+	assert.Equal(s.T(), urlRegex, found.Attributes.Pattern)
+	assert.Equal(s.T(), []string{"domain", "path", "id"}, found.Attributes.Groups)
+}
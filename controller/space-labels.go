@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/application"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/label"
+	"github.com/almighty/almighty-core/login"
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+)
+
+// SpaceLabelsController implements the space-labels resource.
+type SpaceLabelsController struct {
+	*goa.Controller
+	db application.DB
+}
+
+// NewSpaceLabelsController creates a space-labels controller.
+func NewSpaceLabelsController(service *goa.Service, db application.DB) *SpaceLabelsController {
+	return &SpaceLabelsController{Controller: service.NewController("SpaceLabelsController"), db: db}
+}
+
+// Create runs the create action.
+func (c *SpaceLabelsController) Create(ctx *app.CreateSpaceLabelsContext) error {
+	_, err := login.ContextIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrUnauthorized(err.Error()))
+	}
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(err.Error()))
+	}
+
+	// Validate Request
+	if ctx.Payload.Data == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("data", nil).Expected("not nil"))
+	}
+
+	return application.Transactional(c.db, func(appl application.Application) error {
+		_, err = appl.Spaces().Load(ctx, spaceID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(err.Error()))
+		}
+
+		newLabel, err := appl.Labels().Create(ctx, &label.Label{
+			SpaceID: spaceID,
+			Name:    ctx.Payload.Data.Attributes.Name,
+		})
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+
+		return ctx.Created(&app.LabelSingle{Data: ConvertLabel(newLabel)})
+	})
+}
+
+// List runs the list action.
+func (c *SpaceLabelsController) List(ctx *app.ListSpaceLabelsContext) error {
+	spaceID, err := uuid.FromString(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(err.Error()))
+	}
+
+	return application.Transactional(c.db, func(appl application.Application) error {
+		_, err = appl.Spaces().Load(ctx, spaceID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, goa.ErrNotFound(err.Error()))
+		}
+
+		labels, err := appl.Labels().List(ctx, spaceID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+
+		res := &app.LabelList{Data: make([]*app.Label, len(labels))}
+		for i, l := range labels {
+			res.Data[i] = ConvertLabel(l)
+		}
+		return ctx.OK(res)
+	})
+}
+
+// ConvertLabel converts a label of the vocabulary of a space to its JSONAPI representation
+func ConvertLabel(l *label.Label) *app.Label {
+	return &app.Label{
+		Type:       label.APIStringTypeLabels,
+		ID:         &l.ID,
+		Attributes: &app.LabelAttributes{Name: l.Name},
+	}
+}
@@ -0,0 +1,154 @@
+package controller_test
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/app/test"
+	"github.com/almighty/almighty-core/application"
+	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/label"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+	testsupport "github.com/almighty/almighty-core/test"
+	almtoken "github.com/almighty/almighty-core/token"
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/goadesign/goa"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TestSpaceLabelsREST struct {
+	gormsupport.DBTestSuite
+
+	db    *gormapplication.GormDB
+	clean func()
+}
+
+func TestRunSpaceLabelsREST(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		require.Nil(t, err)
+	}
+	suite.Run(t, &TestSpaceLabelsREST{DBTestSuite: gormsupport.NewDBTestSuite(pwd + "/../config.yaml")})
+}
+
+func (rest *TestSpaceLabelsREST) SetupTest() {
+	rest.db = gormapplication.NewGormDB(rest.DB)
+	rest.clean = cleaner.DeleteCreatedEntities(rest.DB)
+}
+
+func (rest *TestSpaceLabelsREST) TearDownTest() {
+	rest.clean()
+}
+
+func (rest *TestSpaceLabelsREST) SecuredController() (*goa.Service, *SpaceLabelsController) {
+	pub, _ := almtoken.ParsePublicKey([]byte(almtoken.RSAPublicKey))
+	svc := testsupport.ServiceAsUser("Labels-Service", almtoken.NewManager(pub), testsupport.TestIdentity)
+	return svc, NewSpaceLabelsController(svc, rest.db)
+}
+
+func (rest *TestSpaceLabelsREST) UnSecuredController() (*goa.Service, *SpaceLabelsController) {
+	svc := goa.New("Labels-Service")
+	return svc, NewSpaceLabelsController(svc, rest.db)
+}
+
+func newCreateSpaceLabelPayload(name string) *app.CreateSpaceLabelsPayload {
+	return &app.CreateSpaceLabelsPayload{
+		Data: &app.Label{
+			Type:       label.APIStringTypeLabels,
+			Attributes: &app.LabelAttributes{Name: name},
+		},
+	}
+}
+
+func (rest *TestSpaceLabelsREST) createSpace() *space.Space {
+	var s *space.Space
+	application.Transactional(rest.db, func(appl application.Application) error {
+		var err error
+		s, err = appl.Spaces().Create(context.Background(), &space.Space{
+			Name: "Labeled space " + uuid.NewV4().String(),
+		})
+		require.Nil(rest.T(), err)
+		return nil
+	})
+	return s
+}
+
+func (rest *TestSpaceLabelsREST) TestCreateAndListLabels() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+	// given
+	s := rest.createSpace()
+	svc, ctrl := rest.SecuredController()
+	// when
+	_, created := test.CreateSpaceLabelsCreated(t, svc.Context, svc, ctrl, s.ID.String(), newCreateSpaceLabelPayload("  needs   review "))
+	test.CreateSpaceLabelsCreated(t, svc.Context, svc, ctrl, s.ID.String(), newCreateSpaceLabelPayload("backend"))
+	_, labels := test.ListSpaceLabelsOK(t, svc.Context, svc, ctrl, s.ID.String())
+	// then the labels are normalized and listed in alphabetical order
+	require.NotNil(t, created.Data.ID)
+	assert.Equal(t, "needs review", created.Data.Attributes.Name)
+	require.Len(t, labels.Data, 2)
+	assert.Equal(t, "backend", labels.Data[0].Attributes.Name)
+	assert.Equal(t, *created.Data.ID, *labels.Data[1].ID)
+	// when/then a label the space already has is rejected
+	test.CreateSpaceLabelsBadRequest(t, svc.Context, svc, ctrl, s.ID.String(), newCreateSpaceLabelPayload("needs review"))
+}
+
+func (rest *TestSpaceLabelsREST) TestLabelFieldsTakeLabelsOfTheSpace() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+	// given a space with the label "ui"
+	s := rest.createSpace()
+	svc, ctrl := rest.SecuredController()
+	test.CreateSpaceLabelsCreated(t, svc.Context, svc, ctrl, s.ID.String(), newCreateSpaceLabelPayload("ui"))
+	application.Transactional(rest.db, func(appl application.Application) error {
+		labelKind := string(workitem.KindLabel)
+		wit, err := appl.WorkItemTypes().Create(context.Background(), s.ID, nil, nil, "labeled", nil, "fa-tag", map[string]app.FieldDefinition{
+			"labels": {
+				Type: &app.FieldType{Kind: string(workitem.KindList), ComponentType: &labelKind},
+			},
+		})
+		require.Nil(t, err)
+		identity, err := testsupport.CreateTestIdentity(rest.DB, "jdoe", "test")
+		require.Nil(t, err)
+		// when
+		_, err = appl.WorkItems().Create(context.Background(), s.ID, *wit.Data.ID, map[string]interface{}{"labels": []interface{}{"ui"}}, identity.ID)
+		// then
+		require.Nil(t, err)
+		// when
+		_, err = appl.WorkItems().Create(context.Background(), s.ID, *wit.Data.ID, map[string]interface{}{"labels": []interface{}{"ui", "backend"}}, identity.ID)
+		// then
+		require.NotNil(t, err)
+		assert.IsType(t, errors.BadParameterError{}, errs.Cause(err))
+		return nil
+	})
+}
+
+func (rest *TestSpaceLabelsREST) TestCreateLabelUnauthorized() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+	// given
+	s := rest.createSpace()
+	svc, ctrl := rest.UnSecuredController()
+	// when/then
+	test.CreateSpaceLabelsUnauthorized(t, svc.Context, svc, ctrl, s.ID.String(), newCreateSpaceLabelPayload("ui"))
+}
+
+func (rest *TestSpaceLabelsREST) TestListLabelsOfUnknownSpace() {
+	t := rest.T()
+	resource.Require(t, resource.Database)
+	svc, ctrl := rest.UnSecuredController()
+	// when/then
+	test.ListSpaceLabelsNotFound(t, svc.Context, svc, ctrl, uuid.NewV4().String())
+}
@@ -48,6 +48,9 @@ func (c *SpaceController) Create(ctx *app.CreateSpaceContext) error {
 		if reqSpace.Attributes.Description != nil {
 			newSpace.Description = *reqSpace.Attributes.Description
 		}
+		if reqSpace.Attributes.TextSearchConfig != nil {
+			newSpace.TextSearchConfig = *reqSpace.Attributes.TextSearchConfig
+		}
 
 		space, err := appl.Spaces().Create(ctx, &newSpace)
 		if err != nil {
@@ -159,6 +162,9 @@ func (c *SpaceController) Update(ctx *app.UpdateSpaceContext) error {
 		if ctx.Payload.Data.Attributes.Description != nil {
 			s.Description = *ctx.Payload.Data.Attributes.Description
 		}
+		if ctx.Payload.Data.Attributes.TextSearchConfig != nil {
+			s.TextSearchConfig = *ctx.Payload.Data.Attributes.TextSearchConfig
+		}
 
 		s, err = appl.Spaces().Save(ctx.Context, s)
 		if err != nil {
@@ -224,11 +230,12 @@ func ConvertSpace(request *goa.RequestData, p *space.Space, additional ...SpaceC
 		ID:   &p.ID,
 		Type: "spaces",
 		Attributes: &app.SpaceAttributes{
-			Name:        &p.Name,
-			Description: &p.Description,
-			CreatedAt:   &p.CreatedAt,
-			UpdatedAt:   &p.UpdatedAt,
-			Version:     &p.Version,
+			Name:             &p.Name,
+			Description:      &p.Description,
+			TextSearchConfig: &p.TextSearchConfig,
+			CreatedAt:        &p.CreatedAt,
+			UpdatedAt:        &p.UpdatedAt,
+			Version:          &p.Version,
 		},
 		Links: &app.GenericLinks{
 			Self: &selfURL,
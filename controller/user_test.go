@@ -13,6 +13,8 @@ import (
 	"github.com/almighty/almighty-core/comment"
 	. "github.com/almighty/almighty-core/controller"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/label"
+	"github.com/almighty/almighty-core/query"
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/space"
 	almtoken "github.com/almighty/almighty-core/token"
@@ -246,6 +248,16 @@ func (g *GormTestBase) Areas() area.Repository {
 	return nil
 }
 
+// Queries returns a saved query repository
+func (g *GormTestBase) Queries() query.Repository {
+	return nil
+}
+
+// Labels returns a label repository
+func (g *GormTestBase) Labels() label.Repository {
+	return nil
+}
+
 func (g *GormTestBase) DB() *gorm.DB {
 	return nil
 }
@@ -60,6 +60,10 @@ func (c *WorkItemCommentsController) Create(ctx *app.CreateWorkItemCommentsConte
 
 // List runs the list action.
 func (c *WorkItemCommentsController) List(ctx *app.ListWorkItemCommentsContext) error {
+	keyset, err := computeKeyset(ctx.PageAfter, ctx.PageBefore, ctx.PageLimit)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
 	offset, limit := computePagingLimts(ctx.PageOffset, ctx.PageLimit)
 	return application.Transactional(c.db, func(appl application.Application) error {
 		_, err := appl.WorkItems().Load(ctx, ctx.ID)
@@ -70,6 +74,18 @@ func (c *WorkItemCommentsController) List(ctx *app.ListWorkItemCommentsContext)
 		res := &app.CommentList{}
 		res.Data = []*app.Comment{}
 
+		if keyset != nil {
+			comments, page, err := appl.Comments().ListPage(ctx, ctx.ID, *keyset)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, err)
+			}
+			res.Meta = &app.CommentListMeta{TotalCount: int(page.TotalCount)}
+			res.Data = ConvertComments(ctx.RequestData, comments)
+			res.Links = &app.PagingLinks{}
+			setCursorPagingLinks(res.Links, buildAbsoluteURL(ctx.RequestData), keyset.Limit, page)
+			return ctx.OK(res)
+		}
+
 		comments, tc, err := appl.Comments().List(ctx, ctx.ID, &offset, &limit)
 		count := int(tc)
 		if err != nil {
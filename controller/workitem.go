@@ -3,7 +3,9 @@ package controller
 import (
 	"fmt"
 	"html"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"golang.org/x/net/context"
 
@@ -14,9 +16,10 @@ import (
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/login"
-	query "github.com/almighty/almighty-core/query/simple"
+	"github.com/almighty/almighty-core/query"
 	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/rest"
+	"github.com/almighty/almighty-core/search"
 	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/workitem"
 
@@ -35,26 +38,67 @@ const (
 // WorkitemController implements the workitem resource.
 type WorkitemController struct {
 	*goa.Controller
-	db application.DB
+	db            application.DB
+	configuration workItemConfiguration
+}
+
+type workItemConfiguration interface {
+	GetBulkMaxOperations() int
 }
 
 // NewWorkitemController creates a workitem controller.
-func NewWorkitemController(service *goa.Service, db application.DB) *WorkitemController {
+func NewWorkitemController(service *goa.Service, db application.DB, configuration workItemConfiguration) *WorkitemController {
 	if db == nil {
 		panic("db must not be nil")
 	}
-	return &WorkitemController{Controller: service.NewController("WorkitemController"), db: db}
+	return &WorkitemController{Controller: service.NewController("WorkitemController"), db: db, configuration: configuration}
 }
 
 // List runs the list action.
 // Prev and Next links will be present only when there actually IS a next or previous page.
 // Last will always be present. Total Item count needs to be computed from the "Last" link.
+// Pages are selected by offset unless one of the page[after] and page[before] cursors is given.
 func (c *WorkitemController) List(ctx *app.ListWorkitemContext) error {
 	var additionalQuery []string
-	exp, err := query.Parse(ctx.Filter)
+	var currentUserIdentityID *uuid.UUID
+	if ctx.Filter != nil || ctx.FilterQuery != nil {
+		// listing is allowed for anonymous users, the identity is only needed to resolve "me" in the filter
+		// and to find private saved queries
+		currentUserIdentityID, _ = login.ContextIdentity(ctx)
+	}
+	exp, err := query.Parse(ctx.Filter, currentUserIdentityID)
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse filter", err))
 	}
+	sort := ctx.Sort
+	if ctx.FilterQuery != nil {
+		var saved *query.Query
+		err := application.Transactional(c.db, func(appl application.Application) error {
+			var err error
+			saved, err = loadVisibleQuery(ctx, appl, *ctx.FilterQuery, currentUserIdentityID)
+			return err
+		})
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		savedExp, err := query.Parse(&saved.Filter, currentUserIdentityID)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse filter of saved query", err))
+		}
+		exp = criteria.And(exp, savedExp)
+		// an explicit sort order takes precedence over the one of the saved query
+		if sort == nil {
+			sort = &saved.Sort
+		}
+		additionalQuery = append(additionalQuery, "filter[query]="+ctx.FilterQuery.String())
+	}
+	order, err := query.ParseSort(sort)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse sort", err))
+	}
+	if ctx.Sort != nil {
+		additionalQuery = append(additionalQuery, "sort="+*ctx.Sort)
+	}
 	if ctx.FilterAssignee != nil {
 		exp = criteria.And(exp, criteria.Equals(criteria.Field("system.assignees"), criteria.Literal([]string{*ctx.FilterAssignee})))
 		additionalQuery = append(additionalQuery, "filter[assignee]="+*ctx.FilterAssignee)
@@ -76,9 +120,30 @@ func (c *WorkitemController) List(ctx *app.ListWorkitemContext) error {
 		additionalQuery = append(additionalQuery, "filter[workitemstate]="+*ctx.FilterWorkitemstate)
 	}
 
+	keyset, err := computeKeyset(ctx.PageAfter, ctx.PageBefore, ctx.PageLimit)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if keyset != nil {
+		return application.Transactional(c.db, func(tx application.Application) error {
+			result, page, err := tx.WorkItems().ListPage(ctx.Context, exp, order, *keyset)
+			if err != nil {
+				return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "Error listing work items"))
+			}
+			response := app.WorkItem2List{
+				Links: &app.PagingLinks{},
+				Meta:  &app.WorkItemListResponseMeta{TotalCount: int(page.TotalCount)},
+				Data:  ConvertWorkItems(ctx.RequestData, result),
+			}
+			setCursorPagingLinks(response.Links, buildAbsoluteURL(ctx.RequestData), keyset.Limit, page, additionalQuery...)
+			addFilterLinks(response.Links, ctx.RequestData)
+			return ctx.OK(&response)
+		})
+	}
+
 	offset, limit := computePagingLimts(ctx.PageOffset, ctx.PageLimit)
 	return application.Transactional(c.db, func(tx application.Application) error {
-		result, tc, err := tx.WorkItems().List(ctx.Context, exp, &offset, &limit)
+		result, tc, err := tx.WorkItems().List(ctx.Context, exp, order, &offset, &limit)
 		count := int(tc)
 		if err != nil {
 			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "Error listing work items"))
@@ -94,6 +159,54 @@ func (c *WorkitemController) List(ctx *app.ListWorkitemContext) error {
 	})
 }
 
+// Aggregate runs the aggregate action, counting the filtered work items per combination of the group-by values
+func (c *WorkitemController) Aggregate(ctx *app.AggregateWorkitemContext) error {
+	var currentUserIdentityID *uuid.UUID
+	if ctx.Filter != nil {
+		// aggregating is allowed for anonymous users, the identity is only needed to resolve "me" in the filter
+		currentUserIdentityID, _ = login.ContextIdentity(ctx)
+	}
+	exp, err := query.Parse(ctx.Filter, currentUserIdentityID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse filter", err))
+	}
+	groupBy, err := query.ParseGroupBy(ctx.GroupBy)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("could not parse group-by", err))
+	}
+	return application.Transactional(c.db, func(tx application.Application) error {
+		buckets, err := tx.WorkItems().Aggregate(ctx.Context, exp, groupBy)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "Error aggregating work items"))
+		}
+		return ctx.OK(ConvertAggregation(groupBy, buckets))
+	})
+}
+
+// ConvertAggregation converts the buckets of an aggregation to the REST representation
+func ConvertAggregation(groupBy []string, buckets []workitem.AggregationBucket) *app.WorkItemAggregationList {
+	result := &app.WorkItemAggregationList{
+		Data: make([]*app.WorkItemAggregation, len(buckets)),
+		Meta: &app.WorkItemAggregationMeta{GroupBy: groupBy},
+	}
+	for i, bucket := range buckets {
+		keys := make(map[string]interface{}, len(groupBy))
+		for j, name := range groupBy {
+			if bucket.Keys[j] == nil {
+				keys[name] = nil
+			} else {
+				keys[name] = *bucket.Keys[j]
+			}
+		}
+		result.Data[i] = &app.WorkItemAggregation{
+			Keys:  keys,
+			Count: int(bucket.Count),
+			Sums:  bucket.Sums,
+		}
+	}
+	return result
+}
+
 // Update does PATCH workitem
 func (c *WorkitemController) Update(ctx *app.UpdateWorkitemContext) error {
 	currentUserIdentityID, err := login.ContextIdentity(ctx)
@@ -105,22 +218,10 @@ func (c *WorkitemController) Update(ctx *app.UpdateWorkitemContext) error {
 		if ctx.Payload == nil || ctx.Payload.Data == nil || ctx.Payload.Data.ID == nil {
 			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("missing data.ID element in request", nil))
 		}
-		wi, err := appl.WorkItems().Load(ctx, *ctx.Payload.Data.ID)
-		if err != nil {
-			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, fmt.Sprintf("Failed to load work item with id %v", *ctx.Payload.Data.ID)))
-		}
-		// Type changes of WI are not allowed which is why we overwrite it the
-		// type with the old one after the WI has been converted.
-		oldType := wi.Type
-		err = ConvertJSONAPIToWorkItem(appl, *ctx.Payload.Data, wi)
+		wi, err := updateWorkItem(ctx, appl, *currentUserIdentityID, *ctx.Payload.Data.ID, *ctx.Payload.Data)
 		if err != nil {
 			return jsonapi.JSONErrorResponse(ctx, err)
 		}
-		wi.Type = oldType
-		wi, err = appl.WorkItems().Save(ctx, *wi, *currentUserIdentityID)
-		if err != nil {
-			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, "Error updating work item"))
-		}
 		wi2 := ConvertWorkItem(ctx.RequestData, wi)
 		resp := &app.WorkItem2Single{
 			Data: wi2,
@@ -132,6 +233,23 @@ func (c *WorkitemController) Update(ctx *app.UpdateWorkitemContext) error {
 	})
 }
 
+// updateWorkItem applies the attributes and relationships of the payload data to the work item with the given ID
+// as a merge patch, the fields missing in the payload are left as they are and null values remove fields
+func updateWorkItem(ctx context.Context, appl application.Application, modifierID uuid.UUID, id string, data app.WorkItem2) (*app.WorkItem, error) {
+	patch := app.WorkItem{
+		Fields: make(map[string]interface{}),
+	}
+	// Type changes of WI are not allowed which is why the type of the patch is ignored
+	if err := ConvertJSONAPIToWorkItem(appl, data, &patch); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	wi, err := appl.WorkItems().Patch(ctx, id, patch.Version, patch.Fields, modifierID)
+	if err != nil {
+		return nil, errs.Wrap(err, "Error updating work item")
+	}
+	return wi, nil
+}
+
 // Create does POST workitem
 func (c *WorkitemController) Create(ctx *app.CreateWorkitemContext) error {
 	currentUserIdentityID, err := login.ContextIdentity(ctx)
@@ -139,28 +257,22 @@ func (c *WorkitemController) Create(ctx *app.CreateWorkitemContext) error {
 		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(err.Error()))
 		return ctx.Unauthorized(jerrors)
 	}
-	var wit *uuid.UUID
-	if ctx.Payload.Data != nil && ctx.Payload.Data.Relationships != nil &&
-		ctx.Payload.Data.Relationships.BaseType != nil && ctx.Payload.Data.Relationships.BaseType.Data != nil {
-		wit = &ctx.Payload.Data.Relationships.BaseType.Data.ID
-	}
-	if wit == nil { // TODO Figure out path source etc. Should be a required relation
-		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("Data.Relationships.BaseType.Data.ID", err))
-	}
-	wi := app.WorkItem{
-		Fields: make(map[string]interface{}),
-	}
 	return application.Transactional(c.db, func(appl application.Application) error {
-		err := ConvertJSONAPIToWorkItem(appl, *ctx.Payload.Data, &wi)
-		if err != nil {
-			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, fmt.Sprintf("Error creating work item")))
+		if ctx.Payload == nil || ctx.Payload.Data == nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("data", nil).Expected("the work item to create"))
 		}
-
-		wi, err := appl.WorkItems().Create(ctx, *ctx.Payload.Data.Relationships.Space.Data.ID, *wit, wi.Fields, *currentUserIdentityID)
+		findDuplicates := ctx.Duplicates != nil && *ctx.Duplicates
+		wi, duplicates, err := createWorkItem(ctx, appl, *currentUserIdentityID, *ctx.Payload.Data, findDuplicates)
 		if err != nil {
-			return jsonapi.JSONErrorResponse(ctx, errs.Wrap(err, fmt.Sprintf("Error creating work item")))
+			return jsonapi.JSONErrorResponse(ctx, err)
 		}
 		wi2 := ConvertWorkItem(ctx.RequestData, wi)
+		if duplicates != nil {
+			if wi2.Meta == nil {
+				wi2.Meta = map[string]interface{}{}
+			}
+			wi2.Meta["duplicates"] = ConvertDuplicateHits(ctx.RequestData, duplicates)
+		}
 		resp := &app.WorkItem2Single{
 			Data: wi2,
 			Links: &app.WorkItemLinks{
@@ -172,6 +284,55 @@ func (c *WorkitemController) Create(ctx *app.CreateWorkitemContext) error {
 	})
 }
 
+// searchDuplicatesOf returns the likely duplicates of a work item which is about to be created,
+// none if it has no title
+func searchDuplicatesOf(ctx context.Context, appl application.Application, spaceID uuid.UUID, wi app.WorkItem) ([]*app.DuplicateHit, error) {
+	title, _ := wi.Fields[workitem.SystemTitle].(string)
+	if strings.TrimSpace(title) == "" {
+		return []*app.DuplicateHit{}, nil
+	}
+	var description string
+	if markup, ok := wi.Fields[workitem.SystemDescription].(rendering.MarkupContent); ok {
+		description = markup.Content
+	}
+	return appl.SearchItems().SearchDuplicates(ctx, spaceID, title, description, search.DefaultDuplicatesLimit)
+}
+
+// Duplicates runs the duplicates action.
+func (c *WorkitemController) Duplicates(ctx *app.DuplicatesWorkitemContext) error {
+	limit := search.DefaultDuplicatesLimit
+	if ctx.Limit != nil {
+		limit = *ctx.Limit
+	}
+	var description string
+	if ctx.Description != nil {
+		description = *ctx.Description
+	}
+	return application.Transactional(c.db, func(appl application.Application) error {
+		duplicates, err := appl.SearchItems().SearchDuplicates(ctx, ctx.Space, ctx.Title, description, limit)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
+		}
+		return ctx.OK(&app.WorkItemDuplicateList{
+			Data: ConvertDuplicateHits(ctx.RequestData, duplicates),
+		})
+	})
+}
+
+// ConvertDuplicateHits converts the likely duplicates of a draft, the similarity of a hit is added to the
+// meta object of its work item
+func ConvertDuplicateHits(request *goa.RequestData, hits []*app.DuplicateHit) []*app.WorkItem2 {
+	result := make([]*app.WorkItem2, len(hits))
+	for index, hit := range hits {
+		result[index] = ConvertWorkItem(request, hit.WorkItem)
+		if result[index].Meta == nil {
+			result[index].Meta = map[string]interface{}{}
+		}
+		result[index].Meta["similarity"] = hit.Similarity
+	}
+	return result
+}
+
 // Show does GET workitem
 func (c *WorkitemController) Show(ctx *app.ShowWorkitemContext) error {
 	return application.Transactional(c.db, func(appl application.Application) error {
@@ -196,17 +357,162 @@ func (c *WorkitemController) Delete(ctx *app.DeleteWorkitemContext) error {
 		return ctx.Unauthorized(jerrors)
 	}
 	return application.Transactional(c.db, func(appl application.Application) error {
-		err := appl.WorkItems().Delete(ctx, ctx.ID, *currentUserIdentityID)
-		if err != nil {
-			return jsonapi.JSONErrorResponse(ctx, errs.Wrapf(err, "error deleting work item %s", ctx.ID))
-		}
-		if err := appl.WorkItemLinks().DeleteRelatedLinks(ctx, ctx.ID); err != nil {
-			return jsonapi.JSONErrorResponse(ctx, errs.Wrapf(err, "failed to delete work item links related to work item %s", ctx.ID))
+		if err := deleteWorkItem(ctx, appl, *currentUserIdentityID, ctx.ID); err != nil {
+			return jsonapi.JSONErrorResponse(ctx, err)
 		}
 		return ctx.OK([]byte{})
 	})
 }
 
+// deleteWorkItem deletes the work item with the given ID and the links to and from it
+func deleteWorkItem(ctx context.Context, appl application.Application, suppressorID uuid.UUID, id string) error {
+	if err := appl.WorkItems().Delete(ctx, id, suppressorID); err != nil {
+		return errs.Wrapf(err, "error deleting work item %s", id)
+	}
+	if err := appl.WorkItemLinks().DeleteRelatedLinks(ctx, id); err != nil {
+		return errs.Wrapf(err, "failed to delete work item links related to work item %s", id)
+	}
+	return nil
+}
+
+// The operations and modes of bulk requests
+const (
+	BulkOperationCreate = "create"
+	BulkOperationUpdate = "update"
+	BulkOperationDelete = "delete"
+
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best-effort"
+)
+
+// Bulk runs the create, update and delete operations of the payload in a single transaction. In the atomic mode
+// the transaction is rolled back if an operation fails and the error of the operation is returned, in the
+// best-effort mode only the changes of the failed operations are rolled back and their errors are reported
+// in their results. Requests with more operations than configured are rejected.
+func (c *WorkitemController) Bulk(ctx *app.BulkWorkitemContext) error {
+	currentUserIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(err.Error()))
+		return ctx.Unauthorized(jerrors)
+	}
+	if max := c.configuration.GetBulkMaxOperations(); len(ctx.Payload.Data) > max {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("data", len(ctx.Payload.Data)).Expected(fmt.Sprintf("at most %d operations", max)))
+	}
+	results := make([]*app.WorkItemBulkResult, len(ctx.Payload.Data))
+	failed := 0
+	failedAt := -1
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		for index, operation := range ctx.Payload.Data {
+			run := func() error {
+				var err error
+				results[index], err = runBulkOperation(ctx, appl, *currentUserIdentityID, operation)
+				return err
+			}
+			if ctx.Mode != BulkModeBestEffort {
+				if err := run(); err != nil {
+					failedAt = index
+					return err
+				}
+				continue
+			}
+			// the savepoint keeps the transaction usable after an operation failed
+			if err := application.Savepoint(appl, "bulk_operation", run); err != nil {
+				jerr, status := jsonapi.ErrorToJSONAPIError(err)
+				results[index] = &app.WorkItemBulkResult{
+					Op:     operation.Op,
+					ID:     operation.ID,
+					Status: status,
+					Errors: []*app.JSONAPIError{&jerr},
+				}
+				failed++
+			}
+		}
+		return nil
+	})
+	if err != nil && failedAt >= 0 {
+		return jsonapi.JSONErrorResponseWithPointer(ctx, err, fmt.Sprintf("/data/%d", failedAt))
+	}
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(&app.WorkItemBulkResultList{
+		Data: results,
+		Meta: &app.WorkItemBulkResultMeta{
+			Applied: len(results) - failed,
+			Failed:  failed,
+		},
+	})
+}
+
+// runBulkOperation runs an operation of a bulk request and returns its result
+func runBulkOperation(ctx *app.BulkWorkitemContext, appl application.Application, currentUserIdentityID uuid.UUID, operation *app.WorkItemBulkOperation) (*app.WorkItemBulkResult, error) {
+	id := operation.ID
+	if id == nil && operation.Data != nil {
+		id = operation.Data.ID
+	}
+	if operation.Op != BulkOperationCreate && id == nil {
+		return nil, errors.NewBadParameterError("id", nil).Expected("the ID of the work item to " + operation.Op)
+	}
+	if operation.Op != BulkOperationDelete && operation.Data == nil {
+		return nil, errors.NewBadParameterError("data", nil).Expected("the work item to " + operation.Op)
+	}
+	result := &app.WorkItemBulkResult{Op: operation.Op, ID: id}
+	var wi *app.WorkItem
+	var err error
+	switch operation.Op {
+	case BulkOperationCreate:
+		wi, _, err = createWorkItem(ctx, appl, currentUserIdentityID, *operation.Data, false)
+		result.Status = http.StatusCreated
+	case BulkOperationUpdate:
+		wi, err = updateWorkItem(ctx, appl, currentUserIdentityID, *id, *operation.Data)
+		result.Status = http.StatusOK
+	case BulkOperationDelete:
+		err = deleteWorkItem(ctx, appl, currentUserIdentityID, *id)
+		result.Status = http.StatusOK
+	default:
+		err = errors.NewBadParameterError("op", operation.Op).Expected("create, update or delete")
+	}
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+	if wi != nil {
+		result.ID = &wi.ID
+		result.Data = ConvertWorkItem(ctx.RequestData, wi)
+	}
+	return result, nil
+}
+
+// createWorkItem creates a work item of the type and in the space of the relationships of the payload data. With
+// findDuplicates it also returns the work items of the space which are similar to the new one, they are looked up
+// before the work item is created so that it doesn't find itself.
+func createWorkItem(ctx context.Context, appl application.Application, creatorID uuid.UUID, data app.WorkItem2, findDuplicates bool) (*app.WorkItem, []*app.DuplicateHit, error) {
+	if data.Relationships == nil || data.Relationships.BaseType == nil || data.Relationships.BaseType.Data == nil {
+		return nil, nil, errors.NewBadParameterError("data.relationships.baseType.data.id", nil)
+	}
+	if data.Relationships.Space == nil || data.Relationships.Space.Data == nil || data.Relationships.Space.Data.ID == nil {
+		return nil, nil, errors.NewBadParameterError("data.relationships.space.data.id", nil)
+	}
+	wi := app.WorkItem{
+		Fields: make(map[string]interface{}),
+	}
+	if err := ConvertJSONAPIToWorkItem(appl, data, &wi); err != nil {
+		return nil, nil, errs.Wrap(err, "Error creating work item")
+	}
+	var duplicates []*app.DuplicateHit
+	if findDuplicates {
+		var err error
+		duplicates, err = searchDuplicatesOf(ctx, appl, *data.Relationships.Space.Data.ID, wi)
+		if err != nil {
+			return nil, nil, errs.WithStack(err)
+		}
+	}
+	created, err := appl.WorkItems().Create(ctx, *data.Relationships.Space.Data.ID, data.Relationships.BaseType.Data.ID, wi.Fields, creatorID)
+	if err != nil {
+		return nil, nil, errs.Wrap(err, "Error creating work item")
+	}
+	return created, duplicates, nil
+}
+
 // ConvertJSONAPIToWorkItem is responsible for converting given WorkItem model object into a
 // response resource object by jsonapi.org specifications
 func ConvertJSONAPIToWorkItem(appl application.Application, source app.WorkItem2, target *app.WorkItem) error {
@@ -219,7 +525,7 @@ func ConvertJSONAPIToWorkItem(appl application.Application, source app.WorkItem2
 
 	if source.Relationships != nil && source.Relationships.Assignees != nil {
 		if source.Relationships.Assignees.Data == nil {
-			delete(target.Fields, workitem.SystemAssignees)
+			target.Fields[workitem.SystemAssignees] = nil
 		} else {
 			var ids []string
 			for _, d := range source.Relationships.Assignees.Data {
@@ -237,7 +543,7 @@ func ConvertJSONAPIToWorkItem(appl application.Application, source app.WorkItem2
 	}
 	if source.Relationships != nil && source.Relationships.Iteration != nil {
 		if source.Relationships.Iteration.Data == nil {
-			delete(target.Fields, workitem.SystemIteration)
+			target.Fields[workitem.SystemIteration] = nil
 		} else {
 			d := source.Relationships.Iteration.Data
 			iterationUUID, err := uuid.FromString(*d.ID)
@@ -252,7 +558,7 @@ func ConvertJSONAPIToWorkItem(appl application.Application, source app.WorkItem2
 	}
 	if source.Relationships != nil && source.Relationships.Area != nil {
 		if source.Relationships.Area.Data == nil {
-			delete(target.Fields, workitem.SystemArea)
+			target.Fields[workitem.SystemArea] = nil
 		} else {
 			d := source.Relationships.Area.Data
 			areaUUID, err := uuid.FromString(*d.ID)
@@ -272,8 +578,14 @@ func ConvertJSONAPIToWorkItem(appl application.Application, source app.WorkItem2
 	}
 
 	for key, val := range source.Attributes {
-		// convert legacy description to markup content
-		if key == workitem.SystemDescription {
+		if key == "version" {
+			// the version is not a field
+			continue
+		} else if val == nil {
+			// a null value removes the field when updating
+			target.Fields[key] = nil
+		} else if key == workitem.SystemDescription {
+			// convert legacy description to markup content
 			if m := rendering.NewMarkupContentFromValue(val); m != nil {
 				target.Fields[key] = *m
 			}
@@ -104,11 +104,11 @@ func (s *WorkItemSuite) TearDownSuite() {
 
 func (s *WorkItemSuite) SetupTest() {
 	s.svc = testsupport.ServiceAsUser("TestUpdateWI-Service", almtoken.NewManagerWithPrivateKey(s.priKey), s.testIdentity)
-	s.controller = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.db))
+	s.controller = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.db), wibConfiguration)
 	payload := minimumRequiredCreateWithType(workitem.SystemBug)
 	payload.Data.Attributes[workitem.SystemTitle] = "Test WI"
 	payload.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, &payload)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, nil, &payload)
 	s.wi = wi.Data
 	s.minimumPayload = getMinimumRequiredUpdatePayload(s.wi)
 }
@@ -142,7 +142,7 @@ func (s *WorkItemSuite) TestCreateWI() {
 	payload.Data.Attributes[workitem.SystemTitle] = "Test WI"
 	payload.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
 	// when
-	_, created := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, &payload)
+	_, created := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, nil, &payload)
 	// then
 	require.NotNil(s.T(), created.Data.ID)
 	assert.NotEmpty(s.T(), *created.Data.ID)
@@ -158,7 +158,7 @@ func (s *WorkItemSuite) TestCreateWorkItemWithoutContext() {
 	payload.Data.Attributes[workitem.SystemTitle] = "Test WI"
 	payload.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
 	// when/then
-	test.CreateWorkitemUnauthorized(s.T(), s.svc.Context, s.svc, s.controller, &payload)
+	test.CreateWorkitemUnauthorized(s.T(), s.svc.Context, s.svc, s.controller, nil, &payload)
 }
 
 func (s *WorkItemSuite) TestListByFields() {
@@ -166,23 +166,101 @@ func (s *WorkItemSuite) TestListByFields() {
 	payload := minimumRequiredCreateWithType(workitem.SystemBug)
 	payload.Data.Attributes[workitem.SystemTitle] = "run integration test"
 	payload.Data.Attributes[workitem.SystemState] = workitem.SystemStateClosed
-	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, &payload)
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, nil, &payload)
 	// when
 	filter := "{\"system.title\":\"run integration test\"}"
 	offset := "0"
 	limit := 1
-	_, result := test.ListWorkitemOK(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result := test.ListWorkitemOK(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	// then
 	require.NotNil(s.T(), result)
 	require.Equal(s.T(), 1, len(result.Data))
 	// when
 	filter = fmt.Sprintf("{\"system.creator\":\"%s\"}", s.testIdentity.ID.String())
 	// then
-	_, result = test.ListWorkitemOK(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result = test.ListWorkitemOK(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	require.NotNil(s.T(), result)
 	require.Equal(s.T(), 1, len(result.Data))
 }
 
+func (s *WorkItemSuite) TestListByQueryLanguage() {
+	// given
+	payload := minimumRequiredCreateWithType(workitem.SystemBug)
+	payload.Data.Attributes[workitem.SystemTitle] = "run query language test"
+	payload.Data.Attributes[workitem.SystemState] = workitem.SystemStateResolved
+	test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, nil, &payload)
+	// when
+	filter := `title = "run query language test" and state in ("closed", "resolved")`
+	offset := "0"
+	limit := 10
+	_, result := test.ListWorkitemOK(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
+	// then
+	require.NotNil(s.T(), result)
+	require.Equal(s.T(), 1, len(result.Data))
+	// when
+	filter = `title = "run query language test" and not state = "resolved"`
+	_, result = test.ListWorkitemOK(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
+	// then
+	require.NotNil(s.T(), result)
+	require.Equal(s.T(), 0, len(result.Data))
+	// when
+	filter = `title = "run query language test" and (state = "resolved"`
+	// then
+	test.ListWorkitemBadRequest(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
+}
+
+func (s *WorkItemSuite) TestAggregate() {
+	// given
+	title := "run aggregation test " + uuid.NewV4().String()
+	for _, state := range []string{workitem.SystemStateNew, workitem.SystemStateNew, workitem.SystemStateClosed} {
+		payload := minimumRequiredCreateWithType(workitem.SystemBug)
+		payload.Data.Attributes[workitem.SystemTitle] = title
+		payload.Data.Attributes[workitem.SystemState] = state
+		test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, nil, &payload)
+	}
+	// when
+	filter := `title = "` + title + `"`
+	_, result := test.AggregateWorkitemOK(s.T(), nil, nil, s.controller, &filter, "state")
+	// then
+	require.NotNil(s.T(), result)
+	assert.Equal(s.T(), []string{workitem.SystemState}, result.Meta.GroupBy)
+	require.Len(s.T(), result.Data, 2)
+	counts := map[interface{}]int{}
+	for _, bucket := range result.Data {
+		counts[bucket.Keys[workitem.SystemState]] = bucket.Count
+	}
+	assert.Equal(s.T(), map[interface{}]int{workitem.SystemStateNew: 2, workitem.SystemStateClosed: 1}, counts)
+	// then
+	test.AggregateWorkitemBadRequest(s.T(), nil, nil, s.controller, &filter, "title")
+	test.AggregateWorkitemBadRequest(s.T(), nil, nil, s.controller, &filter, "state,")
+}
+
+func (s *WorkItemSuite) TestListSorted() {
+	// given
+	for _, title := range []string{"sort test b", "sort test c", "sort test a"} {
+		payload := minimumRequiredCreateWithType(workitem.SystemBug)
+		payload.Data.Attributes[workitem.SystemTitle] = title
+		test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.controller, nil, &payload)
+	}
+	filter := `title ~ "sort test"`
+	offset := "0"
+	limit := 2
+	// when
+	sort := "-title"
+	_, result := test.ListWorkitemOK(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, &sort)
+	// then
+	require.NotNil(s.T(), result)
+	require.Equal(s.T(), 2, len(result.Data))
+	assert.Equal(s.T(), "sort test c", result.Data[0].Attributes[workitem.SystemTitle])
+	assert.Equal(s.T(), "sort test b", result.Data[1].Attributes[workitem.SystemTitle])
+	require.NotNil(s.T(), result.Links.Next)
+	assert.Contains(s.T(), *result.Links.Next, "sort=-title")
+	// when
+	sort = "title,"
+	// then
+	test.ListWorkitemBadRequest(s.T(), nil, nil, s.controller, &filter, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, &sort)
+}
+
 func getWorkItemTestData(t *testing.T) []testSecureAPI {
 	privatekey, err := jwt.ParseRSAPrivateKeyFromPEM((wibConfiguration.GetTokenPrivateKey()))
 	if err != nil {
@@ -315,7 +393,7 @@ func (s *WorkItemSuite) TestUnauthorizeWorkItemCUD() {
 	UnauthorizeCreateUpdateDeleteTest(s.T(), getWorkItemTestData, func() *goa.Service {
 		return goa.New("TestUnauthorizedCreateWI-Service")
 	}, func(service *goa.Service) error {
-		controller := NewWorkitemController(service, gormapplication.NewGormDB(DB))
+		controller := NewWorkitemController(service, gormapplication.NewGormDB(DB), wibConfiguration)
 		app.MountWorkitemController(service, controller)
 		return nil
 	})
@@ -327,7 +405,7 @@ func createPagingTest(t *testing.T, ctx context.Context, controller *WorkitemCon
 		repo.ListReturns(makeWorkItems(count), uint64(totalCount), nil)
 		offset := strconv.Itoa(start)
 
-		_, response := test.ListWorkitemOK(t, ctx, nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+		_, response := test.ListWorkitemOK(t, ctx, nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 		assertLink(t, "first", first, response.Links.First)
 		assertLink(t, "last", last, response.Links.Last)
 		assertLink(t, "prev", prev, response.Links.Prev)
@@ -545,8 +623,8 @@ func (s *WorkItem2Suite) SetupSuite() {
 	require.Nil(s.T(), err)
 	s.priKey, _ = almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
 	s.svc = testsupport.ServiceAsUser("TestUpdateWI2-Service", almtoken.NewManagerWithPrivateKey(s.priKey), testIdentity)
-	s.wiCtrl = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.db))
-	s.wi2Ctrl = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.db))
+	s.wiCtrl = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.db), wibConfiguration)
+	s.wi2Ctrl = NewWorkitemController(s.svc, gormapplication.NewGormDB(s.db), wibConfiguration)
 	s.linkCatCtrl = NewWorkItemLinkCategoryController(s.svc, gormapplication.NewGormDB(DB))
 	s.linkTypeCtrl = NewWorkItemLinkTypeController(s.svc, gormapplication.NewGormDB(DB))
 	s.linkCtrl = NewWorkItemLinkController(s.svc, gormapplication.NewGormDB(DB))
@@ -575,7 +653,7 @@ func (s *WorkItem2Suite) SetupTest() {
 	payload.Data.Attributes[workitem.SystemTitle] = "Test WI"
 	payload.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
 
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wiCtrl, &payload)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wiCtrl, nil, &payload)
 	s.wi = wi.Data
 	s.minimumPayload = getMinimumRequiredUpdatePayload(s.wi)
 }
@@ -592,6 +670,29 @@ func (s *WorkItem2Suite) TestWI2UpdateOnlyState() {
 	assert.Equal(s.T(), updatedWI.Data.Attributes[workitem.SystemState], newStateValue)
 }
 
+func (s *WorkItem2Suite) TestWI2UpdateOnlyGivenFields() {
+	// given
+	description := "= Description"
+	s.minimumPayload.Data.Attributes[workitem.SystemDescription] = description
+	_, wi := test.UpdateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *s.wi.ID, s.minimumPayload)
+	// when only the state is sent
+	u := getMinimumRequiredUpdatePayload(wi.Data)
+	u.Data.Relationships = nil
+	u.Data.Attributes[workitem.SystemState] = workitem.SystemStateClosed
+	_, updated := test.UpdateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *s.wi.ID, u)
+	// then the other fields are left as they are
+	assert.Equal(s.T(), workitem.SystemStateClosed, updated.Data.Attributes[workitem.SystemState])
+	assert.Equal(s.T(), s.wi.Attributes[workitem.SystemTitle], updated.Data.Attributes[workitem.SystemTitle])
+	assert.Equal(s.T(), description, updated.Data.Attributes[workitem.SystemDescription])
+	// when the description is removed
+	u = getMinimumRequiredUpdatePayload(updated.Data)
+	u.Data.Attributes[workitem.SystemDescription] = nil
+	_, updated = test.UpdateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *s.wi.ID, u)
+	// then
+	assert.Nil(s.T(), updated.Data.Attributes[workitem.SystemDescription])
+	assert.Equal(s.T(), workitem.SystemStateClosed, updated.Data.Attributes[workitem.SystemState])
+}
+
 func (s *WorkItem2Suite) TestWI2UpdateVersionConflict() {
 	s.minimumPayload.Data.Attributes[workitem.SystemTitle] = "Test title"
 	test.UpdateWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *s.wi.ID, s.minimumPayload)
@@ -613,12 +714,142 @@ func (s *WorkItem2Suite) TestWI2UpdateWithInvalidID() {
 	test.UpdateWorkitemNotFound(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *s.wi.ID, s.minimumPayload)
 }
 
+func (s *WorkItem2Suite) TestWI2CreateReportsDuplicates() {
+	// given
+	c := minimumRequiredCreateWithType(workitem.SystemBug)
+	c.Data.Attributes[workitem.SystemTitle] = "Zanzibarian parser crashes on deeply nested input"
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	_, original := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
+
+	// when
+	duplicates := true
+	draft := "Zanzibarian parser crashes on nested input"
+	c.Data.Attributes[workitem.SystemTitle] = draft
+	_, created := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &duplicates, &c)
+	// then the new work item doesn't find itself
+	require.NotNil(s.T(), created.Data.Meta)
+	reported := created.Data.Meta["duplicates"].([]*app.WorkItem2)
+	require.Len(s.T(), reported, 1)
+	assert.Equal(s.T(), *original.Data.ID, *reported[0].ID)
+	similarity := reported[0].Meta["similarity"].(float64)
+	assert.True(s.T(), similarity > 0.5 && similarity < 1, "similarity %f", similarity)
+
+	// when
+	_, list := test.DuplicatesWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, space.SystemSpace, draft)
+	// then the most similar work item comes first
+	require.Len(s.T(), list.Data, 2)
+	assert.Equal(s.T(), *created.Data.ID, *list.Data[0].ID)
+	assert.Equal(s.T(), 1.0, list.Data[0].Meta["similarity"])
+	assert.Equal(s.T(), *original.Data.ID, *list.Data[1].ID)
+}
+
+func (s *WorkItem2Suite) TestWI2DuplicatesBadRequest() {
+	test.DuplicatesWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, space.SystemSpace, " ")
+}
+
+// bulkPayload creates a work item, updates the title of the given one and deletes a work item which doesn't exist
+func bulkPayload(wi *app.WorkItem2, title string) *app.WorkItemBulkPayload {
+	c := minimumRequiredCreateWithType(workitem.SystemBug)
+	c.Data.Attributes[workitem.SystemTitle] = title
+	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
+	u := getMinimumRequiredUpdatePayload(wi)
+	u.Data.Attributes[workitem.SystemTitle] = title
+	missing := "2398475203"
+	return &app.WorkItemBulkPayload{
+		Data: []*app.WorkItemBulkOperation{
+			{Op: BulkOperationCreate, Data: c.Data},
+			{Op: BulkOperationUpdate, ID: wi.ID, Data: u.Data},
+			{Op: BulkOperationDelete, ID: &missing},
+		},
+	}
+}
+
+func (s *WorkItem2Suite) TestWI2BulkAtomic() {
+	// given
+	payload := bulkPayload(s.wi, "Bulk title")
+	payload.Data = payload.Data[:2]
+	// when
+	_, result := test.BulkWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, BulkModeAtomic, payload)
+	// then
+	require.Len(s.T(), result.Data, 2)
+	assert.Equal(s.T(), http.StatusCreated, result.Data[0].Status)
+	assert.Equal(s.T(), "Bulk title", result.Data[0].Data.Attributes[workitem.SystemTitle])
+	assert.Equal(s.T(), *result.Data[0].Data.ID, *result.Data[0].ID)
+	assert.Equal(s.T(), http.StatusOK, result.Data[1].Status)
+	assert.Equal(s.T(), "Bulk title", result.Data[1].Data.Attributes[workitem.SystemTitle])
+	assert.Equal(s.T(), 2, result.Meta.Applied)
+	assert.Equal(s.T(), 0, result.Meta.Failed)
+}
+
+func (s *WorkItem2Suite) TestWI2BulkAtomicRollsBack() {
+	// given
+	payload := bulkPayload(s.wi, "Rolled back title")
+	// when
+	_, jerrs := test.BulkWorkitemNotFound(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, BulkModeAtomic, payload)
+	// then the error points to the failed operation
+	require.Len(s.T(), jerrs.Errors, 1)
+	assert.Equal(s.T(), "/data/2", jerrs.Errors[0].Source["pointer"])
+	// and the update was rolled back
+	_, wi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *s.wi.ID)
+	assert.Equal(s.T(), s.wi.Attributes[workitem.SystemTitle], wi.Data.Attributes[workitem.SystemTitle])
+	assert.Equal(s.T(), s.wi.Attributes["version"], wi.Data.Attributes["version"])
+}
+
+func (s *WorkItem2Suite) TestWI2BulkBestEffort() {
+	// given
+	payload := bulkPayload(s.wi, "Best effort title")
+	// when
+	_, result := test.BulkWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, BulkModeBestEffort, payload)
+	// then
+	require.Len(s.T(), result.Data, 3)
+	assert.Equal(s.T(), http.StatusCreated, result.Data[0].Status)
+	assert.Equal(s.T(), http.StatusOK, result.Data[1].Status)
+	assert.Equal(s.T(), http.StatusNotFound, result.Data[2].Status)
+	assert.Nil(s.T(), result.Data[2].Data)
+	require.Len(s.T(), result.Data[2].Errors, 1)
+	assert.Equal(s.T(), 2, result.Meta.Applied)
+	assert.Equal(s.T(), 1, result.Meta.Failed)
+	// and the operations after the failed one were applied
+	_, wi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *s.wi.ID)
+	assert.Equal(s.T(), "Best effort title", wi.Data.Attributes[workitem.SystemTitle])
+	_, created := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *result.Data[0].ID)
+	assert.Equal(s.T(), "Best effort title", created.Data.Attributes[workitem.SystemTitle])
+}
+
+func (s *WorkItem2Suite) TestWI2BulkBadOperation() {
+	// given an update without the ID of the work item
+	payload := bulkPayload(s.wi, "Bad title")
+	payload.Data[1].ID = nil
+	payload.Data[1].Data.ID = nil
+	// when
+	_, result := test.BulkWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, BulkModeBestEffort, payload)
+	// then
+	require.Len(s.T(), result.Data, 3)
+	assert.Equal(s.T(), http.StatusBadRequest, result.Data[1].Status)
+	assert.Equal(s.T(), 1, result.Meta.Applied)
+	assert.Equal(s.T(), 2, result.Meta.Failed)
+}
+
+func (s *WorkItem2Suite) TestWI2BulkTooManyOperations() {
+	// given more operations than configured
+	payload := &app.WorkItemBulkPayload{}
+	for i := 0; i <= wibConfiguration.GetBulkMaxOperations(); i++ {
+		missing := strconv.Itoa(1000000 + i)
+		payload.Data = append(payload.Data, &app.WorkItemBulkOperation{Op: BulkOperationDelete, ID: &missing})
+	}
+	// when
+	_, jerrs := test.BulkWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, BulkModeBestEffort, payload)
+	// then no operation is run
+	require.NotNil(s.T(), jerrs)
+	require.Len(s.T(), jerrs.Errors, 1)
+}
+
 func (s *WorkItem2Suite) TestWI2UpdateSetBaseType() {
 	c := minimumRequiredCreateWithType(workitem.SystemBug)
 	c.Data.Attributes[workitem.SystemTitle] = "Test title"
 	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
 
-	_, created := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, created := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	assert.Equal(s.T(), created.Data.Relationships.BaseType.Data.ID, workitem.SystemBug)
 
 	u := minimumRequiredUpdatePayload()
@@ -761,7 +992,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateWorkItem() {
 		},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	assert.NotNil(s.T(), wi.Data)
 	assert.NotNil(s.T(), wi.Data.ID)
@@ -788,7 +1019,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateWorkItemWithoutDescription() {
 		},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	require.NotNil(s.T(), wi.Data)
 	require.NotNil(s.T(), wi.Data.Attributes)
@@ -812,7 +1043,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateWorkItemWithLegacyDescription() {
 		},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	require.NotNil(s.T(), wi.Data)
 	require.NotNil(s.T(), wi.Data.Attributes)
@@ -837,7 +1068,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateWorkItemWithDescriptionAndMarkup()
 		},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	require.NotNil(s.T(), wi.Data)
 	require.NotNil(s.T(), wi.Data.Attributes)
@@ -862,7 +1093,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateWorkItemWithDescriptionAndNoMarkup(
 		},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	require.NotNil(s.T(), wi.Data)
 	require.NotNil(s.T(), wi.Data.Attributes)
@@ -887,7 +1118,7 @@ func (s *WorkItem2Suite) TestWI2FailCreateWorkItemWithDescriptionAndUnsupportedM
 		},
 	}
 	// when/then
-	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 }
 
 func (s *WorkItem2Suite) TestWI2FailCreateMissingBaseType() {
@@ -896,7 +1127,7 @@ func (s *WorkItem2Suite) TestWI2FailCreateMissingBaseType() {
 	c.Data.Attributes[workitem.SystemTitle] = "Title"
 	c.Data.Attributes[workitem.SystemState] = workitem.SystemStateNew
 	// when/then
-	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 }
 
 func (s *WorkItem2Suite) TestWI2FailCreateWithAssigneeAsField() {
@@ -913,7 +1144,7 @@ func (s *WorkItem2Suite) TestWI2FailCreateWithAssigneeAsField() {
 		},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	assert.NotNil(s.T(), wi.Data)
 	assert.NotNil(s.T(), wi.Data.ID)
@@ -933,7 +1164,7 @@ func (s *WorkItem2Suite) TestWI2FailCreateWithMissingTitle() {
 		},
 	}
 	// when/then
-	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 }
 
 func (s *WorkItem2Suite) TestWI2FailCreateWithEmptyTitle() {
@@ -948,7 +1179,7 @@ func (s *WorkItem2Suite) TestWI2FailCreateWithEmptyTitle() {
 		},
 	}
 	// when/then
-	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 }
 
 func (s *WorkItem2Suite) TestWI2SuccessCreateWithAssigneeRelation() {
@@ -973,7 +1204,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateWithAssigneeRelation() {
 			}},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	assert.NotNil(s.T(), wi.Data)
 	assert.NotNil(s.T(), wi.Data.ID)
@@ -1003,7 +1234,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateWithAssigneesRelation() {
 		},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	assert.NotNil(s.T(), wi.Data)
 	assert.NotNil(s.T(), wi.Data.ID)
@@ -1050,7 +1281,7 @@ func (s *WorkItem2Suite) TestWI2ListByAssigneeFilter() {
 		},
 	}
 	// when
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	assert.NotNil(s.T(), wi.Data)
 	assert.NotNil(s.T(), wi.Data.ID)
@@ -1059,7 +1290,7 @@ func (s *WorkItem2Suite) TestWI2ListByAssigneeFilter() {
 	assert.Len(s.T(), wi.Data.Relationships.Assignees.Data, 1)
 	assert.Equal(s.T(), newUser.ID.String(), *wi.Data.Relationships.Assignees.Data[0].ID)
 	newUserID := newUser.ID.String()
-	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, &newUserID, nil, nil, nil, nil, nil)
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, &newUserID, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	assert.Len(s.T(), list.Data, 1)
 	assert.Equal(s.T(), newUser.ID.String(), *list.Data[0].Relationships.Assignees.Data[0].ID)
 	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[assignee]"))
@@ -1077,12 +1308,12 @@ func (s *WorkItem2Suite) TestWI2ListByWorkitemtypeFilter() {
 		},
 	}
 	// when
-	_, expected := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, expected := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	// then
 	assert.NotNil(s.T(), expected.Data)
 	require.NotNil(s.T(), expected.Data.ID)
 	require.NotNil(s.T(), expected.Data.Type)
-	_, actual := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, nil, nil, nil, &workitem.SystemBug, nil, nil)
+	_, actual := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, nil, nil, nil, nil, &workitem.SystemBug, nil, nil, nil, nil, nil)
 	require.NotNil(s.T(), actual)
 	require.True(s.T(), len(actual.Data) > 1)
 	assert.Contains(s.T(), *actual.Links.First, fmt.Sprintf("filter[workitemtype]=%s", workitem.SystemBug))
@@ -1113,8 +1344,8 @@ func (s *WorkItem2Suite) TestWI2ListByWorkitemstateFilter() {
 		},
 	}
 	// when
-	_, expected := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
-	_, notExpected := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &l)
+	_, expected := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
+	_, notExpected := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &l)
 	// then
 	assert.NotNil(s.T(), expected.Data)
 	require.NotNil(s.T(), expected.Data.ID)
@@ -1125,7 +1356,7 @@ func (s *WorkItem2Suite) TestWI2ListByWorkitemstateFilter() {
 	dataArray = append(dataArray, expected)
 	wiNew := workitem.SystemStateNew
 	// var foundExpected bool
-	_, actual := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, nil, nil, &wiNew, nil, nil, nil)
+	_, actual := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, nil, nil, nil, &wiNew, nil, nil, nil, nil, nil, nil)
 
 	require.NotNil(s.T(), actual)
 	require.True(s.T(), len(actual.Data) > 1)
@@ -1158,7 +1389,7 @@ func (s *WorkItem2Suite) TestWI2ListByAreaFilter() {
 			ID: &areaID,
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	require.NotNil(s.T(), wi.Data)
 	require.NotNil(s.T(), wi.Data.ID)
 	require.NotNil(s.T(), wi.Data.Type)
@@ -1166,7 +1397,7 @@ func (s *WorkItem2Suite) TestWI2ListByAreaFilter() {
 	require.NotNil(s.T(), wi.Data.Relationships.Area)
 	assert.Equal(s.T(), areaID, *wi.Data.Relationships.Area.Data.ID)
 
-	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &areaID, nil, nil, nil, nil, nil, nil)
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &areaID, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 	require.Len(s.T(), list.Data, 1)
 	assert.Equal(s.T(), areaID, *list.Data[0].Relationships.Area.Data.ID)
 	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[area]"))
@@ -1190,7 +1421,7 @@ func (s *WorkItem2Suite) TestWI2ListByIterationFilter() {
 			ID: &iterationID,
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	require.NotNil(s.T(), wi.Data)
 	require.NotNil(s.T(), wi.Data.ID)
 	require.NotNil(s.T(), wi.Data.Type)
@@ -1198,7 +1429,7 @@ func (s *WorkItem2Suite) TestWI2ListByIterationFilter() {
 	require.NotNil(s.T(), wi.Data.Relationships.Iteration)
 	assert.Equal(s.T(), iterationID, *wi.Data.Relationships.Iteration.Data.ID)
 
-	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, nil, &iterationID, nil, nil, nil, nil)
+	_, list := test.ListWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, nil, nil, &iterationID, nil, nil, nil, nil, nil, nil, nil, nil)
 	require.Len(s.T(), list.Data, 1)
 	assert.Equal(s.T(), iterationID, *list.Data[0].Relationships.Iteration.Data.ID)
 	assert.True(s.T(), strings.Contains(*list.Links.First, "filter[iteration]"))
@@ -1221,7 +1452,7 @@ func (s *WorkItem2Suite) TestWI2FailCreateInvalidAssignees() {
 		},
 	}
 	// when/then
-	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	test.CreateWorkitemBadRequest(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 }
 
 func (s *WorkItem2Suite) TestWI2FailUpdateInvalidAssignees() {
@@ -1240,7 +1471,7 @@ func (s *WorkItem2Suite) TestWI2FailUpdateInvalidAssignees() {
 			ident(newUser.ID),
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 
 	update := minimumRequiredUpdatePayload()
 	update.Data.ID = wi.Data.ID
@@ -1274,7 +1505,7 @@ func (s *WorkItem2Suite) TestWI2SuccessUpdateWithAssigneesRelation() {
 			ident(newUser2.ID),
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	assert.NotNil(s.T(), wi.Data)
 	assert.NotNil(s.T(), wi.Data.ID)
 	assert.NotNil(s.T(), wi.Data.Type)
@@ -1292,7 +1523,7 @@ func (s *WorkItem2Suite) TestWI2SuccessShow() {
 			ID:   workitem.SystemBug,
 		},
 	}
-	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	_, fetchedWi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *createdWi.Data.ID)
 	assert.NotNil(s.T(), fetchedWi.Data)
 	assert.NotNil(s.T(), fetchedWi.Data.ID)
@@ -1319,7 +1550,7 @@ func (s *WorkItem2Suite) TestWI2SuccessDelete() {
 			ID:   workitem.SystemBug,
 		},
 	}
-	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *createdWi.Data.ID)
 	test.DeleteWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *createdWi.Data.ID)
 	test.ShowWorkitemNotFound(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *createdWi.Data.ID)
@@ -1339,10 +1570,10 @@ func (s *WorkItem2Suite) TestWI2DeleteLinksOnWIDeletionOK() {
 			ID:   workitem.SystemBug,
 		},
 	}
-	_, wi1 := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi1 := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	require.NotNil(s.T(), wi1)
 	c.Data.Attributes[workitem.SystemTitle] = "WI2"
-	_, wi2 := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi2 := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	require.NotNil(s.T(), wi2)
 
 	// Create link category
@@ -1411,7 +1642,7 @@ func (s *WorkItem2Suite) TestWI2CreateWithArea() {
 			},
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	assert.NotNil(t, wi.Data.Relationships.Area)
 	assert.Equal(t, areaID, *wi.Data.Relationships.Area.Data.ID)
 }
@@ -1431,7 +1662,7 @@ func (s *WorkItem2Suite) TestWI2UpdateWithArea() {
 			ID:   workitem.SystemBug,
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	assert.NotNil(t, wi.Data.Relationships.Area)
 	assert.Nil(t, wi.Data.Relationships.Area.Data)
 
@@ -1475,7 +1706,7 @@ func (s *WorkItem2Suite) TestWI2CreateUnknownArea() {
 			ID:   &areaID,
 		},
 	}
-	test.CreateWorkitemBadRequest(t, s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	test.CreateWorkitemBadRequest(t, s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 }
 
 func (s *WorkItem2Suite) TestWI2CreateWithIteration() {
@@ -1508,7 +1739,7 @@ func (s *WorkItem2Suite) TestWI2CreateWithIteration() {
 			},
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	assert.NotNil(t, wi.Data.Relationships.Iteration)
 	assert.Equal(t, iterationID, *wi.Data.Relationships.Iteration.Data.ID)
 }
@@ -1529,7 +1760,7 @@ func (s *WorkItem2Suite) TestWI2UpdateWithIteration() {
 			ID:   workitem.SystemBug,
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	assert.NotNil(t, wi.Data.Relationships.Iteration)
 	assert.Nil(t, wi.Data.Relationships.Iteration.Data)
 
@@ -1575,7 +1806,7 @@ func (s *WorkItem2Suite) TestWI2UpdateRemoveIteration() {
 			ID:   &iterationID,
 		},
 	}
-	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, wi := test.CreateWorkitemCreated(t, s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	assert.NotNil(t, wi.Data.Relationships.Iteration)
 	assert.NotNil(t, wi.Data.Relationships.Iteration.Data)
 
@@ -1611,7 +1842,7 @@ func (s *WorkItem2Suite) TestWI2CreateUnknownIteration() {
 			ID:   &iterationID,
 		},
 	}
-	test.CreateWorkitemBadRequest(t, s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	test.CreateWorkitemBadRequest(t, s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 }
 
 func (s *WorkItem2Suite) TestWI2SuccessCreateAndPreventJavascriptInjectionWithLegacyDescription() {
@@ -1627,7 +1858,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateAndPreventJavascriptInjectionWithLe
 			ID:   workitem.SystemBug,
 		},
 	}
-	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	_, fetchedWi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *createdWi.Data.ID)
 	require.NotNil(s.T(), fetchedWi.Data)
 	require.NotNil(s.T(), fetchedWi.Data.Attributes)
@@ -1648,7 +1879,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateAndPreventJavascriptInjectionWithPl
 			ID:   workitem.SystemBug,
 		},
 	}
-	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	_, fetchedWi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *createdWi.Data.ID)
 	require.NotNil(s.T(), fetchedWi.Data)
 	require.NotNil(s.T(), fetchedWi.Data.Attributes)
@@ -1669,7 +1900,7 @@ func (s *WorkItem2Suite) TestWI2SuccessCreateAndPreventJavascriptInjectionWithMa
 			ID:   workitem.SystemBug,
 		},
 	}
-	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	_, fetchedWi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *createdWi.Data.ID)
 	require.NotNil(s.T(), fetchedWi.Data)
 	require.NotNil(s.T(), fetchedWi.Data.Attributes)
@@ -1700,7 +1931,7 @@ func (s *WorkItem2Suite) TestCreateWIWithCodebase() {
 		LineNumber: line,
 	}
 	c.Data.Attributes[workitem.SystemCodebase] = cbase.ToMap()
-	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	_, createdWi := test.CreateWorkitemCreated(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 	require.NotNil(t, createdWi)
 	_, fetchedWi := test.ShowWorkitemOK(s.T(), s.svc.Context, s.svc, s.wi2Ctrl, *createdWi.Data.ID)
 	require.NotNil(t, fetchedWi.Data)
@@ -1736,5 +1967,5 @@ func (s *WorkItem2Suite) TestFailToCreateWIWithCodebase() {
 		Branch: branch,
 	}
 	c.Data.Attributes[workitem.SystemCodebase] = cbase.ToMap()
-	test.CreateWorkitemBadRequest(t, s.svc.Context, s.svc, s.wi2Ctrl, &c)
+	test.CreateWorkitemBadRequest(t, s.svc.Context, s.svc, s.wi2Ctrl, nil, &c)
 }
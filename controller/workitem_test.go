@@ -7,6 +7,7 @@ import (
 
 	"github.com/almighty/almighty-core/app/test"
 	. "github.com/almighty/almighty-core/controller"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/resource"
 	testsupport "github.com/almighty/almighty-core/test"
 	"github.com/goadesign/goa"
@@ -18,7 +19,7 @@ func TestPagingLinks(t *testing.T) {
 	svc := goa.New("TestPaginLinks-Service")
 	assert.NotNil(t, svc)
 	db := testsupport.NewMockDB()
-	controller := NewWorkitemController(svc, db)
+	controller := NewWorkitemController(svc, db, wibConfiguration)
 
 	repo := db.WorkItems().(*testsupport.WorkItemRepository)
 	pagingTest := createPagingTest(t, svc.Context, controller, repo, 13)
@@ -41,34 +42,34 @@ func TestPagingErrors(t *testing.T) {
 	resource.Require(t, resource.UnitTest)
 	svc := goa.New("TestPaginErrors-Service")
 	db := testsupport.NewMockDB()
-	controller := NewWorkitemController(svc, db)
+	controller := NewWorkitemController(svc, db, wibConfiguration)
 	repo := db.WorkItems().(*testsupport.WorkItemRepository)
 	repo.ListReturns(makeWorkItems(100), uint64(100), nil)
 
 	var offset string = "-1"
 	var limit int = 2
-	_, result := test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result := test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	if !strings.Contains(*result.Links.First, "page[offset]=0") {
 		assert.Fail(t, "Offset is negative", "Expected offset to be %d, but was %s", 0, *result.Links.First)
 	}
 
 	offset = "0"
 	limit = 0
-	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=20") {
 		assert.Fail(t, "Limit is 0", "Expected limit to be default size %d, but was %s", 20, *result.Links.First)
 	}
 
 	offset = "0"
 	limit = -1
-	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=20") {
 		assert.Fail(t, "Limit is negative", "Expected limit to be default size %d, but was %s", 20, *result.Links.First)
 	}
 
 	offset = "-3"
 	limit = -1
-	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=20") {
 		assert.Fail(t, "Limit is negative", "Expected limit to be default size %d, but was %s", 20, *result.Links.First)
 	}
@@ -78,7 +79,7 @@ func TestPagingErrors(t *testing.T) {
 
 	offset = "ALPHA"
 	limit = 40
-	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=40") {
 		assert.Fail(t, "Limit is within range", "Expected limit to be size %d, but was %s", 40, *result.Links.First)
 	}
@@ -91,7 +92,7 @@ func TestPagingLinksHasAbsoluteURL(t *testing.T) {
 	resource.Require(t, resource.UnitTest)
 	svc := goa.New("TestPaginAbsoluteURL-Service")
 	db := testsupport.NewMockDB()
-	controller := NewWorkitemController(svc, db)
+	controller := NewWorkitemController(svc, db, wibConfiguration)
 
 	offset := "10"
 	limit := 10
@@ -99,7 +100,7 @@ func TestPagingLinksHasAbsoluteURL(t *testing.T) {
 	repo := db.WorkItems().(*testsupport.WorkItemRepository)
 	repo.ListReturns(makeWorkItems(10), uint64(100), nil)
 
-	_, result := test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result := test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	if !strings.HasPrefix(*result.Links.First, "http://") {
 		assert.Fail(t, "Not Absolute URL", "Expected link %s to contain absolute URL but was %s", "First", *result.Links.First)
 	}
@@ -118,26 +119,75 @@ func TestPagingDefaultAndMaxSize(t *testing.T) {
 	resource.Require(t, resource.UnitTest)
 	svc := goa.New("TestPaginSize-Service")
 	db := testsupport.NewMockDB()
-	controller := NewWorkitemController(svc, db)
+	controller := NewWorkitemController(svc, db, wibConfiguration)
 
 	offset := "0"
 	var limit int
 	repo := db.WorkItems().(*testsupport.WorkItemRepository)
 	repo.ListReturns(makeWorkItems(10), uint64(100), nil)
 
-	_, result := test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, &offset)
+	_, result := test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, &offset, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=20") {
 		assert.Fail(t, "Limit is nil", "Expected limit to be default size %d, got %v", 20, *result.Links.First)
 	}
 	limit = 1000
-	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=100") {
 		assert.Fail(t, "Limit is more than max", "Expected limit to be %d, got %v", 100, *result.Links.First)
 	}
 
 	limit = 50
-	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, &limit, &offset)
+	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, nil, &limit, &offset, nil)
 	if !strings.Contains(*result.Links.First, "page[limit]=50") {
 		assert.Fail(t, "Limit is within range", "Expected limit to be %d, got %v", 50, *result.Links.First)
 	}
 }
+
+func TestCursorPagingLinks(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	svc := goa.New("TestCursorPagingLinks-Service")
+	db := testsupport.NewMockDB()
+	controller := NewWorkitemController(svc, db, wibConfiguration)
+	repo := db.WorkItems().(*testsupport.WorkItemRepository)
+
+	keys := []pagination.Key{{Expression: "id"}}
+	first := "1"
+	last := "5"
+	repo.ListPageReturns(makeWorkItems(5), &pagination.Page{
+		First:      pagination.NewCursor(keys, []*string{&first}),
+		Last:       pagination.NewCursor(keys, []*string{&last}),
+		HasPrev:    true,
+		HasNext:    true,
+		TotalCount: 100,
+	}, nil)
+
+	after := pagination.NewCursor(keys, []*string{&first}).Encode()
+	limit := 5
+	_, result := test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, &after, nil, &limit, nil, nil)
+	_, _, _, keyset := repo.ListPageArgsForCall(0)
+	assert.Equal(t, pagination.Keyset{Cursor: pagination.NewCursor(keys, []*string{&first}), Direction: pagination.After, Limit: 5}, keyset)
+	assert.Equal(t, 100, result.Meta.TotalCount)
+	assert.True(t, strings.HasSuffix(*result.Links.Next, "?page[after]="+pagination.NewCursor(keys, []*string{&last}).Encode()+"&page[limit]=5"), *result.Links.Next)
+	assert.True(t, strings.HasSuffix(*result.Links.Prev, "?page[before]="+pagination.NewCursor(keys, []*string{&first}).Encode()+"&page[limit]=5"), *result.Links.Prev)
+	assert.True(t, strings.HasSuffix(*result.Links.First, "?page[after]=&page[limit]=5"), *result.Links.First)
+	assert.True(t, strings.HasSuffix(*result.Links.Last, "?page[before]=&page[limit]=5"), *result.Links.Last)
+
+	// the last page has no next link
+	repo.ListPageReturns(makeWorkItems(2), &pagination.Page{
+		First:      pagination.NewCursor(keys, []*string{&first}),
+		Last:       pagination.NewCursor(keys, []*string{&last}),
+		HasPrev:    true,
+		TotalCount: 100,
+	}, nil)
+	before := ""
+	_, result = test.ListWorkitemOK(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, nil, &before, &limit, nil, nil)
+	_, _, _, keyset = repo.ListPageArgsForCall(1)
+	assert.Equal(t, pagination.Keyset{Direction: pagination.Before, Limit: 5}, keyset)
+	assert.Nil(t, result.Links.Next)
+	assert.NotNil(t, result.Links.Prev)
+
+	// malformed cursors and both cursors at once are rejected
+	malformed := "not a cursor!"
+	test.ListWorkitemBadRequest(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, &malformed, nil, &limit, nil, nil)
+	test.ListWorkitemBadRequest(t, context.Background(), nil, controller, nil, nil, nil, nil, nil, nil, nil, &after, &before, &limit, nil, nil)
+}
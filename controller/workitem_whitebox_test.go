@@ -73,7 +73,7 @@ func TestNewWorkitemController(t *testing.T) {
 	t.Parallel()
 	resource.Require(t, resource.UnitTest)
 	assert.Panics(t, func() {
-		NewWorkitemController(goa.New("Test service"), nil)
+		NewWorkitemController(goa.New("Test service"), nil, configuration)
 	})
 }
 
@@ -313,3 +313,24 @@ func TestConvertJSONAPIToWorkItemWithEmptyTitle(t *testing.T) {
 	// then: no error expected at this level, even though the title is missing
 	require.Nil(t, err)
 }
+
+func TestConvertJSONAPIToWorkItemWithNullAttributes(t *testing.T) {
+	// given
+	appl := new(application.Application)
+	attributes := map[string]interface{}{
+		"version":                  1,
+		workitem.SystemDescription: nil,
+		workitem.SystemCodebase:    nil,
+	}
+	source := prepareWI2(attributes)
+	target := &app.WorkItem{Fields: map[string]interface{}{}}
+	// when
+	err := ConvertJSONAPIToWorkItem(*appl, source, target)
+	// then the null values are kept to remove the fields and the version is not a field
+	require.Nil(t, err)
+	assert.Equal(t, 1, target.Version)
+	assert.Equal(t, map[string]interface{}{
+		workitem.SystemDescription: nil,
+		workitem.SystemCodebase:    nil,
+	}, target.Fields)
+}
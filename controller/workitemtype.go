@@ -4,8 +4,13 @@ import (
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/jsonapi"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/login"
+	"github.com/almighty/almighty-core/space"
 	"github.com/goadesign/goa"
 	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -54,6 +59,52 @@ func (c *WorkitemtypeController) Create(ctx *app.CreateWorkitemtypeContext) erro
 	})
 }
 
+// Update runs the update action.
+func (c *WorkitemtypeController) Update(ctx *app.UpdateWorkitemtypeContext) error {
+	currentUserIdentityID, err := login.ContextIdentity(ctx)
+	if err != nil {
+		jerrors, _ := jsonapi.ErrorToJSONAPIErrors(goa.ErrUnauthorized(err.Error()))
+		return ctx.Unauthorized(jerrors)
+	}
+	var result *app.WorkItemTypeUpdate
+	// the error is returned from the transaction so that the work items migrated before it are rolled back
+	err = application.Transactional(c.db, func(appl application.Application) error {
+		if err := checkWorkItemTypeOwner(ctx, appl, ctx.WitID, *currentUserIdentityID); err != nil {
+			return err
+		}
+		var err error
+		result, err = appl.WorkItemTypes().Update(ctx.Context, ctx.WitID, ctx.Payload.Version, ctx.Payload.Changes, *currentUserIdentityID, ctx.DryRun)
+		return err
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(result)
+}
+
+// checkWorkItemTypeOwner checks that the identity owns the space of the work item type and may change the type,
+// the types of the system space can't be changed
+func checkWorkItemTypeOwner(ctx context.Context, appl application.Application, witID uuid.UUID, identityID uuid.UUID) error {
+	wit, err := appl.WorkItemTypes().Load(ctx, witID)
+	if err != nil {
+		return errs.WithStack(err)
+	}
+	spaceID := *wit.Data.Relationships.Space.Data.ID
+	if uuid.Equal(spaceID, space.SystemSpace) {
+		log.Error(ctx, map[string]interface{}{"witID": witID}, "Work item type is a system type")
+		return goa.NewErrorClass("forbidden", 403)("System work item types can't be changed")
+	}
+	s, err := appl.Spaces().Load(ctx, spaceID)
+	if err != nil {
+		return errs.WithStack(err)
+	}
+	if !uuid.Equal(identityID, s.OwnerId) {
+		log.Error(ctx, map[string]interface{}{"currentUser": identityID, "owner": s.OwnerId}, "Current user is not owner")
+		return goa.NewErrorClass("forbidden", 403)("User is not the space owner")
+	}
+	return nil
+}
+
 // List runs the list action
 func (c *WorkitemtypeController) List(ctx *app.ListWorkitemtypeContext) error {
 	start, limit, err := parseLimit(ctx.Page)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/app/test"
+	"github.com/almighty/almighty-core/application"
 	config "github.com/almighty/almighty-core/configuration"
 	. "github.com/almighty/almighty-core/controller"
 	"github.com/almighty/almighty-core/gormapplication"
@@ -286,6 +287,93 @@ func (s *workItemTypeSuite) TestShowWorkItemType() {
 	require.EqualValues(s.T(), wit, wit2)
 }
 
+// TestUpdateWorkItemType tests if the owner of a space can rename the "test" field of a work item type of the
+// space, first with a dry run and then for real
+func (s *workItemTypeSuite) TestUpdateWorkItemType() {
+	// given
+	identity, err := testsupport.CreateTestIdentity(s.DB, "TestUpdateWorkItemType-"+uuid.NewV4().String(), "test")
+	require.Nil(s.T(), err)
+	ownedSpace := space.Space{Name: "TestUpdateWorkItemType-" + uuid.NewV4().String(), OwnerId: identity.ID}
+	err = application.Transactional(gormapplication.NewGormDB(s.DB), func(appl application.Application) error {
+		_, err := appl.Spaces().Create(context.Background(), &ownedSpace)
+		return err
+	})
+	require.Nil(s.T(), err)
+	createPayload := CreateWorkItemType(uuid.NewV4(), ownedSpace.ID)
+	_, wit := test.CreateWorkitemtypeCreated(s.T(), nil, nil, s.typeCtrl, &createPayload)
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	svc := testsupport.ServiceAsUser("workItemTypeUpdate-Service", almtoken.NewManagerWithPrivateKey(priv), identity)
+	ctrl := NewWorkitemtypeController(svc, gormapplication.NewGormDB(s.DB))
+	newName := "full_name"
+	payload := app.WorkItemTypeUpdatePayload{
+		Version: wit.Data.Attributes.Version,
+		Changes: []*app.FieldChange{
+			{Op: workitem.FieldChangeRename, Field: "test", NewName: &newName},
+		},
+	}
+	// when
+	_, dryRun := test.UpdateWorkitemtypeOK(s.T(), svc.Context, svc, ctrl, true, *wit.Data.ID, &payload)
+	// then
+	require.NotNil(s.T(), dryRun.Meta)
+	assert.True(s.T(), dryRun.Meta.DryRun)
+	require.Len(s.T(), dryRun.Meta.Changes, 1)
+	assert.Equal(s.T(), 0, dryRun.Meta.Changes[0].Affected)
+	assert.Contains(s.T(), dryRun.Data.Attributes.Fields, newName)
+	_, shown := test.ShowWorkitemtypeOK(s.T(), nil, nil, s.typeCtrl, *wit.Data.ID)
+	assert.Contains(s.T(), shown.Data.Attributes.Fields, "test")
+	// when
+	_, updated := test.UpdateWorkitemtypeOK(s.T(), svc.Context, svc, ctrl, false, *wit.Data.ID, &payload)
+	// then
+	assert.False(s.T(), updated.Meta.DryRun)
+	assert.Equal(s.T(), wit.Data.Attributes.Version+1, updated.Data.Attributes.Version)
+	_, shown = test.ShowWorkitemtypeOK(s.T(), nil, nil, s.typeCtrl, *wit.Data.ID)
+	assert.Contains(s.T(), shown.Data.Attributes.Fields, newName)
+	assert.NotContains(s.T(), shown.Data.Attributes.Fields, "test")
+	// when the version is outdated
+	test.UpdateWorkitemtypeBadRequest(s.T(), svc.Context, svc, ctrl, false, *wit.Data.ID, &payload)
+}
+
+// TestUpdateWorkItemTypeForbidden tests that only the owner of the space of a work item type may change it and
+// that the types of the system space can't be changed
+func (s *workItemTypeSuite) TestUpdateWorkItemTypeForbidden() {
+	// given
+	owner, err := testsupport.CreateTestIdentity(s.DB, "TestUpdateWorkItemTypeForbidden-"+uuid.NewV4().String(), "test")
+	require.Nil(s.T(), err)
+	other, err := testsupport.CreateTestIdentity(s.DB, "TestUpdateWorkItemTypeForbidden-"+uuid.NewV4().String(), "test")
+	require.Nil(s.T(), err)
+	ownedSpace := space.Space{Name: "TestUpdateWorkItemTypeForbidden-" + uuid.NewV4().String(), OwnerId: owner.ID}
+	err = application.Transactional(gormapplication.NewGormDB(s.DB), func(appl application.Application) error {
+		_, err := appl.Spaces().Create(context.Background(), &ownedSpace)
+		return err
+	})
+	require.Nil(s.T(), err)
+	createPayload := CreateWorkItemType(uuid.NewV4(), ownedSpace.ID)
+	_, wit := test.CreateWorkitemtypeCreated(s.T(), nil, nil, s.typeCtrl, &createPayload)
+	_, systemType := s.createWorkItemTypePerson()
+	priv, _ := almtoken.ParsePrivateKey([]byte(almtoken.RSAPrivateKey))
+	newName := "full_name"
+	rename := func(field string, version int) *app.WorkItemTypeUpdatePayload {
+		return &app.WorkItemTypeUpdatePayload{
+			Version: version,
+			Changes: []*app.FieldChange{
+				{Op: workitem.FieldChangeRename, Field: field, NewName: &newName},
+			},
+		}
+	}
+	// when another identity changes the type
+	svc := testsupport.ServiceAsUser("workItemTypeUpdate-Service", almtoken.NewManagerWithPrivateKey(priv), other)
+	ctrl := NewWorkitemtypeController(svc, gormapplication.NewGormDB(s.DB))
+	// then
+	test.UpdateWorkitemtypeForbidden(s.T(), svc.Context, svc, ctrl, false, *wit.Data.ID, rename("test", wit.Data.Attributes.Version))
+	// when the owner of a space changes a system type
+	svc = testsupport.ServiceAsUser("workItemTypeUpdate-Service", almtoken.NewManagerWithPrivateKey(priv), owner)
+	ctrl = NewWorkitemtypeController(svc, gormapplication.NewGormDB(s.DB))
+	// then
+	test.UpdateWorkitemtypeForbidden(s.T(), svc.Context, svc, ctrl, false, *systemType.Data.ID, rename("name", systemType.Data.Attributes.Version))
+	_, shown := test.ShowWorkitemtypeOK(s.T(), nil, nil, s.typeCtrl, *wit.Data.ID)
+	assert.Contains(s.T(), shown.Data.Attributes.Fields, "test")
+}
+
 // TestListWorkItemType tests if we can find the work item types
 // "person" and "animal" in the list of work item types
 func (s *workItemTypeSuite) TestListWorkItemType() {
@@ -405,6 +493,7 @@ func getWorkItemTypeTestData(t *testing.T) []testSecureAPI {
 	differentPrivatekey, err := jwt.ParseRSAPrivateKeyFromPEM(([]byte(RSADifferentPrivateKeyTest)))
 	require.Nil(t, err)
 
+	updateWITPayloadString := bytes.NewBuffer([]byte(`{"version": 0, "changes": [{"op": "remove", "field": "effort"}]}`))
 	createWITPayloadString := bytes.NewBuffer([]byte(`{"fields": {"system.administrator": {"Required": true,"Type": {"Kind": "string"}}},"name": "Epic"}`))
 
 	return []testSecureAPI{
@@ -438,6 +527,22 @@ func getWorkItemTypeTestData(t *testing.T) []testSecureAPI {
 			payload:            createWITPayloadString,
 			jwtToken:           "",
 		},
+		// Update a work item type without a valid token
+		{
+			method:             http.MethodPatch,
+			url:                endpointWorkItemTypes + "/2e889d4e-49a9-463b-8cd4-6a3a95155103",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedErrorCode:  jsonapi.ErrorCodeJWTSecurityError,
+			payload:            updateWITPayloadString,
+			jwtToken:           getExpiredAuthHeader(t, privatekey),
+		}, {
+			method:             http.MethodPatch,
+			url:                endpointWorkItemTypes + "/2e889d4e-49a9-463b-8cd4-6a3a95155103",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedErrorCode:  jsonapi.ErrorCodeJWTSecurityError,
+			payload:            updateWITPayloadString,
+			jwtToken:           "",
+		},
 		// Try fetching a random work Item Type
 		// We do not have security on GET hence this should return 404 not found
 		{
@@ -35,12 +35,27 @@ type BinaryExpression interface {
 	Right() Expression
 }
 
+// UnaryExpression represents expressions with a single child
+type UnaryExpression interface {
+	Expression
+	Operand() Expression
+}
+
 // ExpressionVisitor is an implementation of the visitor pattern for expressions
 type ExpressionVisitor interface {
 	Field(t *FieldExpression) interface{}
 	And(a *AndExpression) interface{}
 	Or(a *OrExpression) interface{}
 	Equals(e *EqualsExpression) interface{}
+	NotEquals(e *NotEqualsExpression) interface{}
+	GreaterThan(e *GreaterThanExpression) interface{}
+	GreaterOrEquals(e *GreaterOrEqualsExpression) interface{}
+	LessThan(e *LessThanExpression) interface{}
+	LessOrEquals(e *LessOrEqualsExpression) interface{}
+	In(e *InExpression) interface{}
+	Substring(e *SubstringExpression) interface{}
+	IsNull(e *IsNullExpression) interface{}
+	Not(e *NotExpression) interface{}
 	Parameter(v *ParameterExpression) interface{}
 	Literal(c *LiteralExpression) interface{}
 }
@@ -198,3 +213,172 @@ func (t *EqualsExpression) Accept(visitor ExpressionVisitor) interface{} {
 func Equals(left Expression, right Expression) Expression {
 	return reparent(&EqualsExpression{binaryExpression{expression{}, left, right}})
 }
+
+// !=
+
+// NotEqualsExpression represents the inequality operator
+type NotEqualsExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *NotEqualsExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.NotEquals(t)
+}
+
+// NotEquals constructs a NotEqualsExpression
+func NotEquals(left Expression, right Expression) Expression {
+	return reparent(&NotEqualsExpression{binaryExpression{expression{}, left, right}})
+}
+
+// >
+
+// GreaterThanExpression represents the "greater than" operator
+type GreaterThanExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *GreaterThanExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.GreaterThan(t)
+}
+
+// GreaterThan constructs a GreaterThanExpression
+func GreaterThan(left Expression, right Expression) Expression {
+	return reparent(&GreaterThanExpression{binaryExpression{expression{}, left, right}})
+}
+
+// >=
+
+// GreaterOrEqualsExpression represents the "greater than or equal" operator
+type GreaterOrEqualsExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *GreaterOrEqualsExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.GreaterOrEquals(t)
+}
+
+// GreaterOrEquals constructs a GreaterOrEqualsExpression
+func GreaterOrEquals(left Expression, right Expression) Expression {
+	return reparent(&GreaterOrEqualsExpression{binaryExpression{expression{}, left, right}})
+}
+
+// <
+
+// LessThanExpression represents the "less than" operator
+type LessThanExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *LessThanExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.LessThan(t)
+}
+
+// LessThan constructs a LessThanExpression
+func LessThan(left Expression, right Expression) Expression {
+	return reparent(&LessThanExpression{binaryExpression{expression{}, left, right}})
+}
+
+// <=
+
+// LessOrEqualsExpression represents the "less than or equal" operator
+type LessOrEqualsExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *LessOrEqualsExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.LessOrEquals(t)
+}
+
+// LessOrEquals constructs a LessOrEqualsExpression
+func LessOrEquals(left Expression, right Expression) Expression {
+	return reparent(&LessOrEqualsExpression{binaryExpression{expression{}, left, right}})
+}
+
+// In
+
+// InExpression represents the membership operator. The right hand side is expected to evaluate to a list of values.
+type InExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *InExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.In(t)
+}
+
+// In constructs an InExpression
+func In(left Expression, right Expression) Expression {
+	return reparent(&InExpression{binaryExpression{expression{}, left, right}})
+}
+
+// Substring
+
+// SubstringExpression represents a case insensitive test whether the left string contains the right string
+type SubstringExpression struct {
+	binaryExpression
+}
+
+// Accept implements ExpressionVisitor
+func (t *SubstringExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.Substring(t)
+}
+
+// Substring constructs a SubstringExpression
+func Substring(left Expression, right Expression) Expression {
+	return reparent(&SubstringExpression{binaryExpression{expression{}, left, right}})
+}
+
+// IsNull
+
+// IsNullExpression represents a test for a missing value
+type IsNullExpression struct {
+	expression
+	operand Expression
+}
+
+// Operand implements UnaryExpression
+func (t *IsNullExpression) Operand() Expression {
+	return t.operand
+}
+
+// Accept implements ExpressionVisitor
+func (t *IsNullExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.IsNull(t)
+}
+
+// IsNull constructs an IsNullExpression
+func IsNull(operand Expression) Expression {
+	result := &IsNullExpression{expression{}, operand}
+	operand.setParent(result)
+	return result
+}
+
+// Not
+
+// NotExpression represents the negation of a term
+type NotExpression struct {
+	expression
+	operand Expression
+}
+
+// Operand implements UnaryExpression
+func (t *NotExpression) Operand() Expression {
+	return t.operand
+}
+
+// Accept implements ExpressionVisitor
+func (t *NotExpression) Accept(visitor ExpressionVisitor) interface{} {
+	return visitor.Not(t)
+}
+
+// Not constructs a NotExpression
+func Not(operand Expression) Expression {
+	result := &NotExpression{expression{}, operand}
+	operand.setParent(result)
+	return result
+}
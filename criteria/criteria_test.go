@@ -14,3 +14,12 @@ func TestGetParent(t *testing.T) {
 		t.Errorf("parent should be %v, but is %v", expr, l.Parent())
 	}
 }
+
+func TestGetParentOfNot(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	operand := Equals(Field("a"), Literal(5))
+	expr := Not(operand)
+	if operand.Parent() != expr {
+		t.Errorf("parent should be %v, but is %v", expr, operand.Parent())
+	}
+}
@@ -27,6 +27,42 @@ func (i *postOrderIterator) Equals(exp *EqualsExpression) interface{} {
 	return i.binary(exp)
 }
 
+func (i *postOrderIterator) NotEquals(exp *NotEqualsExpression) interface{} {
+	return i.binary(exp)
+}
+
+func (i *postOrderIterator) GreaterThan(exp *GreaterThanExpression) interface{} {
+	return i.binary(exp)
+}
+
+func (i *postOrderIterator) GreaterOrEquals(exp *GreaterOrEqualsExpression) interface{} {
+	return i.binary(exp)
+}
+
+func (i *postOrderIterator) LessThan(exp *LessThanExpression) interface{} {
+	return i.binary(exp)
+}
+
+func (i *postOrderIterator) LessOrEquals(exp *LessOrEqualsExpression) interface{} {
+	return i.binary(exp)
+}
+
+func (i *postOrderIterator) In(exp *InExpression) interface{} {
+	return i.binary(exp)
+}
+
+func (i *postOrderIterator) Substring(exp *SubstringExpression) interface{} {
+	return i.binary(exp)
+}
+
+func (i *postOrderIterator) IsNull(exp *IsNullExpression) interface{} {
+	return i.unary(exp)
+}
+
+func (i *postOrderIterator) Not(exp *NotExpression) interface{} {
+	return i.unary(exp)
+}
+
 func (i *postOrderIterator) Parameter(exp *ParameterExpression) interface{} {
 	return i.visit(exp)
 }
@@ -35,6 +71,13 @@ func (i *postOrderIterator) Literal(exp *LiteralExpression) interface{} {
 	return i.visit(exp)
 }
 
+func (i *postOrderIterator) unary(exp UnaryExpression) bool {
+	if exp.Operand().Accept(i) == false {
+		return false
+	}
+	return i.visit(exp)
+}
+
 func (i *postOrderIterator) binary(exp BinaryExpression) bool {
 	if exp.Left().Accept(i) == false {
 		return false
@@ -0,0 +1,372 @@
+package criteria
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// The JSON form of an expression is an object with an "op" member naming the node type.
+// Binary expressions have "left" and "right", unary expressions an "operand", fields a "name"
+// and literals a "type" and a "value", for example
+//   {"op":"and","left":{"op":"field","name":"a"},"right":{"op":"literal","type":"bool","value":true}}
+// The type of literals is recorded, so that an expression reads back exactly as it was written.
+// Annotations are not part of the JSON form.
+
+// names of the node types in the JSON form
+const (
+	opField           = "field"
+	opParameter       = "parameter"
+	opLiteral         = "literal"
+	opAnd             = "and"
+	opOr              = "or"
+	opEquals          = "eq"
+	opNotEquals       = "ne"
+	opGreaterThan     = "gt"
+	opGreaterOrEquals = "ge"
+	opLessThan        = "lt"
+	opLessOrEquals    = "le"
+	opIn              = "in"
+	opSubstring       = "substring"
+	opIsNull          = "isnull"
+	opNot             = "not"
+)
+
+// names of the literal types in the JSON form
+const (
+	literalNull    = "null"
+	literalBool    = "bool"
+	literalString  = "string"
+	literalInt     = "int"
+	literalInt64   = "int64"
+	literalUint    = "uint"
+	literalUint64  = "uint64"
+	literalFloat64 = "float64"
+	literalUUID    = "uuid"
+	literalStrings = "strings"
+	literalList    = "list"
+)
+
+var binaryConstructors = map[string]func(left Expression, right Expression) Expression{
+	opAnd:             And,
+	opOr:              Or,
+	opEquals:          Equals,
+	opNotEquals:       NotEquals,
+	opGreaterThan:     GreaterThan,
+	opGreaterOrEquals: GreaterOrEquals,
+	opLessThan:        LessThan,
+	opLessOrEquals:    LessOrEquals,
+	opIn:              In,
+	opSubstring:       Substring,
+}
+
+var unaryConstructors = map[string]func(operand Expression) Expression{
+	opIsNull: IsNull,
+	opNot:    Not,
+}
+
+// jsonExpression is the JSON form of an expression node
+type jsonExpression struct {
+	Op      string          `json:"op"`
+	Name    string          `json:"name,omitempty"`
+	Left    *jsonExpression `json:"left,omitempty"`
+	Right   *jsonExpression `json:"right,omitempty"`
+	Operand *jsonExpression `json:"operand,omitempty"`
+	Type    string          `json:"type,omitempty"`
+	Value   json.RawMessage `json:"value,omitempty"`
+}
+
+// jsonLiteral is the JSON form of a literal value
+type jsonLiteral struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Marshal returns the JSON form of the expression. Literals may hold nil, bool, string, int, int64,
+// uint, uint64, float64, uuid.UUID, []string or []interface{} of those values, an error is returned
+// for other literal values.
+func Marshal(exp Expression) ([]byte, error) {
+	marshaller := jsonMarshaller{}
+	result := exp.Accept(&marshaller)
+	if marshaller.err != nil {
+		return nil, marshaller.err
+	}
+	return json.Marshal(result)
+}
+
+// Unmarshal reads back an expression written by Marshal.
+// Returns an error if the data isn't the JSON form of an expression.
+func Unmarshal(data []byte) (Expression, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	node := jsonExpression{}
+	if err := decoder.Decode(&node); err != nil {
+		return nil, errors.Wrap(err, "invalid expression")
+	}
+	return node.expression()
+}
+
+// jsonMarshaller converts an expression to its JSON form
+// implements ExpressionVisitor
+type jsonMarshaller struct {
+	err error
+}
+
+func (m *jsonMarshaller) binary(op string, exp BinaryExpression) interface{} {
+	return &jsonExpression{
+		Op:    op,
+		Left:  exp.Left().Accept(m).(*jsonExpression),
+		Right: exp.Right().Accept(m).(*jsonExpression),
+	}
+}
+
+func (m *jsonMarshaller) unary(op string, exp UnaryExpression) interface{} {
+	return &jsonExpression{Op: op, Operand: exp.Operand().Accept(m).(*jsonExpression)}
+}
+
+func (m *jsonMarshaller) Field(exp *FieldExpression) interface{} {
+	return &jsonExpression{Op: opField, Name: exp.FieldName}
+}
+
+func (m *jsonMarshaller) Parameter(exp *ParameterExpression) interface{} {
+	return &jsonExpression{Op: opParameter}
+}
+
+func (m *jsonMarshaller) Literal(exp *LiteralExpression) interface{} {
+	literal, err := marshalLiteral(exp.Value)
+	if err != nil {
+		if m.err == nil {
+			m.err = err
+		}
+		return &jsonExpression{Op: opLiteral}
+	}
+	return &jsonExpression{Op: opLiteral, Type: literal.Type, Value: literal.Value}
+}
+
+func (m *jsonMarshaller) And(exp *AndExpression) interface{} {
+	return m.binary(opAnd, exp)
+}
+
+func (m *jsonMarshaller) Or(exp *OrExpression) interface{} {
+	return m.binary(opOr, exp)
+}
+
+func (m *jsonMarshaller) Equals(exp *EqualsExpression) interface{} {
+	return m.binary(opEquals, exp)
+}
+
+func (m *jsonMarshaller) NotEquals(exp *NotEqualsExpression) interface{} {
+	return m.binary(opNotEquals, exp)
+}
+
+func (m *jsonMarshaller) GreaterThan(exp *GreaterThanExpression) interface{} {
+	return m.binary(opGreaterThan, exp)
+}
+
+func (m *jsonMarshaller) GreaterOrEquals(exp *GreaterOrEqualsExpression) interface{} {
+	return m.binary(opGreaterOrEquals, exp)
+}
+
+func (m *jsonMarshaller) LessThan(exp *LessThanExpression) interface{} {
+	return m.binary(opLessThan, exp)
+}
+
+func (m *jsonMarshaller) LessOrEquals(exp *LessOrEqualsExpression) interface{} {
+	return m.binary(opLessOrEquals, exp)
+}
+
+func (m *jsonMarshaller) In(exp *InExpression) interface{} {
+	return m.binary(opIn, exp)
+}
+
+func (m *jsonMarshaller) Substring(exp *SubstringExpression) interface{} {
+	return m.binary(opSubstring, exp)
+}
+
+func (m *jsonMarshaller) IsNull(exp *IsNullExpression) interface{} {
+	return m.unary(opIsNull, exp)
+}
+
+func (m *jsonMarshaller) Not(exp *NotExpression) interface{} {
+	return m.unary(opNot, exp)
+}
+
+// marshalLiteral returns the JSON form of a literal value
+func marshalLiteral(value interface{}) (*jsonLiteral, error) {
+	var literalType string
+	var encoded interface{} = value
+	switch t := value.(type) {
+	case nil:
+		literalType = literalNull
+	case bool:
+		literalType = literalBool
+	case string:
+		literalType = literalString
+	case int:
+		literalType = literalInt
+	case int64:
+		literalType = literalInt64
+	case uint:
+		literalType = literalUint
+	case uint64:
+		literalType = literalUint64
+	case float64:
+		literalType = literalFloat64
+	case uuid.UUID:
+		literalType = literalUUID
+		encoded = t.String()
+	case []string:
+		literalType = literalStrings
+	case []interface{}:
+		literalType = literalList
+		elements := make([]*jsonLiteral, len(t))
+		for i, element := range t {
+			var err error
+			if elements[i], err = marshalLiteral(element); err != nil {
+				return nil, err
+			}
+		}
+		encoded = elements
+	default:
+		return nil, errors.Errorf("unsupported literal value %v of type %T", value, value)
+	}
+	raw, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &jsonLiteral{Type: literalType, Value: raw}, nil
+}
+
+// expression converts the JSON form back into an expression
+func (node *jsonExpression) expression() (Expression, error) {
+	if constructor, ok := binaryConstructors[node.Op]; ok {
+		if node.Left == nil || node.Right == nil {
+			return nil, errors.Errorf("'%s' expression without operands", node.Op)
+		}
+		left, err := node.Left.expression()
+		if err != nil {
+			return nil, err
+		}
+		right, err := node.Right.expression()
+		if err != nil {
+			return nil, err
+		}
+		return constructor(left, right), nil
+	}
+	if constructor, ok := unaryConstructors[node.Op]; ok {
+		if node.Operand == nil {
+			return nil, errors.Errorf("'%s' expression without operand", node.Op)
+		}
+		operand, err := node.Operand.expression()
+		if err != nil {
+			return nil, err
+		}
+		return constructor(operand), nil
+	}
+	switch node.Op {
+	case opField:
+		if node.Name == "" {
+			return nil, errors.New("field expression without a name")
+		}
+		return Field(node.Name), nil
+	case opParameter:
+		return Parameter(), nil
+	case opLiteral:
+		if node.Type == "" {
+			return nil, errors.New("literal expression without a type")
+		}
+		value, err := (&jsonLiteral{Type: node.Type, Value: node.Value}).value()
+		if err != nil {
+			return nil, err
+		}
+		return Literal(value), nil
+	}
+	return nil, errors.Errorf("unknown expression '%s'", node.Op)
+}
+
+// value converts the JSON form of a literal back into the literal value
+func (literal *jsonLiteral) value() (interface{}, error) {
+	var result interface{}
+	var err error
+	switch literal.Type {
+	case literalNull:
+		return nil, nil
+	case literalBool:
+		var b bool
+		err = literal.decode(&b)
+		result = b
+	case literalString:
+		var s string
+		err = literal.decode(&s)
+		result = s
+	case literalInt, literalInt64, literalUint, literalUint64, literalFloat64:
+		result, err = literal.number()
+	case literalUUID:
+		var s string
+		if err = literal.decode(&s); err == nil {
+			result, err = uuid.FromString(s)
+		}
+	case literalStrings:
+		var s []string
+		err = literal.decode(&s)
+		result = s
+	case literalList:
+		return literal.list()
+	default:
+		return nil, errors.Errorf("unknown literal type '%s'", literal.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s literal", literal.Type)
+	}
+	return result, nil
+}
+
+// decode decodes the value of the literal into target
+func (literal *jsonLiteral) decode(target interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(literal.Value))
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+// number converts the value of a numeric literal, without going through float64 for integers
+func (literal *jsonLiteral) number() (interface{}, error) {
+	var n json.Number
+	if err := literal.decode(&n); err != nil {
+		return nil, err
+	}
+	switch literal.Type {
+	case literalInt:
+		i, err := strconv.ParseInt(n.String(), 10, strconv.IntSize)
+		return int(i), err
+	case literalInt64:
+		return strconv.ParseInt(n.String(), 10, 64)
+	case literalUint:
+		u, err := strconv.ParseUint(n.String(), 10, strconv.IntSize)
+		return uint(u), err
+	case literalUint64:
+		return strconv.ParseUint(n.String(), 10, 64)
+	}
+	return strconv.ParseFloat(n.String(), 64)
+}
+
+// list converts the value of a list literal
+func (literal *jsonLiteral) list() (interface{}, error) {
+	elements := []*jsonLiteral{}
+	if err := literal.decode(&elements); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s literal", literal.Type)
+	}
+	result := make([]interface{}, len(elements))
+	for i, element := range elements {
+		if element == nil {
+			return nil, errors.Errorf("invalid %s literal", literal.Type)
+		}
+		var err error
+		if result[i], err = element.value(); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
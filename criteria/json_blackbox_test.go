@@ -0,0 +1,95 @@
+package criteria_test
+
+import (
+	"testing"
+
+	. "github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/resource"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func roundTrip(t *testing.T, exp Expression) Expression {
+	data, err := Marshal(exp)
+	require.Nil(t, err, "failed to marshal %s", String(exp))
+	result, err := Unmarshal(data)
+	require.Nil(t, err, "failed to unmarshal %s", data)
+	return result
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	id := uuid.NewV4()
+	for _, exp := range []Expression{
+		Field("a"),
+		Parameter(),
+		Literal(nil),
+		Literal(true),
+		Literal("it's"),
+		Literal(-1),
+		Literal(int64(9007199254740993)),
+		Literal(uint(7)),
+		Literal(uint64(18446744073709551615)),
+		Literal(2.5),
+		Literal(3.0),
+		Literal(id),
+		Literal([]string{"a", "b"}),
+		Literal([]interface{}{"a", int64(1), 1.5, nil, []interface{}{true}}),
+		Literal([]interface{}{}),
+		And(Equals(Field("a"), Literal(1)), Or(NotEquals(Field("b"), Literal("x")), Not(IsNull(Field("c"))))),
+		And(GreaterThan(Field("a"), Literal(1)), GreaterOrEquals(Field("a"), Literal(2))),
+		Or(LessThan(Field("a"), Literal(1)), LessOrEquals(Field("a"), Parameter())),
+		And(In(Field("a"), Literal([]interface{}{"x"})), Substring(Field("b"), Literal("y"))),
+	} {
+		assert.Equal(t, exp, roundTrip(t, exp))
+	}
+}
+
+func TestMarshalSetsParents(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	exp := roundTrip(t, Not(Equals(Field("a"), Literal(1))))
+	operand := exp.(*NotExpression).Operand()
+	assert.Equal(t, exp, operand.Parent())
+	assert.Equal(t, operand, operand.(*EqualsExpression).Left().Parent())
+}
+
+func TestMarshalFormat(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	data, err := Marshal(And(Equals(Field("a"), Literal([]string{"x"})), IsNull(Field("b"))))
+	require.Nil(t, err)
+	assert.JSONEq(t, `{"op":"and",
+		"left":{"op":"eq","left":{"op":"field","name":"a"},"right":{"op":"literal","type":"strings","value":["x"]}},
+		"right":{"op":"isnull","operand":{"op":"field","name":"b"}}}`, string(data))
+}
+
+func TestMarshalErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	_, err := Marshal(Equals(Field("a"), Literal(struct{}{})))
+	assert.NotNil(t, err)
+	_, err = Marshal(In(Field("a"), Literal([]interface{}{[]int{1}})))
+	assert.NotNil(t, err)
+
+	for _, data := range []string{
+		``,
+		`[]`,
+		`{"op":"xor"}`,
+		`{"op":"and","left":{"op":"field","name":"a"}}`,
+		`{"op":"not"}`,
+		`{"op":"field"}`,
+		`{"op":"literal"}`,
+		`{"op":"literal","type":"complex","value":1}`,
+		`{"op":"literal","type":"int","value":1.5}`,
+		`{"op":"literal","type":"uint64","value":-1}`,
+		`{"op":"literal","type":"bool","value":"yes"}`,
+		`{"op":"literal","type":"uuid","value":"not a uuid"}`,
+		`{"op":"literal","type":"list","value":[1]}`,
+	} {
+		_, err := Unmarshal([]byte(data))
+		assert.NotNil(t, err, "expected an error for %s", data)
+	}
+}
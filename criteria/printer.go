@@ -0,0 +1,176 @@
+package criteria
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// String returns the canonical textual form of the expression, like `system.state = "open" and not (a > 1 or
+// b is null)`, for logging and comparing expressions. It reflects the expression tree exactly: parentheses are
+// only left out where the precedence of the operators implies them and nothing is simplified or rewritten.
+// Field names which aren't made of letters, digits, '_' and '.' or which are keywords are enclosed in
+// backquotes, parameters are printed as '?'. []string literals are printed as ["a", "b"] and other lists as
+// ("a", 1). The form resembles the work item query language but isn't meant to be parsed by query.Parse:
+// field names aren't mapped back to their aliases and backquotes, parameters and [...] lists aren't part of
+// the query language.
+func String(exp Expression) string {
+	return exp.Accept(&printer{}).(string)
+}
+
+// precedence of the operators, higher binds tighter
+const (
+	precedenceOr = iota + 1
+	precedenceAnd
+	precedenceNot
+	precedenceComparison
+	precedenceOperand
+)
+
+// printer produces the canonical textual form of an expression
+// implements ExpressionVisitor
+type printer struct{}
+
+// keywords of the query language which can't be used as field names unquoted
+var keywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true, "is": true, "null": true, "true": true, "false": true, "me": true,
+}
+
+func precedence(exp Expression) int {
+	switch exp.(type) {
+	case *OrExpression:
+		return precedenceOr
+	case *AndExpression:
+		return precedenceAnd
+	case *NotExpression:
+		return precedenceNot
+	case *FieldExpression, *LiteralExpression, *ParameterExpression:
+		return precedenceOperand
+	}
+	return precedenceComparison
+}
+
+// operand prints exp as an operand of an operator with the given precedence. Boolean operators are
+// left associative, so their right operand needs parentheses on the same level already. Comparisons
+// can't be chained, their operands need parentheses unless they are fields, literals or parameters.
+func (p *printer) operand(exp Expression, parent int, right bool) string {
+	result := exp.Accept(p).(string)
+	sameLevel := precedence(exp) == parent && (right || parent == precedenceComparison)
+	if precedence(exp) < parent || sameLevel {
+		return "(" + result + ")"
+	}
+	return result
+}
+
+func (p *printer) binary(exp BinaryExpression, op string) string {
+	parent := precedence(exp)
+	return p.operand(exp.Left(), parent, false) + " " + op + " " + p.operand(exp.Right(), parent, true)
+}
+
+func (p *printer) Field(exp *FieldExpression) interface{} {
+	name := exp.FieldName
+	quote := name == "" || keywords[strings.ToLower(name)]
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+			quote = true
+		}
+	}
+	if quote {
+		return "`" + strings.Replace(name, "`", "``", -1) + "`"
+	}
+	return name
+}
+
+func (p *printer) Parameter(exp *ParameterExpression) interface{} {
+	return "?"
+}
+
+func (p *printer) Literal(exp *LiteralExpression) interface{} {
+	return formatLiteral(exp.Value)
+}
+
+// formatLiteral prints a literal value, strings are quoted with '"' and a backslash escapes the character following it
+func formatLiteral(value interface{}) string {
+	switch t := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(t) + `"`
+	case uuid.UUID:
+		return formatLiteral(t.String())
+	case float64:
+		result := strconv.FormatFloat(t, 'g', -1, 64)
+		if !strings.ContainsAny(result, ".eIN") {
+			// keep floats apart from integers
+			result += ".0"
+		}
+		return result
+	case []string:
+		elements := make([]string, len(t))
+		for i, element := range t {
+			elements[i] = formatLiteral(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+	values := reflect.ValueOf(value)
+	if values.Kind() == reflect.Slice || values.Kind() == reflect.Array {
+		elements := make([]string, values.Len())
+		for i := range elements {
+			elements[i] = formatLiteral(values.Index(i).Interface())
+		}
+		return "(" + strings.Join(elements, ", ") + ")"
+	}
+	return fmt.Sprint(value)
+}
+
+func (p *printer) And(exp *AndExpression) interface{} {
+	return p.binary(exp, "and")
+}
+
+func (p *printer) Or(exp *OrExpression) interface{} {
+	return p.binary(exp, "or")
+}
+
+func (p *printer) Equals(exp *EqualsExpression) interface{} {
+	return p.binary(exp, "=")
+}
+
+func (p *printer) NotEquals(exp *NotEqualsExpression) interface{} {
+	return p.binary(exp, "!=")
+}
+
+func (p *printer) GreaterThan(exp *GreaterThanExpression) interface{} {
+	return p.binary(exp, ">")
+}
+
+func (p *printer) GreaterOrEquals(exp *GreaterOrEqualsExpression) interface{} {
+	return p.binary(exp, ">=")
+}
+
+func (p *printer) LessThan(exp *LessThanExpression) interface{} {
+	return p.binary(exp, "<")
+}
+
+func (p *printer) LessOrEquals(exp *LessOrEqualsExpression) interface{} {
+	return p.binary(exp, "<=")
+}
+
+func (p *printer) In(exp *InExpression) interface{} {
+	return p.binary(exp, "in")
+}
+
+func (p *printer) Substring(exp *SubstringExpression) interface{} {
+	return p.binary(exp, "~")
+}
+
+func (p *printer) IsNull(exp *IsNullExpression) interface{} {
+	return p.operand(exp.Operand(), precedenceComparison, true) + " is null"
+}
+
+func (p *printer) Not(exp *NotExpression) interface{} {
+	return "not " + p.operand(exp.Operand(), precedenceNot, false)
+}
@@ -0,0 +1,55 @@
+package criteria_test
+
+import (
+	"testing"
+
+	. "github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/resource"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringOperators(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	assert.Equal(t, `a = "x"`, String(Equals(Field("a"), Literal("x"))))
+	assert.Equal(t, `a != 1`, String(NotEquals(Field("a"), Literal(1))))
+	assert.Equal(t, `a > 1.0`, String(GreaterThan(Field("a"), Literal(1.0))))
+	assert.Equal(t, `a >= 2.5`, String(GreaterOrEquals(Field("a"), Literal(2.5))))
+	assert.Equal(t, `a < true`, String(LessThan(Field("a"), Literal(true))))
+	assert.Equal(t, `a <= ?`, String(LessOrEquals(Field("a"), Parameter())))
+	assert.Equal(t, `a in ("x", 1)`, String(In(Field("a"), Literal([]interface{}{"x", 1}))))
+	assert.Equal(t, `a = ["x", "y"]`, String(Equals(Field("a"), Literal([]string{"x", "y"}))))
+	assert.Equal(t, `a ~ "it's \"quoted\" \\"`, String(Substring(Field("a"), Literal(`it's "quoted" \`))))
+	assert.Equal(t, `a is null`, String(IsNull(Field("a"))))
+	assert.Equal(t, `not a is null`, String(Not(IsNull(Field("a")))))
+	assert.Equal(t, `a = null`, String(Equals(Field("a"), Literal(nil))))
+	id := uuid.NewV4()
+	assert.Equal(t, `Type = "`+id.String()+`"`, String(Equals(Field("Type"), Literal(id))))
+}
+
+func TestStringFieldNames(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	assert.Equal(t, "system.title is null", String(IsNull(Field("system.title"))))
+	assert.Equal(t, "`my field` is null", String(IsNull(Field("my field"))))
+	assert.Equal(t, "`and` is null", String(IsNull(Field("and"))))
+	assert.Equal(t, "`a``b` is null", String(IsNull(Field("a`b"))))
+}
+
+func TestStringParentheses(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	a := func() Expression { return Equals(Field("a"), Literal(1)) }
+	b := func() Expression { return Equals(Field("b"), Literal(2)) }
+	c := func() Expression { return Equals(Field("c"), Literal(3)) }
+	assert.Equal(t, "a = 1 or b = 2 and c = 3", String(Or(a(), And(b(), c()))))
+	assert.Equal(t, "(a = 1 or b = 2) and c = 3", String(And(Or(a(), b()), c())))
+	assert.Equal(t, "a = 1 and b = 2 and c = 3", String(And(And(a(), b()), c())))
+	assert.Equal(t, "a = 1 and (b = 2 and c = 3)", String(And(a(), And(b(), c()))))
+	assert.Equal(t, "not a = 1 and b = 2", String(And(Not(a()), b())))
+	assert.Equal(t, "not (a = 1 and b = 2)", String(Not(And(a(), b()))))
+	assert.Equal(t, "not not a = 1", String(Not(Not(a()))))
+	assert.Equal(t, "true = (a = 1)", String(Equals(Literal(true), a())))
+	assert.Equal(t, "(a = 1) is null", String(IsNull(a())))
+}
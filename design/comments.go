@@ -172,6 +172,8 @@ var _ = a.Resource("work-item-comments", func() {
 			a.Param("page[offset]", d.String, `Paging start position is a string pointing to
 			the beginning of pagination.  The value starts from 0 onwards.`)
 			a.Param("page[limit]", d.Integer, `Paging size is the number of items in a page`)
+			a.Param("page[after]", d.String, "Cursor of the comment the page follows, taken from a next link. Empty for the first page")
+			a.Param("page[before]", d.String, "Cursor of the comment the page precedes, taken from a prev link. Empty for the last page")
 		})
 		a.Response(d.OK, func() {
 			a.Media(commentArray)
@@ -0,0 +1,69 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+var label = a.Type("Label", func() {
+	a.Description(`JSONAPI store for a label of the vocabulary of a space. The label fields of the work items in the space
+take their values from the vocabulary. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("labels")
+	})
+	a.Attribute("id", d.UUID, "ID of the label", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", labelAttributes)
+	a.Required("type", "attributes")
+})
+
+var labelAttributes = a.Type("LabelAttributes", func() {
+	a.Attribute("name", d.String, "The label, white space is trimmed and collapsed to single spaces", func() {
+		a.Example("backend")
+	})
+	a.Required("name")
+})
+
+var labelList = JSONList(
+	"label", "Holds the list of the labels of a space",
+	label,
+	nil,
+	nil)
+
+var labelSingle = JSONSingle(
+	"label", "Holds a label of a space",
+	label,
+	nil)
+
+var _ = a.Resource("space-labels", func() {
+	a.Parent("space")
+
+	a.Action("list", func() {
+		a.Routing(
+			a.GET("labels"),
+		)
+		a.Description("List the labels of the space in alphabetical order.")
+		a.Response(d.OK, func() {
+			a.Media(labelList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+	a.Action("create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("labels"),
+		)
+		a.Description("Add a label to the vocabulary of the space.")
+		a.Payload(labelSingle)
+		a.Response(d.Created, func() {
+			a.Media(labelSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+})
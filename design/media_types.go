@@ -49,6 +49,39 @@ var workItem = a.MediaType("application/vnd.workitem+json", func() {
 	})
 })
 
+// searchHit is the media type for the work items found by the full text search
+// Like workItem it's only used as internal model.
+var searchHit = a.MediaType("application/vnd.searchhit+json", func() {
+	a.TypeName("SearchHit")
+	a.Description("A work item matching a search together with the snippets of its searchable fields")
+	a.Attribute("workItem", workItem, "The matching work item")
+	a.Attribute("highlights", a.HashOf(d.String, d.String), "Snippets of the searchable fields of a search hit with the matched words in <mark> tags")
+
+	a.Required("workItem")
+
+	a.View("default", func() {
+		a.Attribute("workItem")
+		a.Attribute("highlights")
+	})
+})
+
+// duplicateHit is the media type for the likely duplicates of a draft found by the duplicates search
+// Like workItem it's only used as internal model.
+var duplicateHit = a.MediaType("application/vnd.duplicatehit+json", func() {
+	a.TypeName("DuplicateHit")
+	a.Description("A work item similar to a draft together with its similarity")
+	a.Attribute("workItem", workItem, "The similar work item")
+	a.Attribute("similarity", d.Number, "Similarity between 0 and 1 of a likely duplicate to the draft it was found for")
+
+	a.Required("workItem")
+	a.Required("similarity")
+
+	a.View("default", func() {
+		a.Attribute("workItem")
+		a.Attribute("similarity")
+	})
+})
+
 var pagingLinks = a.Type("pagingLinks", func() {
 	a.Attribute("prev", d.String)
 	a.Attribute("next", d.String)
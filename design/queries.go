@@ -0,0 +1,159 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+var savedQuery = a.Type("Query", func() {
+	a.Description(`JSONAPI store for the data of a saved work item query. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("queries")
+	})
+	a.Attribute("id", d.UUID, "ID of the query", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", queryAttributes)
+	a.Attribute("relationships", queryRelationships)
+	a.Attribute("links", genericLinks)
+	a.Required("type", "attributes")
+})
+
+var queryAttributes = a.Type("QueryAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of a saved query. See also http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("title", d.String, "The title of the query", func() {
+		a.Example("My open bugs")
+	})
+	a.Attribute("description", d.String, "Description of the query", func() {
+		a.Example("Bugs assigned to me which aren't closed yet")
+	})
+	a.Attribute("filter", d.String, "a query language expression selecting the work items", func() {
+		a.Example(`assignee = me and state != "closed"`)
+	})
+	a.Attribute("sort", d.String, "comma separated list of sort keys, a leading '-' sorts in descending order", func() {
+		a.Example("-updated")
+	})
+	a.Attribute("version", d.Integer, "Version for optimistic concurrency control (optional during creating)", func() {
+		a.Example(23)
+	})
+	a.Attribute("created-at", d.DateTime, "When the query was created", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("updated-at", d.DateTime, "When the query was updated", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var queryRelationships = a.Type("QueryRelationships", func() {
+	a.Attribute("owned-by", queryOwnedBy, "The owner of the query")
+	a.Attribute("space", relationSpaces, "The space the query is shared with, missing for private queries")
+	a.Attribute("workitems", relationGeneric, "The work items selected by the query")
+})
+
+var queryOwnedBy = a.Type("QueryOwnedBy", func() {
+	a.Attribute("data", identityRelationData)
+	a.Required("data")
+})
+
+var queryList = JSONList(
+	"Query", "Holds the list of saved queries",
+	savedQuery,
+	nil,
+	nil)
+
+var querySingle = JSONSingle(
+	"Query", "Holds a single saved query",
+	savedQuery,
+	nil)
+
+var _ = a.Resource("query", func() {
+	a.BasePath("/queries")
+
+	a.Action("list", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("List the queries of the current user together with the queries shared with the given space.")
+		a.Params(func() {
+			a.Param("filter[space]", d.UUID, "ID of the space whose shared queries are listed as well")
+		})
+		a.Response(d.OK, func() {
+			a.Media(queryList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("show", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:id"),
+		)
+		a.Description("Retrieve the saved query with the given id.")
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+		})
+		a.Response(d.OK, func() {
+			a.Media(querySingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST(""),
+		)
+		a.Description("Save a query. Only the owner of a space may share a query with it.")
+		a.Payload(querySingle)
+		a.Response(d.Created, "/queries/.*", func() {
+			a.Media(querySingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("update", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/:id"),
+		)
+		a.Description("Update the saved query with the given id. Only the owner of a space may share a query with it.")
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+		})
+		a.Payload(querySingle)
+		a.Response(d.OK, func() {
+			a.Media(querySingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("delete", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.DELETE("/:id"),
+		)
+		a.Description("Delete the saved query with the given id.")
+		a.Params(func() {
+			a.Param("id", d.String, "id")
+		})
+		a.Response(d.OK)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
@@ -9,7 +9,23 @@ var searchWorkItemList = JSONList(
 	"SearchWorkItem", "Holds the paginated response to a search request",
 	workItem2,
 	pagingLinks,
-	meta)
+	searchWorkItemListMeta)
+
+var searchWorkItemListMeta = a.Type("SearchWorkItemListMeta", func() {
+	a.Attribute("totalCount", d.Integer)
+	a.Attribute("facets", a.HashOf(d.String, a.ArrayOf(searchFacetValue)), "Number of matching work items per value of the type, state, assignee, area and iteration, only present if requested")
+	a.Required("totalCount")
+})
+
+var searchFacetValue = a.Type("SearchFacetValue", func() {
+	a.Attribute("value", d.String, "Value of the facet, absent for the work items without a value", func() {
+		a.Example("closed")
+	})
+	a.Attribute("count", d.Integer, "Number of matching work items with the value", func() {
+		a.Example(42)
+	})
+	a.Required("count")
+})
 
 var searchSpaceList = JSONList(
 	"SearchSpace", "Holds the paginated response to a search request",
@@ -17,6 +33,22 @@ var searchSpaceList = JSONList(
 	pagingLinks,
 	spaceListMeta)
 
+var knownURLAttributes = a.Type("KnownURLAttributes", func() {
+	a.Attribute("pattern", d.String, "Regular expression matching the URLs without the protocol", func() {
+		a.Example(`(?P<domain>demo.almighty.io)(?P<path>/work-item/list/detail/)(?P<id>\d*)`)
+	})
+	a.Attribute("groups", a.ArrayOf(d.String), "Names of the groups of the pattern, the id group holds the ID of the work item", func() {
+		a.Example([]string{"domain", "path", "id"})
+	})
+	a.Required("pattern", "groups")
+})
+
+var knownURLList = JSONList(
+	"KnownURL", "Holds the URL patterns the search resolves to the work items they refer to",
+	JSONResourceObject("KnownURL", knownURLAttributes, nil),
+	nil,
+	nil)
+
 var _ = a.Resource("search", func() {
 	a.BasePath("/search")
 
@@ -31,9 +63,18 @@ var _ = a.Resource("search", func() {
 				1) "id:100" :- Look for work item hainvg id 100
 				2) "url:http://demo.almighty.io/details/500" :- Search on WI having id 500 and check 
 					if this URL is mentioned in searchable columns of work item
-				3) "simple keywords separated by space" :- Search in Work Items based on these keywords.`)
+				3) "simple keywords separated by space" :- Search in Work Items based on these keywords.
+				4) "state:open assignee:me created:>2017-01-31" :- Restrict the search to work items with the given
+					state, assignee, creator, area, iteration, space or creation date. Repeating a qualifier
+					matches any of its values.
+				5) '"memory leak" (server OR client) -windows' :- Quoted phrases match words next to each other,
+					OR matches either of its sides, a leading - excludes a word and parentheses group words.
+					Qualifiers can't be excluded or combined with OR.`)
+			a.Param("facets", d.Boolean, "Include the number of matching work items per type, state, assignee, area and iteration in meta.facets")
 			a.Param("page[offset]", d.String, "Paging start position") // #428
 			a.Param("page[limit]", d.Integer, "Paging size")
+			a.Param("page[after]", d.String, "Cursor of the item the page follows, taken from a next link. Empty for the first page")
+			a.Param("page[before]", d.String, "Cursor of the item the page precedes, taken from a prev link. Empty for the last page")
 			a.Required("q")
 		})
 		a.Response(d.OK, func() {
@@ -59,4 +100,13 @@ var _ = a.Resource("search", func() {
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
 	})
+	a.Action("known_urls", func() {
+		a.Routing(
+			a.GET("known-urls"),
+		)
+		a.Description("List the URL patterns the search resolves to the work items they refer to, e.g. to generate matching links")
+		a.Response(d.OK, func() {
+			a.Media(knownURLList)
+		})
+	})
 })
@@ -39,6 +39,9 @@ var spaceAttributes = a.Type("SpaceAttributes", func() {
 	a.Attribute("description", d.String, "Description for the space", func() {
 		a.Example("This is the foobar collaboration space")
 	})
+	a.Attribute("text-search-config", d.String, "Text search configuration the work items of the space are indexed and searched with (optional, defaults to english)", func() {
+		a.Example("german")
+	})
 	a.Attribute("version", d.Integer, "Version for optimistic concurrency control (optional during creating)", func() {
 		a.Example(23)
 	})
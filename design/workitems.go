@@ -30,6 +30,8 @@ var workItem2 = a.Type("WorkItem2", func() {
 	})
 	a.Attribute("relationships", workItemRelationships)
 	a.Attribute("links", genericLinksForWorkItem)
+	a.Attribute("meta", a.HashOf(d.String, d.Any), `non-standard information about the work item, like the
+highlighted snippets of a search hit`)
 	a.Required("type", "attributes")
 })
 
@@ -80,6 +82,84 @@ var workItemSingle = JSONSingle(
 	workItem2,
 	workItemLinks)
 
+// workItemDuplicateList holds the likely duplicates of a draft, the most similar first
+var workItemDuplicateList = JSONList(
+	"WorkItemDuplicate", "Holds the work items similar to a draft, with their similarity in meta.similarity",
+	workItem2,
+	nil,
+	nil)
+
+// workItemAggregation holds the aggregated values of the work items sharing the values of the group-by fields
+var workItemAggregation = a.Type("WorkItemAggregation", func() {
+	a.Attribute("keys", a.HashOf(d.String, d.Any), "The values of the group-by fields, null for work items without a value", func() {
+		a.Example(map[string]interface{}{"system.state": "open", "system.assignees": nil})
+	})
+	a.Attribute("count", d.Integer, "Number of work items in the bucket")
+	a.Attribute("sums", a.HashOf(d.String, d.Number), "Sums of the numeric fields of the work items in the bucket", func() {
+		a.Example(map[string]interface{}{"story_points": 13})
+	})
+	a.Required("keys", "count", "sums")
+})
+
+// workItemAggregationMeta names the fields the work items are grouped by
+var workItemAggregationMeta = a.Type("WorkItemAggregationMeta", func() {
+	a.Attribute("groupBy", a.ArrayOf(d.String), "The names of the group-by fields in the order of the request")
+	a.Required("groupBy")
+})
+
+// workItemAggregationList holds the buckets of an aggregation request
+var workItemAggregationList = JSONList(
+	"WorkItemAggregation", "Holds the buckets of a work item aggregation, one per combination of the group-by values",
+	workItemAggregation,
+	nil,
+	workItemAggregationMeta)
+
+// workItemBulkOperation is one of the operations of a bulk request
+var workItemBulkOperation = a.Type("WorkItemBulkOperation", func() {
+	a.Attribute("op", d.String, "The operation on the work item", func() {
+		a.Enum("create", "update", "delete")
+	})
+	a.Attribute("id", d.String, "ID of the work item to update or delete", func() {
+		a.Example("42")
+	})
+	a.Attribute("data", workItem2, "The work item to create or the attributes and relationships to update, like the payload of the single requests")
+	a.Required("op")
+})
+
+// workItemBulkPayload holds the operations of a bulk request in the order they are run
+var workItemBulkPayload = a.Type("WorkItemBulkPayload", func() {
+	a.Attribute("data", a.ArrayOf(workItemBulkOperation), "At most as many operations as bulk.maxoperations of the configuration allows", func() {
+		a.MinLength(1)
+	})
+	a.Required("data")
+})
+
+// workItemBulkResult holds the outcome of one operation of a bulk request
+var workItemBulkResult = a.Type("WorkItemBulkResult", func() {
+	a.Attribute("op", d.String, "The operation on the work item")
+	a.Attribute("id", d.String, "ID of the work item, the new ID for a created one")
+	a.Attribute("status", d.Integer, "The HTTP status code the single request would have answered with", func() {
+		a.Example(201)
+	})
+	a.Attribute("data", workItem2, "The created or updated work item")
+	a.Attribute("errors", a.ArrayOf(JSONAPIError), "Why the operation failed")
+	a.Required("op", "status")
+})
+
+// workItemBulkResultMeta counts the operations of a bulk request
+var workItemBulkResultMeta = a.Type("WorkItemBulkResultMeta", func() {
+	a.Attribute("applied", d.Integer, "Number of operations which were applied")
+	a.Attribute("failed", d.Integer, "Number of operations which failed and were rolled back")
+	a.Required("applied", "failed")
+})
+
+// workItemBulkResultList holds the outcomes of the operations of a bulk request in the order of the request
+var workItemBulkResultList = JSONList(
+	"WorkItemBulkResult", "Holds the outcomes of the operations of a bulk request",
+	workItemBulkResult,
+	nil,
+	workItemBulkResultMeta)
+
 // new version of "list" for migration
 var _ = a.Resource("workitem", func() {
 	a.BasePath("/workitems")
@@ -104,14 +184,20 @@ var _ = a.Resource("workitem", func() {
 		)
 		a.Description("List work items.")
 		a.Params(func() {
-			a.Param("filter", d.String, "a query language expression restricting the set of found work items")
+			a.Param("filter", d.String, `a query language expression restricting the set of found work items,
+for example: state in ("open", "in progress") and (assignee = me or area = "UI")`)
+			a.Param("sort", d.String, `comma separated list of sort keys, a leading "-" sorts in descending order,
+for example: -updated,title. Keys are id, version, created, updated or a work item field`)
 			a.Param("page[offset]", d.String, "Paging start position")
 			a.Param("page[limit]", d.Integer, "Paging size")
+			a.Param("page[after]", d.String, "Cursor of the item the page follows, taken from a next link. Empty for the first page")
+			a.Param("page[before]", d.String, "Cursor of the item the page precedes, taken from a prev link. Empty for the last page")
 			a.Param("filter[assignee]", d.String, "Work Items assigned to the given user")
 			a.Param("filter[iteration]", d.String, "IterationID to filter work items")
 			a.Param("filter[workitemtype]", d.UUID, "ID of work item type to filter work items by")
 			a.Param("filter[area]", d.String, "AreaID to filter work items")
 			a.Param("filter[workitemstate]", d.String, "work item state to filter work items by")
+			a.Param("filter[query]", d.UUID, "ID of a saved query whose filter and sort order are applied")
 
 		})
 		a.Response(d.OK, func() {
@@ -119,6 +205,46 @@ var _ = a.Resource("workitem", func() {
 		})
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+	})
+	a.Action("aggregate", func() {
+		a.Routing(
+			a.GET("/aggregate"),
+		)
+		a.Description("Count the work items and sum up their numeric fields, grouped by one or two fields.")
+		a.Params(func() {
+			a.Param("filter", d.String, "a query language expression restricting the set of aggregated work items")
+			a.Param("group-by", d.String, `comma separated list of one or two fields the work items are grouped by,
+for example: state,assignee. Fields are the type or enum, user, area, iteration, boolean or label fields or
+lists of those, a work item is counted once for each value of a list field like the assignees or the labels`)
+			a.Required("group-by")
+		})
+		a.Response(d.OK, func() {
+			a.Media(workItemAggregationList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+	})
+	a.Action("duplicates", func() {
+		a.Routing(
+			a.GET("/duplicates"),
+		)
+		a.Description("List the work items of a space which are likely duplicates of a draft, the most similar first.")
+		a.Params(func() {
+			a.Param("space", d.UUID, "ID of the space the draft belongs to")
+			a.Param("title", d.String, "Title of the draft, only work items with a similar title are found")
+			a.Param("description", d.String, "Description of the draft, refines the similarity")
+			a.Param("limit", d.Integer, "Maximum number of work items, 5 by default", func() {
+				a.Minimum(1)
+				a.Maximum(50)
+			})
+			a.Required("space", "title")
+		})
+		a.Response(d.OK, func() {
+			a.Media(workItemDuplicateList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
 	})
 	a.Action("create", func() {
 		a.Security("jwt")
@@ -126,6 +252,9 @@ var _ = a.Resource("workitem", func() {
 			a.POST(""),
 		)
 		a.Description("create work item with type and id.")
+		a.Params(func() {
+			a.Param("duplicates", d.Boolean, "Report the likely duplicates of the new work item in its space in data.meta.duplicates")
+		})
 		a.Payload(workItemSingle)
 		a.Response(d.Created, "/workitems/.*", func() {
 			a.Media(workItemSingle)
@@ -149,12 +278,38 @@ var _ = a.Resource("workitem", func() {
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
 	})
+	a.Action("bulk", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/bulk"),
+		)
+		a.Description(`create, update and delete work items in a single transaction. In the atomic mode
+no operation is applied if one fails, in the best-effort mode the failed ones are skipped. Requests with
+more operations than configured are rejected.`)
+		a.Params(func() {
+			a.Param("mode", d.String, "How failed operations are handled", func() {
+				a.Enum("atomic", "best-effort")
+				a.Default("atomic")
+			})
+		})
+		a.Payload(workItemBulkPayload)
+		a.Response(d.OK, func() {
+			a.Media(workItemBulkResultList)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
 	a.Action("update", func() {
 		a.Security("jwt")
 		a.Routing(
 			a.PATCH("/:id"),
 		)
-		a.Description("update the work item with the given id.")
+		a.Description(`update the work item with the given id. Only the given attributes and relationships are
+changed, like with a JSON merge patch (RFC 7396): a null value removes a field, the omitted fields are left as
+they are. If the work item changed since the given version, the changes are merged unless they change the same
+fields, the conflicting fields are listed in the meta of the version conflict error.`)
 		a.Params(func() {
 			a.Param("id", d.String, "id")
 		})
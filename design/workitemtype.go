@@ -16,6 +16,31 @@ var fieldType = a.Type("fieldType", func() {
 	a.Required("kind")
 })
 
+// fieldConstraints restrict the values of a field beyond its type
+var fieldConstraints = a.Type("fieldConstraints", func() {
+	a.Description(`Constraints restrict the values of a field beyond its type. The constraints of a list field apply to
+each of its values. Clients can check them before they send a work item, the server rejects work items which violate them.`)
+	a.Attribute("min", d.Any, "The minimum of a number or instant field. Instants are given in RFC 3339 or like 2017-01-31", func() {
+		a.Example(0)
+	})
+	a.Attribute("max", d.Any, "The maximum of a number or instant field. Instants are given in RFC 3339 or like 2017-01-31", func() {
+		a.Example(100)
+	})
+	a.Attribute("minLength", d.Integer, "The minimum number of characters of a string, label, URL or markup field", func() {
+		a.Minimum(0)
+	})
+	a.Attribute("maxLength", d.Integer, "The maximum number of characters of a string, label, URL or markup field", func() {
+		a.Minimum(0)
+	})
+	a.Attribute("pattern", d.String, "A regular expression in RE2 syntax which the whole value of a string, label or URL field must match", func() {
+		a.Example("[A-Z]+-[0-9]+")
+	})
+	a.Attribute("maxItems", d.Integer, "The maximum number of values of a list field", func() {
+		a.Minimum(1)
+	})
+	a.Attribute("unique", d.Boolean, "Whether no two work items of the type in a space may have the same value. Not supported for list fields")
+})
+
 // fieldDefinition defines the possible values for a field in a work item type
 var fieldDefinition = a.Type("fieldDefinition", func() {
 	a.Description("A fieldDefinition aggregates a fieldType and additional field metadata")
@@ -29,6 +54,8 @@ var fieldDefinition = a.Type("fieldDefinition", func() {
 		a.Example("The iteration field tells to which iteration a work item belongs.")
 		a.MinLength(1)
 	})
+	a.Attribute("searchable", d.Boolean, "Whether the values of a string or markup field are indexed for the full text search")
+	a.Attribute("constraints", fieldConstraints)
 	a.Required("required", "type", "label", "description")
 })
 
@@ -112,6 +139,65 @@ var workItemTypeSingle = JSONSingle(
 	workItemTypeData,
 	workItemTypeLinks)
 
+// fieldChange is a change of a field of an existing work item type
+var fieldChange = a.Type("FieldChange", func() {
+	a.Description(`A fieldChange adds, removes, renames or retypes a field of a work item type and migrates the
+values of the field in the existing work items of the type`)
+	a.Attribute("op", d.String, "The kind of change", func() {
+		a.Enum("add", "remove", "rename", "retype")
+	})
+	a.Attribute("field", d.String, "The name of the changed field", func() {
+		a.Example("effort")
+		a.MinLength(1)
+	})
+	a.Attribute("newName", d.String, "The new name of a renamed field", func() {
+		a.Example("story_points")
+		a.MinLength(1)
+	})
+	a.Attribute("definition", fieldDefinition, "The definition of an added field or the new definition of a retyped field")
+	a.Attribute("value", d.Any, "The value set on the existing work items for an added field. Required for required fields")
+	a.Required("op", "field")
+})
+
+// workItemTypeUpdatePayload lists the changes of an update of a work item type
+var workItemTypeUpdatePayload = a.Type("WorkItemTypeUpdatePayload", func() {
+	a.Attribute("version", d.Integer, "Version of the work item type the changes are made on")
+	a.Attribute("changes", a.ArrayOf(fieldChange), "The changes, applied in the given order", func() {
+		a.MinLength(1)
+	})
+	a.Required("version", "changes")
+})
+
+// fieldChangeReport tells how many work items a change of a work item type migrates
+var fieldChangeReport = a.Type("FieldChangeReport", func() {
+	a.Attribute("op", d.String, "The kind of change")
+	a.Attribute("field", d.String, "The name of the changed field")
+	a.Attribute("affected", d.Integer, "The number of work items whose values are migrated by the change")
+	a.Attribute("failed", d.Integer, "The number of work items whose values can't be migrated by the change")
+	a.Required("op", "field", "affected", "failed")
+})
+
+var workItemTypeUpdateMeta = a.Type("WorkItemTypeUpdateMeta", func() {
+	a.Attribute("dryRun", d.Boolean, "Whether the changes were only checked and not stored")
+	a.Attribute("changes", a.ArrayOf(fieldChangeReport), "The reports of the changes, in the order of the changes")
+	a.Required("dryRun", "changes")
+})
+
+// workItemTypeUpdate is the media type for the result of an update of a work item type
+var workItemTypeUpdate = a.MediaType("application/vnd.workitemtypeupdate+json", func() {
+	a.UseTrait("jsonapi-media-type")
+	a.TypeName("WorkItemTypeUpdate")
+	a.Description("The changed work item type along with a report of the migration of its work items")
+	a.Attribute("data", workItemTypeData)
+	a.Attribute("meta", workItemTypeUpdateMeta)
+	a.Required("data", "meta")
+	a.View("default", func() {
+		a.Attribute("data")
+		a.Attribute("meta")
+		a.Required("data", "meta")
+	})
+})
+
 var _ = a.Resource("workitemtype", func() {
 	a.BasePath("/workitemtypes")
 	a.Action("show", func() {
@@ -143,6 +229,30 @@ var _ = a.Resource("workitemtype", func() {
 		a.Response(d.InternalServerError, JSONAPIErrors)
 		a.Response(d.Unauthorized, JSONAPIErrors)
 	})
+	a.Action("update", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/:witId"),
+		)
+		a.Description(`Change the fields of a work item type and migrate the values of the fields in the existing work
+items of the type. With dryRun the changes are only checked and reported. Only the owner of the space of the type
+may change it, the types of the system space can't be changed.`)
+		a.Params(func() {
+			a.Param("witId", d.UUID, "ID of the work item type")
+			a.Param("dryRun", d.Boolean, "Report how many work items the changes affect without storing them", func() {
+				a.Default(false)
+			})
+		})
+		a.Payload(workItemTypeUpdatePayload)
+		a.Response(d.OK, func() {
+			a.Media(workItemTypeUpdate)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
 	a.Action("list", func() {
 		a.Routing(
 			a.GET(""),
@@ -1,11 +1,15 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	stBadParameterErrorMsg         = "Bad value for parameter '%s': '%v'"
 	stBadParameterErrorExpectedMsg = "Bad value for parameter '%s': '%v' (expected: '%v')"
 	stNotFoundErrorMsg             = "%s with id '%s' not found"
+	stFieldConflictErrorMsg        = "version conflict on the fields '%s'"
 )
 
 // Constants that can be used to identify internal server errors
@@ -44,11 +48,21 @@ type UnauthorizedError struct {
 // VersionConflictError means that the version was not as expected in an update operation
 type VersionConflictError struct {
 	simpleError
+	// Fields holds the names of the fields which were changed concurrently, if they are known
+	Fields []string
 }
 
 // NewVersionConflictError returns the custom defined error of type VersionConflictError.
 func NewVersionConflictError(msg string) VersionConflictError {
-	return VersionConflictError{simpleError{msg}}
+	return VersionConflictError{simpleError: simpleError{msg}}
+}
+
+// NewFieldConflictError returns a VersionConflictError for the given fields which were changed concurrently.
+func NewFieldConflictError(fields []string) VersionConflictError {
+	return VersionConflictError{
+		simpleError: simpleError{fmt.Sprintf(stFieldConflictErrorMsg, strings.Join(fields, "', '"))},
+		Fields:      fields,
+	}
 }
 
 // BadParameterError means that a parameter was not as required
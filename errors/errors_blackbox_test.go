@@ -56,3 +56,12 @@ func TestNewUnauthorizedError(t *testing.T) {
 
 	assert.Equal(t, msg, err.Error())
 }
+
+func TestNewFieldConflictError(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	err := errors.NewFieldConflictError([]string{"system.state", "system.title"})
+	assert.Equal(t, "version conflict on the fields 'system.state', 'system.title'", err.Error())
+	assert.Equal(t, []string{"system.state", "system.title"}, err.Fields)
+	assert.Nil(t, errors.NewVersionConflictError("version conflict").Fields)
+}
@@ -8,7 +8,10 @@ import (
 	"github.com/almighty/almighty-core/application"
 	"github.com/almighty/almighty-core/area"
 	"github.com/almighty/almighty-core/comment"
+	"github.com/almighty/almighty-core/gormsupport"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/label"
+	"github.com/almighty/almighty-core/query"
 	"github.com/almighty/almighty-core/remoteworkitem"
 	"github.com/almighty/almighty-core/search"
 	"github.com/almighty/almighty-core/space"
@@ -46,6 +49,8 @@ var x application.Application = &GormDB{}
 
 var y application.Application = &GormTransaction{}
 
+var z application.Savepointer = &GormTransaction{}
+
 func NewGormDB(db *gorm.DB) *GormDB {
 	return &GormDB{GormBase{db}, ""}
 }
@@ -127,6 +132,16 @@ func (g *GormBase) Areas() area.Repository {
 	return area.NewAreaRepository(g.db)
 }
 
+// Queries returns a saved query repository
+func (g *GormBase) Queries() query.Repository {
+	return query.NewQueryRepository(g.db)
+}
+
+// Labels returns a label repository
+func (g *GormBase) Labels() label.Repository {
+	return label.NewLabelRepository(g.db)
+}
+
 func (g *GormBase) DB() *gorm.DB {
 	return g.db
 }
@@ -155,6 +170,7 @@ func (g *GormDB) BeginTransaction() (application.Transaction, error) {
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
+	tx = gormsupport.WithTransactionHooks(tx)
 	if len(g.txIsoLevel) != 0 {
 		tx := tx.Exec(fmt.Sprintf("set transaction isolation level %s", g.txIsoLevel))
 		if tx.Error != nil {
@@ -167,14 +183,29 @@ func (g *GormDB) BeginTransaction() (application.Transaction, error) {
 
 // Commit implements TransactionSupport
 func (g *GormTransaction) Commit() error {
-	err := g.db.Commit().Error
+	err := gormsupport.Commit(g.db)
 	g.db = nil
 	return errors.WithStack(err)
 }
 
 // Rollback implements TransactionSupport
 func (g *GormTransaction) Rollback() error {
-	err := g.db.Rollback().Error
+	err := gormsupport.Rollback(g.db)
 	g.db = nil
 	return errors.WithStack(err)
 }
+
+// Savepoint implements application.Savepointer
+func (g *GormTransaction) Savepoint(name string) error {
+	return errors.WithStack(gormsupport.Savepoint(g.db, name))
+}
+
+// RollbackToSavepoint implements application.Savepointer
+func (g *GormTransaction) RollbackToSavepoint(name string) error {
+	return errors.WithStack(gormsupport.RollbackToSavepoint(g.db, name))
+}
+
+// ReleaseSavepoint implements application.Savepointer
+func (g *GormTransaction) ReleaseSavepoint(name string) error {
+	return errors.WithStack(gormsupport.ReleaseSavepoint(g.db, name))
+}
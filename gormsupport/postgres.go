@@ -3,8 +3,9 @@ package gormsupport
 import "github.com/lib/pq"
 
 const (
-	errCheckViolation  = "23514"
-	errUniqueViolation = "23505"
+	errCheckViolation      = "23514"
+	errUniqueViolation     = "23505"
+	errForeignKeyViolation = "23503"
 )
 
 // IsCheckViolation returns true if the error is a violation of the given check
@@ -24,3 +25,12 @@ func IsUniqueViolation(err error, indexName string) bool {
 	}
 	return pqError.Code == errUniqueViolation && pqError.Constraint == indexName
 }
+
+// IsForeignKeyViolation returns true if the error is a violation of the given foreign key constraint
+func IsForeignKeyViolation(err error, constraintName string) bool {
+	pqError, ok := err.(*pq.Error)
+	if !ok {
+		return false
+	}
+	return pqError.Code == errForeignKeyViolation && pqError.Constraint == constraintName
+}
@@ -0,0 +1,186 @@
+package gormsupport
+
+import (
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+// transactionHooksKey is the gorm setting holding the hooks of a transaction
+const transactionHooksKey = "almighty:transaction_hooks"
+
+// transactionHook is a function run when a transaction ends
+type transactionHook struct {
+	f func()
+	// always tells whether the function also runs after a rollback
+	always bool
+}
+
+// savepoint marks the number of hooks registered before the savepoint was set
+type savepoint struct {
+	name  string
+	hooks int
+}
+
+// transactionHooks holds the functions which run after a transaction and the savepoints of the transaction
+type transactionHooks struct {
+	lock       sync.Mutex
+	hooks      []transactionHook
+	savepoints []savepoint
+}
+
+func (h *transactionHooks) add(f func(), always bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.hooks = append(h.hooks, transactionHook{f: f, always: always})
+}
+
+func (h *transactionHooks) savepoint(name string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.savepoints = append(h.savepoints, savepoint{name: name, hooks: len(h.hooks)})
+}
+
+// find returns the index of the latest savepoint with the given name or -1 if there is none
+func (h *transactionHooks) find(name string) int {
+	for i := len(h.savepoints) - 1; i >= 0; i-- {
+		if h.savepoints[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// rollbackToSavepoint drops the hooks registered after the savepoint which only run after a commit. The
+// savepoint is kept and the savepoints set after it are released, like the database does.
+func (h *transactionHooks) rollbackToSavepoint(name string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	i := h.find(name)
+	if i < 0 {
+		return
+	}
+	mark := h.savepoints[i].hooks
+	h.savepoints = h.savepoints[:i+1]
+	kept := h.hooks[:mark]
+	for _, hook := range h.hooks[mark:] {
+		if hook.always {
+			kept = append(kept, hook)
+		}
+	}
+	h.hooks = kept
+}
+
+// releaseSavepoint forgets the savepoint and the savepoints set after it, the hooks registered after it
+// now belong to the enclosing savepoint or transaction
+func (h *transactionHooks) releaseSavepoint(name string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if i := h.find(name); i >= 0 {
+		h.savepoints = h.savepoints[:i]
+	}
+}
+
+// run runs the hooks in the order they were registered, the ones which only run after a commit are
+// skipped if the transaction wasn't committed. The hooks are cleared, so they run only once.
+func (h *transactionHooks) run(committed bool) {
+	h.lock.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.savepoints = nil
+	h.lock.Unlock()
+	for _, hook := range hooks {
+		if committed || hook.always {
+			hook.f()
+		}
+	}
+}
+
+func hooksOf(db *gorm.DB) *transactionHooks {
+	if value, ok := db.Get(transactionHooksKey); ok {
+		return value.(*transactionHooks)
+	}
+	return nil
+}
+
+// WithTransactionHooks returns the transaction tx prepared for AfterCommit and AfterTransaction. The
+// transaction has to be ended with Commit or Rollback of this package to run the registered functions.
+func WithTransactionHooks(tx *gorm.DB) *gorm.DB {
+	return tx.Set(transactionHooksKey, &transactionHooks{})
+}
+
+// AfterCommit runs f after the transaction of db was committed. f is dropped if the transaction is
+// rolled back or rolled back to a savepoint set before f was registered. Without a transaction
+// prepared by WithTransactionHooks f runs right away.
+func AfterCommit(db *gorm.DB, f func()) {
+	if hooks := hooksOf(db); hooks != nil {
+		hooks.add(f, false)
+		return
+	}
+	f()
+}
+
+// AfterTransaction runs f after the transaction of db was committed or rolled back, e.g. to release
+// resources held for the transaction. Without a transaction prepared by WithTransactionHooks f runs
+// right away.
+func AfterTransaction(db *gorm.DB, f func()) {
+	if hooks := hooksOf(db); hooks != nil {
+		hooks.add(f, true)
+		return
+	}
+	f()
+}
+
+// Commit commits the transaction tx and runs the functions registered with AfterCommit and
+// AfterTransaction. Only the latter run if the commit fails.
+func Commit(tx *gorm.DB) error {
+	err := tx.Commit().Error
+	if hooks := hooksOf(tx); hooks != nil {
+		hooks.run(err == nil)
+	}
+	return err
+}
+
+// Rollback rolls back the transaction tx and runs the functions registered with AfterTransaction
+func Rollback(tx *gorm.DB) error {
+	err := tx.Rollback().Error
+	if hooks := hooksOf(tx); hooks != nil {
+		hooks.run(false)
+	}
+	return err
+}
+
+// Savepoint sets a savepoint in the transaction tx
+func Savepoint(tx *gorm.DB, name string) error {
+	if err := tx.Exec("SAVEPOINT " + name).Error; err != nil {
+		return err
+	}
+	if hooks := hooksOf(tx); hooks != nil {
+		hooks.savepoint(name)
+	}
+	return nil
+}
+
+// RollbackToSavepoint rolls back the changes made in the transaction tx since the savepoint was set and
+// drops the functions registered with AfterCommit since then
+func RollbackToSavepoint(tx *gorm.DB, name string) error {
+	if err := tx.Exec("ROLLBACK TO SAVEPOINT " + name).Error; err != nil {
+		return err
+	}
+	if hooks := hooksOf(tx); hooks != nil {
+		hooks.rollbackToSavepoint(name)
+	}
+	return nil
+}
+
+// ReleaseSavepoint releases the savepoint of the transaction tx, the changes made since it was set are
+// kept
+func ReleaseSavepoint(tx *gorm.DB, name string) error {
+	if err := tx.Exec("RELEASE SAVEPOINT " + name).Error; err != nil {
+		return err
+	}
+	if hooks := hooksOf(tx); hooks != nil {
+		hooks.releaseSavepoint(name)
+	}
+	return nil
+}
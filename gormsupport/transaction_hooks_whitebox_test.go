@@ -0,0 +1,78 @@
+package gormsupport
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionHooksRunAfterCommit(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	tx := WithTransactionHooks(&gorm.DB{})
+	calls := []string{}
+	AfterCommit(tx.Where("id = ?", 1), func() { calls = append(calls, "commit") })
+	AfterTransaction(tx, func() { calls = append(calls, "always") })
+	require.Empty(t, calls)
+	// when
+	hooksOf(tx).run(true)
+	// then
+	assert.Equal(t, []string{"commit", "always"}, calls)
+	// when run again
+	hooksOf(tx).run(true)
+	// then the hooks don't run twice
+	assert.Len(t, calls, 2)
+}
+
+func TestTransactionHooksRollback(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	tx := WithTransactionHooks(&gorm.DB{})
+	calls := []string{}
+	AfterCommit(tx, func() { calls = append(calls, "commit") })
+	AfterTransaction(tx, func() { calls = append(calls, "always") })
+	// when
+	hooksOf(tx).run(false)
+	// then
+	assert.Equal(t, []string{"always"}, calls)
+}
+
+func TestTransactionHooksRollbackToSavepoint(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	tx := WithTransactionHooks(&gorm.DB{})
+	hooks := hooksOf(tx)
+	calls := []string{}
+	AfterCommit(tx, func() { calls = append(calls, "before") })
+	hooks.savepoint("outer")
+	AfterCommit(tx, func() { calls = append(calls, "outer") })
+	hooks.savepoint("inner")
+	AfterCommit(tx, func() { calls = append(calls, "inner") })
+	AfterTransaction(tx, func() { calls = append(calls, "always") })
+	hooks.releaseSavepoint("inner")
+	// when
+	hooks.rollbackToSavepoint("outer")
+	AfterCommit(tx, func() { calls = append(calls, "after") })
+	hooks.run(true)
+	// then
+	assert.Equal(t, []string{"before", "always", "after"}, calls)
+}
+
+func TestTransactionHooksWithoutTransaction(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	calls := []string{}
+	// when
+	AfterCommit(&gorm.DB{}, func() { calls = append(calls, "commit") })
+	AfterTransaction(&gorm.DB{}, func() { calls = append(calls, "always") })
+	// then
+	assert.Equal(t, []string{"commit", "always"}, calls)
+}
@@ -31,6 +31,7 @@ func ErrorToJSONAPIError(err error) (app.JSONAPIError, int) {
 	var title, code string
 	var statusCode int
 	var id *string
+	var meta map[string]interface{}
 	switch cause.(type) {
 	case errors.NotFoundError:
 		code = ErrorCodeNotFound
@@ -48,6 +49,9 @@ func ErrorToJSONAPIError(err error) (app.JSONAPIError, int) {
 		code = ErrorCodeVersionConflict
 		title = "Version conflict error"
 		statusCode = http.StatusBadRequest
+		if fields := cause.(errors.VersionConflictError).Fields; fields != nil {
+			meta = map[string]interface{}{"fields": fields}
+		}
 	case errors.InternalError:
 		code = ErrorCodeInternalError
 		title = "Internal error"
@@ -76,6 +80,7 @@ func ErrorToJSONAPIError(err error) (app.JSONAPIError, int) {
 		Status: &statusCodeStr,
 		Title:  &title,
 		Detail: detail,
+		Meta:   meta,
 	}
 	return jerr, statusCode
 }
@@ -119,6 +124,19 @@ type Forbidden interface {
 // If all else fails, InternalServerError is returned
 func JSONErrorResponse(x InternalServerError, err error) error {
 	jsonErr, status := ErrorToJSONAPIErrors(err)
+	return jsonErrorsResponse(x, jsonErr, status)
+}
+
+// JSONErrorResponseWithPointer is like JSONErrorResponse but it also points to the part of the request document
+// which caused the error, e.g. "/data/3" for the fourth element of the primary data
+func JSONErrorResponseWithPointer(x InternalServerError, err error, pointer string) error {
+	jsonErr, status := ErrorToJSONAPIErrors(err)
+	jsonErr.Errors[0].Source = map[string]interface{}{"pointer": pointer}
+	return jsonErrorsResponse(x, jsonErr, status)
+}
+
+// jsonErrorsResponse maps the status to the response type of the context
+func jsonErrorsResponse(x InternalServerError, jsonErr *app.JSONAPIErrors, status int) error {
 	switch status {
 	case http.StatusBadRequest:
 		if ctx, ok := x.(BadRequest); ok {
@@ -0,0 +1,3 @@
+// Package label manages the vocabulary of labels of a space. The label fields of the work items in a space
+// take their values from it.
+package label
@@ -0,0 +1,137 @@
+package label
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// APIStringTypeLabels is the JSON-API type of labels
+const APIStringTypeLabels = "labels"
+
+// MaxLength is the maximum number of characters of a label
+const MaxLength = 64
+
+// Label is a label of the vocabulary of a space
+type Label struct {
+	gormsupport.Lifecycle
+	ID      uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	SpaceID uuid.UUID `sql:"type:uuid"`
+	// Name is the label itself, normalized by Normalize
+	Name string
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (l Label) TableName() string {
+	return "labels"
+}
+
+// Normalize trims the white space around a label and replaces the white space within it with single
+// spaces, so that the labels of a space are distinct values
+// returns an error if the label is empty or too long
+func Normalize(name string) (string, error) {
+	normalized := strings.Join(strings.Fields(name), " ")
+	if normalized == "" {
+		return "", fmt.Errorf("value %q should be %s", name, "a label which is not empty")
+	}
+	if utf8.RuneCountInString(normalized) > MaxLength {
+		return "", fmt.Errorf("value %q should be %s", name, fmt.Sprintf("a label of at most %d characters", MaxLength))
+	}
+	return normalized, nil
+}
+
+// Repository encapsulates storage & retrieval of the labels of spaces
+type Repository interface {
+	Create(ctx context.Context, l *Label) (*Label, error)
+	List(ctx context.Context, spaceID uuid.UUID) ([]*Label, error)
+	Missing(ctx context.Context, spaceID uuid.UUID, names []string) ([]string, error)
+}
+
+// NewLabelRepository creates a new label repository
+func NewLabelRepository(db *gorm.DB) *GormLabelRepository {
+	return &GormLabelRepository{db: db}
+}
+
+// GormLabelRepository implements Repository using gorm
+type GormLabelRepository struct {
+	db *gorm.DB
+}
+
+// Create adds the label to the vocabulary of its space, the name is normalized first
+// returns BadParameterError if the name is invalid, the space doesn't exist or already has the label,
+// InternalError
+func (r *GormLabelRepository) Create(ctx context.Context, l *Label) (*Label, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "label", "create"}, time.Now())
+	name, err := Normalize(l.Name)
+	if err != nil {
+		return nil, errors.NewBadParameterError("name", l.Name).Expected(err.Error())
+	}
+	l.Name = name
+	l.ID = uuid.NewV4()
+	if err := r.db.Create(l).Error; err != nil {
+		return nil, convertError(err, l)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"labelID": l.ID,
+		"spaceID": l.SpaceID,
+	}, "Label created successfully")
+	return l, nil
+}
+
+// convertError turns constraint violations into BadParameterErrors
+func convertError(err error, l *Label) error {
+	if gormsupport.IsUniqueViolation(err, "labels_space_id_name_idx") {
+		return errors.NewBadParameterError("name", l.Name).Expected("a label the space doesn't have yet")
+	}
+	if gormsupport.IsForeignKeyViolation(err, "labels_space_id_spaces_id_fk") {
+		return errors.NewBadParameterError("space", l.SpaceID.String()).Expected("existing space")
+	}
+	return errors.NewInternalError(err.Error())
+}
+
+// List returns the labels of the space in alphabetical order
+// returns InternalError
+func (r *GormLabelRepository) List(ctx context.Context, spaceID uuid.UUID) ([]*Label, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "label", "list"}, time.Now())
+	result := []*Label{}
+	if err := r.db.Where("space_id = ?", spaceID).Order("name").Find(&result).Error; err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return result, nil
+}
+
+// Missing returns the given names which aren't labels of the space, in the given order. The names have to be
+// normalized already.
+// returns InternalError
+func (r *GormLabelRepository) Missing(ctx context.Context, spaceID uuid.UUID, names []string) ([]string, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "label", "missing"}, time.Now())
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var existing []Label
+	if err := r.db.Select("name").Where("space_id = ? AND name in (?)", spaceID, names).Find(&existing).Error; err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	known := make(map[string]bool, len(existing))
+	for _, l := range existing {
+		known[l.Name] = true
+	}
+	var missing []string
+	for _, name := range names {
+		if !known[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,109 @@
+package label_test
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/label"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// when
+	normalized, err := label.Normalize("  needs \t review ")
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, "needs review", normalized)
+	for _, name := range []string{"", " \t ", strings.Repeat("x", label.MaxLength+1)} {
+		// when
+		_, err := label.Normalize(name)
+		// then
+		assert.NotNil(t, err, "label %q", name)
+	}
+}
+
+type TestLabelRepository struct {
+	gormsupport.DBTestSuite
+
+	clean func()
+}
+
+func TestRunLabelRepository(t *testing.T) {
+	suite.Run(t, &TestLabelRepository{DBTestSuite: gormsupport.NewDBTestSuite("../config.yaml")})
+}
+
+func (test *TestLabelRepository) SetupTest() {
+	test.clean = cleaner.DeleteCreatedEntities(test.DB)
+}
+
+func (test *TestLabelRepository) TearDownTest() {
+	test.clean()
+}
+
+func (test *TestLabelRepository) TestCreateAndListLabels() {
+	t := test.T()
+	resource.Require(t, resource.Database)
+	// given
+	s, err := space.NewRepository(test.DB).Create(context.Background(), &space.Space{Name: uuid.NewV4().String()})
+	require.Nil(t, err)
+	repo := label.NewLabelRepository(test.DB)
+	// when
+	for _, name := range []string{"ui", " needs  review", "backend"} {
+		_, err := repo.Create(context.Background(), &label.Label{SpaceID: s.ID, Name: name})
+		require.Nil(t, err)
+	}
+	labels, err := repo.List(context.Background(), s.ID)
+	// then
+	require.Nil(t, err)
+	var names []string
+	for _, l := range labels {
+		names = append(names, l.Name)
+	}
+	assert.Equal(t, []string{"backend", "needs review", "ui"}, names)
+	// when
+	missing, err := repo.Missing(context.Background(), s.ID, []string{"ui", "frontend", "backend", "docs"})
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, []string{"frontend", "docs"}, missing)
+	// when
+	labels, err = repo.List(context.Background(), uuid.NewV4())
+	// then
+	require.Nil(t, err)
+	assert.Empty(t, labels)
+}
+
+func (test *TestLabelRepository) TestCreateInvalidLabels() {
+	t := test.T()
+	resource.Require(t, resource.Database)
+	// given
+	s, err := space.NewRepository(test.DB).Create(context.Background(), &space.Space{Name: uuid.NewV4().String()})
+	require.Nil(t, err)
+	repo := label.NewLabelRepository(test.DB)
+	_, err = repo.Create(context.Background(), &label.Label{SpaceID: s.ID, Name: "ui"})
+	require.Nil(t, err)
+	for _, l := range []label.Label{
+		{SpaceID: s.ID, Name: " ui "},
+		{SpaceID: s.ID, Name: " "},
+		{SpaceID: uuid.NewV4(), Name: "ui"},
+	} {
+		// when
+		_, err := repo.Create(context.Background(), &l)
+		// then
+		require.NotNil(t, err, "label %+v", l)
+		assert.IsType(t, errors.BadParameterError{}, errs.Cause(err), "label %+v", l)
+	}
+}
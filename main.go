@@ -4,7 +4,9 @@ import (
 	"flag"
 	"net/http"
 	"os"
+	"os/signal"
 	"os/user"
+	"syscall"
 	"time"
 
 	"golang.org/x/net/context"
@@ -20,12 +22,14 @@ import (
 	config "github.com/almighty/almighty-core/configuration"
 	"github.com/almighty/almighty-core/controller"
 	"github.com/almighty/almighty-core/gormapplication"
+	"github.com/almighty/almighty-core/gormsupport"
 	"github.com/almighty/almighty-core/jsonapi"
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/login"
 	"github.com/almighty/almighty-core/migration"
 	"github.com/almighty/almighty-core/models"
 	"github.com/almighty/almighty-core/remoteworkitem"
+	"github.com/almighty/almighty-core/search"
 	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/token"
 	"github.com/almighty/almighty-core/workitem"
@@ -36,6 +40,7 @@ import (
 	"github.com/goadesign/goa/middleware"
 	"github.com/goadesign/goa/middleware/gzip"
 	"github.com/goadesign/goa/middleware/security/jwt"
+	uuid "github.com/satori/go.uuid"
 )
 
 func main() {
@@ -45,10 +50,12 @@ func main() {
 	var configFilePath string
 	var printConfig bool
 	var migrateDB bool
+	var reindexSearch string
 	var scheduler *remoteworkitem.Scheduler
 	flag.StringVar(&configFilePath, "config", "", "Path to the config file to read")
 	flag.BoolVar(&printConfig, "printConfig", false, "Prints the config (including merged environment variables) and exits")
 	flag.BoolVar(&migrateDB, "migrateDatabase", false, "Migrates the database to the newest version and exits.")
+	flag.StringVar(&reindexSearch, "reindexSearch", "", "Rebuilds the full text search index of the work items of the given type ID, or of all work items for \"all\", and exits.")
 	flag.Parse()
 
 	// Override default -config switch with environment variable only if -config switch was
@@ -142,6 +149,64 @@ func main() {
 		}
 	}
 
+	// The search resolves the work item URLs of the deployment to the work items they refer to
+	if err := search.RegisterKnownURLs(configuration.GetSearchKnownURLs()); err != nil {
+		log.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "invalid known URLs in the search configuration")
+	}
+
+	// Choose the search engine, the embedded one is kept in sync with the work item changes
+	engine, err := search.OpenEngine(db, configuration.GetSearchEngine(), configuration.GetSearchIndexPath())
+	if err != nil {
+		log.Panic(nil, map[string]interface{}{
+			"engine": configuration.GetSearchEngine(),
+			"err":    err,
+		}, "failed to open the search engine")
+	}
+	search.UseEngine(engine)
+	if embedded, ok := engine.(*search.EmbeddedEngine); ok {
+		// the index only follows the changes of this instance, it is brought up to date with the database first
+		if err := embedded.Claim(context.Background()); err != nil {
+			log.Panic(nil, map[string]interface{}{
+				"err": err,
+			}, "failed to claim the search index")
+		}
+		workitem.RegisterChangeListener(embedded)
+		defer embedded.Close()
+		closeOnSignal(embedded)
+	}
+
+	// Rebuild the search index, e.g. after the searchable fields of a type changed
+	if reindexSearch != "" {
+		var typeID *uuid.UUID
+		if reindexSearch != "all" {
+			id, err := uuid.FromString(reindexSearch)
+			if err != nil {
+				log.Panic(nil, map[string]interface{}{
+					"reindexSearch": reindexSearch,
+					"err":           err,
+				}, "the work item type to reindex must be a UUID or \"all\"")
+			}
+			typeID = &id
+		}
+		// the embedded engine swaps in the rebuilt index once the transaction is committed
+		tx := gormsupport.WithTransactionHooks(db.Begin())
+		count, err := search.NewGormSearchRepository(tx).Reindex(context.Background(), typeID)
+		if err == nil {
+			err = gormsupport.Commit(tx)
+		} else {
+			gormsupport.Rollback(tx)
+		}
+		if err != nil {
+			log.Panic(nil, map[string]interface{}{
+				"err": err,
+			}, "failed to rebuild the search index")
+		}
+		log.Logger().Infof("Rebuilt the search index of %d work items", count)
+		os.Exit(0)
+	}
+
 	// Create service
 	service := goa.New("alm")
 
@@ -195,7 +260,7 @@ func main() {
 	app.MountStatusController(service, statusCtrl)
 
 	// Mount "workitem" controller
-	workitemCtrl := controller.NewWorkitemController(service, appDB)
+	workitemCtrl := controller.NewWorkitemController(service, appDB, configuration)
 	app.MountWorkitemController(service, workitemCtrl)
 
 	// Mount "workitemtype" controller
@@ -277,9 +342,16 @@ func main() {
 	spaceAreaCtrl := controller.NewSpaceAreasController(service, appDB)
 	app.MountSpaceAreasController(service, spaceAreaCtrl)
 
-	filterCtrl := controller.NewFilterController(service)
+	spaceLabelsCtrl := controller.NewSpaceLabelsController(service, appDB)
+	app.MountSpaceLabelsController(service, spaceLabelsCtrl)
+
+	filterCtrl := controller.NewFilterController(service, appDB)
 	app.MountFilterController(service, filterCtrl)
 
+	// Mount "query" controller
+	queryCtrl := controller.NewQueryController(service, appDB)
+	app.MountQueryController(service, queryCtrl)
+
 	// Mount "namedspaces" controller
 	namedSpacesCtrl := controller.NewNamedspacesController(service, appDB)
 	app.MountNamedspacesController(service, namedSpacesCtrl)
@@ -304,6 +376,26 @@ func main() {
 
 }
 
+// closeOnSignal writes the index of the embedded search engine and releases its claim when the process is
+// interrupted or terminated, deferred functions don't run then. The signal is raised again afterwards to end
+// the process.
+func closeOnSignal(embedded *search.EmbeddedEngine) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		if err := embedded.Close(); err != nil {
+			log.Error(nil, map[string]interface{}{
+				"err": err,
+			}, "failed to write the search index")
+		}
+		signal.Stop(signals)
+		if p, err := os.FindProcess(os.Getpid()); err == nil {
+			p.Signal(sig)
+		}
+	}()
+}
+
 func printUserInfo() {
 	u, err := user.Current()
 	if err != nil {
@@ -239,6 +239,27 @@ func getMigrations() migrations {
 	// Version 40
 	m = append(m, steps{executeSQLFile("040-add-space-id-wi-wit-tq.sql", space.SystemSpace.String())})
 
+	// Version 41
+	m = append(m, steps{executeSQLFile("041-queries.sql")})
+
+	// Version 42
+	m = append(m, steps{executeSQLFile("042-search-custom-fields-comments.sql")})
+
+	// Version 43
+	m = append(m, steps{executeSQLFile("043-space-text-search-config.sql")})
+
+	// Version 44
+	m = append(m, steps{executeSQLFile("044-work-item-title-trigrams.sql")})
+
+	// Version 45
+	m = append(m, steps{executeSQLFile("045-work-item-type-versions.sql")})
+
+	// Version 46
+	m = append(m, steps{executeSQLFile("046-work-item-search-texts.sql")})
+
+	// Version 47
+	m = append(m, steps{executeSQLFile("047-labels.sql")})
+
 	// Version N
 	//
 	// In order to add an upgrade, simply append an array of MigrationFunc to the
@@ -642,8 +663,7 @@ func createOrUpdateType(typeID uuid.UUID, spaceID uuid.UUID, name string, descri
 		}
 		wit.Fields = convertedFields
 		wit.Path = path
-		db = db.Save(wit)
-		return db.Error
+		return witr.Save(ctx, wit)
 	}
 	return nil
 }
@@ -661,6 +681,8 @@ func loadFields(ctx context.Context, wit *workitem.WorkItemType, into workitem.F
 				Description: value.Description,
 				Required:    into[key].Required,
 				Type:        into[key].Type,
+				Searchable:  into[key].Searchable,
+				Constraints: into[key].Constraints,
 			}
 		}
 	}
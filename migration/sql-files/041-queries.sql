@@ -0,0 +1,17 @@
+-- saved work item queries, owned by an identity and optionally shared with a space
+CREATE TABLE queries (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    version integer DEFAULT 0 NOT NULL,
+    title text NOT NULL CONSTRAINT queries_title_check CHECK (title <> ''),
+    description text,
+    filter text NOT NULL,
+    sort text,
+    owner_id uuid NOT NULL,
+    space_id uuid CONSTRAINT queries_space_id_spaces_id_fk REFERENCES spaces (id) ON DELETE CASCADE
+);
+
+CREATE INDEX ix_queries_owner_id ON queries USING btree (owner_id);
+CREATE INDEX ix_queries_space_id ON queries USING btree (space_id);
@@ -0,0 +1,56 @@
+-- the search vector of a work item holds its ID, title and description, the string and markup
+-- fields its type marks as searchable and the bodies of its comments
+CREATE OR REPLACE FUNCTION workitem_tsv(wi_id bigint, wi_type uuid, wi_fields jsonb) RETURNS tsvector AS $$
+declare
+  custom_text text;
+  comment_text text;
+begin
+  -- markup fields hold their text in the 'content' element
+  SELECT string_agg(coalesce(wi_fields#>>array[f.key, 'content'], wi_fields->>f.key), ' ') INTO custom_text
+    FROM work_item_types t, jsonb_each(t.fields) f
+    WHERE t.id = wi_type
+      AND f.key NOT IN ('system.title', 'system.description')
+      AND f.value->'Type'->>'Kind' IN ('string', 'markup')
+      AND coalesce((f.value->>'Searchable')::boolean, false);
+  SELECT string_agg(body, ' ') INTO comment_text
+    FROM comments
+    WHERE parent_id = wi_id::text AND deleted_at IS NULL;
+  return
+    setweight(to_tsvector('english', wi_id::text),'A') ||
+    setweight(to_tsvector('english', coalesce(wi_fields->>'system.title','')),'B') ||
+    setweight(to_tsvector('english', coalesce(wi_fields#>>'{system.description, content}','')),'C') ||
+    setweight(to_tsvector('english', coalesce(custom_text,'')),'C') ||
+    setweight(to_tsvector('english', coalesce(comment_text,'')),'D');
+end
+$$ LANGUAGE plpgsql STABLE;
+
+DROP TRIGGER IF EXISTS upd_tsvector ON work_items;
+DROP FUNCTION IF EXISTS workitem_tsv_trigger() CASCADE;
+
+CREATE FUNCTION workitem_tsv_trigger() RETURNS trigger AS $$
+begin
+  new.tsv := workitem_tsv(new.id, new.type, new.fields);
+  return new;
+end
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER upd_tsvector BEFORE INSERT OR UPDATE OF id, type, fields ON work_items
+FOR EACH ROW EXECUTE PROCEDURE workitem_tsv_trigger();
+
+-- comments change the search vector of the work item they belong to
+CREATE FUNCTION comment_tsv_trigger() RETURNS trigger AS $$
+begin
+  IF TG_OP <> 'INSERT' THEN
+    UPDATE work_items SET tsv = workitem_tsv(id, type, fields) WHERE id::text = old.parent_id;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    UPDATE work_items SET tsv = workitem_tsv(id, type, fields) WHERE id::text = new.parent_id;
+  END IF;
+  return null;
+end
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER upd_comment_tsvector AFTER INSERT OR UPDATE OF parent_id, body, deleted_at OR DELETE ON comments
+FOR EACH ROW EXECUTE PROCEDURE comment_tsv_trigger();
+
+UPDATE work_items SET tsv = workitem_tsv(id, type, fields);
@@ -0,0 +1,70 @@
+-- every space chooses the text search configuration its work items are indexed and searched with
+ALTER TABLE spaces ADD text_search_config text NOT NULL DEFAULT 'english';
+
+-- the search vector is built with the configuration of the space of the work item
+DROP FUNCTION IF EXISTS workitem_tsv(bigint, uuid, jsonb) CASCADE;
+
+CREATE FUNCTION workitem_tsv(wi_id bigint, wi_space uuid, wi_type uuid, wi_fields jsonb) RETURNS tsvector AS $$
+declare
+  config regconfig;
+  custom_text text;
+  comment_text text;
+begin
+  SELECT coalesce((SELECT text_search_config FROM spaces WHERE id = wi_space), 'english')::regconfig INTO config;
+  -- markup fields hold their text in the 'content' element
+  SELECT string_agg(coalesce(wi_fields#>>array[f.key, 'content'], wi_fields->>f.key), ' ') INTO custom_text
+    FROM work_item_types t, jsonb_each(t.fields) f
+    WHERE t.id = wi_type
+      AND f.key NOT IN ('system.title', 'system.description')
+      AND f.value->'Type'->>'Kind' IN ('string', 'markup')
+      AND coalesce((f.value->>'Searchable')::boolean, false);
+  SELECT string_agg(body, ' ') INTO comment_text
+    FROM comments
+    WHERE parent_id = wi_id::text AND deleted_at IS NULL;
+  return
+    setweight(to_tsvector(config, wi_id::text),'A') ||
+    setweight(to_tsvector(config, coalesce(wi_fields->>'system.title','')),'B') ||
+    setweight(to_tsvector(config, coalesce(wi_fields#>>'{system.description, content}','')),'C') ||
+    setweight(to_tsvector(config, coalesce(custom_text,'')),'C') ||
+    setweight(to_tsvector(config, coalesce(comment_text,'')),'D');
+end
+$$ LANGUAGE plpgsql STABLE;
+
+DROP TRIGGER IF EXISTS upd_tsvector ON work_items;
+DROP FUNCTION IF EXISTS workitem_tsv_trigger() CASCADE;
+
+CREATE FUNCTION workitem_tsv_trigger() RETURNS trigger AS $$
+begin
+  new.tsv := workitem_tsv(new.id, new.space_id, new.type, new.fields);
+  return new;
+end
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER upd_tsvector BEFORE INSERT OR UPDATE OF id, space_id, type, fields ON work_items
+FOR EACH ROW EXECUTE PROCEDURE workitem_tsv_trigger();
+
+CREATE OR REPLACE FUNCTION comment_tsv_trigger() RETURNS trigger AS $$
+begin
+  IF TG_OP <> 'INSERT' THEN
+    UPDATE work_items SET tsv = workitem_tsv(id, space_id, type, fields) WHERE id::text = old.parent_id;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    UPDATE work_items SET tsv = workitem_tsv(id, space_id, type, fields) WHERE id::text = new.parent_id;
+  END IF;
+  return null;
+end
+$$ LANGUAGE plpgsql;
+
+-- changing the configuration of a space reindexes its work items
+CREATE FUNCTION space_tsv_trigger() RETURNS trigger AS $$
+begin
+  UPDATE work_items SET tsv = workitem_tsv(id, space_id, type, fields) WHERE space_id = new.id;
+  return null;
+end
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER upd_space_tsvector AFTER UPDATE OF text_search_config ON spaces
+FOR EACH ROW WHEN (old.text_search_config IS DISTINCT FROM new.text_search_config)
+EXECUTE PROCEDURE space_tsv_trigger();
+
+UPDATE work_items SET tsv = workitem_tsv(id, space_id, type, fields);
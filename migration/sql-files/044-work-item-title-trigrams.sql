@@ -0,0 +1,4 @@
+-- similar titles of work items are found with trigrams, e.g. to report likely duplicates
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
+CREATE INDEX ix_work_items_title_trgm ON work_items USING gin ((coalesce(fields->>'system.title', '')) gin_trgm_ops);
@@ -0,0 +1,12 @@
+-- the definitions of the versions of the work item types along with the changes of the fields made on the
+-- previous version
+CREATE TABLE work_item_type_versions (
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    created_at timestamp with time zone default current_timestamp,
+    modifier_id uuid CONSTRAINT work_item_type_versions_identity_fk REFERENCES identities(id),
+    work_item_type_id uuid NOT NULL CONSTRAINT work_item_type_versions_work_item_types_fk REFERENCES work_item_types(id) ON DELETE CASCADE,
+    version integer NOT NULL,
+    fields jsonb NOT NULL,
+    changes jsonb,
+    CONSTRAINT work_item_type_versions_version_uniq UNIQUE (work_item_type_id, version)
+);
@@ -0,0 +1,42 @@
+-- the texts of a work item the full text search indexes, ordered by their weight: its ID, title and
+-- description, the string and markup fields its type marks as searchable and the bodies of its comments.
+-- The search vector and the index of the embedded search engine are both built from them.
+CREATE FUNCTION workitem_search_texts(wi_id bigint, wi_type uuid, wi_fields jsonb) RETURNS text[] AS $$
+declare
+  custom_text text;
+  comment_text text;
+begin
+  -- markup fields hold their text in the 'content' element
+  SELECT string_agg(coalesce(wi_fields#>>array[f.key, 'content'], wi_fields->>f.key), ' ') INTO custom_text
+    FROM work_item_types t, jsonb_each(t.fields) f
+    WHERE t.id = wi_type
+      AND f.key NOT IN ('system.title', 'system.description')
+      AND f.value->'Type'->>'Kind' IN ('string', 'markup')
+      AND coalesce((f.value->>'Searchable')::boolean, false);
+  SELECT string_agg(body, ' ') INTO comment_text
+    FROM comments
+    WHERE parent_id = wi_id::text AND deleted_at IS NULL;
+  return array[
+    wi_id::text,
+    coalesce(wi_fields->>'system.title',''),
+    coalesce(wi_fields#>>'{system.description, content}',''),
+    coalesce(custom_text,''),
+    coalesce(comment_text,'')];
+end
+$$ LANGUAGE plpgsql STABLE;
+
+CREATE OR REPLACE FUNCTION workitem_tsv(wi_id bigint, wi_space uuid, wi_type uuid, wi_fields jsonb) RETURNS tsvector AS $$
+declare
+  config regconfig;
+  texts text[];
+begin
+  SELECT coalesce((SELECT text_search_config FROM spaces WHERE id = wi_space), 'english')::regconfig INTO config;
+  texts := workitem_search_texts(wi_id, wi_type, wi_fields);
+  return
+    setweight(to_tsvector(config, texts[1]),'A') ||
+    setweight(to_tsvector(config, texts[2]),'B') ||
+    setweight(to_tsvector(config, texts[3]),'C') ||
+    setweight(to_tsvector(config, texts[4]),'C') ||
+    setweight(to_tsvector(config, texts[5]),'D');
+end
+$$ LANGUAGE plpgsql STABLE;
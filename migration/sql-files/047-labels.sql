@@ -0,0 +1,52 @@
+-- the vocabulary of labels of a space, the label fields of the work items in the space take their values from it
+CREATE TABLE labels (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    id uuid primary key DEFAULT uuid_generate_v4() NOT NULL,
+    space_id uuid NOT NULL CONSTRAINT labels_space_id_spaces_id_fk REFERENCES spaces (id) ON DELETE CASCADE,
+    name text NOT NULL CONSTRAINT labels_name_check CHECK (name <> '')
+);
+
+CREATE UNIQUE INDEX labels_space_id_name_idx ON labels (space_id, name) WHERE deleted_at IS NULL;
+
+-- normalize_label normalizes a label like the service does, the white space within it is replaced with single
+-- spaces and the white space around it is trimmed
+CREATE FUNCTION normalize_label(label text) RETURNS text AS $$
+    SELECT btrim(regexp_replace(label, '\s+', ' ', 'g'));
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- the label fields of the work items, which are label fields or lists of label fields of their types
+CREATE TEMPORARY TABLE label_fields ON COMMIT DROP AS
+SELECT work_items.id, work_items.space_id, definitions.name FROM work_items
+    JOIN work_item_types ON work_item_types.id = work_items.type
+    CROSS JOIN LATERAL jsonb_each(work_item_types.fields) AS definitions(name, definition)
+    WHERE work_items.fields ? definitions.name
+    AND 'label' IN (definitions.definition->'Type'->>'Kind', definitions.definition->'Type'->'ComponentType'->>'Kind');
+
+-- the stored labels are normalized, so that they match the vocabulary
+UPDATE work_items SET fields = work_items.fields || normalized.fields FROM (
+    SELECT label_fields.id, jsonb_object_agg(label_fields.name, CASE jsonb_typeof(work_items.fields->label_fields.name)
+        WHEN 'string' THEN to_jsonb(normalize_label(work_items.fields->>label_fields.name))
+        WHEN 'array' THEN (SELECT coalesce(jsonb_agg(CASE jsonb_typeof(elements.value)
+                WHEN 'string' THEN to_jsonb(normalize_label(elements.value#>>'{}'))
+                ELSE elements.value END ORDER BY elements.position), '[]')
+            FROM jsonb_array_elements(work_items.fields->label_fields.name) WITH ORDINALITY AS elements(value, position))
+        ELSE work_items.fields->label_fields.name END) AS fields
+    FROM label_fields JOIN work_items ON work_items.id = label_fields.id
+    GROUP BY label_fields.id) AS normalized
+    WHERE work_items.id = normalized.id AND work_items.fields || normalized.fields <> work_items.fields;
+
+-- the labels the work items use already form the initial vocabulary of their spaces, labels which are empty or
+-- longer than 64 characters are left out since they aren't valid labels
+INSERT INTO labels (created_at, updated_at, space_id, name)
+SELECT DISTINCT now(), now(), label_fields.space_id, labels.label FROM label_fields
+    JOIN work_items ON work_items.id = label_fields.id
+    CROSS JOIN LATERAL jsonb_array_elements_text(
+        CASE jsonb_typeof(work_items.fields->label_fields.name)
+        WHEN 'array' THEN work_items.fields->label_fields.name
+        ELSE jsonb_build_array(work_items.fields->label_fields.name) END) AS labels(label)
+    WHERE work_items.deleted_at IS NULL AND label_fields.space_id IS NOT NULL
+    AND labels.label IS NOT NULL AND labels.label <> '' AND char_length(labels.label) <= 64;
+
+DROP FUNCTION normalize_label(text);
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"github.com/almighty/almighty-core/gormsupport"
+
 	"github.com/jinzhu/gorm"
 	errs "github.com/pkg/errors"
 )
@@ -12,10 +14,10 @@ func Transactional(db *gorm.DB, todo func(tx *gorm.DB) error) error {
 	if tx.Error != nil {
 		return tx.Error
 	}
+	tx = gormsupport.WithTransactionHooks(tx)
 	if err := todo(tx); err != nil {
-		tx.Rollback()
+		gormsupport.Rollback(tx)
 		return errs.WithStack(err)
 	}
-	tx.Commit()
-	return tx.Error
+	return gormsupport.Commit(tx)
 }
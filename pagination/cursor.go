@@ -0,0 +1,275 @@
+// Package pagination implements cursor based (keyset) paging of ordered lists.
+// A cursor records the sort key values of an item, the following page is then selected
+// by comparing the sort keys with those values instead of skipping a number of rows.
+// That keeps pages stable when items are inserted and doesn't slow down on later pages.
+package pagination
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/almighty/almighty-core/errors"
+)
+
+// Direction tells whether a page follows or precedes its cursor
+type Direction int
+
+// The paging directions
+const (
+	After Direction = iota
+	Before
+)
+
+// Key is an SQL expression a list is ordered by
+type Key struct {
+	Expression string
+	Descending bool
+}
+
+// Cursor holds the sort key values of an item in an ordered list. Cursors are
+// handed out as opaque strings, see Encode and Decode.
+type Cursor struct {
+	// Order identifies the keys the cursor was created for
+	Order string `json:"o"`
+	// Values holds the textual representation of the key values, nil stands for NULL
+	Values []*string `json:"v"`
+}
+
+// NewCursor creates a cursor for an item with the given key values
+func NewCursor(keys []Key, values []*string) *Cursor {
+	return &Cursor{Order: fingerprint(keys), Values: values}
+}
+
+// Encode returns the opaque string representation of the cursor
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode parses a cursor returned by Encode, an empty string yields a nil cursor.
+// returns BadParameterError if the string isn't a valid cursor
+func Decode(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.NewBadParameterError("page cursor", s)
+	}
+	result := Cursor{}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, errors.NewBadParameterError("page cursor", s)
+	}
+	return &result, nil
+}
+
+// fingerprint identifies the order given by the keys, so that cursors can't be
+// used with a different order than the one they were created for
+func fingerprint(keys []Key) string {
+	h := fnv.New32a()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s %t;", key.Expression, key.Descending)
+	}
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// Keyset describes a page of an ordered list relative to a cursor
+type Keyset struct {
+	// Cursor is the item the page follows or precedes. Without a cursor
+	// the page is the first page, or the last page for Direction Before.
+	Cursor    *Cursor
+	Direction Direction
+	Limit     int
+}
+
+// Page tells where a page fetched for a Keyset is located in the complete list
+type Page struct {
+	// First and Last are the cursors of the first and the last item on the page, nil if the page is empty
+	First      *Cursor
+	Last       *Cursor
+	HasPrev    bool
+	HasNext    bool
+	TotalCount uint64
+}
+
+// Validate checks that the keyset fits the given keys
+// returns BadParameterError for a non positive limit or a cursor created for other keys
+func (k Keyset) Validate(keys []Key) error {
+	if k.Limit <= 0 {
+		return errors.NewBadParameterError("limit", k.Limit)
+	}
+	if k.Cursor != nil && (k.Cursor.Order != fingerprint(keys) || len(k.Cursor.Values) != len(keys)) {
+		return errors.NewBadParameterError("page cursor", k.Cursor.Encode())
+	}
+	return nil
+}
+
+// OrderBy returns the SQL order clause for fetching the page. Pages preceding
+// their cursor are fetched in reverse order and need to be reversed after fetching.
+func (k Keyset) OrderBy(keys []Key) string {
+	clauses := make([]string, len(keys))
+	for i, key := range keys {
+		if key.Descending != (k.Direction == Before) {
+			clauses[i] = key.Expression + " desc"
+		} else {
+			clauses[i] = key.Expression + " asc"
+		}
+	}
+	return strings.Join(clauses, ", ")
+}
+
+// Columns returns the select list entries which yield the key values as text,
+// so that a cursor can be created for every row
+func Columns(keys []Key) string {
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		columns[i] = fmt.Sprintf("(%s)::text as page_key_%d", key.Expression, i)
+	}
+	return strings.Join(columns, ", ")
+}
+
+// Rows are the rows of a query result, like *sql.Rows
+type Rows interface {
+	Next() bool
+	Columns() ([]string, error)
+	Scan(dest ...interface{}) error
+	Err() error
+}
+
+// ScanValues reads the key values selected with Columns from the current row. The key
+// values must be the last of columnCount columns.
+func ScanValues(rows Rows, columnCount int, keys []Key) ([]*string, error) {
+	var ignore interface{}
+	values := make([]sql.NullString, len(keys))
+	targets := make([]interface{}, columnCount)
+	for i := range targets {
+		targets[i] = &ignore
+	}
+	for i := range values {
+		targets[columnCount-len(keys)+i] = &values[i]
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	result := make([]*string, len(keys))
+	for i, value := range values {
+		if value.Valid {
+			s := value.String
+			result[i] = &s
+		}
+	}
+	return result, nil
+}
+
+// Condition returns the SQL where clause selecting the items on the page side of the cursor
+// together with its parameters. Like in Postgres NULLs sort after all other values.
+// Returns "true" if the keyset has no cursor.
+func (k Keyset) Condition(keys []Key) (string, []interface{}) {
+	if k.Cursor == nil {
+		return "true", nil
+	}
+	disjunction := []string{}
+	parameters := []interface{}{}
+	equal := []string{}
+	equalParameters := []interface{}{}
+	for i, key := range keys {
+		value := k.Cursor.Values[i]
+		// tells whether the page holds greater values than the cursor for this key
+		after := key.Descending == (k.Direction == Before)
+		var clause string
+		switch {
+		case value == nil && after:
+			// nothing follows NULL
+		case value == nil:
+			clause = fmt.Sprintf("%s is not null", key.Expression)
+		case after:
+			clause = fmt.Sprintf("(%s > ? or %s is null)", key.Expression, key.Expression)
+		default:
+			clause = fmt.Sprintf("%s < ?", key.Expression)
+		}
+		if clause != "" {
+			disjunction = append(disjunction, "("+strings.Join(append(append([]string{}, equal...), clause), " and ")+")")
+			parameters = append(parameters, equalParameters...)
+			if value != nil {
+				parameters = append(parameters, *value)
+			}
+		}
+		if value == nil {
+			equal = append(equal, fmt.Sprintf("%s is null", key.Expression))
+		} else {
+			equal = append(equal, fmt.Sprintf("%s = ?", key.Expression))
+			equalParameters = append(equalParameters, *value)
+		}
+	}
+	if len(disjunction) == 0 {
+		return "false", nil
+	}
+	return "(" + strings.Join(disjunction, " or ") + ")", parameters
+}
+
+// NewPage computes the page information from the key values of the fetched rows. The rows must have
+// been fetched in the order given by OrderBy with a limit of one more than the keyset limit.
+// Returns the number of rows which belong to the page; for pages preceding their cursor those are the first rows
+// in reverse order.
+func (k Keyset) NewPage(keys []Key, values [][]*string, totalCount uint64) (Page, int) {
+	result := Page{TotalCount: totalCount}
+	more := len(values) > k.Limit
+	n := len(values)
+	if more {
+		n = k.Limit
+	}
+	if k.Direction == Before {
+		result.HasPrev = more
+		result.HasNext = k.Cursor != nil
+		if n > 0 {
+			result.First = NewCursor(keys, values[n-1])
+			result.Last = NewCursor(keys, values[0])
+		}
+	} else {
+		result.HasPrev = k.Cursor != nil
+		result.HasNext = more
+		if n > 0 {
+			result.First = NewCursor(keys, values[0])
+			result.Last = NewCursor(keys, values[n-1])
+		}
+	}
+	return result, n
+}
+
+// ScanPage reads the rows of a page fetched in the order given by OrderBy with a limit of one more than the keyset
+// limit and with the key columns selected with Columns last. scan reads the item of the current row and appends it
+// to the caller's result, swap exchanges two items of that result. The items on the page are arranged in the order
+// of the list.
+// Returns the page and the number of items which belong to it, the first items of the result; InternalError if a
+// row can't be read
+func (k Keyset) ScanPage(rows Rows, keys []Key, totalCount uint64, scan func() error, swap func(i, j int)) (*Page, int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, errors.NewInternalError(err.Error())
+	}
+	values := [][]*string{}
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return nil, 0, errors.NewInternalError(err.Error())
+		}
+		keyValues, err := ScanValues(rows, len(columns), keys)
+		if err != nil {
+			return nil, 0, err
+		}
+		values = append(values, keyValues)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.NewInternalError(err.Error())
+	}
+	page, n := k.NewPage(keys, values, totalCount)
+	if k.Direction == Before {
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	return &page, n, nil
+}
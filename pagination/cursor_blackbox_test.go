@@ -0,0 +1,220 @@
+package pagination_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/pagination"
+	"github.com/almighty/almighty-core/resource"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var keys = []pagination.Key{
+	{Expression: "a"},
+	{Expression: "b", Descending: true},
+	{Expression: "id"},
+}
+
+func values(v ...string) []*string {
+	result := make([]*string, len(v))
+	for i := range v {
+		if v[i] != "NULL" {
+			result[i] = &v[i]
+		}
+	}
+	return result
+}
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	cursor := pagination.NewCursor(keys, values("x'y", "NULL", "42"))
+	decoded, err := pagination.Decode(cursor.Encode())
+	require.Nil(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	decoded, err = pagination.Decode("")
+	require.Nil(t, err)
+	assert.Nil(t, decoded)
+
+	for _, s := range []string{"not a cursor!", "bm90IGpzb24"} {
+		_, err = pagination.Decode(s)
+		require.NotNil(t, err)
+		assert.IsType(t, errors.BadParameterError{}, errs.Cause(err))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	cursor := pagination.NewCursor(keys, values("1", "2", "3"))
+	assert.Nil(t, pagination.Keyset{Cursor: cursor, Limit: 10}.Validate(keys))
+	assert.Nil(t, pagination.Keyset{Limit: 10}.Validate(keys))
+	// cursors can't be used with another order
+	err := pagination.Keyset{Cursor: cursor, Limit: 10}.Validate(keys[1:])
+	assert.IsType(t, errors.BadParameterError{}, errs.Cause(err))
+	err = pagination.Keyset{Cursor: cursor}.Validate(keys)
+	assert.IsType(t, errors.BadParameterError{}, errs.Cause(err))
+}
+
+func TestOrderBy(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	assert.Equal(t, "a asc, b desc, id asc", pagination.Keyset{}.OrderBy(keys))
+	assert.Equal(t, "a desc, b asc, id desc", pagination.Keyset{Direction: pagination.Before}.OrderBy(keys))
+	assert.Equal(t, "(a)::text as page_key_0, (b)::text as page_key_1, (id)::text as page_key_2", pagination.Columns(keys))
+}
+
+func TestCondition(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	condition, parameters := pagination.Keyset{}.Condition(keys)
+	assert.Equal(t, "true", condition)
+	assert.Empty(t, parameters)
+
+	keyset := pagination.Keyset{Cursor: pagination.NewCursor(keys, values("1", "2", "3"))}
+	condition, parameters = keyset.Condition(keys)
+	assert.Equal(t, "(((a > ? or a is null)) or (a = ? and b < ?) or (a = ? and b = ? and (id > ? or id is null)))", condition)
+	assert.Equal(t, []interface{}{"1", "1", "2", "1", "2", "3"}, parameters)
+
+	keyset.Direction = pagination.Before
+	condition, parameters = keyset.Condition(keys)
+	assert.Equal(t, "((a < ?) or (a = ? and (b > ? or b is null)) or (a = ? and b = ? and id < ?))", condition)
+	assert.Equal(t, []interface{}{"1", "1", "2", "1", "2", "3"}, parameters)
+}
+
+func TestConditionNull(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	keyset := pagination.Keyset{Cursor: pagination.NewCursor(keys, values("NULL", "NULL", "3"))}
+	// NULLs come last in ascending order and first in descending order
+	condition, parameters := keyset.Condition(keys)
+	assert.Equal(t, "((a is null and b is not null) or (a is null and b is null and (id > ? or id is null)))", condition)
+	assert.Equal(t, []interface{}{"3"}, parameters)
+
+	keyset.Direction = pagination.Before
+	condition, parameters = keyset.Condition(keys)
+	assert.Equal(t, "((a is not null) or (a is null and b is null and id < ?))", condition)
+	assert.Equal(t, []interface{}{"3"}, parameters)
+}
+
+func TestNewPage(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	rows := [][]*string{values("1", "1", "1"), values("2", "2", "2"), values("3", "3", "3")}
+
+	// first page with more items following
+	page, n := pagination.Keyset{Limit: 2}.NewPage(keys, rows, 7)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, pagination.Page{
+		First:      pagination.NewCursor(keys, rows[0]),
+		Last:       pagination.NewCursor(keys, rows[1]),
+		HasNext:    true,
+		TotalCount: 7,
+	}, page)
+
+	// last page, following a cursor
+	cursor := pagination.NewCursor(keys, values("0", "0", "0"))
+	page, n = pagination.Keyset{Cursor: cursor, Limit: 3}.NewPage(keys, rows, 7)
+	assert.Equal(t, 3, n)
+	assert.True(t, page.HasPrev)
+	assert.False(t, page.HasNext)
+	assert.Equal(t, pagination.NewCursor(keys, rows[2]), page.Last)
+
+	// page preceding a cursor, fetched in reverse order
+	page, n = pagination.Keyset{Cursor: cursor, Direction: pagination.Before, Limit: 2}.NewPage(keys, rows, 7)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, pagination.Page{
+		First:      pagination.NewCursor(keys, rows[1]),
+		Last:       pagination.NewCursor(keys, rows[0]),
+		HasPrev:    true,
+		HasNext:    true,
+		TotalCount: 7,
+	}, page)
+
+	// empty page
+	page, n = pagination.Keyset{Limit: 2}.NewPage(keys, nil, 0)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, pagination.Page{}, page)
+}
+
+// fakeRows holds rows of an item column followed by the key columns
+type fakeRows struct {
+	rows    [][]string
+	current int
+	err     error
+}
+
+func (r *fakeRows) Next() bool {
+	r.current++
+	return r.current <= len(r.rows)
+}
+
+func (r *fakeRows) Columns() ([]string, error) {
+	return []string{"item", "page_key_0", "page_key_1", "page_key_2"}, nil
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.current-1] {
+		switch target := dest[i].(type) {
+		case *sql.NullString:
+			*target = sql.NullString{String: value, Valid: true}
+		case *interface{}:
+			*target = value
+		default:
+			return fmt.Errorf("unexpected scan target %T", target)
+		}
+	}
+	return nil
+}
+
+func (r *fakeRows) Err() error {
+	return r.err
+}
+
+func TestScanPage(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given a page preceding a cursor, fetched in reverse order
+	rows := &fakeRows{rows: [][]string{{"c", "3", "3", "3"}, {"b", "2", "2", "2"}, {"a", "1", "1", "1"}}}
+	keyset := pagination.Keyset{Cursor: pagination.NewCursor(keys, values("4", "4", "4")), Direction: pagination.Before, Limit: 2}
+	items := []string{}
+	// when
+	page, n, err := keyset.ScanPage(rows, keys, 7, func() error {
+		var item interface{}
+		if err := rows.Scan(&item, new(interface{}), new(interface{}), new(interface{})); err != nil {
+			return err
+		}
+		items = append(items, item.(string))
+		return nil
+	}, func(i, j int) { items[i], items[j] = items[j], items[i] })
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"b", "c"}, items[:n])
+	assert.Equal(t, pagination.NewCursor(keys, values("2", "2", "2")), page.First)
+	assert.True(t, page.HasPrev)
+	assert.True(t, page.HasNext)
+}
+
+func TestScanPageFails(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	for _, test := range []struct {
+		rows *fakeRows
+		scan func() error
+	}{
+		{&fakeRows{rows: [][]string{{"a", "1", "1", "1"}}}, func() error { return fmt.Errorf("scan failed") }},
+		{&fakeRows{err: fmt.Errorf("connection lost")}, func() error { return nil }},
+	} {
+		// when
+		_, _, err := pagination.Keyset{Limit: 2}.ScanPage(test.rows, keys, 1, test.scan, func(i, j int) {})
+		// then
+		require.NotNil(t, err)
+		assert.IsType(t, errors.InternalError{}, errs.Cause(err))
+	}
+}
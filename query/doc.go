@@ -0,0 +1,5 @@
+// Package query implements the query language used to filter work items.
+// It parses textual expressions like `state in ("open", "in progress") and (assignee = me or area = "UI")`
+// into criteria.Expression trees that can be compiled against the database.
+// Queries can be saved, either privately for their owner or shared with a space, see Query and Repository.
+package query
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseGroupBy parses a comma separated list of the fields work items are grouped by in an
+// aggregation, like `state,assignee`. The field aliases of the query language apply.
+// Returns a SyntaxError if exp is empty or malformed or if it names a field twice.
+func ParseGroupBy(exp string) ([]string, error) {
+	result := []string{}
+	seen := map[string]bool{}
+	pos := 0
+	for _, part := range strings.Split(exp, ",") {
+		name := strings.TrimSpace(part)
+		fieldPos := pos + strings.Index(part, name)
+		pos += len(part) + 1
+		if len(name) == 0 {
+			return nil, errors.WithStack(SyntaxError{fieldPos, "expected a field name"})
+		}
+		for _, r := range name {
+			if !isIdentifierRune(r) {
+				return nil, errors.WithStack(SyntaxError{fieldPos, fmt.Sprintf("invalid field name '%s'", name)})
+			}
+		}
+		field := name
+		if alias, ok := fieldAliases[strings.ToLower(name)]; ok {
+			field = alias
+		}
+		if seen[field] {
+			return nil, errors.WithStack(SyntaxError{fieldPos, fmt.Sprintf("duplicate field '%s'", name)})
+		}
+		seen[field] = true
+		result = append(result, field)
+	}
+	return result, nil
+}
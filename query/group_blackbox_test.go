@@ -0,0 +1,40 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/query"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/workitem"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	fields, err := query.ParseGroupBy(` state, Assignee`)
+	require.Nil(t, err)
+	assert.Equal(t, []string{workitem.SystemState, workitem.SystemAssignees}, fields)
+
+	fields, err = query.ParseGroupBy(`type,severity`)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"Type", "severity"}, fields)
+}
+
+func TestParseGroupByErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	testData := map[string]query.SyntaxError{
+		``:                    {Pos: 0, Msg: "expected a field name"},
+		`state,`:              {Pos: 6, Msg: "expected a field name"},
+		`state,sev'erity`:     {Pos: 6, Msg: "invalid field name 'sev'erity'"},
+		`state, system.state`: {Pos: 7, Msg: "duplicate field 'system.state'"},
+	}
+	for exp, expected := range testData {
+		_, err := query.ParseGroupBy(exp)
+		require.NotNil(t, err, "expected an error for %s", exp)
+		assert.Equal(t, expected, errs.Cause(err), "unexpected error for %s", exp)
+	}
+}
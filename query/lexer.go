@@ -0,0 +1,171 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind identifies the lexical class of a token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of input"
+	case tokenIdentifier:
+		return "identifier"
+	case tokenString:
+		return "string"
+	case tokenNumber:
+		return "number"
+	case tokenOperator:
+		return "operator"
+	case tokenLeftParen:
+		return "'('"
+	case tokenRightParen:
+		return "')'"
+	case tokenComma:
+		return "','"
+	}
+	return "unknown token"
+}
+
+// token is a lexical unit of a query. For strings, text holds the unquoted value.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// SyntaxError is returned for malformed queries.
+type SyntaxError struct {
+	// Pos is the byte offset (starting at 0) of the offending input
+	Pos int
+	Msg string
+}
+
+// Error implements the error interface
+func (err SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", err.Pos, err.Msg)
+}
+
+// operators ordered so that longer operators are matched first
+var operators = []string{"!=", "<>", "<=", ">=", "==", "=", "<", ">", "~"}
+
+// lex splits the input into tokens, the last token is always of kind tokenEOF
+func lex(input string) ([]token, error) {
+	tokens := []token{}
+	pos := 0
+	for {
+		for pos < len(input) {
+			r, size := utf8.DecodeRuneInString(input[pos:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			pos += size
+		}
+		if pos >= len(input) {
+			return append(tokens, token{kind: tokenEOF, pos: pos}), nil
+		}
+		t, end, err := nextToken(input, pos)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		pos = end
+	}
+}
+
+// nextToken reads the token starting at pos and returns it together with the offset following it
+func nextToken(input string, pos int) (token, int, error) {
+	c := input[pos]
+	switch {
+	case c == '(':
+		return token{kind: tokenLeftParen, text: "(", pos: pos}, pos + 1, nil
+	case c == ')':
+		return token{kind: tokenRightParen, text: ")", pos: pos}, pos + 1, nil
+	case c == ',':
+		return token{kind: tokenComma, text: ",", pos: pos}, pos + 1, nil
+	case c == '"' || c == '\'':
+		return scanString(input, pos)
+	case c == '-' || (c >= '0' && c <= '9'):
+		return scanNumber(input, pos)
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(input[pos:], op) {
+			return token{kind: tokenOperator, text: op, pos: pos}, pos + len(op), nil
+		}
+	}
+	end := pos
+	for end < len(input) {
+		r, size := utf8.DecodeRuneInString(input[end:])
+		if !isIdentifierRune(r) {
+			break
+		}
+		end += size
+	}
+	if end == pos {
+		r, _ := utf8.DecodeRuneInString(input[pos:])
+		return token{}, 0, SyntaxError{pos, fmt.Sprintf("unexpected character %q", r)}
+	}
+	return token{kind: tokenIdentifier, text: input[pos:end], pos: pos}, end, nil
+}
+
+// identifiers are used for field names and keywords, field names look like "system.title"
+func isIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// scanString reads a string delimited by either single or double quotes.
+// A backslash escapes the character following it.
+func scanString(input string, pos int) (token, int, error) {
+	quote := input[pos]
+	value := []byte{}
+	for i := pos + 1; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			i++
+			if i >= len(input) {
+				return token{}, 0, SyntaxError{pos, "unterminated string"}
+			}
+			value = append(value, input[i])
+		case quote:
+			return token{kind: tokenString, text: string(value), pos: pos}, i + 1, nil
+		default:
+			value = append(value, input[i])
+		}
+	}
+	return token{}, 0, SyntaxError{pos, "unterminated string"}
+}
+
+// scanNumber reads an optionally signed integer or decimal number
+func scanNumber(input string, pos int) (token, int, error) {
+	end := pos
+	if input[end] == '-' {
+		end++
+	}
+	digits := 0
+	for end < len(input) && (input[end] >= '0' && input[end] <= '9' || input[end] == '.') {
+		if input[end] != '.' {
+			digits++
+		}
+		end++
+	}
+	if digits == 0 {
+		return token{}, 0, SyntaxError{pos, fmt.Sprintf("invalid number '%s'", input[pos:end])}
+	}
+	return token{kind: tokenNumber, text: input[pos:end], pos: pos}, end, nil
+}
@@ -0,0 +1,399 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Keywords of the query language. Keywords are case insensitive.
+const (
+	keywordAnd   = "and"
+	keywordOr    = "or"
+	keywordNot   = "not"
+	keywordIn    = "in"
+	keywordTrue  = "true"
+	keywordFalse = "false"
+	keywordMe    = "me"
+	keywordIs    = "is"
+	keywordNull  = "null"
+)
+
+// fieldAliases maps the short field names of the query language to the names used in the work item storage
+var fieldAliases = map[string]string{
+	"id":        "ID",
+	"type":      "Type",
+	"title":     workitem.SystemTitle,
+	"state":     workitem.SystemState,
+	"assignee":  workitem.SystemAssignees,
+	"creator":   workitem.SystemCreator,
+	"iteration": workitem.SystemIteration,
+	"area":      workitem.SystemArea,
+}
+
+// listFields hold lists of values. Comparing them with a value means testing for containment.
+var listFields = map[string]bool{
+	workitem.SystemAssignees: true,
+}
+
+// Parse parses a query language expression like `state = "open" and (assignee = me or area = "UI")`
+// into a criteria.Expression. The keyword "me" refers to the identity given in currentUser, which may
+// be nil for anonymous requests. For compatibility, strings of the form { "attribute1":value1,"attribute2":value2}
+// are still accepted and mean "attribute1=value1 and attribute2=value2".
+// Returns the expression "true" if exp is empty and a SyntaxError if exp is malformed.
+func Parse(exp *string, currentUser *uuid.UUID) (criteria.Expression, error) {
+	if exp == nil || len(strings.TrimSpace(*exp)) == 0 {
+		return criteria.Literal(true), nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(*exp), "{") {
+		return parseJSON(*exp)
+	}
+	tokens, err := lex(*exp)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p := parser{tokens: tokens, currentUser: currentUser}
+	result, err := p.parseOr()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, errors.WithStack(p.unexpected(t, "'and', 'or' or end of input"))
+	}
+	return result, nil
+}
+
+// parseJSON converts a flat json object into a conjunction of equality checks
+func parseJSON(exp string) (criteria.Expression, error) {
+	var unmarshalled map[string]interface{}
+	err := json.Unmarshal([]byte(exp), &unmarshalled)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// sort the keys so that the resulting expression doesn't depend on map ordering
+	keys := make([]string, 0, len(unmarshalled))
+	for key := range unmarshalled {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var result criteria.Expression
+	for _, key := range keys {
+		current := criteria.Equals(criteria.Field(key), criteria.Literal(unmarshalled[key]))
+		if result == nil {
+			result = current
+		} else {
+			result = criteria.And(result, current)
+		}
+	}
+	if result == nil {
+		return criteria.Literal(true), nil
+	}
+	return result, nil
+}
+
+// parser is a recursive descent parser for the grammar
+//
+//	or         := and ("or" and)*
+//	and        := not ("and" not)*
+//	not        := "not" not | primary
+//	primary    := "(" or ")" | comparison
+//	comparison := field operator value | field ["not"] "in" "(" value ("," value)* ")" | field "is" ["not"] "null"
+//	operator   := "=" | "==" | "!=" | "<>" | "<" | "<=" | ">" | ">=" | "~"
+//	value      := string | number | "true" | "false" | "null" | "me" | identifier
+//
+// "~" is a case insensitive substring test, comparing with null tests for missing values.
+type parser struct {
+	tokens      []token
+	index       int
+	currentUser *uuid.UUID
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.index]
+	if t.kind != tokenEOF {
+		p.index++
+	}
+	return t
+}
+
+// isKeyword returns true if the token is the given keyword
+func isKeyword(t token, keyword string) bool {
+	return t.kind == tokenIdentifier && strings.EqualFold(t.text, keyword)
+}
+
+func isReserved(t token) bool {
+	for _, keyword := range []string{keywordAnd, keywordOr, keywordNot, keywordIn, keywordTrue, keywordFalse, keywordMe, keywordIs, keywordNull} {
+		if isKeyword(t, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) unexpected(t token, expected string) error {
+	if t.kind == tokenEOF {
+		return SyntaxError{t.pos, fmt.Sprintf("expected %s but found end of input", expected)}
+	}
+	return SyntaxError{t.pos, fmt.Sprintf("expected %s but found '%s'", expected, t.text)}
+}
+
+func (p *parser) parseOr() (criteria.Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for isKeyword(p.peek(), keywordOr) {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = criteria.Or(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (criteria.Expression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for isKeyword(p.peek(), keywordAnd) {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = criteria.And(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (criteria.Expression, error) {
+	if isKeyword(p.peek(), keywordNot) {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return criteria.Not(operand), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (criteria.Expression, error) {
+	if p.peek().kind == tokenLeftParen {
+		p.next()
+		result, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRightParen {
+			return nil, p.unexpected(t, "')'")
+		}
+		return result, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (criteria.Expression, error) {
+	fieldToken := p.next()
+	if fieldToken.kind != tokenIdentifier || isReserved(fieldToken) {
+		return nil, p.unexpected(fieldToken, "a field name")
+	}
+	fieldName := fieldToken.text
+	if alias, ok := fieldAliases[strings.ToLower(fieldName)]; ok {
+		fieldName = alias
+	}
+	field := criteria.Field(fieldName)
+
+	if isKeyword(p.peek(), keywordIs) {
+		p.next()
+		negated := false
+		if isKeyword(p.peek(), keywordNot) {
+			p.next()
+			negated = true
+		}
+		if t := p.next(); !isKeyword(t, keywordNull) {
+			return nil, p.unexpected(t, "'null'")
+		}
+		return negateIf(negated, criteria.IsNull(field)), nil
+	}
+
+	negated := false
+	if isKeyword(p.peek(), keywordNot) {
+		p.next()
+		negated = true
+		if !isKeyword(p.peek(), keywordIn) {
+			return nil, p.unexpected(p.peek(), "'in'")
+		}
+	}
+	if isKeyword(p.peek(), keywordIn) {
+		p.next()
+		result, err := p.parseIn(fieldName)
+		if err != nil {
+			return nil, err
+		}
+		return negateIf(negated, result), nil
+	}
+
+	opToken := p.next()
+	if opToken.kind != tokenOperator {
+		return nil, p.unexpected(opToken, "an operator")
+	}
+	if isKeyword(p.peek(), keywordNull) {
+		p.next()
+		switch opToken.text {
+		case "=", "==":
+			return criteria.IsNull(field), nil
+		case "!=", "<>":
+			return criteria.Not(criteria.IsNull(field)), nil
+		}
+		return nil, SyntaxError{opToken.pos, fmt.Sprintf("operator '%s' can't be used with null", opToken.text)}
+	}
+	valueToken := p.peek()
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	switch opToken.text {
+	case "=", "==", "!=", "<>":
+		literal, err := fieldValue(fieldName, value, valueToken)
+		if err != nil {
+			return nil, err
+		}
+		if opToken.text == "=" || opToken.text == "==" {
+			return criteria.Equals(field, literal), nil
+		}
+		return criteria.NotEquals(field, literal), nil
+	}
+	if listFields[fieldName] {
+		return nil, SyntaxError{opToken.pos, fmt.Sprintf("operator '%s' can't be used with the list field '%s'", opToken.text, fieldToken.text)}
+	}
+	switch opToken.text {
+	case "~":
+		if _, isString := value.(string); !isString {
+			return nil, p.unexpected(valueToken, "a string")
+		}
+		return criteria.Substring(field, criteria.Literal(value)), nil
+	case "<":
+		return criteria.LessThan(field, criteria.Literal(value)), nil
+	case "<=":
+		return criteria.LessOrEquals(field, criteria.Literal(value)), nil
+	case ">":
+		return criteria.GreaterThan(field, criteria.Literal(value)), nil
+	default:
+		return criteria.GreaterOrEquals(field, criteria.Literal(value)), nil
+	}
+}
+
+func negateIf(negate bool, exp criteria.Expression) criteria.Expression {
+	if negate {
+		return criteria.Not(exp)
+	}
+	return exp
+}
+
+// parseIn parses the value list of an "in" comparison. Since comparing a list field with a value
+// tests for containment, "in" on a list field is a disjunction of containment tests.
+func (p *parser) parseIn(fieldName string) (criteria.Expression, error) {
+	if t := p.next(); t.kind != tokenLeftParen {
+		return nil, p.unexpected(t, "'('")
+	}
+	values := []interface{}{}
+	valueTokens := []token{}
+	for {
+		valueTokens = append(valueTokens, p.peek())
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		t := p.next()
+		if t.kind == tokenRightParen {
+			break
+		}
+		if t.kind != tokenComma {
+			return nil, p.unexpected(t, "',' or ')'")
+		}
+	}
+	if !listFields[fieldName] {
+		return criteria.In(criteria.Field(fieldName), criteria.Literal(values)), nil
+	}
+	var result criteria.Expression
+	for i, value := range values {
+		literal, err := fieldValue(fieldName, value, valueTokens[i])
+		if err != nil {
+			return nil, err
+		}
+		current := criteria.Equals(criteria.Field(fieldName), literal)
+		if result == nil {
+			result = current
+		} else {
+			result = criteria.Or(result, current)
+		}
+	}
+	return result, nil
+}
+
+// fieldValue returns the literal to compare the field with. Values compared with list fields are wrapped into a list.
+func fieldValue(fieldName string, value interface{}, t token) (criteria.Expression, error) {
+	if !listFields[fieldName] {
+		return criteria.Literal(value), nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return nil, SyntaxError{t.pos, fmt.Sprintf("expected a string value for the list field '%s'", fieldName)}
+	}
+	return criteria.Literal([]string{s}), nil
+}
+
+// parseValue parses a literal value
+func (p *parser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		if strings.Contains(t.text, ".") {
+			f, err := strconv.ParseFloat(t.text, 64)
+			if err != nil {
+				return nil, SyntaxError{t.pos, fmt.Sprintf("invalid number '%s'", t.text)}
+			}
+			return f, nil
+		}
+		i, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, SyntaxError{t.pos, fmt.Sprintf("invalid number '%s'", t.text)}
+		}
+		return i, nil
+	case tokenIdentifier:
+		switch {
+		case isKeyword(t, keywordTrue):
+			return true, nil
+		case isKeyword(t, keywordFalse):
+			return false, nil
+		case isKeyword(t, keywordMe):
+			if p.currentUser == nil {
+				return nil, SyntaxError{t.pos, "'me' can only be used by authenticated users"}
+			}
+			return p.currentUser.String(), nil
+		case isReserved(t):
+			return nil, p.unexpected(t, "a value")
+		}
+		// unquoted words are treated as strings, e.g. state = open
+		return t.text, nil
+	}
+	return nil, p.unexpected(t, "a value")
+}
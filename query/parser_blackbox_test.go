@@ -0,0 +1,150 @@
+package query_test
+
+import (
+	"testing"
+
+	. "github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/query"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/workitem"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, exp string, currentUser *uuid.UUID) Expression {
+	result, err := query.Parse(&exp, currentUser)
+	require.Nil(t, err, "failed to parse %s", exp)
+	return result
+}
+
+func TestParseEmpty(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	assert.Equal(t, Literal(true), parse(t, "", nil))
+	assert.Equal(t, Literal(true), parse(t, "  ", nil))
+	result, err := query.Parse(nil, nil)
+	require.Nil(t, err)
+	assert.Equal(t, Literal(true), result)
+}
+
+func TestParseComparisons(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	assert.Equal(t, Equals(Field(workitem.SystemState), Literal("open")), parse(t, `state = "open"`, nil))
+	assert.Equal(t, Equals(Field(workitem.SystemState), Literal("open")), parse(t, `state == open`, nil))
+	assert.Equal(t, NotEquals(Field("system.title"), Literal("it's here")), parse(t, `system.title != 'it\'s here'`, nil))
+	assert.Equal(t, NotEquals(Field("estimate"), Literal(int64(3))), parse(t, `estimate <> 3`, nil))
+	assert.Equal(t, GreaterThan(Field("estimate"), Literal(2.5)), parse(t, `estimate > 2.5`, nil))
+	assert.Equal(t, GreaterOrEquals(Field("estimate"), Literal(int64(-1))), parse(t, `estimate >= -1`, nil))
+	assert.Equal(t, LessThan(Field("ID"), Literal(int64(10))), parse(t, `id < 10`, nil))
+	assert.Equal(t, LessOrEquals(Field("done"), Literal(true)), parse(t, `done <= TRUE`, nil))
+}
+
+func TestParseBooleanOperators(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	a := func() Expression { return Equals(Field("a"), Literal(int64(1))) }
+	b := func() Expression { return Equals(Field("b"), Literal(int64(2))) }
+	c := func() Expression { return Equals(Field("c"), Literal(int64(3))) }
+	// and binds tighter than or
+	assert.Equal(t, Or(a(), And(b(), c())), parse(t, `a = 1 or b = 2 and c = 3`, nil))
+	assert.Equal(t, And(Or(a(), b()), c()), parse(t, `(a = 1 or b = 2) and c = 3`, nil))
+	// not binds tighter than and
+	assert.Equal(t, And(Not(a()), b()), parse(t, `not a = 1 AND b = 2`, nil))
+	assert.Equal(t, Not(And(a(), b())), parse(t, `not (a = 1 and b = 2)`, nil))
+	// left associative
+	assert.Equal(t, And(And(a(), b()), c()), parse(t, `a = 1 and b = 2 and c = 3`, nil))
+}
+
+func TestParseIn(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expected := In(Field(workitem.SystemState), Literal([]interface{}{"open", "in progress"}))
+	assert.Equal(t, expected, parse(t, `state in ("open","in progress")`, nil))
+	expected = Not(In(Field("estimate"), Literal([]interface{}{int64(1), 2.5})))
+	assert.Equal(t, expected, parse(t, `estimate not in (1, 2.5)`, nil))
+	// list fields test for containment of any of the values
+	expected = Or(Equals(Field(workitem.SystemAssignees), Literal([]string{"a"})), Equals(Field(workitem.SystemAssignees), Literal([]string{"b"})))
+	assert.Equal(t, expected, parse(t, `assignee in ("a", "b")`, nil))
+}
+
+func TestParseNull(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	assert.Equal(t, IsNull(Field(workitem.SystemAssignees)), parse(t, `assignee is null`, nil))
+	assert.Equal(t, Not(IsNull(Field(workitem.SystemIteration))), parse(t, `iteration IS NOT NULL`, nil))
+	assert.Equal(t, IsNull(Field(workitem.SystemArea)), parse(t, `area = null`, nil))
+	assert.Equal(t, Not(IsNull(Field(workitem.SystemArea))), parse(t, `area != null`, nil))
+}
+
+func TestParseSubstring(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	assert.Equal(t, Substring(Field(workitem.SystemTitle), Literal("crash")), parse(t, `title ~ "crash"`, nil))
+}
+
+func TestParseMe(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	me := uuid.NewV4()
+	expected := Or(Equals(Field(workitem.SystemAssignees), Literal([]string{me.String()})), Equals(Field(workitem.SystemArea), Literal("UI")))
+	assert.Equal(t, expected, parse(t, `assignee = me or area = "UI"`, &me))
+
+	exp := `assignee = me`
+	_, err := query.Parse(&exp, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, query.SyntaxError{Pos: 11, Msg: "'me' can only be used by authenticated users"}, errs.Cause(err))
+}
+
+func TestParseCompiles(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	me := uuid.NewV4()
+	exp := parse(t, `state in ("open","in progress") and (assignee = me or area = "UI")`, &me)
+	where, parameters, compileErrors := workitem.Compile(exp)
+	require.Empty(t, compileErrors)
+	assert.Equal(t, `(coalesce(Fields->'system.state' <@ ?::jsonb, false) and `+
+		`((Fields@>'{"system.assignees" : ["`+me.String()+`"]}') or (Fields@>'{"system.area" : "UI"}')))`, where)
+	assert.Equal(t, []interface{}{`["open","in progress"]`}, parameters)
+}
+
+func TestParseJSON(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expected := And(Equals(Field("a"), Literal(float64(1))), Equals(Field("system.title"), Literal("foo")))
+	assert.Equal(t, expected, parse(t, `{"system.title": "foo", "a": 1}`, nil))
+	assert.Equal(t, Literal(true), parse(t, `{}`, nil))
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	testData := map[string]query.SyntaxError{
+		`state = "open`:          {Pos: 8, Msg: "unterminated string"},
+		`state = `:               {Pos: 8, Msg: "expected a value but found end of input"},
+		`state "open"`:           {Pos: 6, Msg: "expected an operator but found 'open'"},
+		`(state = open`:          {Pos: 13, Msg: "expected ')' but found end of input"},
+		`state = open)`:          {Pos: 12, Msg: "expected 'and', 'or' or end of input but found ')'"},
+		`and = 1`:                {Pos: 0, Msg: "expected a field name but found 'and'"},
+		`state in ("a" "b")`:     {Pos: 14, Msg: "expected ',' or ')' but found 'b'"},
+		`state not = 1`:          {Pos: 10, Msg: "expected 'in' but found '='"},
+		`state = 1 & b = 2`:      {Pos: 10, Msg: "unexpected character '&'"},
+		`assignee > "x"`:         {Pos: 9, Msg: "operator '>' can't be used with the list field 'assignee'"},
+		`assignee = 5`:           {Pos: 11, Msg: "expected a string value for the list field 'system.assignees'"},
+		`estimate > -`:           {Pos: 11, Msg: "invalid number '-'"},
+		`a = 1 or not (b = 2 or`: {Pos: 22, Msg: "expected a field name but found end of input"},
+		`title ~ 5`:              {Pos: 8, Msg: "expected a string but found '5'"},
+		`area is "x"`:            {Pos: 8, Msg: "expected 'null' but found 'x'"},
+		`estimate > null`:        {Pos: 9, Msg: "operator '>' can't be used with null"},
+		`state in (null)`:        {Pos: 10, Msg: "expected a value but found 'null'"},
+		`assignee ~ "x"`:         {Pos: 9, Msg: "operator '~' can't be used with the list field 'assignee'"},
+	}
+	for exp, expected := range testData {
+		exp := exp
+		_, err := query.Parse(&exp, nil)
+		require.NotNil(t, err, "expected an error for %s", exp)
+		assert.Equal(t, expected, errs.Cause(err), "unexpected error for %s", exp)
+	}
+}
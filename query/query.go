@@ -0,0 +1,198 @@
+package query
+
+import (
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+
+	"github.com/goadesign/goa"
+	"github.com/jinzhu/gorm"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// APIStringTypeQueries is the JSON-API type of saved queries
+const APIStringTypeQueries = "queries"
+
+// Query is a saved work item query. It is private to its owner unless it is shared with a space.
+type Query struct {
+	gormsupport.Lifecycle
+	ID      uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	Version int
+	Title   string
+	// Description is optional
+	Description string
+	// Filter is an expression of the query language, see Parse
+	Filter string
+	// Sort is a list of sort keys as accepted by ParseSort, empty for the default order
+	Sort    string
+	OwnerID uuid.UUID `sql:"type:uuid"`
+	// SpaceID is the space the query is shared with, nil for private queries
+	SpaceID *uuid.UUID `sql:"type:uuid"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (q Query) TableName() string {
+	return "queries"
+}
+
+// VisibleTo tells whether the given identity may see and run the query. Queries shared with a space are
+// visible to every identity which can see the space, other queries only to their owner. Anonymous users,
+// whose identityID is nil, don't see any query.
+func (q Query) VisibleTo(identityID *uuid.UUID) bool {
+	if identityID == nil {
+		return false
+	}
+	return q.SpaceID != nil || uuid.Equal(*identityID, q.OwnerID)
+}
+
+// validate makes sure the filter and the sort keys of the query can be parsed. "me" in the
+// filter stands for the user running the query, so it's resolved to the owner here.
+func (q Query) validate() error {
+	if _, err := Parse(&q.Filter, &q.OwnerID); err != nil {
+		return errors.NewBadParameterError("filter", q.Filter).Expected(err.Error())
+	}
+	if _, err := ParseSort(&q.Sort); err != nil {
+		return errors.NewBadParameterError("sort", q.Sort).Expected(err.Error())
+	}
+	return nil
+}
+
+// Repository encapsulates storage & retrieval of saved queries
+type Repository interface {
+	Create(ctx context.Context, q *Query) (*Query, error)
+	Save(ctx context.Context, q *Query) (*Query, error)
+	Load(ctx context.Context, id uuid.UUID) (*Query, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, ownerID *uuid.UUID, spaceID *uuid.UUID) ([]*Query, error)
+}
+
+// NewQueryRepository creates a new saved query repository
+func NewQueryRepository(db *gorm.DB) *GormQueryRepository {
+	return &GormQueryRepository{db: db}
+}
+
+// GormQueryRepository implements Repository using gorm
+type GormQueryRepository struct {
+	db *gorm.DB
+}
+
+// Create stores a new saved query
+// returns BadParameterError or InternalError
+func (r *GormQueryRepository) Create(ctx context.Context, q *Query) (*Query, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "query", "create"}, time.Now())
+	if err := q.validate(); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	q.ID = uuid.NewV4()
+	tx := r.db.Create(q)
+	if err := tx.Error; err != nil {
+		return nil, convertError(err, q)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"queryID": q.ID,
+	}, "Query created successfully")
+	return q, nil
+}
+
+// Save updates the given query. Version must be the same as the one in the stored version, it's
+// only incremented if the query was updated
+// returns NotFoundError, BadParameterError, VersionConflictError or InternalError
+func (r *GormQueryRepository) Save(ctx context.Context, q *Query) (*Query, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "query", "save"}, time.Now())
+	if err := q.validate(); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	existing := Query{}
+	tx := r.db.Where("id = ?", q.ID).First(&existing)
+	if tx.RecordNotFound() {
+		return nil, errors.NewNotFoundError("query", q.ID.String())
+	}
+	if err := tx.Error; err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	updated := *q
+	updated.Version = q.Version + 1
+	tx = tx.Where("version = ?", q.Version).Save(&updated)
+	if err := tx.Error; err != nil {
+		return nil, convertError(err, q)
+	}
+	if tx.RowsAffected == 0 {
+		return nil, errors.NewVersionConflictError("version conflict")
+	}
+	*q = updated
+	log.Info(ctx, map[string]interface{}{
+		"queryID": q.ID,
+	}, "Query updated successfully")
+	return q, nil
+}
+
+// convertError turns constraint violations into BadParameterErrors
+func convertError(err error, q *Query) error {
+	if gormsupport.IsCheckViolation(err, "queries_title_check") {
+		return errors.NewBadParameterError("title", q.Title).Expected("not empty")
+	}
+	if gormsupport.IsForeignKeyViolation(err, "queries_space_id_spaces_id_fk") {
+		return errors.NewBadParameterError("space", q.SpaceID.String()).Expected("existing space")
+	}
+	return errors.NewInternalError(err.Error())
+}
+
+// Load returns the saved query with the given id
+// returns NotFoundError or InternalError
+func (r *GormQueryRepository) Load(ctx context.Context, id uuid.UUID) (*Query, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "query", "get"}, time.Now())
+	result := Query{}
+	tx := r.db.Where("id = ?", id).First(&result)
+	if tx.RecordNotFound() {
+		return nil, errors.NewNotFoundError("query", id.String())
+	}
+	if tx.Error != nil {
+		return nil, errors.NewInternalError(tx.Error.Error())
+	}
+	return &result, nil
+}
+
+// Delete deletes the saved query with the given id
+// returns NotFoundError or InternalError
+func (r *GormQueryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer goa.MeasureSince([]string{"goa", "db", "query", "delete"}, time.Now())
+	if id == uuid.Nil {
+		return errors.NewNotFoundError("query", id.String())
+	}
+	tx := r.db.Delete(Query{ID: id})
+	if err := tx.Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	if tx.RowsAffected == 0 {
+		return errors.NewNotFoundError("query", id.String())
+	}
+	return nil
+}
+
+// List returns the queries owned by the given identity together with the queries shared with the given
+// space, ordered by title. Either of them may be nil.
+// returns InternalError
+func (r *GormQueryRepository) List(ctx context.Context, ownerID *uuid.UUID, spaceID *uuid.UUID) ([]*Query, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "query", "list"}, time.Now())
+	result := []*Query{}
+	if ownerID == nil && spaceID == nil {
+		return result, nil
+	}
+	db := r.db
+	if ownerID != nil && spaceID != nil {
+		db = db.Where("owner_id = ? or space_id = ?", *ownerID, *spaceID)
+	} else if ownerID != nil {
+		db = db.Where("owner_id = ?", *ownerID)
+	} else {
+		db = db.Where("space_id = ?", *spaceID)
+	}
+	if err := db.Order("title, id").Find(&result).Error; err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return result, nil
+}
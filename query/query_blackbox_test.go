@@ -0,0 +1,183 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/gormsupport/cleaner"
+	"github.com/almighty/almighty-core/query"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/space"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/context"
+)
+
+func TestQueryVisibleTo(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	owner := uuid.NewV4()
+	other := uuid.NewV4()
+	spaceID := uuid.NewV4()
+
+	private := query.Query{OwnerID: owner}
+	assert.True(t, private.VisibleTo(&owner))
+	assert.False(t, private.VisibleTo(&other))
+	assert.False(t, private.VisibleTo(nil))
+
+	shared := query.Query{OwnerID: owner, SpaceID: &spaceID}
+	assert.True(t, shared.VisibleTo(&owner))
+	assert.True(t, shared.VisibleTo(&other))
+	assert.False(t, shared.VisibleTo(nil))
+}
+
+func TestRunQueryRepoBBTest(t *testing.T) {
+	suite.Run(t, &queryRepoBBTest{DBTestSuite: gormsupport.NewDBTestSuite("../config.yaml")})
+}
+
+type queryRepoBBTest struct {
+	gormsupport.DBTestSuite
+	repo  query.Repository
+	clean func()
+	ctx   context.Context
+}
+
+func (s *queryRepoBBTest) SetupTest() {
+	s.repo = query.NewQueryRepository(s.DB)
+	s.clean = cleaner.DeleteCreatedEntities(s.DB)
+	s.ctx = context.Background()
+}
+
+func (s *queryRepoBBTest) TearDownTest() {
+	s.clean()
+}
+
+func (s *queryRepoBBTest) newQuery(title string, owner uuid.UUID, spaceID *uuid.UUID) *query.Query {
+	return &query.Query{
+		Title:   title,
+		Filter:  `assignee = me and state != "closed"`,
+		Sort:    "-updated",
+		OwnerID: owner,
+		SpaceID: spaceID,
+	}
+}
+
+func (s *queryRepoBBTest) TestCreateAndLoad() {
+	owner := uuid.NewV4()
+	created, err := s.repo.Create(s.ctx, s.newQuery("My bugs", owner, nil))
+	require.Nil(s.T(), err)
+	assert.NotEqual(s.T(), uuid.Nil, created.ID)
+
+	loaded, err := s.repo.Load(s.ctx, created.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "My bugs", loaded.Title)
+	assert.Equal(s.T(), created.Filter, loaded.Filter)
+	assert.Equal(s.T(), "-updated", loaded.Sort)
+	assert.Equal(s.T(), owner, loaded.OwnerID)
+	assert.Nil(s.T(), loaded.SpaceID)
+
+	_, err = s.repo.Load(s.ctx, uuid.NewV4())
+	require.IsType(s.T(), errors.NotFoundError{}, err)
+}
+
+func (s *queryRepoBBTest) TestCreateInvalid() {
+	owner := uuid.NewV4()
+
+	q := s.newQuery("", owner, nil)
+	_, err := s.repo.Create(s.ctx, q)
+	require.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+
+	q = s.newQuery("Broken filter", owner, nil)
+	q.Filter = "state = "
+	_, err = s.repo.Create(s.ctx, q)
+	require.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+
+	q = s.newQuery("Broken sort", owner, nil)
+	q.Sort = "-"
+	_, err = s.repo.Create(s.ctx, q)
+	require.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+
+	unknownSpace := uuid.NewV4()
+	_, err = s.repo.Create(s.ctx, s.newQuery("Unknown space", owner, &unknownSpace))
+	require.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
+
+func (s *queryRepoBBTest) TestSave() {
+	created, err := s.repo.Create(s.ctx, s.newQuery("My bugs", uuid.NewV4(), nil))
+	require.Nil(s.T(), err)
+	version := created.Version
+
+	created.Title = "My open bugs"
+	saved, err := s.repo.Save(s.ctx, created)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "My open bugs", saved.Title)
+	assert.Equal(s.T(), version+1, saved.Version)
+
+	stale := *saved
+	stale.Version = version
+	_, err = s.repo.Save(s.ctx, &stale)
+	require.IsType(s.T(), errors.VersionConflictError{}, errs.Cause(err))
+	assert.Equal(s.T(), version, stale.Version)
+
+	saved.Title = ""
+	_, err = s.repo.Save(s.ctx, saved)
+	require.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+
+	unknown := s.newQuery("Unknown", uuid.NewV4(), nil)
+	unknown.ID = uuid.NewV4()
+	_, err = s.repo.Save(s.ctx, unknown)
+	require.IsType(s.T(), errors.NotFoundError{}, errs.Cause(err))
+}
+
+func (s *queryRepoBBTest) TestDelete() {
+	created, err := s.repo.Create(s.ctx, s.newQuery("My bugs", uuid.NewV4(), nil))
+	require.Nil(s.T(), err)
+
+	require.Nil(s.T(), s.repo.Delete(s.ctx, created.ID))
+	_, err = s.repo.Load(s.ctx, created.ID)
+	require.IsType(s.T(), errors.NotFoundError{}, err)
+
+	require.IsType(s.T(), errors.NotFoundError{}, s.repo.Delete(s.ctx, created.ID))
+	require.IsType(s.T(), errors.NotFoundError{}, s.repo.Delete(s.ctx, uuid.Nil))
+}
+
+func (s *queryRepoBBTest) TestList() {
+	owner := uuid.NewV4()
+	other := uuid.NewV4()
+	sp, err := space.NewRepository(s.DB).Create(s.ctx, &space.Space{Name: "query-test-" + uuid.NewV4().String()})
+	require.Nil(s.T(), err)
+
+	_, err = s.repo.Create(s.ctx, s.newQuery("b private", owner, nil))
+	require.Nil(s.T(), err)
+	_, err = s.repo.Create(s.ctx, s.newQuery("a shared", other, &sp.ID))
+	require.Nil(s.T(), err)
+	_, err = s.repo.Create(s.ctx, s.newQuery("c other", other, nil))
+	require.Nil(s.T(), err)
+
+	titles := func(queries []*query.Query) []string {
+		result := []string{}
+		for _, q := range queries {
+			result = append(result, q.Title)
+		}
+		return result
+	}
+
+	list, err := s.repo.List(s.ctx, &owner, nil)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{"b private"}, titles(list))
+
+	list, err = s.repo.List(s.ctx, &owner, &sp.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{"a shared", "b private"}, titles(list))
+
+	list, err = s.repo.List(s.ctx, nil, &sp.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []string{"a shared"}, titles(list))
+
+	list, err = s.repo.List(s.ctx, nil, nil)
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), list)
+}
@@ -0,0 +1,57 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/pkg/errors"
+)
+
+// sortAliases maps the short names of the work item columns usable as sort keys
+var sortAliases = map[string]string{
+	"version": workitem.SortByVersion,
+	"created": workitem.SortByCreatedAt,
+	"updated": workitem.SortByUpdatedAt,
+}
+
+// ParseSort parses a comma separated list of sort keys like `-updated,title` as used by the
+// JSON-API sort parameter. A leading "-" sorts the key in descending order. Keys are either
+// the columns id, version, created and updated or work item fields, where the field aliases
+// of the query language apply. Returns an empty list if exp is empty and a SyntaxError if exp is malformed.
+func ParseSort(exp *string) ([]workitem.SortKey, error) {
+	result := []workitem.SortKey{}
+	if exp == nil || len(strings.TrimSpace(*exp)) == 0 {
+		return result, nil
+	}
+	pos := 0
+	for _, part := range strings.Split(*exp, ",") {
+		key := workitem.SortKey{}
+		name := strings.TrimSpace(part)
+		keyPos := pos + strings.Index(part, name)
+		pos += len(part) + 1
+		if strings.HasPrefix(name, "-") {
+			key.Descending = true
+			name = name[1:]
+		}
+		if len(name) == 0 {
+			return nil, errors.WithStack(SyntaxError{keyPos, "expected a sort key"})
+		}
+		for _, r := range name {
+			if !isIdentifierRune(r) {
+				return nil, errors.WithStack(SyntaxError{keyPos, fmt.Sprintf("invalid sort key '%s'", name)})
+			}
+		}
+		key.Field = name
+		if alias, ok := sortAliases[strings.ToLower(name)]; ok {
+			key.Field = alias
+		} else if alias, ok := fieldAliases[strings.ToLower(name)]; ok {
+			key.Field = alias
+		}
+		if listFields[key.Field] {
+			return nil, errors.WithStack(SyntaxError{keyPos, fmt.Sprintf("can't sort by the list field '%s'", name)})
+		}
+		result = append(result, key)
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/query"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/workitem"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSort(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	exp := ` -updated, title,story_points ,ID,-created,version`
+	keys, err := query.ParseSort(&exp)
+	require.Nil(t, err)
+	expected := []workitem.SortKey{
+		{Field: workitem.SortByUpdatedAt, Descending: true},
+		{Field: workitem.SystemTitle},
+		{Field: "story_points"},
+		{Field: workitem.SortByID},
+		{Field: workitem.SortByCreatedAt, Descending: true},
+		{Field: workitem.SortByVersion},
+	}
+	assert.Equal(t, expected, keys)
+
+	keys, err = query.ParseSort(nil)
+	require.Nil(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestParseSortErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	testData := map[string]query.SyntaxError{
+		`title,`:          {Pos: 6, Msg: "expected a sort key"},
+		`title, -`:        {Pos: 7, Msg: "expected a sort key"},
+		`title,sta'te`:    {Pos: 6, Msg: "invalid sort key 'sta'te'"},
+		`state,-assignee`: {Pos: 6, Msg: "can't sort by the list field 'assignee'"},
+	}
+	for exp, expected := range testData {
+		exp := exp
+		_, err := query.ParseSort(&exp)
+		require.NotNil(t, err, "expected an error for %s", exp)
+		assert.Equal(t, expected, errs.Cause(err), "unexpected error for %s", exp)
+	}
+}
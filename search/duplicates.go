@@ -0,0 +1,103 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/workitem"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// The limits of the number of duplicates SearchDuplicates returns
+const (
+	DefaultDuplicatesLimit = 5
+	MaxDuplicatesLimit     = 50
+)
+
+// duplicateTitleWeight is the share of the title in the similarity of a duplicate if a description is given,
+// the description makes up the rest
+const duplicateTitleWeight = 0.7
+
+// duplicateTitle and duplicateDescription are the texts of a work item the draft is compared with,
+// the description is a markup content or a plain string
+var (
+	duplicateTitle       = fmt.Sprintf("coalesce(%s.fields->>'%s', '')", workitem.WorkItem{}.TableName(), workitem.SystemTitle)
+	duplicateDescription = fmt.Sprintf("coalesce(case when jsonb_typeof(%[1]s.fields->'%[2]s') = 'object' "+
+		"then %[1]s.fields->'%[2]s'->>'content' else %[1]s.fields->>'%[2]s' end, '')",
+		workitem.WorkItem{}.TableName(), workitem.SystemDescription)
+)
+
+// SearchDuplicates returns the work items of the space which are likely duplicates of a draft with the
+// given title and description, the most similar first, along with the similarity of the trigrams of the texts.
+// Only work items with a similar title are considered, see the
+// similarity threshold of pg_trgm, the description is optional and refines the similarity.
+// returns BadParameterError for an empty title or a limit out of range
+func (r *GormSearchRepository) SearchDuplicates(ctx context.Context, spaceID uuid.UUID, title string, description string, limit int) ([]*app.DuplicateHit, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, errors.NewBadParameterError("title", title).Expected("a non-empty title")
+	}
+	if limit <= 0 || limit > MaxDuplicatesLimit {
+		return nil, errors.NewBadParameterError("limit", limit).Expected(fmt.Sprintf("a limit between 1 and %d", MaxDuplicatesLimit))
+	}
+	similarity := "similarity(" + duplicateTitle + ", ?)"
+	parameters := []interface{}{title}
+	if description = strings.TrimSpace(description); description != "" {
+		similarity = fmt.Sprintf("%[1]g * %[2]s + %[3]g * similarity(%[4]s, ?)",
+			duplicateTitleWeight, similarity, 1-duplicateTitleWeight, duplicateDescription)
+		parameters = append(parameters, description)
+	}
+	db := r.db.Model(workitem.WorkItem{}).
+		Select(workitem.WorkItem{}.TableName()+".*, "+similarity+" as similarity", parameters...).
+		Where(workitem.WorkItem{}.TableName()+".space_id = ?", spaceID).
+		// the trigram index of the titles serves the % operator
+		Where(duplicateTitle+" % ?", title).
+		Order("similarity desc").Order(workitem.WorkItem{}.TableName() + ".id desc").
+		Limit(limit)
+	rows, err := db.Rows()
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	hits := []searchHit{}
+	for rows.Next() {
+		hit := searchHit{}
+		if err := db.ScanRows(rows, &hit.WorkItem); err != nil {
+			return nil, errors.NewInternalError(err.Error())
+		}
+		var ignore interface{}
+		var value float64
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = &ignore
+			if column == "similarity" {
+				values[i] = &value
+			}
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, errors.NewInternalError(err.Error())
+		}
+		hit.similarity = value
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	workItems, err := r.convertWorkItems(ctx, hits)
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+	result := make([]*app.DuplicateHit, len(hits))
+	for index, hit := range hits {
+		result[index] = &app.DuplicateHit{WorkItem: workItems[index], Similarity: hit.similarity}
+	}
+	return result, nil
+}
@@ -0,0 +1,342 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// maxEmbeddedHits caps the number of the best matching work items the embedded engine passes to the
+// database, less relevant work items aren't found
+const maxEmbeddedHits = 10000
+
+// embeddedFlushDelay is how long changes are collected before the index is written to disk
+const embeddedFlushDelay = 5 * time.Second
+
+// reindexBatchSize is the number of work items loaded at once while reindexing
+const reindexBatchSize = 1000
+
+// embeddedClaimKey names the advisory lock held by the process using the embedded engine. The index is only
+// kept in sync with the changes made by that process, so a single instance of the service may use it.
+const embeddedClaimKey = "search:embedded"
+
+// embeddedSyncMargin is how long before the index was written the changes are caught up with when the index
+// is claimed, so that changes committed late or notified after the write aren't missed
+const embeddedSyncMargin = 10 * time.Minute
+
+// EmbeddedEngine keeps an index of the texts of the work items in a file, the same texts the PostgresEngine
+// searches. Unlike the PostgresEngine it ranks with BM25 and tolerates typos, a word of the search string
+// matches words differing in one character, or in two characters for words of eight or more characters.
+// The index is kept in sync by registering the engine with workitem.RegisterChangeListener, which notifies
+// the engine about committed changes only. The words are split and lower cased by the engine, the text search
+// configurations of the spaces aren't applied.
+type EmbeddedEngine struct {
+	// db is used to load the texts of changed work items
+	db *gorm.DB
+	// claim is the transaction holding the advisory lock taken by Claim, nil if the engine isn't claimed
+	claim *gorm.DB
+	path  string
+	lock  sync.RWMutex
+	index *embeddedIndex
+	// flush is the pending write of the index, nil if the file is up to date
+	flush *time.Timer
+	// flushLock serializes the writes of the file
+	flushLock sync.Mutex
+}
+
+// OpenEmbeddedEngine returns the engine with the index kept in the file at the given path, the index is
+// empty if the file doesn't exist yet.
+// returns InternalError if the file can't be read
+func OpenEmbeddedEngine(db *gorm.DB, path string) (*EmbeddedEngine, error) {
+	index, err := loadEmbeddedIndex(path)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("failed to read the search index %s: %s", path, err.Error()))
+	}
+	return &EmbeddedEngine{db: db, path: path, index: index}, nil
+}
+
+// Claim makes the engine the only one using the embedded index, since the index is only kept in sync with
+// the changes made by this process. The work items changed since the index was written are indexed again and
+// the deleted ones are dropped. The claim is held until Close.
+// returns InternalError if another process claimed the embedded engine already
+func (e *EmbeddedEngine) Claim(ctx context.Context) error {
+	tx := e.db.Begin()
+	if tx.Error != nil {
+		return errors.NewInternalError(tx.Error.Error())
+	}
+	var claimed bool
+	if err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", embeddedClaimKey).Row().Scan(&claimed); err != nil {
+		tx.Rollback()
+		return errors.NewInternalError(err.Error())
+	}
+	if !claimed {
+		tx.Rollback()
+		return errors.NewInternalError("the embedded search engine is used by another instance, it supports a single instance only")
+	}
+	if err := e.catchUp(ctx); err != nil {
+		tx.Rollback()
+		return errs.WithStack(err)
+	}
+	e.claim = tx
+	return nil
+}
+
+// Close writes the index to its file and releases the claim of the engine
+// returns InternalError if the file can't be written
+func (e *EmbeddedEngine) Close() error {
+	if e.claim != nil {
+		e.claim.Rollback()
+		e.claim = nil
+	}
+	return e.Flush()
+}
+
+// catchUp indexes the work items changed or commented since the index was written again and drops the work
+// items which don't exist anymore
+func (e *EmbeddedEngine) catchUp(ctx context.Context) error {
+	e.lock.RLock()
+	since := e.index.Synced.Add(-embeddedSyncMargin)
+	e.lock.RUnlock()
+	var existing []uint64
+	if err := e.db.Table(workitem.WorkItem{}.TableName()).Where("deleted_at IS NULL").Pluck("id", &existing).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	var changed []uint64
+	if err := e.db.Table(workitem.WorkItem{}.TableName()).
+		Where("deleted_at IS NULL AND (updated_at > ? OR id::text IN (SELECT parent_id FROM comments WHERE updated_at > ? OR deleted_at > ?))", since, since, since).
+		Pluck("id", &changed).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	var workItems []indexedWorkItem
+	for start := 0; start < len(changed); start += reindexBatchSize {
+		end := start + reindexBatchSize
+		if end > len(changed) {
+			end = len(changed)
+		}
+		var batch []indexedWorkItem
+		if err := selectIndexedWorkItems(e.db).Where("id IN (?)", changed[start:end]).Find(&batch).Error; err != nil {
+			return errors.NewInternalError(err.Error())
+		}
+		workItems = append(workItems, batch...)
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	exists := make(map[uint64]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+	for id := range e.index.Documents {
+		if !exists[id] {
+			e.index.remove(id)
+		}
+	}
+	for _, wi := range workItems {
+		e.index.remove(wi.ID)
+		e.index.add(wi.ID, wi.Type, wi.Texts)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"since":     since,
+		"reindexed": len(workItems),
+	}, "caught up with the changes of the work items in the search index")
+	e.scheduleFlush()
+	return nil
+}
+
+// indexedWorkItem holds the texts of a work item the embedded index is built from
+type indexedWorkItem struct {
+	ID    uint64
+	Type  uuid.UUID
+	Texts pq.StringArray
+}
+
+// selectIndexedWorkItems selects the indexedWorkItems of the work items, their texts are returned by the
+// workitem_search_texts SQL function the search vectors are built with too
+func selectIndexedWorkItems(db *gorm.DB) *gorm.DB {
+	return db.Table(workitem.WorkItem{}.TableName()).
+		Select("id, type, workitem_search_texts(id, type, fields) as texts").
+		Where("deleted_at IS NULL")
+}
+
+// Size returns the number of indexed work items
+func (e *EmbeddedEngine) Size() int {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return len(e.index.Documents)
+}
+
+// WorkItemChanged indexes the created or saved work item or the work item whose comments changed
+func (e *EmbeddedEngine) WorkItemChanged(ctx context.Context, id uint64) {
+	var workItems []indexedWorkItem
+	if err := selectIndexedWorkItems(e.db).Where("id = ?", id).Find(&workItems).Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"wiID": id,
+			"err":  err,
+		}, "failed to load the texts of the work item for the search index")
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	// the work item may have been deleted meanwhile
+	e.index.remove(id)
+	for _, wi := range workItems {
+		e.index.add(wi.ID, wi.Type, wi.Texts)
+	}
+	e.scheduleFlush()
+}
+
+// WorkItemDeleted drops the deleted work item from the index
+func (e *EmbeddedEngine) WorkItemDeleted(ctx context.Context, id uint64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.index.remove(id)
+	e.scheduleFlush()
+}
+
+// scheduleFlush writes the index after embeddedFlushDelay unless a write is pending already.
+// The lock has to be held.
+func (e *EmbeddedEngine) scheduleFlush() {
+	if e.flush != nil {
+		return
+	}
+	e.flush = time.AfterFunc(embeddedFlushDelay, func() {
+		if err := e.Flush(); err != nil {
+			log.Error(nil, map[string]interface{}{
+				"path": e.path,
+				"err":  err,
+			}, "failed to write the search index")
+		}
+	})
+}
+
+// Flush writes the index to its file. A snapshot of the index is written, so that the index can be searched
+// and changed meanwhile.
+// returns InternalError if the file can't be written
+func (e *EmbeddedEngine) Flush() error {
+	e.flushLock.Lock()
+	defer e.flushLock.Unlock()
+	e.lock.Lock()
+	if e.flush != nil {
+		e.flush.Stop()
+		e.flush = nil
+	}
+	snapshot := e.index.snapshot()
+	snapshot.Synced = time.Now()
+	e.lock.Unlock()
+	if err := snapshot.save(e.path); err != nil {
+		return errors.NewInternalError(fmt.Sprintf("failed to write the search index %s: %s", e.path, err.Error()))
+	}
+	return nil
+}
+
+// rankedHit is a work item found in the index along with its relevance
+type rankedHit struct {
+	id   uint64
+	rank float64
+}
+
+// hits returns the best matching work items
+func (e *EmbeddedEngine) hits(text searchText) []rankedHit {
+	e.lock.RLock()
+	scores := e.index.search(text.nodes)
+	e.lock.RUnlock()
+	result := make([]rankedHit, 0, len(scores))
+	for id, rank := range scores {
+		result = append(result, rankedHit{id, rank})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].rank != result[j].rank {
+			return result[i].rank > result[j].rank
+		}
+		return result[i].id > result[j].id
+	})
+	if len(result) > maxEmbeddedHits {
+		result = result[:maxEmbeddedHits]
+	}
+	return result
+}
+
+func (e *EmbeddedEngine) match(db *gorm.DB, text searchText) *gorm.DB {
+	// the text search query is still needed for highlighting
+	db = joinTextSearchQuery(db, text)
+	if len(text.nodes) == 0 {
+		return db.Joins(", (select 0::float8 as rank) as ranking")
+	}
+	hits := e.hits(text)
+	if len(hits) == 0 {
+		return db.Where("false").Joins(", (select 0::float8 as rank) as ranking")
+	}
+	// the hits are passed as array literals and joined with the work items
+	ids := make([]string, len(hits))
+	ranks := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = strconv.FormatUint(hit.id, 10)
+		ranks[i] = strconv.FormatFloat(hit.rank, 'g', -1, 64)
+	}
+	return db.Joins(", unnest(?::bigint[], ?::float8[]) as ranking(work_item_id, rank)",
+		"{"+strings.Join(ids, ",")+"}", "{"+strings.Join(ranks, ",")+"}").
+		Where(fmt.Sprintf("ranking.work_item_id = %s.id", workitem.WorkItem{}.TableName()))
+}
+
+func (e *EmbeddedEngine) reindex(ctx context.Context, db *gorm.DB, workItemTypeID *uuid.UUID) (int64, error) {
+	// the work items are indexed into a new index, which replaces the current index or the work items
+	// of the type in it once the transaction is committed
+	index := newEmbeddedIndex()
+	var last uint64
+	for {
+		var batch []indexedWorkItem
+		query := selectIndexedWorkItems(db).Where("id > ?", last)
+		if workItemTypeID != nil {
+			query = query.Where("type = ?", *workItemTypeID)
+		}
+		if err := query.Order("id").Limit(reindexBatchSize).Find(&batch).Error; err != nil {
+			return 0, errors.NewInternalError(err.Error())
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, wi := range batch {
+			index.add(wi.ID, wi.Type, wi.Texts)
+		}
+		last = batch[len(batch)-1].ID
+	}
+	gormsupport.AfterCommit(db, func() {
+		e.replace(index, workItemTypeID)
+		if err := e.Flush(); err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"path": e.path,
+				"err":  err,
+			}, "failed to write the search index")
+		}
+	})
+	return int64(len(index.Documents)), nil
+}
+
+// replace replaces the index by the given one, or only the work items of the type if workItemTypeID isn't nil
+func (e *EmbeddedEngine) replace(index *embeddedIndex, workItemTypeID *uuid.UUID) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if workItemTypeID == nil {
+		e.index = index
+		return
+	}
+	// drop the work items which don't exist or have another type by now
+	for id, document := range e.index.Documents {
+		if document.Type == *workItemTypeID && index.Documents[id] == nil {
+			e.index.remove(id)
+		}
+	}
+	e.index.merge(index)
+}
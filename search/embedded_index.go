@@ -0,0 +1,420 @@
+package search
+
+import (
+	"encoding/gob"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/asaskevich/govalidator"
+	uuid "github.com/satori/go.uuid"
+)
+
+// fieldStride separates the positions of the terms of the indexed fields, the positions of a field
+// start at its index times the stride. Terms of a phrase have to be in the same field.
+const fieldStride = 1 << 20
+
+// indexedFields are the texts of the work items in the embedded index along with their weights in the rank.
+// They are the texts the workitem_search_texts SQL function returns, which the search vector the database
+// maintains is built from too, and are weighted alike.
+var indexedFields = []struct {
+	name   string
+	weight float64
+}{
+	{"id", 4},
+	{workitem.SystemTitle, 2},
+	{workitem.SystemDescription, 1},
+	{"searchable fields", 1},
+	{"comments", 0.5},
+}
+
+// The parameters of the Okapi BM25 relevance
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// The boosts of the terms a word of the search string is expanded to. A word matches terms it is a prefix
+// of, like in the database search, and terms differing in a few characters, so that typos are tolerated.
+const (
+	prefixBoost = 0.8
+	fuzzyBoost  = 0.5
+)
+
+// embeddedIndex is an inverted index of the text of work items. It isn't safe for concurrent use.
+type embeddedIndex struct {
+	// Documents maps the IDs of the indexed work items to their documents
+	Documents map[uint64]*indexedDocument
+	// Postings maps the terms to the positions they have in the documents
+	Postings map[string]map[uint64][]int
+	// TotalLengths holds the sum of the numbers of terms per field of all documents
+	TotalLengths []int
+	// Synced is when the index was written, the changes since then are caught up with when it is read again
+	Synced time.Time
+	// terms holds the terms of the postings for looking up the terms matching a word, it isn't stored
+	terms *termTrie
+}
+
+// indexedDocument holds what the index knows about a work item
+type indexedDocument struct {
+	Type uuid.UUID
+	// Lengths holds the number of terms per field
+	Lengths []int
+	// Terms lists the distinct terms of the document
+	Terms []string
+}
+
+func newEmbeddedIndex() *embeddedIndex {
+	return &embeddedIndex{
+		Documents:    map[uint64]*indexedDocument{},
+		Postings:     map[string]map[uint64][]int{},
+		TotalLengths: make([]int, len(indexedFields)),
+		terms:        newTermTrie(),
+	}
+}
+
+// tokenize splits a text into lower case terms of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// add indexes the texts of the work item, replacing what the index held for it before. The texts are the
+// ones of the indexedFields.
+func (x *embeddedIndex) add(id uint64, typeID uuid.UUID, texts []string) {
+	x.remove(id)
+	document := indexedDocument{Type: typeID, Lengths: make([]int, len(indexedFields))}
+	for field, text := range texts[:len(indexedFields)] {
+		terms := tokenize(text)
+		for i, term := range terms {
+			postings, ok := x.Postings[term]
+			if !ok {
+				postings = map[uint64][]int{}
+				x.Postings[term] = postings
+				x.terms.insert(term)
+			}
+			if _, ok := postings[id]; !ok {
+				document.Terms = append(document.Terms, term)
+			}
+			postings[id] = append(postings[id], field*fieldStride+i)
+		}
+		document.Lengths[field] = len(terms)
+		x.TotalLengths[field] += len(terms)
+	}
+	x.Documents[id] = &document
+}
+
+// merge adds the documents of the other index, replacing what the index held for them before
+func (x *embeddedIndex) merge(other *embeddedIndex) {
+	for id, document := range other.Documents {
+		x.remove(id)
+		for _, term := range document.Terms {
+			postings, ok := x.Postings[term]
+			if !ok {
+				postings = map[uint64][]int{}
+				x.Postings[term] = postings
+				x.terms.insert(term)
+			}
+			postings[id] = other.Postings[term][id]
+		}
+		for field, length := range document.Lengths {
+			x.TotalLengths[field] += length
+		}
+		x.Documents[id] = document
+	}
+}
+
+// remove drops the work item from the index
+func (x *embeddedIndex) remove(id uint64) {
+	document, ok := x.Documents[id]
+	if !ok {
+		return
+	}
+	for _, term := range document.Terms {
+		delete(x.Postings[term], id)
+		if len(x.Postings[term]) == 0 {
+			delete(x.Postings, term)
+			x.terms.delete(term)
+		}
+	}
+	for field, length := range document.Lengths {
+		x.TotalLengths[field] -= length
+	}
+	delete(x.Documents, id)
+}
+
+// score returns the BM25 relevance of the term for the documents containing it, the fields are weighted
+func (x *embeddedIndex) score(term string) map[uint64]float64 {
+	postings := x.Postings[term]
+	result := make(map[uint64]float64, len(postings))
+	if len(postings) == 0 {
+		return result
+	}
+	n := float64(len(x.Documents))
+	idf := math.Log(1 + (n-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+	for id, positions := range postings {
+		frequencies := make([]float64, len(indexedFields))
+		for _, position := range positions {
+			frequencies[position/fieldStride]++
+		}
+		var score float64
+		for field, frequency := range frequencies {
+			if frequency == 0 {
+				continue
+			}
+			averageLength := float64(x.TotalLengths[field]) / n
+			length := float64(x.Documents[id].Lengths[field])
+			score += indexedFields[field].weight * frequency * (bm25K1 + 1) / (frequency + bm25K1*(1-bm25B+bm25B*length/averageLength))
+		}
+		result[id] = idf * score
+	}
+	return result
+}
+
+// maxEdits returns how many characters a term may differ from a word of the search string to be matched
+func maxEdits(word string) int {
+	switch n := len([]rune(word)); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	}
+	return 0
+}
+
+// word returns the documents matching a word of the search string with their relevance. The word matches
+// the terms it is a prefix of and the terms which are a few edits away from it, with lower relevance.
+func (x *embeddedIndex) word(word string) map[uint64]float64 {
+	boosts := map[string]float64{}
+	if edits := maxEdits(word); edits > 0 {
+		for _, term := range x.terms.within(word, edits) {
+			boosts[term] = fuzzyBoost
+		}
+	}
+	for _, term := range x.terms.withPrefix(word) {
+		boosts[term] = prefixBoost
+	}
+	if _, ok := x.Postings[word]; ok {
+		boosts[word] = 1
+	}
+	result := map[uint64]float64{}
+	for term, boost := range boosts {
+		// the best matching term decides the relevance of a document
+		for id, score := range x.score(term) {
+			if score*boost > result[id] {
+				result[id] = score * boost
+			}
+		}
+	}
+	return result
+}
+
+// phrase returns the documents holding the terms next to each other in the same field
+func (x *embeddedIndex) phrase(terms []string) map[uint64]float64 {
+	result := map[uint64]float64{}
+	if len(terms) == 0 {
+		return result
+	}
+	for id, positions := range x.Postings[terms[0]] {
+		for _, position := range positions {
+			found := true
+			for i, term := range terms[1:] {
+				if !containsPosition(x.Postings[term][id], position+i+1) {
+					found = false
+					break
+				}
+			}
+			if found {
+				result[id] = 0
+				break
+			}
+		}
+	}
+	for _, term := range terms {
+		scores := x.score(term)
+		for id := range result {
+			result[id] += scores[id]
+		}
+	}
+	return result
+}
+
+// id returns the documents of the work items whose ID starts with the given digits
+func (x *embeddedIndex) id(prefix string) map[uint64]float64 {
+	result := map[uint64]float64{}
+	for id := range x.Documents {
+		if strings.HasPrefix(strconv.FormatUint(id, 10), prefix) {
+			result[id] = indexedFields[0].weight
+		}
+	}
+	return result
+}
+
+// search returns the documents matching all nodes with their relevance
+func (x *embeddedIndex) search(nodes []*searchNode) map[uint64]float64 {
+	return x.evaluate(&searchNode{operator: "&", children: nodes})
+}
+
+func (x *embeddedIndex) evaluate(node *searchNode) map[uint64]float64 {
+	switch node.operator {
+	case "":
+		if node.token.kind == tokenPhrase {
+			return x.phrase(tokenize(node.token.text))
+		}
+		return x.term(unescapeSearchTerm(node.token.text))
+	case "!":
+		excluded := x.evaluate(node.children[0])
+		result := map[uint64]float64{}
+		for id := range x.Documents {
+			if _, ok := excluded[id]; !ok {
+				result[id] = 0
+			}
+		}
+		return result
+	case "|":
+		result := map[uint64]float64{}
+		for _, child := range node.children {
+			for id, score := range x.evaluate(child) {
+				result[id] += score
+			}
+		}
+		return result
+	}
+	var result map[uint64]float64
+	for _, child := range node.children {
+		scores := x.evaluate(child)
+		if result == nil {
+			result = scores
+			continue
+		}
+		for id, score := range result {
+			if other, ok := scores[id]; ok {
+				result[id] = score + other
+			} else {
+				delete(result, id)
+			}
+		}
+	}
+	if result == nil {
+		return map[uint64]float64{}
+	}
+	return result
+}
+
+// term returns the documents matching a term of the search string, which is an ID like id:1234, a URL or a word
+func (x *embeddedIndex) term(term string) map[uint64]float64 {
+	if strings.HasPrefix(term, "id:") {
+		return x.id(strings.TrimPrefix(term, "id:"))
+	}
+	terms := tokenize(term)
+	if govalidator.IsURL(term) {
+		// URLs match as a phrase or by the ID of the work item they refer to, like in termQuery
+		url := trimProtocolFromURLString(strings.ToLower(term))
+		result := x.phrase(tokenize(url))
+		if id := knownURLID(url); id != "" {
+			for id, score := range x.id(id) {
+				result[id] += score
+			}
+		}
+		return result
+	}
+	if len(terms) == 1 {
+		return x.word(terms[0])
+	}
+	// words like models/errors.go are split into several terms
+	return x.phrase(terms)
+}
+
+// knownURLID returns the ID of the work item a known URL refers to or an empty string
+func knownURLID(url string) string {
+	known, patternName := isKnownURL(url)
+	if !known {
+		return ""
+	}
+	knownURLLock.RLock()
+	pattern := knownURLs[patternName]
+	knownURLLock.RUnlock()
+	match := pattern.compiledRegex.FindStringSubmatch(url)
+	for i, name := range pattern.groupNamesInRegex {
+		if name == "id" && i < len(match) {
+			return match[i]
+		}
+	}
+	return ""
+}
+
+func containsPosition(positions []int, position int) bool {
+	for _, p := range positions {
+		if p == position {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot returns a copy of the index for saving it, the copy isn't affected by later changes of the index and
+// has no terms for searching. The documents and the positions of their terms aren't changed once they are
+// indexed, so they are shared with the copy.
+func (x *embeddedIndex) snapshot() *embeddedIndex {
+	result := &embeddedIndex{
+		Documents:    make(map[uint64]*indexedDocument, len(x.Documents)),
+		Postings:     make(map[string]map[uint64][]int, len(x.Postings)),
+		TotalLengths: append([]int{}, x.TotalLengths...),
+	}
+	for id, document := range x.Documents {
+		result.Documents[id] = document
+	}
+	for term, postings := range x.Postings {
+		copied := make(map[uint64][]int, len(postings))
+		for id, positions := range postings {
+			copied[id] = positions
+		}
+		result.Postings[term] = copied
+	}
+	return result
+}
+
+// save writes the index to the file, the file is replaced at once so that it is never left half written
+func (x *embeddedIndex) save(path string) error {
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(file).Encode(x); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// loadEmbeddedIndex reads the index from the file. A missing file or a file holding other fields than the
+// indexedFields yields an empty index, which has to be rebuilt.
+func loadEmbeddedIndex(path string) (*embeddedIndex, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return newEmbeddedIndex(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	x := newEmbeddedIndex()
+	if err := gob.NewDecoder(file).Decode(x); err != nil {
+		return nil, err
+	}
+	if len(x.TotalLengths) != len(indexedFields) {
+		return newEmbeddedIndex(), nil
+	}
+	for term := range x.Postings {
+		x.terms.insert(term)
+	}
+	return x, nil
+}
@@ -0,0 +1,205 @@
+package search
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/almighty/almighty-core/workitem"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTexts returns the indexed texts of a work item with the given title and description
+func testTexts(id uint64, title, description string) []string {
+	return []string{strconv.FormatUint(id, 10), title, description, "", ""}
+}
+
+func newTestIndex() *embeddedIndex {
+	x := newEmbeddedIndex()
+	texts := map[uint64][2]string{
+		1: {"Memory leak in the server", "The server runs out of memory after a day"},
+		2: {"Login fails", "Users can't login with a leaking session"},
+		3: {"Client crashes", "The client crashes on windows when the server restarts"},
+		4: {"Leak", "memory"},
+	}
+	for id, text := range texts {
+		x.add(id, workitem.SystemBug, testTexts(id, text[0], text[1]))
+	}
+	x.add(5, workitem.SystemBug, []string{"5", "Slow startup", "", "customerticket", "reported by the support"})
+	return x
+}
+
+func searchIndex(t *testing.T, x *embeddedIndex, raw string) map[uint64]float64 {
+	_, text, err := parseSearch(raw, nil)
+	require.Nil(t, err)
+	return x.search(text.nodes)
+}
+
+func ids(scores map[uint64]float64) []uint64 {
+	result := []uint64{}
+	for id := range scores {
+		result = append(result, id)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func TestEmbeddedIndexSearch(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	x := newTestIndex()
+	testData := map[string][]uint64{
+		"memory":                   {1, 4},
+		"serv":                     {1, 3},
+		"memry":                    {1, 4},
+		"lek":                      {},
+		"memory leak":              {1, 4},
+		`"memory leak"`:            {1},
+		`"leak memory"`:            {},
+		"login OR windows":         {2, 3},
+		"server -client":           {1},
+		"(login OR client) -fails": {3},
+		"id:2":                     {2},
+		"3":                        {3},
+		"customerticket":           {5},
+		"support":                  {5},
+	}
+	for raw, expected := range testData {
+		assert.Equal(t, expected, ids(searchIndex(t, x, raw)), raw)
+	}
+}
+
+func TestEmbeddedIndexRanking(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	x := newTestIndex()
+	// the title weighs more than the description and exact matches more than typos
+	scores := searchIndex(t, x, "leak")
+	assert.True(t, scores[4] > scores[1])
+	assert.True(t, scores[1] > scores[2])
+}
+
+func TestEmbeddedIndexRemove(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	x := newTestIndex()
+	x.remove(1)
+	x.add(4, workitem.SystemBug, testTexts(4, "Renamed", ""))
+	assert.Equal(t, []uint64{}, ids(searchIndex(t, x, "memory")))
+	assert.Equal(t, []uint64{4}, ids(searchIndex(t, x, "renamed")))
+	assert.Len(t, x.Documents, 4)
+	assert.NotContains(t, x.Postings, "leak")
+}
+
+func TestEmbeddedIndexMerge(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	x := newTestIndex()
+	other := newEmbeddedIndex()
+	other.add(4, workitem.SystemBug, testTexts(4, "Renamed", ""))
+	other.add(6, workitem.SystemBug, testTexts(6, "Memory usage", ""))
+	// when
+	x.merge(other)
+	// then
+	expected := newTestIndex()
+	expected.remove(4)
+	expected.add(4, workitem.SystemBug, testTexts(4, "Renamed", ""))
+	expected.add(6, workitem.SystemBug, testTexts(6, "Memory usage", ""))
+	assert.Equal(t, expected, x)
+	assert.Equal(t, []uint64{1, 6}, ids(searchIndex(t, x, "memory")))
+}
+
+func TestEmbeddedIndexSnapshot(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	// given
+	x := newTestIndex()
+	snapshot := x.snapshot()
+	// when
+	x.remove(1)
+	x.add(6, workitem.SystemBug, testTexts(6, "Memory usage", ""))
+	// then
+	expected := newTestIndex()
+	expected.terms = nil
+	assert.Equal(t, expected, snapshot)
+}
+
+func TestEmbeddedIndexSaveLoad(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	dir, err := ioutil.TempDir("", "search-index")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "index")
+
+	x, err := loadEmbeddedIndex(path)
+	require.Nil(t, err)
+	assert.Empty(t, x.Documents)
+
+	require.Nil(t, newTestIndex().save(path))
+	x, err = loadEmbeddedIndex(path)
+	require.Nil(t, err)
+	assert.Equal(t, newTestIndex(), x)
+	assert.Equal(t, []uint64{1, 4}, ids(searchIndex(t, x, "memory")))
+
+	// an index of other fields has to be rebuilt
+	x.TotalLengths = x.TotalLengths[:3]
+	require.Nil(t, x.save(path))
+	x, err = loadEmbeddedIndex(path)
+	require.Nil(t, err)
+	assert.Empty(t, x.Documents)
+}
+
+func TestEmbeddedEngineFlush(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	dir, err := ioutil.TempDir("", "search-index")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	e, err := OpenEmbeddedEngine(nil, filepath.Join(dir, "index"))
+	require.Nil(t, err)
+
+	e.index.add(7, uuid.NewV4(), testTexts(7, "Typo tolerant", ""))
+	assert.Equal(t, 1, e.Size())
+	require.Nil(t, e.Flush())
+	reopened, err := OpenEmbeddedEngine(nil, filepath.Join(dir, "index"))
+	require.Nil(t, err)
+	assert.Equal(t, 1, reopened.Size())
+
+	e.WorkItemDeleted(nil, 7)
+	assert.Equal(t, 0, e.Size())
+	require.Nil(t, e.Flush())
+}
+
+func TestTermTrieWithin(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	terms := newTermTrie()
+	for _, term := range []string{"memry", "memroy", "leaks", "login"} {
+		terms.insert(term)
+	}
+	sorted := func(terms []string) []string {
+		sort.Strings(terms)
+		return terms
+	}
+	assert.Equal(t, []string{"memry"}, terms.within("memory", 1))
+	assert.Equal(t, []string{"memroy", "memry"}, sorted(terms.within("memory", 2)))
+	assert.Equal(t, []string{"leaks"}, terms.within("leak", 1))
+	assert.Equal(t, []string{"leaks"}, terms.within("leak", 2))
+}
+
+func TestTermTrieWithPrefix(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	terms := newTermTrie()
+	for _, term := range []string{"serv", "server", "servers", "session"} {
+		terms.insert(term)
+	}
+	terms.delete("servers")
+	terms.delete("unknown")
+	result := terms.withPrefix("serv")
+	sort.Strings(result)
+	assert.Equal(t, []string{"serv", "server"}, result)
+	assert.Empty(t, terms.withPrefix("servers"))
+	terms.delete("session")
+	assert.NotContains(t, terms.children['s'].children['e'].children, 's')
+}
@@ -0,0 +1,95 @@
+package search
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/space"
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// The names of the search engines in the configuration
+const (
+	EnginePostgres = "postgres"
+	EngineEmbedded = "embedded"
+)
+
+// Engine matches the text of search strings against the work items and ranks the matching work items.
+// The repository restricts the matches to the qualifiers of the search string, orders, pages and converts them.
+// The engines are PostgresEngine, which uses the full text search of the database, and EmbeddedEngine,
+// which keeps its own index on disk.
+type Engine interface {
+	// match restricts the query on the work items to the ones matching the text and selects their relevance
+	// as rank. The text search query of the text is selected as query, it is used for highlighting.
+	match(db *gorm.DB, text searchText) *gorm.DB
+	// reindex rebuilds the index of the work items of the given type, or of all work items if the type is nil
+	reindex(ctx context.Context, db *gorm.DB, workItemTypeID *uuid.UUID) (int64, error)
+}
+
+var currentEngine Engine = PostgresEngine{}
+var currentEngineLock sync.RWMutex
+
+// UseEngine sets the engine of the search repositories created by NewGormSearchRepository, the
+// PostgresEngine is used until then
+func UseEngine(engine Engine) {
+	currentEngineLock.Lock()
+	defer currentEngineLock.Unlock()
+	currentEngine = engine
+}
+
+// CurrentEngine returns the engine set with UseEngine
+func CurrentEngine() Engine {
+	currentEngineLock.RLock()
+	defer currentEngineLock.RUnlock()
+	return currentEngine
+}
+
+// OpenEngine returns the engine with the given name, the index of the embedded engine is kept in indexPath.
+// returns BadParameterError for an unknown name and InternalError if the index can't be read
+func OpenEngine(db *gorm.DB, name string, indexPath string) (Engine, error) {
+	switch name {
+	case EnginePostgres:
+		return PostgresEngine{}, nil
+	case EngineEmbedded:
+		return OpenEmbeddedEngine(db, indexPath)
+	}
+	return nil, errors.NewBadParameterError("search engine", name).Expected(EnginePostgres + " or " + EngineEmbedded)
+}
+
+// joinTextSearchQuery joins the text search query parsed with the text search configuration of the space
+// the work item belongs to
+func joinTextSearchQuery(db *gorm.DB, text searchText) *gorm.DB {
+	return db.Joins(fmt.Sprintf("join %[1]s as search_space on search_space.id = %[2]s.space_id, "+
+		"to_tsquery(search_space.text_search_config::regconfig, ?) as query",
+		space.Space{}.TableName(), workitem.WorkItem{}.TableName()), text.tsquery)
+}
+
+// PostgresEngine matches the text search vectors the database maintains for the work items, see the
+// workitem_tsv SQL function
+type PostgresEngine struct{}
+
+func (PostgresEngine) match(db *gorm.DB, text searchText) *gorm.DB {
+	if text.tsquery != "" {
+		db = db.Where("tsv @@ query")
+	}
+	return joinTextSearchQuery(db, text).Joins(", ts_rank(tsv, query) as rank")
+}
+
+func (PostgresEngine) reindex(ctx context.Context, db *gorm.DB, workItemTypeID *uuid.UUID) (int64, error) {
+	// the SQL function is the one the triggers on work items and comments use
+	statement := "update " + workitem.WorkItem{}.TableName() + " set tsv = workitem_tsv(id, space_id, type, fields)"
+	parameters := []interface{}{}
+	if workItemTypeID != nil {
+		statement += " where type = ?"
+		parameters = append(parameters, *workItemTypeID)
+	}
+	db = db.Exec(statement, parameters...)
+	if db.Error != nil {
+		return 0, errors.NewInternalError(db.Error.Error())
+	}
+	return db.RowsAffected, nil
+}
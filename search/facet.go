@@ -0,0 +1,107 @@
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/workitem"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/context"
+)
+
+// The names of the facets the matching work items are counted by
+const (
+	FacetType      = "type"
+	FacetState     = "state"
+	FacetAssignee  = "assignee"
+	FacetArea      = "area"
+	FacetIteration = "iteration"
+)
+
+// facets maps the facets in the order of the grouping sets to the SQL expressions of their values.
+// The assignees are unnested by facetAssigneeJoin, work items without assignees get a single null value.
+var facets = []struct {
+	name       string
+	expression string
+}{
+	{FacetType, "work_items.type"},
+	{FacetState, "work_items.fields->>'" + workitem.SystemState + "'"},
+	{FacetAssignee, "facet_assignee.value"},
+	{FacetArea, "work_items.fields->>'" + workitem.SystemArea + "'"},
+	{FacetIteration, "work_items.fields->>'" + workitem.SystemIteration + "'"},
+}
+
+var facetAssigneeJoin = fmt.Sprintf(", jsonb_array_elements_text(case when jsonb_typeof(work_items.fields->'%[1]s') = 'array' "+
+	"and jsonb_array_length(work_items.fields->'%[1]s') > 0 then work_items.fields->'%[1]s' else '[null]'::jsonb end) as facet_assignee(value)",
+	workitem.SystemAssignees)
+
+// SearchFacets counts the work items matching the query per type, state, assignee, area and iteration.
+// All facets are computed in a single query over the full set of matching work items. The facets map to the
+// counts of their values, a work item with several assignees is counted for each of them.
+func (r *GormSearchRepository) SearchFacets(ctx context.Context, rawSearchString string, currentUser *uuid.UUID) (map[string][]*app.SearchFacetValue, error) {
+	parsedSearchDict, text, err := parseSearch(rawSearchString, currentUser)
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+
+	expressions := make([]string, len(facets))
+	for i, facet := range facets {
+		expressions[i] = facet.expression
+	}
+	columns := []string{fmt.Sprintf("grouping(%s)", strings.Join(expressions, ", "))}
+	sets := make([]string, len(facets))
+	for i, expression := range expressions {
+		columns = append(columns, expression+"::text")
+		sets[i] = "(" + expression + ")"
+	}
+	// the assignees multiply the rows of their work items, so the work items are counted distinctly
+	columns = append(columns, "count(distinct work_items.id)")
+
+	db := r.searchQuery(text, parsedSearchDict).Joins(facetAssigneeJoin)
+	db = db.Select(strings.Join(columns, ", ")).Group("grouping sets (" + strings.Join(sets, ", ") + ")")
+	// the most frequent values first
+	db = db.Order(fmt.Sprintf("%d desc", len(columns)))
+	rows, err := db.Rows()
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	defer rows.Close()
+
+	result := make(map[string][]*app.SearchFacetValue, len(facets))
+	for _, facet := range facets {
+		result[facet.name] = []*app.SearchFacetValue{}
+	}
+	values := make([]sql.NullString, len(facets))
+	for rows.Next() {
+		var grouping uint
+		var count int
+		columnValues := []interface{}{&grouping}
+		for i := range values {
+			columnValues = append(columnValues, &values[i])
+		}
+		columnValues = append(columnValues, &count)
+		if err := rows.Scan(columnValues...); err != nil {
+			return nil, errors.NewInternalError(err.Error())
+		}
+		// the bit of the facet the row is grouped by is 0, the first facet is the most significant bit
+		for i, facet := range facets {
+			if grouping&(1<<uint(len(facets)-1-i)) != 0 {
+				continue
+			}
+			value := app.SearchFacetValue{Count: count}
+			if values[i].Valid {
+				text := values[i].String
+				value.Value = &text
+			}
+			result[facet.name] = append(result[facet.name], &value)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package search
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/almighty/almighty-core/workitem"
+)
+
+// The matched words in snippets are enclosed in characters of the Unicode private use area, which can't be
+// confused with markup. They are replaced with <mark> tags after the snippet has been HTML escaped.
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+// highlightedFields maps the searchable fields to the SQL expression of their text and the ts_headline
+// options of their snippet. Titles are highlighted as a whole, descriptions are cut to their matching parts.
+var highlightedFields = []struct {
+	field   string
+	column  string
+	text    string
+	options string
+}{
+	{
+		field:   workitem.SystemTitle,
+		column:  "highlight_title",
+		text:    "fields->>'" + workitem.SystemTitle + "'",
+		options: fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`, highlightStart, highlightStop),
+	},
+	{
+		field:  workitem.SystemDescription,
+		column: "highlight_description",
+		// descriptions are either legacy strings or markup content
+		text:    "coalesce(fields->'" + workitem.SystemDescription + "'->>'content', fields->>'" + workitem.SystemDescription + "')",
+		options: fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" ... "`, highlightStart, highlightStop),
+	},
+}
+
+// highlightColumns returns the select list of the snippets of the searchable fields and their parameters
+func highlightColumns() (string, []interface{}) {
+	columns := make([]string, len(highlightedFields))
+	parameters := make([]interface{}, len(highlightedFields))
+	for i, f := range highlightedFields {
+		columns[i] = fmt.Sprintf("ts_headline(search_space.text_search_config::regconfig, coalesce(%s, ''), query, ?) as %s", f.text, f.column)
+		parameters[i] = f.options
+	}
+	return strings.Join(columns, ", "), parameters
+}
+
+// scanHighlights reads the snippets of the searchable fields from the current row and returns them as safe
+// markup by field name. Fields without a value are left out.
+func scanHighlights(rows *sql.Rows, columns []string) (map[string]string, error) {
+	var ignore interface{}
+	values := make([]interface{}, len(columns))
+	snippets := make([]sql.NullString, len(highlightedFields))
+	for i, column := range columns {
+		values[i] = &ignore
+		for j, f := range highlightedFields {
+			if column == f.column {
+				values[i] = &snippets[j]
+			}
+		}
+	}
+	if err := rows.Scan(values...); err != nil {
+		return nil, err
+	}
+	result := map[string]string{}
+	for i, f := range highlightedFields {
+		if snippets[i].Valid && len(snippets[i].String) > 0 {
+			result[f.field] = highlightMarkup(snippets[i].String)
+		}
+	}
+	return result, nil
+}
+
+// highlightMarkup HTML escapes a snippet returned by ts_headline and encloses the matched words in <mark> tags.
+// The tags are always balanced, even if the text itself contains the highlight characters.
+func highlightMarkup(snippet string) string {
+	var result bytes.Buffer
+	open := false
+	for _, part := range strings.SplitAfter(snippet, highlightStop) {
+		for _, text := range strings.SplitAfter(part, highlightStart) {
+			switch {
+			case strings.HasSuffix(text, highlightStart):
+				result.WriteString(html.EscapeString(strings.TrimSuffix(text, highlightStart)))
+				if !open {
+					result.WriteString("<mark>")
+					open = true
+				}
+			case strings.HasSuffix(text, highlightStop):
+				result.WriteString(html.EscapeString(strings.TrimSuffix(text, highlightStop)))
+				if open {
+					result.WriteString("</mark>")
+					open = false
+				}
+			default:
+				result.WriteString(html.EscapeString(text))
+			}
+		}
+	}
+	if open {
+		result.WriteString("</mark>")
+	}
+	return result.String()
+}
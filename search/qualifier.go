@@ -0,0 +1,164 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/workitem"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// fieldQualifiers maps the qualifiers restricting the search to work items with a field value to the field
+var fieldQualifiers = map[string]string{
+	"state":     workitem.SystemState,
+	"assignee":  workitem.SystemAssignees,
+	"creator":   workitem.SystemCreator,
+	"area":      workitem.SystemArea,
+	"iteration": workitem.SystemIteration,
+}
+
+// caseInsensitiveFields are the fields whose qualifier values are matched regardless of their case, the values
+// are kept in lower case
+var caseInsensitiveFields = map[string]bool{
+	workitem.SystemState: true,
+}
+
+// identityQualifiers accept "me" for the current user
+var identityQualifiers = map[string]bool{
+	"assignee": true,
+	"creator":  true,
+}
+
+// createdCondition compares the creation time of work items with an instant
+type createdCondition struct {
+	operator string
+	instant  time.Time
+}
+
+// parseQualifier parses a search string part like state:closed or created:>2017-01-31 and adds the
+// restriction to the keywords. Returns false if the part isn't a qualifier and a BadParameterError if the
+// value of the qualifier is malformed.
+func parseQualifier(part string, currentUser *uuid.UUID, res *searchKeyword) (bool, error) {
+	i := strings.Index(part, ":")
+	if i < 0 {
+		return false, nil
+	}
+	name := strings.ToLower(part[:i])
+	value := part[i+1:]
+	field, isField := fieldQualifiers[name]
+	if !isField && name != "space" && name != "created" {
+		return false, nil
+	}
+	if len(value) == 0 {
+		return true, errors.NewBadParameterError(name+" must not be empty", part)
+	}
+	switch {
+	case name == "created":
+		conditions, err := parseCreated(value)
+		if err != nil {
+			return true, err
+		}
+		res.created = append(res.created, conditions...)
+	case name == "space":
+		id, err := uuid.FromString(value)
+		if err != nil {
+			return true, errors.NewBadParameterError("failed to parse space ID string as UUID", value)
+		}
+		res.spaces = append(res.spaces, id)
+	case caseInsensitiveFields[field]:
+		res.addFieldValue(field, strings.ToLower(value))
+	case identityQualifiers[name] && strings.ToLower(value) == "me":
+		if currentUser == nil {
+			return true, errors.NewBadParameterError(name+":me requires a logged in user", part)
+		}
+		res.addFieldValue(field, currentUser.String())
+	default:
+		// the other fields hold IDs, which are stored in their canonical form
+		id, err := uuid.FromString(value)
+		if err != nil {
+			return true, errors.NewBadParameterError(fmt.Sprintf("failed to parse %s ID string as UUID", name), value)
+		}
+		res.addFieldValue(field, id.String())
+	}
+	return true, nil
+}
+
+// parseCreated parses the value of a created: qualifier, a date or an RFC 3339 instant optionally
+// preceded by one of the operators >, >=, < and <=. A date without an operator means the whole day.
+func parseCreated(value string) ([]createdCondition, error) {
+	operator := ""
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(value, op) {
+			operator = op
+			value = value[len(op):]
+			break
+		}
+	}
+	instant, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		day, dayErr := time.Parse(workitem.DateLayout, value)
+		if dayErr != nil {
+			return nil, errors.NewBadParameterError("created", value).Expected("a date like 2017-01-31 or an RFC 3339 date and time")
+		}
+		if operator == "" {
+			return []createdCondition{{">=", day}, {"<", day.AddDate(0, 0, 1)}}, nil
+		}
+		instant = day
+	}
+	if operator == "" {
+		operator = "="
+	}
+	return []createdCondition{{operator, instant}}, nil
+}
+
+// addFieldValue adds a value the field may have, a work item matches if its field has any of the values
+func (k *searchKeyword) addFieldValue(field, value string) {
+	if k.fields == nil {
+		k.fields = map[string][]string{}
+	}
+	k.fields[field] = append(k.fields[field], value)
+}
+
+// whereQualifiers restricts the query to the work items matching the qualifiers of the keywords.
+// Values of the same qualifier are alternatives, the different qualifiers all have to match.
+func whereQualifiers(db *gorm.DB, keywords searchKeyword) *gorm.DB {
+	table := workitem.WorkItem{}.TableName()
+	fields := make([]string, 0, len(keywords.fields))
+	for field := range keywords.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		conditions := []string{}
+		parameters := []interface{}{}
+		for _, value := range keywords.fields[field] {
+			if caseInsensitiveFields[field] {
+				conditions = append(conditions, "lower("+table+".fields->>'"+field+"') = ?")
+				parameters = append(parameters, value)
+				continue
+			}
+			// containment tests make use of the index on the fields
+			var document []byte
+			if field == workitem.SystemAssignees {
+				document, _ = json.Marshal(map[string]interface{}{field: []string{value}})
+			} else {
+				document, _ = json.Marshal(map[string]interface{}{field: value})
+			}
+			conditions = append(conditions, table+".fields @> ?::jsonb")
+			parameters = append(parameters, string(document))
+		}
+		db = db.Where(strings.Join(conditions, " or "), parameters...)
+	}
+	if len(keywords.spaces) > 0 {
+		db = db.Where(table+".space_id in (?)", keywords.spaces)
+	}
+	for _, condition := range keywords.created {
+		db = db.Where(table+".created_at "+condition.operator+" ?", condition.instant)
+	}
+	return db
+}
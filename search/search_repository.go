@@ -17,6 +17,7 @@ import (
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/rest"
 	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/workitem"
@@ -36,13 +37,20 @@ const (
 
 // GormSearchRepository provides a Gorm based repository
 type GormSearchRepository struct {
-	db  *gorm.DB
-	wir *workitem.GormWorkItemTypeRepository
+	db     *gorm.DB
+	wir    *workitem.GormWorkItemTypeRepository
+	engine Engine
 }
 
-// NewGormSearchRepository creates a new search repository
+// NewGormSearchRepository creates a new search repository which matches the text with the engine
+// chosen by UseEngine
 func NewGormSearchRepository(db *gorm.DB) *GormSearchRepository {
-	return &GormSearchRepository{db, workitem.NewWorkItemTypeRepository(db)}
+	return NewGormSearchRepositoryWithEngine(db, CurrentEngine())
+}
+
+// NewGormSearchRepositoryWithEngine creates a new search repository which matches the text with the given engine
+func NewGormSearchRepositoryWithEngine(db *gorm.DB, engine Engine) *GormSearchRepository {
+	return &GormSearchRepository{db, workitem.NewWorkItemTypeRepository(db), engine}
 }
 
 func generateSearchQuery(q string) (string, error) {
@@ -80,6 +88,10 @@ type searchKeyword struct {
 	workItemTypes []uuid.UUID
 	id            []string
 	words         []string
+	// fields maps the fields restricted by qualifiers like state:closed to the values they may have
+	fields  map[string][]string
+	spaces  []uuid.UUID
+	created []createdCondition
 }
 
 // KnownURL has a regex string format URL and compiled regex for the same
@@ -117,9 +129,79 @@ func RegisterAsKnownURL(name, urlRegex string) {
 	}
 }
 
+// UnregisterKnownURL removes the known URL with the given name, if there is one
+func UnregisterKnownURL(name string) {
+	knownURLLock.Lock()
+	defer knownURLLock.Unlock()
+	delete(knownURLs, name)
+}
+
+// The names of the groups the patterns of known URLs must have
+const (
+	KnownURLGroupDomain = "domain"
+	KnownURLGroupPath   = "path"
+	KnownURLGroupID     = "id"
+)
+
+// ValidateKnownURL checks that the pattern of a known URL compiles and has the named groups domain, path and id.
+// Like with RegisterAsKnownURL, the pattern must not include the protocol.
+// returns BadParameterError if the pattern is invalid
+func ValidateKnownURL(name, urlRegex string) error {
+	parameter := "known URL " + name
+	if strings.HasPrefix(urlRegex, "http") || strings.HasPrefix(urlRegex, "^http") {
+		return errors.NewBadParameterError(parameter, urlRegex).Expected("a pattern without the protocol")
+	}
+	compiledRegex, err := regexp.Compile(urlRegex)
+	if err != nil {
+		return errors.NewBadParameterError(parameter, urlRegex).Expected("a valid regular expression: " + err.Error())
+	}
+	groups := map[string]bool{}
+	for _, group := range compiledRegex.SubexpNames() {
+		groups[group] = true
+	}
+	for _, group := range []string{KnownURLGroupDomain, KnownURLGroupPath, KnownURLGroupID} {
+		if !groups[group] {
+			return errors.NewBadParameterError(parameter, urlRegex).Expected("a named group " + group + " like (?P<" + group + ">...)")
+		}
+	}
+	return nil
+}
+
+// RegisterKnownURLs validates the patterns of the given known URLs and registers them, e.g. the ones of the
+// configuration. Nothing is registered if a pattern is invalid.
+// returns BadParameterError if a pattern is invalid
+func RegisterKnownURLs(urlRegexes map[string]string) error {
+	for name, urlRegex := range urlRegexes {
+		if err := ValidateKnownURL(name, urlRegex); err != nil {
+			return errs.WithStack(err)
+		}
+	}
+	for name, urlRegex := range urlRegexes {
+		RegisterAsKnownURL(name, urlRegex)
+	}
+	return nil
+}
+
 // GetAllRegisteredURLs returns all known URLs
 func GetAllRegisteredURLs() map[string]KnownURL {
-	return knownURLs
+	knownURLLock.RLock()
+	defer knownURLLock.RUnlock()
+	result := make(map[string]KnownURL, len(knownURLs))
+	for name, known := range knownURLs {
+		result[name] = known
+	}
+	return result
+}
+
+// GroupNames returns the names of the groups of the pattern
+func (k KnownURL) GroupNames() []string {
+	result := []string{}
+	for _, name := range k.groupNamesInRegex {
+		if name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
 }
 
 /*
@@ -131,6 +213,8 @@ func isKnownURL(url string) (bool, string) {
 	// should check on all system's known URLs
 	var mostReleventMatchCount int
 	var mostReleventMatchName string
+	knownURLLock.RLock()
+	defer knownURLLock.RUnlock()
 	for name, known := range knownURLs {
 		match := known.compiledRegex.FindStringSubmatch(url)
 		if len(match) > mostReleventMatchCount {
@@ -151,8 +235,9 @@ func trimProtocolFromURLString(urlString string) string {
 }
 
 func escapeCharFromURLString(urlString string) string {
-	// Replacer will escape `:` and `)` `(`.
-	var replacer = strings.NewReplacer(":", "\\:", "(", "\\(", ")", "\\)")
+	// Replacer will escape the characters tsquery treats as operators, like `:`, `)`, `(`, `&` and `|`.
+	var replacer = strings.NewReplacer("\\", "\\\\", ":", "\\:", "(", "\\(", ")", "\\)", "&", "\\&", "|", "\\|",
+		"!", "\\!", "<", "\\<", ">", "\\>", "'", "\\'", "*", "\\*")
 	return replacer.Replace(urlString)
 }
 
@@ -172,7 +257,9 @@ Finds all string match for given pattern
 Iterates over pattern's groupNames and loads respective values into result
 */
 func getSearchQueryFromURLPattern(patternName, stringToMatch string) string {
+	knownURLLock.RLock()
 	pattern := knownURLs[patternName]
+	knownURLLock.RUnlock()
 	// TODO : handle case for 0 matches
 	match := pattern.compiledRegex.FindStringSubmatch(stringToMatch)
 	result := make(map[string]string)
@@ -223,51 +310,126 @@ func getSearchQueryFromURLString(url string) string {
 	return sanitizeURL(url) + ":*"
 }
 
-// parseSearchString accepts a raw string and generates a searchKeyword object
-func parseSearchString(rawSearchString string) (searchKeyword, error) {
+// parseSearchString accepts a raw string and generates a searchKeyword object.
+// Besides words, the string may hold the qualifiers id:, type:, state:, assignee:, creator:, area:,
+// iteration:, space: and created:, like "assignee:me created:>2017-01-31". The identity given in
+// currentUser, which may be nil for anonymous requests, is used for assignee:me and creator:me.
+// Words may be combined with OR, excluded with a leading - and grouped with parentheses, quoted
+// phrases match words next to each other, e.g. `"memory leak" (server OR client) -windows`.
+// Qualifiers other than id: restrict the whole search, so they can't be negated or combined with OR.
+func parseSearchString(rawSearchString string, currentUser *uuid.UUID) (searchKeyword, error) {
+	res, _, err := parseSearch(rawSearchString, currentUser)
+	return res, err
+}
+
+// searchText is the part of a search string the search engine matches against the text of the work items
+type searchText struct {
+	// tsquery is the text search query of the words and ids, empty if the search string only has qualifiers
+	tsquery string
+	// nodes are the parts of the search string which all have to match, without the qualifiers
+	nodes []*searchNode
+}
+
+// parseSearch parses the search string like parseSearchString and also returns its text
+func parseSearch(rawSearchString string, currentUser *uuid.UUID) (searchKeyword, searchText, error) {
 	// TODO remove special characters and exclaimations if any
 	rawSearchString = strings.Trim(rawSearchString, "/") // get rid of trailing slashes
-	rawSearchString = strings.Trim(rawSearchString, "\"")
-	parts := strings.Fields(rawSearchString)
 	var res searchKeyword
-	for _, part := range parts {
-		// QueryUnescape is required in case of encoded url strings.
-		// And does not harm regular search strings
-		// but this processing is required because at this moment, we do not know if
-		// search input is a regular string or a URL
-
-		part, err := url.QueryUnescape(part)
-		if err != nil {
-			log.Warn(nil, map[string]interface{}{
-				"part": part,
-			}, "unable to escape url!")
-		}
-		// IF part is for search with id:1234
-		// TODO: need to find out the way to use ID fields.
-		if strings.HasPrefix(part, "id:") {
-			res.id = append(res.id, strings.TrimPrefix(part, "id:")+":*A")
-		} else if strings.HasPrefix(part, "type:") {
-			typeIDStr := strings.TrimPrefix(part, "type:")
-			if len(typeIDStr) == 0 {
-				return res, errors.NewBadParameterError("Type ID must not be empty", part)
-			}
-			typeID, err := uuid.FromString(typeIDStr)
-			if err != nil {
-				return res, errors.NewBadParameterError("failed to parse type ID string as UUID", typeIDStr)
+	var text searchText
+	root, err := parseSearchSyntax(rawSearchString)
+	if err != nil || root == nil {
+		return res, text, err
+	}
+	// the words next to each other on the top level are kept apart, they are joined with the ids
+	parts := []*searchNode{root}
+	if root.operator == "&" {
+		parts = root.children
+	}
+	for _, node := range parts {
+		if node.operator == "" && node.token.kind == tokenTerm {
+			part := unescapeSearchTerm(node.token.text)
+			// IF part is for search with id:1234
+			// TODO: need to find out the way to use ID fields.
+			if strings.HasPrefix(part, "id:") {
+				id, err := idQuery(part)
+				if err != nil {
+					return res, text, err
+				}
+				res.id = append(res.id, id)
+				text.nodes = append(text.nodes, node)
+				continue
+			} else if strings.HasPrefix(part, "type:") {
+				typeIDStr := strings.TrimPrefix(part, "type:")
+				if len(typeIDStr) == 0 {
+					return res, text, errors.NewBadParameterError("Type ID must not be empty", part)
+				}
+				typeID, err := uuid.FromString(typeIDStr)
+				if err != nil {
+					return res, text, errors.NewBadParameterError("failed to parse type ID string as UUID", typeIDStr)
+				}
+				res.workItemTypes = append(res.workItemTypes, typeID)
+				continue
+			} else if qualifier, err := parseQualifier(part, currentUser, &res); qualifier {
+				if err != nil {
+					return res, text, err
+				}
+				continue
 			}
-			res.workItemTypes = append(res.workItemTypes, typeID)
-		} else if govalidator.IsURL(part) {
-			part := strings.ToLower(part)
-			part = trimProtocolFromURLString(part)
-			searchQueryFromURL := getSearchQueryFromURLString(part)
-			res.words = append(res.words, searchQueryFromURL)
-		} else {
-			part := strings.ToLower(part)
-			part = sanitizeURL(part)
-			res.words = append(res.words, part+":*")
 		}
+		word, err := node.tsquery(termQuery)
+		if err != nil {
+			return res, text, err
+		}
+		res.words = append(res.words, word)
+		text.nodes = append(text.nodes, node)
+	}
+	text.tsquery = generateSQLSearchInfo(res)
+	return res, text, nil
+}
+
+// unescapeSearchTerm decodes URL encoded terms.
+// QueryUnescape is required in case of encoded url strings.
+// And does not harm regular search strings
+// but this processing is required because at this moment, we do not know if
+// search input is a regular string or a URL
+func unescapeSearchTerm(part string) string {
+	unescaped, err := url.QueryUnescape(part)
+	if err != nil {
+		log.Warn(nil, map[string]interface{}{
+			"part": part,
+		}, "unable to escape url!")
+		return part
 	}
-	return res, nil
+	return unescaped
+}
+
+// idQuery returns the query matching the ID given in a part like id:1234
+func idQuery(part string) (string, error) {
+	id := strings.TrimPrefix(part, "id:")
+	if len(id) == 0 {
+		return "", errors.NewBadParameterError("ID must not be empty", part)
+	}
+	return escapeCharFromURLString(id) + ":*A", nil
+}
+
+// termQuery returns the query matching a term which is combined with other terms by OR, - or
+// parentheses. Such terms may be IDs, URLs or words, qualifiers are rejected.
+func termQuery(term string) (string, error) {
+	part := unescapeSearchTerm(term)
+	if strings.HasPrefix(part, "id:") {
+		return idQuery(part)
+	}
+	var ignore searchKeyword
+	if qualifier, _ := parseQualifier(part, nil, &ignore); qualifier || strings.HasPrefix(part, "type:") {
+		return "", errors.NewBadParameterError("search string", part).Expected("qualifiers not to be negated or combined with OR")
+	}
+	if govalidator.IsURL(part) {
+		part := strings.ToLower(part)
+		part = trimProtocolFromURLString(part)
+		return getSearchQueryFromURLString(part), nil
+	}
+	part = strings.ToLower(part)
+	return sanitizeURL(part) + ":*", nil
 }
 
 // generateSQLSearchInfo accepts searchKeyword and join them in a way that can be used in sql
@@ -282,10 +444,42 @@ func generateSQLSearchInfo(keywords searchKeyword) (sqlParameter string) {
 	return searchStr
 }
 
+// searchKeys orders the search results by relevance, recently updated work items first.
+// The rank is rounded so that its textual representation in page cursors is exact.
+var searchKeys = []pagination.Key{
+	{Expression: "round(rank::numeric, 6)", Descending: true},
+	{Expression: workitem.WorkItem{}.TableName() + ".updated_at", Descending: true},
+	{Expression: workitem.WorkItem{}.TableName() + ".id", Descending: true},
+}
+
+// searchQuery selects the work items matching the text and the types and qualifiers of the keywords.
+// Searching only by qualifiers matches all work items they allow.
+func (r *GormSearchRepository) searchQuery(text searchText, keywords searchKeyword) *gorm.DB {
+	db := r.db.Model(workitem.WorkItem{})
+	if workItemTypes := keywords.workItemTypes; len(workItemTypes) > 0 {
+		// restrict to all given types and their subtypes
+		query := fmt.Sprintf("%[1]s.type in ("+
+			"select distinct subtype.id from %[2]s subtype "+
+			"join %[2]s supertype on subtype.path <@ supertype.path "+
+			"where supertype.id in (?))", workitem.WorkItem{}.TableName(), workitem.WorkItemType{}.TableName())
+		db = db.Where(query, workItemTypes)
+	}
+	db = whereQualifiers(db, keywords)
+	return r.engine.match(db, text)
+}
+
+// searchHit is a found work item along with the snippets of its searchable fields, see highlightedFields,
+// or its similarity to the draft of a duplicate search
+type searchHit struct {
+	workitem.WorkItem
+	highlights map[string]string
+	similarity float64
+}
+
 // extracted this function from List() in order to close the rows object with "defer" for more readability
 // workaround for https://github.com/lib/pq/issues/81
-func (r *GormSearchRepository) search(ctx context.Context, sqlSearchQueryParameter string, workItemTypes []uuid.UUID, start *int, limit *int) ([]workitem.WorkItem, uint64, error) {
-	db := r.db.Model(workitem.WorkItem{}).Where("tsv @@ query")
+func (r *GormSearchRepository) search(ctx context.Context, text searchText, keywords searchKeyword, start *int, limit *int) ([]searchHit, uint64, error) {
+	db := r.searchQuery(text, keywords)
 	if start != nil {
 		if *start < 0 {
 			return nil, 0, errors.NewBadParameterError("start", *start)
@@ -298,18 +492,10 @@ func (r *GormSearchRepository) search(ctx context.Context, sqlSearchQueryParamet
 		}
 		db = db.Limit(*limit)
 	}
-	if len(workItemTypes) > 0 {
-		// restrict to all given types and their subtypes
-		query := fmt.Sprintf("%[1]s.type in ("+
-			"select distinct subtype.id from %[2]s subtype "+
-			"join %[2]s supertype on subtype.path <@ supertype.path "+
-			"where supertype.id in (?))", workitem.WorkItem{}.TableName(), workitem.WorkItemType{}.TableName())
-		db = db.Where(query, workItemTypes)
-	}
 
-	db = db.Select("count(*) over () as cnt2 , *")
-	db = db.Joins(", to_tsquery('english', ?) as query, ts_rank(tsv, query) as rank", sqlSearchQueryParameter)
-	db = db.Order(fmt.Sprintf("rank desc,%s.updated_at desc", workitem.WorkItem{}.TableName()))
+	highlights, highlightParameters := highlightColumns()
+	db = db.Select("count(*) over () as cnt2 , "+workitem.WorkItem{}.TableName()+".*, "+highlights, highlightParameters...)
+	db = db.Order(pagination.Keyset{}.OrderBy(searchKeys))
 
 	rows, err := db.Rows()
 	if err != nil {
@@ -317,8 +503,7 @@ func (r *GormSearchRepository) search(ctx context.Context, sqlSearchQueryParamet
 	}
 	defer rows.Close()
 
-	result := []workitem.WorkItem{}
-	value := workitem.WorkItem{}
+	result := []searchHit{}
 	columns, err := rows.Columns()
 	if err != nil {
 		return nil, 0, errors.NewInternalError(err.Error())
@@ -336,13 +521,17 @@ func (r *GormSearchRepository) search(ctx context.Context, sqlSearchQueryParamet
 	first := true
 
 	for rows.Next() {
-		db.ScanRows(rows, &value)
+		value := searchHit{}
+		db.ScanRows(rows, &value.WorkItem)
 		if first {
 			first = false
 			if err = rows.Scan(columnValues...); err != nil {
 				return nil, 0, errors.NewInternalError(err.Error())
 			}
 		}
+		if value.highlights, err = scanHighlights(rows, columns); err != nil {
+			return nil, 0, errors.NewInternalError(err.Error())
+		}
 		result = append(result, value)
 
 	}
@@ -354,43 +543,135 @@ func (r *GormSearchRepository) search(ctx context.Context, sqlSearchQueryParamet
 	//*/
 }
 
-// SearchFullText Search returns work items for the given query
-func (r *GormSearchRepository) SearchFullText(ctx context.Context, rawSearchString string, start *int, limit *int) ([]*app.WorkItem, uint64, error) {
+// SearchFullText Search returns work items for the given query, currentUser is the identity
+// "me" refers to in qualifiers and may be nil for anonymous requests
+func (r *GormSearchRepository) SearchFullText(ctx context.Context, rawSearchString string, currentUser *uuid.UUID, start *int, limit *int) ([]*app.SearchHit, uint64, error) {
 	// parse
 	// generateSearchQuery
 	// ....
-	parsedSearchDict, err := parseSearchString(rawSearchString)
+	parsedSearchDict, text, err := parseSearch(rawSearchString, currentUser)
 	if err != nil {
 		return nil, 0, errs.WithStack(err)
 	}
 
-	sqlSearchQueryParameter := generateSQLSearchInfo(parsedSearchDict)
-	var rows []workitem.WorkItem
-	rows, count, err := r.search(ctx, sqlSearchQueryParameter, parsedSearchDict.workItemTypes, start, limit)
+	var rows []searchHit
+	rows, count, err := r.search(ctx, text, parsedSearchDict, start, limit)
 	if err != nil {
 		return nil, 0, errs.WithStack(err)
 	}
-	result := make([]*app.WorkItem, len(rows))
+	result, err := r.convertSearchHits(ctx, rows)
+	if err != nil {
+		return nil, 0, errs.WithStack(err)
+	}
+	return result, count, nil
+}
 
-	for index, value := range rows {
-		var err error
-		// FIXME: Against best practice http://go-database-sql.org/retrieving.html
-		wiType, err := r.wir.LoadTypeFromDB(ctx, value.Type)
+func (r *GormSearchRepository) convertWorkItems(ctx context.Context, rows []searchHit) ([]*app.WorkItem, error) {
+	typeIDs := make([]uuid.UUID, len(rows))
+	for index, hit := range rows {
+		typeIDs[index] = hit.Type
+	}
+	wiTypes, err := r.wir.LoadTypesFromDB(ctx, typeIDs)
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	result := make([]*app.WorkItem, len(rows))
+	for index, hit := range rows {
+		value := hit.WorkItem
+		result[index], err = convertFromModel(goa.ContextRequest(ctx), *wiTypes[value.Type], value)
 		if err != nil {
-			return nil, 0, errors.NewInternalError(err.Error())
+			return nil, errors.NewConversionError(err.Error())
 		}
-		result[index], err = convertFromModel(goa.ContextRequest(ctx), *wiType, value)
-		if err != nil {
-			return nil, 0, errors.NewConversionError(err.Error())
+	}
+	return result, nil
+}
+
+// convertSearchHits converts the work items of the hits together with their highlights
+func (r *GormSearchRepository) convertSearchHits(ctx context.Context, rows []searchHit) ([]*app.SearchHit, error) {
+	workItems, err := r.convertWorkItems(ctx, rows)
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+	result := make([]*app.SearchHit, len(rows))
+	for index, hit := range rows {
+		result[index] = &app.SearchHit{WorkItem: workItems[index], Highlights: hit.highlights}
+	}
+	return result, nil
+}
+
+// searchPage fetches the work items matching the query on the page described by the keyset
+func (r *GormSearchRepository) searchPage(ctx context.Context, text searchText, keywords searchKeyword, keyset pagination.Keyset) ([]searchHit, *pagination.Page, error) {
+	if err := keyset.Validate(searchKeys); err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	var count uint64
+	if err := r.searchQuery(text, keywords).Count(&count).Error; err != nil {
+		return nil, nil, errors.NewInternalError(err.Error())
+	}
+	condition, parameters := keyset.Condition(searchKeys)
+	db := r.searchQuery(text, keywords).Where(condition, parameters...)
+	db = db.Order(keyset.OrderBy(searchKeys)).Limit(keyset.Limit + 1)
+	highlights, highlightParameters := highlightColumns()
+	// the page keys have to be the last columns
+	db = db.Select(workitem.WorkItem{}.TableName()+".*, "+highlights+", "+pagination.Columns(searchKeys), highlightParameters...)
+	rows, err := db.Rows()
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, errors.NewInternalError(err.Error())
+	}
+	result := []searchHit{}
+	page, n, err := keyset.ScanPage(rows, searchKeys, count, func() error {
+		value := searchHit{}
+		if err := db.ScanRows(rows, &value.WorkItem); err != nil {
+			return err
+		}
+		var err error
+		if value.highlights, err = scanHighlights(rows, columns); err != nil {
+			return err
 		}
+		result = append(result, value)
+		return nil
+	}, func(i, j int) { result[i], result[j] = result[j], result[i] })
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
 	}
+	return result[:n], page, nil
+}
 
-	return result, count, nil
+// SearchFullTextPage returns the work items for the given query on the page described by the keyset,
+// currentUser is the identity "me" refers to in qualifiers and may be nil for anonymous requests
+func (r *GormSearchRepository) SearchFullTextPage(ctx context.Context, rawSearchString string, currentUser *uuid.UUID, keyset pagination.Keyset) ([]*app.SearchHit, *pagination.Page, error) {
+	parsedSearchDict, text, err := parseSearch(rawSearchString, currentUser)
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	rows, page, err := r.searchPage(ctx, text, parsedSearchDict, keyset)
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	result, err := r.convertSearchHits(ctx, rows)
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	return result, page, nil
 }
 
-func init() {
-	// While registering URLs do not include protocol because it will be removed before scanning starts
-	// Please do not include trailing slashes because it will be removed before scanning starts
-	RegisterAsKnownURL("test-work-item-list-details", `(?P<domain>demo.almighty.io)(?P<path>/work-item/list/detail/)(?P<id>\d*)`)
-	RegisterAsKnownURL("test-work-item-board-details", `(?P<domain>demo.almighty.io)(?P<path>/work-item/board/detail/)(?P<id>\d*)`)
+// Reindex rebuilds the search index of the work items of the given type, or of all work items if
+// workItemTypeID is nil. The index holds the searchable fields of the types, a type change only affects work
+// items saved after it until they are reindexed.
+// returns the number of reindexed work items
+func (r *GormSearchRepository) Reindex(ctx context.Context, workItemTypeID *uuid.UUID) (int64, error) {
+	count, err := r.engine.reindex(ctx, r.db, workItemTypeID)
+	if err != nil {
+		return 0, errs.WithStack(err)
+	}
+	log.Info(ctx, map[string]interface{}{
+		"wit_id":    workItemTypeID,
+		"reindexed": count,
+	}, "rebuilt the search index")
+	return count, nil
 }
@@ -1,16 +1,23 @@
 package search_test
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/comment"
+	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/gormsupport"
 	"github.com/almighty/almighty-core/gormsupport/cleaner"
 	"github.com/almighty/almighty-core/migration"
 	"github.com/almighty/almighty-core/models"
+	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/search"
 	"github.com/almighty/almighty-core/space"
@@ -19,6 +26,7 @@ import (
 
 	"github.com/goadesign/goa"
 	"github.com/jinzhu/gorm"
+	errs "github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -74,11 +82,11 @@ func (s *searchRepositoryBlackboxTest) TestRestrictByType() {
 	params := url.Values{}
 	ctx := goa.NewContext(context.Background(), nil, req, params)
 
-	res, count, err := s.searchRepo.SearchFullText(ctx, "TestRestrictByType", nil, nil)
+	res, count, err := s.searchRepo.SearchFullText(ctx, "TestRestrictByType", nil, nil, nil)
 	require.Nil(s.T(), err)
 	require.True(s.T(), count == uint64(len(res))) // safety check for many, many instances of bogus search results.
-	for _, wi := range res {
-		s.wiRepo.Delete(ctx, wi.ID, s.modifierID)
+	for _, hit := range res {
+		s.wiRepo.Delete(ctx, hit.WorkItem.ID, s.modifierID)
 	}
 
 	extended := workitem.SystemBug
@@ -114,37 +122,398 @@ func (s *searchRepositoryBlackboxTest) TestRestrictByType() {
 	require.Nil(s.T(), err)
 	require.NotNil(s.T(), wi2)
 
-	res, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType", nil, nil)
+	res, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType", nil, nil, nil)
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), uint64(2), count)
 
-	res, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+sub1.Data.ID.String(), nil, nil)
+	res, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+sub1.Data.ID.String(), nil, nil, nil)
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), uint64(1), count)
 	if count == 1 {
-		assert.Equal(s.T(), wi1.ID, res[0].ID)
+		assert.Equal(s.T(), wi1.ID, res[0].WorkItem.ID)
 	}
 
-	res, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+sub2.Data.ID.String(), nil, nil)
+	res, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+sub2.Data.ID.String(), nil, nil, nil)
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), uint64(1), count)
 	if count == 1 {
-		assert.Equal(s.T(), wi2.ID, res[0].ID)
+		assert.Equal(s.T(), wi2.ID, res[0].WorkItem.ID)
 	}
 
-	_, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+base.Data.ID.String(), nil, nil)
+	_, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+base.Data.ID.String(), nil, nil, nil)
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), uint64(2), count)
 
-	_, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+sub2.Data.ID.String()+" type:"+sub1.Data.ID.String(), nil, nil)
+	_, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+sub2.Data.ID.String()+" type:"+sub1.Data.ID.String(), nil, nil, nil)
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), uint64(2), count)
 
-	_, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+base.Data.ID.String()+" type:"+sub1.Data.ID.String(), nil, nil)
+	_, count, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByType type:"+base.Data.ID.String()+" type:"+sub1.Data.ID.String(), nil, nil, nil)
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), uint64(2), count)
 
-	_, count, err = s.searchRepo.SearchFullText(ctx, "TRBTgorxi type:"+base.Data.ID.String(), nil, nil)
+	_, count, err = s.searchRepo.SearchFullText(ctx, "TRBTgorxi type:"+base.Data.ID.String(), nil, nil, nil)
 	assert.Nil(s.T(), err)
 	assert.Equal(s.T(), uint64(0), count)
 }
+
+func (s *searchRepositoryBlackboxTest) TestRestrictByQualifiers() {
+	// given
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+	otherSpace, err := space.NewRepository(s.DB).Create(ctx, &space.Space{Name: "search-test-" + uuid.NewV4().String()})
+	require.Nil(s.T(), err)
+	other := uuid.NewV4().String()
+
+	wi1, err := s.wiRepo.Create(ctx, space.SystemSpace, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle:     "Test TestRestrictByQualifiers",
+		workitem.SystemState:     workitem.SystemStateNew,
+		workitem.SystemAssignees: []interface{}{s.modifierID.String()},
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+	wi2, err := s.wiRepo.Create(ctx, otherSpace.ID, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle:     "Test TestRestrictByQualifiers 2",
+		workitem.SystemState:     workitem.SystemStateClosed,
+		workitem.SystemAssignees: []interface{}{other},
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+
+	for query, expected := range map[string][]string{
+		"TestRestrictByQualifiers":                                                {wi1.ID, wi2.ID},
+		"TestRestrictByQualifiers state:new":                                      {wi1.ID},
+		"TestRestrictByQualifiers state:new state:closed":                         {wi1.ID, wi2.ID},
+		"TestRestrictByQualifiers state:NEW":                                      {wi1.ID},
+		"TestRestrictByQualifiers assignee:me":                                    {wi1.ID},
+		"TestRestrictByQualifiers assignee:" + other:                              {wi2.ID},
+		"TestRestrictByQualifiers creator:me state:closed":                        {wi2.ID},
+		"TestRestrictByQualifiers space:" + otherSpace.ID.String():                {wi2.ID},
+		"TestRestrictByQualifiers created:>2017-01-01":                            {wi1.ID, wi2.ID},
+		"TestRestrictByQualifiers created:<2017-01-01":                            {},
+		"TestRestrictByQualifiers iteration:" + uuid.NewV4().String():             {},
+		"space:" + otherSpace.ID.String() + " assignee:" + strings.ToUpper(other): {wi2.ID},
+	} {
+		// when
+		res, count, err := s.searchRepo.SearchFullText(ctx, query, &s.modifierID, nil, nil)
+		// then
+		require.Nil(s.T(), err, "unexpected error for %s", query)
+		ids := []string{}
+		for _, hit := range res {
+			ids = append(ids, hit.WorkItem.ID)
+		}
+		assert.Equal(s.T(), uint64(len(expected)), count, "unexpected count for %s", query)
+		assert.Subset(s.T(), expected, ids, "unexpected work items for %s", query)
+	}
+
+	// when
+	_, _, err = s.searchRepo.SearchFullText(ctx, "TestRestrictByQualifiers assignee:me", nil, nil, nil)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
+
+func (s *searchRepositoryBlackboxTest) TestSearchPhrase() {
+	// given
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+	wi1, err := s.wiRepo.Create(ctx, space.SystemSpace, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle: "TestSearchPhrase memory leak in the server",
+		workitem.SystemState: workitem.SystemStateNew,
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+	wi2, err := s.wiRepo.Create(ctx, space.SystemSpace, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle: "TestSearchPhrase leak of server memory",
+		workitem.SystemState: workitem.SystemStateNew,
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+
+	for query, expected := range map[string][]string{
+		"TestSearchPhrase memory leak":                        {wi1.ID, wi2.ID},
+		`TestSearchPhrase "memory leak"`:                      {wi1.ID},
+		`TestSearchPhrase "server memory"`:                    {wi2.ID},
+		`TestSearchPhrase "leak memory"`:                      {},
+		`TestSearchPhrase ("memory leak" OR "server memory")`: {wi1.ID, wi2.ID},
+	} {
+		// when
+		res, count, err := s.searchRepo.SearchFullText(ctx, query, nil, nil, nil)
+		// then
+		require.Nil(s.T(), err, "unexpected error for %s", query)
+		ids := []string{}
+		for _, hit := range res {
+			ids = append(ids, hit.WorkItem.ID)
+		}
+		assert.Equal(s.T(), uint64(len(expected)), count, "unexpected count for %s", query)
+		assert.Subset(s.T(), expected, ids, "unexpected work items for %s", query)
+	}
+}
+
+func (s *searchRepositoryBlackboxTest) TestSearchCustomFieldsAndComments() {
+	// given
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+	searchable := true
+	extended := workitem.SystemBug
+	wit, err := s.witRepo.Create(ctx, space.SystemSpace, nil, &extended, "searchable-"+uuid.NewV4().String(), nil, "fa-bomb", map[string]app.FieldDefinition{
+		"notes": {
+			Type:        &app.FieldType{Kind: string(workitem.KindMarkup)},
+			Label:       "Notes",
+			Description: "Searchable notes",
+			Searchable:  &searchable,
+		},
+		"secret": {
+			Type:        &app.FieldType{Kind: string(workitem.KindString)},
+			Label:       "Secret",
+			Description: "Not searchable",
+		},
+	})
+	require.Nil(s.T(), err)
+	wi, err := s.wiRepo.Create(ctx, space.SystemSpace, *wit.Data.ID, map[string]interface{}{
+		workitem.SystemTitle: "Test TestSearchCustomFieldsAndComments",
+		workitem.SystemState: workitem.SystemStateNew,
+		"notes":              rendering.NewMarkupContentFromLegacy("the notes mention zanzibarnotes"),
+		"secret":             "zanzibarsecret",
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+	err = comment.NewRepository(s.DB).Create(ctx, &comment.Comment{ParentID: wi.ID, Body: "a comment about zanzibarcomment", Markup: rendering.SystemMarkupPlainText}, s.modifierID)
+	require.Nil(s.T(), err)
+
+	for query, expected := range map[string]uint64{
+		"zanzibarnotes":   1,
+		"zanzibarcomment": 1,
+		"zanzibarsecret":  0,
+	} {
+		// when
+		_, count, err := s.searchRepo.SearchFullText(ctx, query, nil, nil, nil)
+		// then
+		require.Nil(s.T(), err)
+		assert.Equal(s.T(), expected, count, "unexpected count for %s", query)
+	}
+
+	// when
+	reindexed, err := s.searchRepo.Reindex(ctx, wit.Data.ID)
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), int64(1), reindexed)
+	_, count, err := s.searchRepo.SearchFullText(ctx, "zanzibarcomment", nil, nil, nil)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(1), count)
+}
+
+func (s *searchRepositoryBlackboxTest) TestSpaceTextSearchConfig() {
+	// given
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+	spaceRepo := space.NewRepository(s.DB)
+	germanSpace, err := spaceRepo.Create(ctx, &space.Space{Name: "search-test-" + uuid.NewV4().String(), TextSearchConfig: "german"})
+	require.Nil(s.T(), err)
+	_, err = s.wiRepo.Create(ctx, germanSpace.ID, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle: "Die Katzen schlafen",
+		workitem.SystemState: workitem.SystemStateNew,
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+	query := "Katze space:" + germanSpace.ID.String()
+
+	// when
+	_, count, err := s.searchRepo.SearchFullText(ctx, query, nil, nil, nil)
+	// then the german stemming finds the plural
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(1), count)
+
+	// when
+	germanSpace.TextSearchConfig = "english"
+	_, err = spaceRepo.Save(ctx, germanSpace)
+	require.Nil(s.T(), err)
+	_, count, err = s.searchRepo.SearchFullText(ctx, query, nil, nil, nil)
+	// then the work items of the space have been reindexed
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(0), count)
+}
+
+func (s *searchRepositoryBlackboxTest) TestSearchFacets() {
+	// given
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+	other := uuid.NewV4().String()
+	for _, assignees := range [][]interface{}{{s.modifierID.String(), other}, {other}, {}} {
+		_, err := s.wiRepo.Create(ctx, space.SystemSpace, workitem.SystemBug, map[string]interface{}{
+			workitem.SystemTitle:     "Test TestSearchFacets",
+			workitem.SystemState:     workitem.SystemStateNew,
+			workitem.SystemAssignees: assignees,
+		}, s.modifierID)
+		require.Nil(s.T(), err)
+	}
+
+	// when
+	facets, err := s.searchRepo.SearchFacets(ctx, "TestSearchFacets", nil)
+	// then
+	require.Nil(s.T(), err)
+	counts := map[string]map[string]int{}
+	for name, values := range facets {
+		counts[name] = map[string]int{}
+		for _, value := range values {
+			key := "none"
+			if value.Value != nil {
+				key = *value.Value
+			}
+			counts[name][key] = value.Count
+		}
+	}
+	assert.Equal(s.T(), map[string]int{workitem.SystemBug.String(): 3}, counts[search.FacetType])
+	assert.Equal(s.T(), map[string]int{workitem.SystemStateNew: 3}, counts[search.FacetState])
+	assert.Equal(s.T(), map[string]int{other: 2, s.modifierID.String(): 1, "none": 1}, counts[search.FacetAssignee])
+	assert.Equal(s.T(), map[string]int{"none": 3}, counts[search.FacetArea])
+	assert.Equal(s.T(), map[string]int{"none": 3}, counts[search.FacetIteration])
+
+	// when
+	facets, err = s.searchRepo.SearchFacets(ctx, "TestSearchFacets assignee:me", &s.modifierID)
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), facets[search.FacetState], 1)
+	assert.Equal(s.T(), 1, facets[search.FacetState][0].Count)
+}
+
+func (s *searchRepositoryBlackboxTest) TestEmbeddedEngine() {
+	// given
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+	dir, err := ioutil.TempDir("", "search-index")
+	require.Nil(s.T(), err)
+	defer os.RemoveAll(dir)
+	engine, err := search.OpenEngine(s.DB, search.EngineEmbedded, filepath.Join(dir, "index"))
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), engine.(*search.EmbeddedEngine).Claim(ctx))
+	defer engine.(*search.EmbeddedEngine).Close()
+	searchRepo := search.NewGormSearchRepositoryWithEngine(s.DB, engine)
+	wi, err := s.wiRepo.Create(ctx, space.SystemSpace, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle: "Test TestEmbeddedEngine Zanzibarian",
+		workitem.SystemState: workitem.SystemStateNew,
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+	_, err = searchRepo.Reindex(ctx, nil)
+	require.Nil(s.T(), err)
+
+	// when
+	res, count, err := searchRepo.SearchFullText(ctx, "zanzibaran state:new", nil, nil, nil)
+	// then the typo is tolerated
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), uint64(1), count)
+	assert.Equal(s.T(), wi.ID, res[0].WorkItem.ID)
+
+	// when the work item is commented
+	err = comment.NewRepository(s.DB).Create(ctx, &comment.Comment{ParentID: wi.ID, Body: "a comment about zanzibarianremark", Markup: rendering.SystemMarkupPlainText}, s.modifierID)
+	require.Nil(s.T(), err)
+	engine.(*search.EmbeddedEngine).WorkItemChanged(ctx, mustParseID(s.T(), wi.ID))
+	_, count, err = searchRepo.SearchFullText(ctx, "zanzibarianremark", nil, nil, nil)
+	// then the comment is indexed like in the search vector
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(1), count)
+
+	// when the work item is deleted
+	engine.(*search.EmbeddedEngine).WorkItemDeleted(ctx, mustParseID(s.T(), wi.ID))
+	_, count, err = searchRepo.SearchFullText(ctx, "zanzibarian", nil, nil, nil)
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(0), count)
+}
+
+func (s *searchRepositoryBlackboxTest) TestEmbeddedEngineClaim() {
+	// given
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+	dir, err := ioutil.TempDir("", "search-index")
+	require.Nil(s.T(), err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "index")
+	engine, err := search.OpenEmbeddedEngine(s.DB, path)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), engine.Claim(ctx))
+
+	// when another instance claims the engine
+	other, err := search.OpenEmbeddedEngine(s.DB, path)
+	require.Nil(s.T(), err)
+	err = other.Claim(ctx)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.InternalError{}, errs.Cause(err))
+
+	// when a work item is created without notifying the engine and the index is claimed again
+	wi, err := s.wiRepo.Create(ctx, space.SystemSpace, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle: "Test TestEmbeddedEngineClaim Zanzibarclaim",
+		workitem.SystemState: workitem.SystemStateNew,
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), engine.Close())
+	reopened, err := search.OpenEmbeddedEngine(s.DB, path)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), reopened.Claim(ctx))
+	defer reopened.Close()
+	res, count, err := search.NewGormSearchRepositoryWithEngine(s.DB, reopened).SearchFullText(ctx, "zanzibarclaim", nil, nil, nil)
+	// then the work item has been caught up with
+	require.Nil(s.T(), err)
+	require.Equal(s.T(), uint64(1), count)
+	assert.Equal(s.T(), wi.ID, res[0].WorkItem.ID)
+}
+
+func mustParseID(t *testing.T, id string) uint64 {
+	result, err := strconv.ParseUint(id, 10, 64)
+	require.Nil(t, err)
+	return result
+}
+
+func (s *searchRepositoryBlackboxTest) TestSearchDuplicates() {
+	// given
+	req := &http.Request{Host: "localhost"}
+	params := url.Values{}
+	ctx := goa.NewContext(context.Background(), nil, req, params)
+	spaceRepo := space.NewRepository(s.DB)
+	duplicatesSpace, err := spaceRepo.Create(ctx, &space.Space{Name: "duplicates-test-" + uuid.NewV4().String()})
+	require.Nil(s.T(), err)
+	create := func(title, description string) *app.WorkItem {
+		wi, err := s.wiRepo.Create(ctx, duplicatesSpace.ID, workitem.SystemBug, map[string]interface{}{
+			workitem.SystemTitle:       title,
+			workitem.SystemDescription: rendering.NewMarkupContentFromLegacy(description),
+			workitem.SystemState:       workitem.SystemStateNew,
+		}, s.modifierID)
+		require.Nil(s.T(), err)
+		return wi
+	}
+	crash := create("Login page crashes on submit", "The browser shows a blank page")
+	timeout := create("Login page crashes after a timeout", "The session expires")
+	create("Board columns are misaligned", "The columns overlap")
+	_, err = s.wiRepo.Create(ctx, space.SystemSpace, workitem.SystemBug, map[string]interface{}{
+		workitem.SystemTitle: "Login page crashes on submit",
+		workitem.SystemState: workitem.SystemStateNew,
+	}, s.modifierID)
+	require.Nil(s.T(), err)
+
+	// when
+	res, err := s.searchRepo.SearchDuplicates(ctx, duplicatesSpace.ID, "login page crash on submit", "", search.DefaultDuplicatesLimit)
+	// then only the similar titles of the space are found, the most similar first
+	require.Nil(s.T(), err)
+	require.Len(s.T(), res, 2)
+	assert.Equal(s.T(), crash.ID, res[0].WorkItem.ID)
+	assert.Equal(s.T(), timeout.ID, res[1].WorkItem.ID)
+	assert.True(s.T(), res[0].Similarity > res[1].Similarity)
+
+	// when the description matches the other work item
+	res, err = s.searchRepo.SearchDuplicates(ctx, duplicatesSpace.ID, "Login page crashes", "the session expires after a timeout", 1)
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), res, 1)
+	assert.Equal(s.T(), timeout.ID, res[0].WorkItem.ID)
+
+	// when
+	_, err = s.searchRepo.SearchDuplicates(ctx, duplicatesSpace.ID, " ", "", search.DefaultDuplicatesLimit)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+	_, err = s.searchRepo.SearchDuplicates(ctx, duplicatesSpace.ID, "Login", "", search.MaxDuplicatesLimit+1)
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
@@ -1,15 +1,17 @@
 package search
 
 import (
-	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/configuration"
+	almerrors "github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/gormsupport"
 	"github.com/almighty/almighty-core/migration"
 	"github.com/almighty/almighty-core/models"
@@ -30,6 +32,17 @@ import (
 	"golang.org/x/net/context"
 )
 
+func init() {
+	// the work item URLs of the default configuration are known, like after the startup in main
+	config, err := configuration.NewConfigurationData("")
+	if err != nil {
+		panic(err.Error())
+	}
+	if err := RegisterKnownURLs(config.GetSearchKnownURLs()); err != nil {
+		panic(err.Error())
+	}
+}
+
 func TestRunSearchRepositoryWhiteboxTest(t *testing.T) {
 	resource.Require(t, resource.Database)
 	suite.Run(t, &searchRepositoryWhiteboxTest{DBTestSuite: gormsupport.NewDBTestSuite("../config.yaml")})
@@ -186,15 +199,13 @@ func (s *searchRepositoryWhiteboxTest) TestSearchByText() {
 			// had to dynamically create this since I didn't now the URL/ID of the workitem
 			// till the test data was created.
 			searchString = searchString + workItemURLInSearchString
-			searchString = fmt.Sprintf("\"%s\"", searchString)
 			s.T().Log("using search string: " + searchString)
 			sr := NewGormSearchRepository(tx)
 			var start, limit int = 0, 100
-			workItemList, _, err := sr.SearchFullText(ctx, searchString, &start, &limit)
+			workItemList, _, err := sr.SearchFullText(ctx, searchString, nil, &start, &limit)
 			if err != nil {
 				s.T().Fatal("Error getting search result ", err)
 			}
-			searchString = strings.Trim(searchString, "\"")
 			// Since this test adds test data, whether or not other workitems exist
 			// there must be at least 1 search result returned.
 			if len(workItemList) == minimumResults && minimumResults == 0 {
@@ -215,7 +226,8 @@ func (s *searchRepositoryWhiteboxTest) TestSearchByText() {
 			// We will now check the legitimacy of the search results.
 			// Iterate through all search results and see whether they meet the criteria
 
-			for _, workItemValue := range workItemList {
+			for _, hit := range workItemList {
+				workItemValue := hit.WorkItem
 				s.T().Log("Found search result  ", workItemValue.ID)
 
 				for _, keyWord := range allKeywords {
@@ -297,16 +309,16 @@ func (s *searchRepositoryWhiteboxTest) TestSearchByID() {
 
 		var start, limit int = 0, 100
 		searchString := "id:" + createdWorkItem.ID
-		workItemList, _, err := sr.SearchFullText(ctx, searchString, &start, &limit)
+		workItemList, _, err := sr.SearchFullText(ctx, searchString, nil, &start, &limit)
 		if err != nil {
 			s.T().Fatal("Error gettig search result ", err)
 		}
 
 		// ID is unique, hence search result set's length should be 1
 		assert.Equal(s.T(), len(workItemList), 1)
-		for _, workItemValue := range workItemList {
-			s.T().Log("Found search result for ID Search ", workItemValue.ID)
-			assert.Equal(s.T(), createdWorkItem.ID, workItemValue.ID)
+		for _, hit := range workItemList {
+			s.T().Log("Found search result for ID Search ", hit.WorkItem.ID)
+			assert.Equal(s.T(), createdWorkItem.ID, hit.WorkItem.ID)
 		}
 		return errors.WithStack(err)
 	})
@@ -342,7 +354,7 @@ func TestParseSearchString(t *testing.T) {
 	t.Parallel()
 	resource.Require(t, resource.UnitTest)
 	input := "user input for search string with some ids like id:99 and id:400 but this is not id like 800"
-	op, _ := parseSearchString(input)
+	op, _ := parseSearchString(input, nil)
 	expectedSearchRes := searchKeyword{
 		id:    []string{"99:*A", "400:*A"},
 		words: []string{"user:*", "input:*", "for:*", "search:*", "string:*", "with:*", "some:*", "ids:*", "like:*", "and:*", "but:*", "this:*", "is:*", "not:*", "id:*", "like:*", "800:*"},
@@ -373,7 +385,7 @@ func TestParseSearchStringURL(t *testing.T) {
 	}}
 
 	for _, input := range inputSet {
-		op, _ := parseSearchString(input.query)
+		op, _ := parseSearchString(input.query, nil)
 		assert.True(t, assert.ObjectsAreEqualValues(input.expected, op))
 	}
 }
@@ -396,7 +408,7 @@ func TestParseSearchStringURLWithouID(t *testing.T) {
 	}}
 
 	for _, input := range inputSet {
-		op, _ := parseSearchString(input.query)
+		op, _ := parseSearchString(input.query, nil)
 		assert.True(t, assert.ObjectsAreEqualValues(input.expected, op))
 	}
 
@@ -406,7 +418,7 @@ func TestParseSearchStringDifferentURL(t *testing.T) {
 	t.Parallel()
 	resource.Require(t, resource.UnitTest)
 	input := "http://demo.redhat.io"
-	op, _ := parseSearchString(input)
+	op, _ := parseSearchString(input, nil)
 	expectedSearchRes := searchKeyword{
 		id:    nil,
 		words: []string{"demo.redhat.io:*"},
@@ -420,7 +432,7 @@ func TestParseSearchStringCombination(t *testing.T) {
 	// do combination of ID, full text and URLs
 	// check if it works as expected.
 	input := "http://general.url.io http://demo.almighty.io/work-item/list/detail/100 id:300 golang book and           id:900 \t \n unwanted"
-	op, _ := parseSearchString(input)
+	op, _ := parseSearchString(input, nil)
 	expectedSearchRes := searchKeyword{
 		id:    []string{"300:*A", "900:*A"},
 		words: []string{"general.url.io:*", "(100:* | demo.almighty.io/work-item/list/detail/100:*)", "golang:*", "book:*", "and:*", "unwanted:*"},
@@ -428,6 +440,87 @@ func TestParseSearchStringCombination(t *testing.T) {
 	assert.True(t, assert.ObjectsAreEqualValues(expectedSearchRes, op))
 }
 
+func TestParseSearchStringQualifiers(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	me := uuid.NewV4()
+	id := uuid.NewV4()
+	day := time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC)
+	input := "golang state:new State:Closed assignee:me creator:" + strings.ToUpper(id.String()) +
+		" area:" + id.String() + " iteration:" + id.String() + " space:" + id.String() + " created:>2017-01-31 created:2017-01-31"
+	op, err := parseSearchString(input, &me)
+	require.Nil(t, err)
+	expectedSearchRes := searchKeyword{
+		words: []string{"golang:*"},
+		fields: map[string][]string{
+			workitem.SystemState:     {"new", "closed"},
+			workitem.SystemAssignees: {me.String()},
+			workitem.SystemCreator:   {id.String()},
+			workitem.SystemArea:      {id.String()},
+			workitem.SystemIteration: {id.String()},
+		},
+		spaces:  []uuid.UUID{id},
+		created: []createdCondition{{">", day}, {">=", day}, {"<", day.AddDate(0, 0, 1)}},
+	}
+	assert.Equal(t, expectedSearchRes, op)
+}
+
+func TestParseSearchStringQualifierErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	me := uuid.NewV4()
+	for _, input := range []string{"state:", "assignee:john", "area:garbage", "space:1", "created:yesterday", "created:>"} {
+		_, err := parseSearchString("golang "+input, &me)
+		require.NotNil(t, err, "expected an error for %s", input)
+		assert.IsType(t, almerrors.BadParameterError{}, errors.Cause(err))
+	}
+	// "me" needs a logged in user
+	_, err := parseSearchString("golang creator:me", nil)
+	require.NotNil(t, err)
+	assert.IsType(t, almerrors.BadParameterError{}, errors.Cause(err))
+}
+
+func TestParseSearchStringSyntax(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	for input, expected := range map[string][]string{
+		`"memory leak"`:                        {"(memory <-> leak)"},
+		`"Leak"`:                               {"leak"},
+		"server OR client":                     {"(server:* | client:*)"},
+		"memory server OR client":              {"((memory:* & server:*) | client:*)"},
+		"memory (server OR client)":            {"memory:*", "(server:* | client:*)"},
+		"(memory leak)":                        {"memory:*", "leak:*"},
+		"(value)":                              {"value:*"},
+		"memory -windows":                      {"memory:*", "!windows:*"},
+		"-(windows OR mac)":                    {"!(windows:* | mac:*)"},
+		"a-b --c":                              {"a-b:*", "--c:*"},
+		"o'neil & co|op!":                      {"o\\'neil:*", "\\&:*", "co\\|op\\!:*"},
+		"id:1 OR id:2":                         {"(1:*A | 2:*A)"},
+		`"leak" OR http://demo.redhat.io`:      {"(leak | demo.redhat.io:*)"},
+		`state:new ("memory leak" OR -server)`: {"((memory <-> leak) | !server:*)"},
+		"http://en.wikipedia.org/wiki/Go_(language)":        {"en.wikipedia.org/wiki/go_\\(language\\):*"},
+		"(leak http://en.wikipedia.org/wiki/Go_(language))": {"leak:*", "en.wikipedia.org/wiki/go_\\(language\\):*"},
+		"memory)": {"memory\\):*"},
+	} {
+		op, err := parseSearchString(input, nil)
+		require.Nil(t, err, "unexpected error for %s", input)
+		assert.Equal(t, expected, op.words, "unexpected query for %s", input)
+	}
+}
+
+func TestParseSearchStringSyntaxErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	for _, input := range []string{
+		`"memory leak`, `""`, "OR memory", "memory OR", "memory OR OR leak", "()", "(memory", "memory )",
+		"memory -", "-state:new", "memory OR state:new", "-type:" + uuid.NewV4().String(), "id:",
+	} {
+		_, err := parseSearchString(input, nil)
+		require.NotNil(t, err, "expected an error for %s", input)
+		assert.IsType(t, almerrors.BadParameterError{}, errors.Cause(err), "unexpected error for %s", input)
+	}
+}
+
 func TestRegisterAsKnownURL(t *testing.T) {
 	resource.Require(t, resource.UnitTest)
 	// build 2 fake urls and cross check against RegisterAsKnownURL
@@ -447,6 +540,39 @@ func TestRegisterAsKnownURL(t *testing.T) {
 	delete(knownURLs, routeName)
 }
 
+func TestValidateKnownURL(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	valid := `(?P<domain>my.domain.io)(?P<path>/work-item/list/detail/)(?P<id>\d*)`
+	assert.Nil(t, ValidateKnownURL("valid", valid))
+	invalid := []string{
+		`http://(?P<domain>my.domain.io)(?P<path>/work-item/list/detail/)(?P<id>\d*)`,
+		`(?P<domain>my.domain.io)(?P<path>/work-item/list/detail/)(?P<id>\d*`,
+		`(?P<domain>my.domain.io)(?P<path>/work-item/list/detail/)\d*`,
+		`my.domain.io(?P<path>/work-item/list/detail/)(?P<id>\d*)`,
+	}
+	for _, urlRegex := range invalid {
+		err := ValidateKnownURL("invalid", urlRegex)
+		require.NotNil(t, err, urlRegex)
+		assert.IsType(t, almerrors.BadParameterError{}, errors.Cause(err), urlRegex)
+	}
+}
+
+func TestRegisterKnownURLs(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	valid := `(?P<domain>my.domain.io)(?P<path>/work-item/list/detail/)(?P<id>\d*)`
+	// nothing is registered if one of the patterns is invalid
+	err := RegisterKnownURLs(map[string]string{"custom-valid": valid, "custom-invalid": `(?P<domain>my.domain.io)`})
+	require.NotNil(t, err)
+	assert.NotContains(t, GetAllRegisteredURLs(), "custom-valid")
+
+	require.Nil(t, RegisterKnownURLs(map[string]string{"custom-valid": valid}))
+	defer UnregisterKnownURL("custom-valid")
+	known := GetAllRegisteredURLs()
+	require.Contains(t, known, "custom-valid")
+	assert.Equal(t, []string{"domain", "path", "id"}, known["custom-valid"].GroupNames())
+	assert.Equal(t, "(100:* | my.domain.io/work-item/list/detail/100:*)", getSearchQueryFromURLString("my.domain.io/work-item/list/detail/100"))
+}
+
 func TestIsKnownURL(t *testing.T) {
 	resource.Require(t, resource.UnitTest)
 	// register few URLs and cross check is knwon or not one by one
@@ -502,3 +628,18 @@ func TestGetSearchQueryFromURLString(t *testing.T) {
 	searchQuery = getSearchQueryFromURLString("google.me.io/everything/100")
 	assert.Equal(t, "(100:* | google.me.io/everything/100:*)", searchQuery)
 }
+
+func TestHighlightMarkup(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	testData := map[string]string{
+		"plain <b>text</b>": "plain &lt;b&gt;text&lt;/b&gt;",
+		"a " + highlightStart + "word" + highlightStop + " & more": "a <mark>word</mark> &amp; more",
+		highlightStart + "<script>" + highlightStop:                "<mark>&lt;script&gt;</mark>",
+		// unbalanced highlight characters in the text never break the markup
+		highlightStop + "a" + highlightStart + highlightStart + "b": "a<mark>b</mark>",
+	}
+	for snippet, expected := range testData {
+		assert.Equal(t, expected, highlightMarkup(snippet))
+	}
+}
@@ -0,0 +1,240 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/almighty/almighty-core/errors"
+)
+
+// searchTokenKind tells how a part of a search string is interpreted
+type searchTokenKind int
+
+const (
+	tokenTerm searchTokenKind = iota
+	tokenPhrase
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+// searchToken is a part of a search string, the text of terms and phrases is kept as entered
+type searchToken struct {
+	kind searchTokenKind
+	text string
+}
+
+// tokenizeSearchString splits a search string into terms, quoted phrases, the OR operator, the - prefix
+// excluding a term and parentheses grouping terms.
+// returns BadParameterError if a quote isn't closed
+func tokenizeSearchString(raw string) ([]searchToken, error) {
+	tokens := []searchToken{}
+	runes := []rune(raw)
+	// groups is the number of open groups
+	groups := 0
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, searchToken{kind: tokenOpen})
+			groups++
+			i++
+		case r == ')':
+			tokens = append(tokens, searchToken{kind: tokenClose})
+			groups--
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, errors.NewBadParameterError("search string", raw).Expected("a closing quote")
+			}
+			tokens = append(tokens, searchToken{kind: tokenPhrase, text: string(runes[i+1 : end])})
+			i = end + 1
+		case r == '-' && (i+1 == len(runes) || runes[i+1] != '-'):
+			tokens = append(tokens, searchToken{kind: tokenNot})
+			i++
+		default:
+			// parentheses may be part of a term, e.g. in URLs. A ')' only ends a term if it closes a group
+			// and isn't balanced by a '(' within the term.
+			start := i
+			nested := 0
+			for ; i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '"'; i++ {
+				if runes[i] == '(' {
+					nested++
+				} else if runes[i] == ')' && nested > 0 {
+					nested--
+				} else if runes[i] == ')' && groups > 0 {
+					break
+				}
+			}
+			text := string(runes[start:i])
+			if text == "OR" {
+				tokens = append(tokens, searchToken{kind: tokenOr})
+			} else {
+				tokens = append(tokens, searchToken{kind: tokenTerm, text: text})
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// searchNode is a node of the syntax tree of a search string. Terms and phrases are leaves, the
+// other nodes combine their children with the tsquery operator & or | or negate their only child with !.
+type searchNode struct {
+	operator string
+	token    searchToken
+	children []*searchNode
+}
+
+// searchParser builds the syntax tree of a tokenized search string. Terms next to each other must all
+// match, OR binds weaker than that, e.g. "a b OR c" matches work items with a and b or with c.
+type searchParser struct {
+	raw    string
+	tokens []searchToken
+	pos    int
+}
+
+// parseSearchSyntax returns the syntax tree of the search string or nil if it is blank
+// returns BadParameterError if the search string is malformed
+func parseSearchSyntax(raw string) (*searchNode, error) {
+	tokens, err := tokenizeSearchString(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := searchParser{raw: raw, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		// only an unbalanced closing parenthesis stops parseOr early
+		return nil, p.syntaxError("an opening parenthesis before each closing parenthesis")
+	}
+	return root, nil
+}
+
+func (p *searchParser) syntaxError(expected string) error {
+	return errors.NewBadParameterError("search string", p.raw).Expected(expected)
+}
+
+// accept consumes the next token if it is of the given kind
+func (p *searchParser) accept(kind searchTokenKind) bool {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *searchParser) parseOr() (*searchNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokenOr) {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if node.operator != "|" {
+			node = &searchNode{operator: "|", children: []*searchNode{node}}
+		}
+		node.children = append(node.children, right)
+	}
+	return node, nil
+}
+
+func (p *searchParser) parseAnd() (*searchNode, error) {
+	children := []*searchNode{}
+	for p.pos < len(p.tokens) && p.tokens[p.pos].kind != tokenOr && p.tokens[p.pos].kind != tokenClose {
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	switch len(children) {
+	case 0:
+		return nil, p.syntaxError("a term before and after each OR and inside parentheses")
+	case 1:
+		return children[0], nil
+	}
+	return &searchNode{operator: "&", children: children}, nil
+}
+
+func (p *searchParser) parseUnary() (*searchNode, error) {
+	switch {
+	case p.accept(tokenNot):
+		if p.pos == len(p.tokens) || p.tokens[p.pos].kind == tokenOr || p.tokens[p.pos].kind == tokenClose {
+			return nil, p.syntaxError("a term after -")
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &searchNode{operator: "!", children: []*searchNode{child}}, nil
+	case p.accept(tokenOpen):
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(tokenClose) {
+			return nil, p.syntaxError("a closing parenthesis after each opening parenthesis")
+		}
+		return node, nil
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	return &searchNode{token: token}, nil
+}
+
+// tsquery returns the text search query of the node, terms are converted by the given function
+func (n *searchNode) tsquery(term func(string) (string, error)) (string, error) {
+	switch n.operator {
+	case "":
+		if n.token.kind == tokenPhrase {
+			return phraseQuery(n.token.text)
+		}
+		return term(n.token.text)
+	case "!":
+		child, err := n.children[0].tsquery(term)
+		if err != nil {
+			return "", err
+		}
+		return "!" + child, nil
+	}
+	children := make([]string, len(n.children))
+	for i, child := range n.children {
+		query, err := child.tsquery(term)
+		if err != nil {
+			return "", err
+		}
+		children[i] = query
+	}
+	return "(" + strings.Join(children, " "+n.operator+" ") + ")", nil
+}
+
+// phraseQuery returns a query matching the words of the phrase next to each other. Unlike terms, the
+// words of phrases aren't matched as prefixes. The followed-by operator needs PostgreSQL 9.6.
+func phraseQuery(phrase string) (string, error) {
+	words := strings.Fields(strings.ToLower(phrase))
+	if len(words) == 0 {
+		return "", errors.NewBadParameterError("search string", `"`+phrase+`"`).Expected("a word in each quoted phrase")
+	}
+	for i, word := range words {
+		words[i] = escapeCharFromURLString(word)
+	}
+	if len(words) == 1 {
+		return words[0], nil
+	}
+	return "(" + strings.Join(words, " <-> ") + ")", nil
+}
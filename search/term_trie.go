@@ -0,0 +1,117 @@
+package search
+
+// termTrie holds the terms of the embedded index, so that the terms starting with a word and the terms a few
+// edits away from it are found without comparing the word with every term. It isn't safe for concurrent use.
+type termTrie struct {
+	children map[rune]*termTrie
+	// term tells whether a term ends at the node
+	term bool
+}
+
+func newTermTrie() *termTrie {
+	return &termTrie{children: map[rune]*termTrie{}}
+}
+
+// insert adds the term
+func (t *termTrie) insert(term string) {
+	node := t
+	for _, r := range term {
+		child, ok := node.children[r]
+		if !ok {
+			child = newTermTrie()
+			node.children[r] = child
+		}
+		node = child
+	}
+	node.term = true
+}
+
+// delete drops the term along with the nodes which don't lead to another term
+func (t *termTrie) delete(term string) {
+	t.deleteRunes([]rune(term))
+}
+
+// deleteRunes drops the term and tells whether the node is empty afterwards
+func (t *termTrie) deleteRunes(term []rune) bool {
+	if len(term) == 0 {
+		t.term = false
+	} else if child, ok := t.children[term[0]]; ok && child.deleteRunes(term[1:]) {
+		delete(t.children, term[0])
+	}
+	return !t.term && len(t.children) == 0
+}
+
+// withPrefix returns the terms starting with the prefix, including the prefix itself if it is a term
+func (t *termTrie) withPrefix(prefix string) []string {
+	node := t
+	for _, r := range prefix {
+		child, ok := node.children[r]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+	result := []string{}
+	node.collect([]rune(prefix), &result)
+	return result
+}
+
+func (t *termTrie) collect(prefix []rune, result *[]string) {
+	if t.term {
+		*result = append(*result, string(prefix))
+	}
+	for r, child := range t.children {
+		child.collect(append(prefix, r), result)
+	}
+}
+
+// within returns the terms whose Levenshtein distance to the word is at most max. The rows of the distance
+// matrix are computed along the paths of the trie, so terms sharing a prefix share their computation and
+// subtrees are skipped as soon as no term in them can be close enough.
+func (t *termTrie) within(word string, max int) []string {
+	runes := []rune(word)
+	row := make([]int, len(runes)+1)
+	for j := range row {
+		row[j] = j
+	}
+	result := []string{}
+	for r, child := range t.children {
+		child.collectWithin(runes, []rune{r}, row, max, &result)
+	}
+	return result
+}
+
+// collectWithin computes the row of the distance matrix for the prefix ending at the node from the row of
+// its parent
+func (t *termTrie) collectWithin(word []rune, prefix []rune, previous []int, max int, result *[]string) {
+	last := prefix[len(prefix)-1]
+	current := make([]int, len(word)+1)
+	current[0] = previous[0] + 1
+	rowMin := current[0]
+	for j := 1; j <= len(word); j++ {
+		cost := 1
+		if word[j-1] == last {
+			cost = 0
+		}
+		current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		if current[j] < rowMin {
+			rowMin = current[j]
+		}
+	}
+	if t.term && current[len(word)] <= max {
+		*result = append(*result, string(prefix))
+	}
+	if rowMin > max {
+		return
+	}
+	for r, child := range t.children {
+		child.collectWithin(word, append(prefix, r), current, max, result)
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -20,6 +20,9 @@ var (
 	SpaceType   = "spaces"
 )
 
+// DefaultTextSearchConfig is the text search configuration of spaces which don't choose one
+const DefaultTextSearchConfig = "english"
+
 // Space represents a Space on the domain and db layer
 type Space struct {
 	gormsupport.Lifecycle
@@ -28,6 +31,15 @@ type Space struct {
 	Name        string
 	Description string
 	OwnerId     satoriuuid.UUID `sql:"type:uuid"` // Belongs To Identity
+	// TextSearchConfig is the Postgres text search configuration the work items of the space are indexed
+	// and searched with, e.g. "german" or "simple" for languages without stemming support
+	TextSearchConfig string
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (p Space) TableName() string {
+	return "spaces"
 }
 
 // Ensure Fields implements the Equaler interface
@@ -56,6 +68,9 @@ func (p Space) Equal(u convert.Equaler) bool {
 	if !satoriuuid.Equal(p.OwnerId, other.OwnerId) {
 		return false
 	}
+	if p.TextSearchConfig != other.TextSearchConfig {
+		return false
+	}
 	return true
 }
 
@@ -139,6 +154,12 @@ func (r *GormRepository) Save(ctx context.Context, p *Space) (*Space, error) {
 	if err := tx.Error; err != nil {
 		return nil, errors.NewInternalError(err.Error())
 	}
+	if p.TextSearchConfig == "" {
+		p.TextSearchConfig = pr.TextSearchConfig
+	}
+	if err := r.checkTextSearchConfig(p.TextSearchConfig); err != nil {
+		return nil, err
+	}
 	tx = tx.Where("Version = ?", oldVersion).Save(p)
 	if err := tx.Error; err != nil {
 		if gormsupport.IsCheckViolation(tx.Error, "spaces_name_check") {
@@ -166,6 +187,12 @@ func (r *GormRepository) Create(ctx context.Context, space *Space) (*Space, erro
 	if space.ID == satoriuuid.Nil {
 		space.ID = satoriuuid.NewV4()
 	}
+	if space.TextSearchConfig == "" {
+		space.TextSearchConfig = DefaultTextSearchConfig
+	}
+	if err := r.checkTextSearchConfig(space.TextSearchConfig); err != nil {
+		return nil, err
+	}
 
 	tx := r.db.Create(space)
 	if err := tx.Error; err != nil {
@@ -184,6 +211,19 @@ func (r *GormRepository) Create(ctx context.Context, space *Space) (*Space, erro
 	return space, nil
 }
 
+// checkTextSearchConfig makes sure the text search configuration is known to the database
+// returns BadParameterError or InternalError
+func (r *GormRepository) checkTextSearchConfig(config string) error {
+	var count int
+	if err := r.db.Table("pg_ts_config").Where("cfgname = ?", config).Count(&count).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	if count == 0 {
+		return errors.NewBadParameterError("TextSearchConfig", config).Expected("a text search configuration of the database")
+	}
+	return nil
+}
+
 // extracted this function from List() in order to close the rows object with "defer" for more readability
 // workaround for https://github.com/lib/pq/issues/81
 func (r *GormRepository) listSpaceFromDB(ctx context.Context, q *string, start *int, limit *int) ([]*Space, uint64, error) {
@@ -74,6 +74,24 @@ func (test *repoBBTest) TestSaveFail() {
 	expectSpace(test.save(*p1), test.assertBadParameter())
 }
 
+func (test *repoBBTest) TestTextSearchConfig() {
+	res, _ := expectSpace(test.create(testSpace), test.requireOk)
+	assert.Equal(test.T(), space.DefaultTextSearchConfig, res.TextSearchConfig)
+
+	res.TextSearchConfig = "german"
+	res2, _ := expectSpace(test.save(*res), test.requireOk)
+	assert.Equal(test.T(), "german", res2.TextSearchConfig)
+
+	res2.TextSearchConfig = "klingon"
+	expectSpace(test.save(*res2), test.assertBadParameter())
+
+	klingon := space.Space{
+		Name:             testSpace2,
+		TextSearchConfig: "klingon",
+	}
+	expectSpace(func() (*space.Space, error) { return test.repo.Create(context.Background(), &klingon) }, test.assertBadParameter())
+}
+
 func (test *repoBBTest) TestSaveNew() {
 	p := space.Space{
 		ID:      satoriuuid.NewV4(),
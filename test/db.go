@@ -6,6 +6,8 @@ import (
 	"github.com/almighty/almighty-core/area"
 	"github.com/almighty/almighty-core/comment"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/label"
+	"github.com/almighty/almighty-core/query"
 	"github.com/almighty/almighty-core/space"
 	"github.com/almighty/almighty-core/workitem"
 	"github.com/almighty/almighty-core/workitem/link"
@@ -66,6 +68,14 @@ func (db *MockDB) Areas() area.Repository {
 	return nil
 }
 
+func (db *MockDB) Queries() query.Repository {
+	return nil
+}
+
+func (db *MockDB) Labels() label.Repository {
+	return nil
+}
+
 func (db *MockDB) Commit() error {
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/workitem"
 	uuid "github.com/satori/go.uuid"
 	"golang.org/x/net/context"
@@ -33,6 +34,19 @@ type WorkItemRepository struct {
 		result1 *app.WorkItem
 		result2 error
 	}
+	PatchStub        func(ctx context.Context, ID string, version int, fields map[string]interface{}, modifierID uuid.UUID) (*app.WorkItem, error)
+	patchMutex       sync.RWMutex
+	patchArgsForCall []struct {
+		ctx        context.Context
+		ID         string
+		version    int
+		fields     map[string]interface{}
+		modifierID uuid.UUID
+	}
+	patchReturns struct {
+		result1 *app.WorkItem
+		result2 error
+	}
 	DeleteStub        func(ctx context.Context, ID string, suppressorID uuid.UUID) error
 	deleteMutex       sync.RWMutex
 	deleteArgsForCall []struct {
@@ -56,11 +70,12 @@ type WorkItemRepository struct {
 		result1 *app.WorkItem
 		result2 error
 	}
-	ListStub        func(ctx context.Context, criteria criteria.Expression, start *int, length *int) ([]*app.WorkItem, uint64, error)
+	ListStub        func(ctx context.Context, criteria criteria.Expression, order []workitem.SortKey, start *int, length *int) ([]*app.WorkItem, uint64, error)
 	listMutex       sync.RWMutex
 	listArgsForCall []struct {
 		ctx      context.Context
 		criteria criteria.Expression
+		order    []workitem.SortKey
 		start    *int
 		length   *int
 	}
@@ -69,6 +84,19 @@ type WorkItemRepository struct {
 		result2 uint64
 		result3 error
 	}
+	ListPageStub        func(ctx context.Context, criteria criteria.Expression, order []workitem.SortKey, keyset pagination.Keyset) ([]*app.WorkItem, *pagination.Page, error)
+	listPageMutex       sync.RWMutex
+	listPageArgsForCall []struct {
+		ctx      context.Context
+		criteria criteria.Expression
+		order    []workitem.SortKey
+		keyset   pagination.Keyset
+	}
+	listPageReturns struct {
+		result1 []*app.WorkItem
+		result2 *pagination.Page
+		result3 error
+	}
 	FetchStub        func(ctx context.Context, criteria criteria.Expression) (*app.WorkItem, error)
 	fetchMutex       sync.RWMutex
 	fetchArgsForCall []struct {
@@ -99,6 +127,17 @@ type WorkItemRepository struct {
 		result1 map[string]workitem.WICountsPerIteration
 		result2 error
 	}
+	AggregateStub        func(ctx context.Context, criteria criteria.Expression, groupBy []string) ([]workitem.AggregationBucket, error)
+	aggregateMutex       sync.RWMutex
+	aggregateArgsForCall []struct {
+		ctx      context.Context
+		criteria criteria.Expression
+		groupBy  []string
+	}
+	aggregateReturns struct {
+		result1 []workitem.AggregationBucket
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -172,6 +211,43 @@ func (fake *WorkItemRepository) SaveReturns(result1 *app.WorkItem, result2 error
 	}{result1, result2}
 }
 
+func (fake *WorkItemRepository) Patch(ctx context.Context, ID string, version int, fields map[string]interface{}, modifierID uuid.UUID) (*app.WorkItem, error) {
+	fake.patchMutex.Lock()
+	fake.patchArgsForCall = append(fake.patchArgsForCall, struct {
+		ctx        context.Context
+		ID         string
+		version    int
+		fields     map[string]interface{}
+		modifierID uuid.UUID
+	}{ctx, ID, version, fields, modifierID})
+	fake.recordInvocation("Patch", []interface{}{ctx, ID, version, fields, modifierID})
+	fake.patchMutex.Unlock()
+	if fake.PatchStub != nil {
+		return fake.PatchStub(ctx, ID, version, fields, modifierID)
+	}
+	return fake.patchReturns.result1, fake.patchReturns.result2
+}
+
+func (fake *WorkItemRepository) PatchCallCount() int {
+	fake.patchMutex.RLock()
+	defer fake.patchMutex.RUnlock()
+	return len(fake.patchArgsForCall)
+}
+
+func (fake *WorkItemRepository) PatchArgsForCall(i int) (context.Context, string, int, map[string]interface{}, uuid.UUID) {
+	fake.patchMutex.RLock()
+	defer fake.patchMutex.RUnlock()
+	return fake.patchArgsForCall[i].ctx, fake.patchArgsForCall[i].ID, fake.patchArgsForCall[i].version, fake.patchArgsForCall[i].fields, fake.patchArgsForCall[i].modifierID
+}
+
+func (fake *WorkItemRepository) PatchReturns(result1 *app.WorkItem, result2 error) {
+	fake.PatchStub = nil
+	fake.patchReturns = struct {
+		result1 *app.WorkItem
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *WorkItemRepository) Delete(ctx context.Context, ID string, suppressorID uuid.UUID) error {
 	fake.deleteMutex.Lock()
 	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
@@ -243,18 +319,24 @@ func (fake *WorkItemRepository) CreateReturns(result1 *app.WorkItem, result2 err
 	}{result1, result2}
 }
 
-func (fake *WorkItemRepository) List(ctx context.Context, c criteria.Expression, start *int, length *int) ([]*app.WorkItem, uint64, error) {
+func (fake *WorkItemRepository) List(ctx context.Context, c criteria.Expression, order []workitem.SortKey, start *int, length *int) ([]*app.WorkItem, uint64, error) {
+	var orderCopy []workitem.SortKey
+	if order != nil {
+		orderCopy = make([]workitem.SortKey, len(order))
+		copy(orderCopy, order)
+	}
 	fake.listMutex.Lock()
 	fake.listArgsForCall = append(fake.listArgsForCall, struct {
 		ctx      context.Context
 		criteria criteria.Expression
+		order    []workitem.SortKey
 		start    *int
 		length   *int
-	}{ctx, c, start, length})
-	fake.recordInvocation("List", []interface{}{ctx, c, start, length})
+	}{ctx, c, orderCopy, start, length})
+	fake.recordInvocation("List", []interface{}{ctx, c, orderCopy, start, length})
 	fake.listMutex.Unlock()
 	if fake.ListStub != nil {
-		return fake.ListStub(ctx, c, start, length)
+		return fake.ListStub(ctx, c, order, start, length)
 	}
 	return fake.listReturns.result1, fake.listReturns.result2, fake.listReturns.result3
 }
@@ -265,10 +347,10 @@ func (fake *WorkItemRepository) ListCallCount() int {
 	return len(fake.listArgsForCall)
 }
 
-func (fake *WorkItemRepository) ListArgsForCall(i int) (context.Context, criteria.Expression, *int, *int) {
+func (fake *WorkItemRepository) ListArgsForCall(i int) (context.Context, criteria.Expression, []workitem.SortKey, *int, *int) {
 	fake.listMutex.RLock()
 	defer fake.listMutex.RUnlock()
-	return fake.listArgsForCall[i].ctx, fake.listArgsForCall[i].criteria, fake.listArgsForCall[i].start, fake.listArgsForCall[i].length
+	return fake.listArgsForCall[i].ctx, fake.listArgsForCall[i].criteria, fake.listArgsForCall[i].order, fake.listArgsForCall[i].start, fake.listArgsForCall[i].length
 }
 
 func (fake *WorkItemRepository) ListReturns(result1 []*app.WorkItem, result2 uint64, result3 error) {
@@ -280,6 +362,48 @@ func (fake *WorkItemRepository) ListReturns(result1 []*app.WorkItem, result2 uin
 	}{result1, result2, result3}
 }
 
+func (fake *WorkItemRepository) ListPage(ctx context.Context, c criteria.Expression, order []workitem.SortKey, keyset pagination.Keyset) ([]*app.WorkItem, *pagination.Page, error) {
+	var orderCopy []workitem.SortKey
+	if order != nil {
+		orderCopy = make([]workitem.SortKey, len(order))
+		copy(orderCopy, order)
+	}
+	fake.listPageMutex.Lock()
+	fake.listPageArgsForCall = append(fake.listPageArgsForCall, struct {
+		ctx      context.Context
+		criteria criteria.Expression
+		order    []workitem.SortKey
+		keyset   pagination.Keyset
+	}{ctx, c, orderCopy, keyset})
+	fake.recordInvocation("ListPage", []interface{}{ctx, c, orderCopy, keyset})
+	fake.listPageMutex.Unlock()
+	if fake.ListPageStub != nil {
+		return fake.ListPageStub(ctx, c, order, keyset)
+	}
+	return fake.listPageReturns.result1, fake.listPageReturns.result2, fake.listPageReturns.result3
+}
+
+func (fake *WorkItemRepository) ListPageCallCount() int {
+	fake.listPageMutex.RLock()
+	defer fake.listPageMutex.RUnlock()
+	return len(fake.listPageArgsForCall)
+}
+
+func (fake *WorkItemRepository) ListPageArgsForCall(i int) (context.Context, criteria.Expression, []workitem.SortKey, pagination.Keyset) {
+	fake.listPageMutex.RLock()
+	defer fake.listPageMutex.RUnlock()
+	return fake.listPageArgsForCall[i].ctx, fake.listPageArgsForCall[i].criteria, fake.listPageArgsForCall[i].order, fake.listPageArgsForCall[i].keyset
+}
+
+func (fake *WorkItemRepository) ListPageReturns(result1 []*app.WorkItem, result2 *pagination.Page, result3 error) {
+	fake.ListPageStub = nil
+	fake.listPageReturns = struct {
+		result1 []*app.WorkItem
+		result2 *pagination.Page
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *WorkItemRepository) Fetch(ctx context.Context, c criteria.Expression) (*app.WorkItem, error) {
 	fake.fetchMutex.Lock()
 	fake.fetchArgsForCall = append(fake.fetchArgsForCall, struct {
@@ -382,6 +506,46 @@ func (fake *WorkItemRepository) GetCountsForIterationReturns(result1 map[string]
 	}{result1, result2}
 }
 
+func (fake *WorkItemRepository) Aggregate(ctx context.Context, criteria criteria.Expression, groupBy []string) ([]workitem.AggregationBucket, error) {
+	var groupByCopy []string
+	if groupBy != nil {
+		groupByCopy = make([]string, len(groupBy))
+		copy(groupByCopy, groupBy)
+	}
+	fake.aggregateMutex.Lock()
+	fake.aggregateArgsForCall = append(fake.aggregateArgsForCall, struct {
+		ctx      context.Context
+		criteria criteria.Expression
+		groupBy  []string
+	}{ctx, criteria, groupByCopy})
+	fake.recordInvocation("Aggregate", []interface{}{ctx, criteria, groupByCopy})
+	fake.aggregateMutex.Unlock()
+	if fake.AggregateStub != nil {
+		return fake.AggregateStub(ctx, criteria, groupBy)
+	}
+	return fake.aggregateReturns.result1, fake.aggregateReturns.result2
+}
+
+func (fake *WorkItemRepository) AggregateCallCount() int {
+	fake.aggregateMutex.RLock()
+	defer fake.aggregateMutex.RUnlock()
+	return len(fake.aggregateArgsForCall)
+}
+
+func (fake *WorkItemRepository) AggregateArgsForCall(i int) (context.Context, criteria.Expression, []string) {
+	fake.aggregateMutex.RLock()
+	defer fake.aggregateMutex.RUnlock()
+	return fake.aggregateArgsForCall[i].ctx, fake.aggregateArgsForCall[i].criteria, fake.aggregateArgsForCall[i].groupBy
+}
+
+func (fake *WorkItemRepository) AggregateReturns(result1 []workitem.AggregationBucket, result2 error) {
+	fake.AggregateStub = nil
+	fake.aggregateReturns = struct {
+		result1 []workitem.AggregationBucket
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *WorkItemRepository) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -389,18 +553,24 @@ func (fake *WorkItemRepository) Invocations() map[string][][]interface{} {
 	defer fake.loadMutex.RUnlock()
 	fake.saveMutex.RLock()
 	defer fake.saveMutex.RUnlock()
+	fake.patchMutex.RLock()
+	defer fake.patchMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
 	fake.createMutex.RLock()
 	defer fake.createMutex.RUnlock()
 	fake.listMutex.RLock()
 	defer fake.listMutex.RUnlock()
+	fake.listPageMutex.RLock()
+	defer fake.listPageMutex.RUnlock()
 	fake.fetchMutex.RLock()
 	defer fake.fetchMutex.RUnlock()
 	fake.getCountsPerIterationMutex.RLock()
 	defer fake.getCountsPerIterationMutex.RUnlock()
 	fake.getCountsForIterationMutex.RLock()
 	defer fake.getCountsForIterationMutex.RUnlock()
+	fake.aggregateMutex.RLock()
+	defer fake.aggregateMutex.RUnlock()
 	return fake.invocations
 }
 
@@ -0,0 +1,126 @@
+package workitem
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/almighty/almighty-core/errors"
+)
+
+// MaxGroupByFields is the maximum number of fields work items can be grouped by in an aggregation
+const MaxGroupByFields = 2
+
+// AggregationBucket holds the aggregated values of the work items sharing the same values of the group-by fields
+type AggregationBucket struct {
+	// Keys holds the values of the group-by fields in the order of the fields, nil for work items without a value
+	Keys []*string
+	// Count is the number of work items in the bucket
+	Count uint64
+	// Sums maps the names of the numeric fields to the sum of their values in the bucket
+	Sums map[string]float64
+}
+
+// groupableKinds are the kinds of fields work items can be grouped by, they have a small set of values
+var groupableKinds = map[Kind]bool{
+	KindEnum:      true,
+	KindUser:      true,
+	KindArea:      true,
+	KindIteration: true,
+	KindBoolean:   true,
+	KindLabel:     true,
+}
+
+// numericKinds are the kinds of fields which are summed up in aggregations
+var numericKinds = map[Kind]bool{
+	KindInteger:  true,
+	KindFloat:    true,
+	KindDuration: true,
+}
+
+// aggregation holds the parts of the select statement which aggregates the work items
+type aggregation struct {
+	// keys are the expressions selecting the values of the group-by fields
+	keys []string
+	// joins unnest list fields, an item is in the bucket of each value of its list
+	joins []string
+	// sumFields are the names of the numeric fields which are summed up
+	sumFields []string
+}
+
+// newAggregation checks that the work items can be grouped by the given fields and builds the parts of the
+// select statement. Fields have to be the work item type or enum, user, area, iteration, boolean or label fields or
+// lists of those.
+// returns BadParameterError if a field can't be used for grouping
+func newAggregation(groupBy []string, fields map[string][]FieldDefinition) (*aggregation, error) {
+	if len(groupBy) == 0 || len(groupBy) > MaxGroupByFields {
+		return nil, errors.NewBadParameterError("group-by", strings.Join(groupBy, ",")).Expected(fmt.Sprintf("1 to %d fields", MaxGroupByFields))
+	}
+	result := aggregation{}
+	for i, name := range groupBy {
+		if name == "Type" {
+			result.keys = append(result.keys, "work_items.type::text")
+			continue
+		}
+		groupable, list := groupableField(fields[name])
+		if !groupable || !isJSONField(name) || strings.Contains(name, "'") {
+			return nil, errors.NewBadParameterError("group-by", name).Expected("the type or an enum, user, area, iteration, boolean or label field or a list of those")
+		}
+		if !list {
+			result.keys = append(result.keys, "work_items.fields->>'"+name+"'")
+			continue
+		}
+		value := "work_items.fields->'" + name + "'"
+		alias := fmt.Sprintf("group_by_%d", i)
+		result.joins = append(result.joins, fmt.Sprintf(
+			"left join lateral jsonb_array_elements_text(case jsonb_typeof(%s) when 'array' then %s else '[]'::jsonb end) as %s(value) on true",
+			value, value, alias))
+		result.keys = append(result.keys, alias+".value")
+	}
+	for name, definitions := range fields {
+		for _, definition := range definitions {
+			if numericKinds[definition.Type.GetKind()] && !strings.Contains(name, "'") {
+				result.sumFields = append(result.sumFields, name)
+				break
+			}
+		}
+	}
+	sort.Strings(result.sumFields)
+	return &result, nil
+}
+
+// groupableField tells whether one of the definitions of a field allows grouping by it and whether the field holds a list
+func groupableField(definitions []FieldDefinition) (groupable bool, list bool) {
+	for _, definition := range definitions {
+		if groupableKinds[elementType(definition.Type).GetKind()] {
+			groupable = true
+			if _, ok := definition.Type.(ListType); ok {
+				list = true
+			}
+		}
+	}
+	return groupable, list
+}
+
+// selectClause returns the select list, the keys followed by the count and the sums
+func (a aggregation) selectClause() string {
+	columns := make([]string, 0, len(a.keys)+1+len(a.sumFields))
+	for i, key := range a.keys {
+		columns = append(columns, fmt.Sprintf("%s as key_%d", key, i))
+	}
+	columns = append(columns, "count(*) as count")
+	for i, name := range a.sumFields {
+		// other types may define the field differently, so only numbers are summed up
+		columns = append(columns, fmt.Sprintf("sum(case jsonb_typeof(work_items.fields->'%s') when 'number' then (work_items.fields->>'%s')::numeric end) as sum_%d", name, name, i))
+	}
+	return strings.Join(columns, ", ")
+}
+
+// groupClause returns the positions of the keys in the select list, used for grouping and ordering
+func (a aggregation) groupClause() string {
+	positions := make([]string, len(a.keys))
+	for i := range a.keys {
+		positions[i] = fmt.Sprint(i + 1)
+	}
+	return strings.Join(positions, ", ")
+}
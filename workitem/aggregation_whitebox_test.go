@@ -0,0 +1,65 @@
+package workitem
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var aggregationFields = map[string][]FieldDefinition{
+	SystemState: {{Type: EnumType{
+		SimpleType: SimpleType{Kind: KindEnum},
+		BaseType:   SimpleType{Kind: KindString},
+		Values:     []interface{}{SystemStateNew, SystemStateClosed},
+	}}},
+	SystemAssignees: {{Type: ListType{
+		SimpleType:    SimpleType{Kind: KindList},
+		ComponentType: SimpleType{Kind: KindUser},
+	}}},
+	SystemTitle:    {{Type: SimpleType{Kind: KindString}}},
+	"story_points": {{Type: SimpleType{Kind: KindInteger}}},
+	"effort":       {{Type: SimpleType{Kind: KindString}}, {Type: SimpleType{Kind: KindFloat}}},
+}
+
+func TestNewAggregation(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	a, err := newAggregation([]string{"Type", SystemState}, aggregationFields)
+	require.Nil(t, err)
+	assert.Empty(t, a.joins)
+	assert.Equal(t, []string{"effort", "story_points"}, a.sumFields)
+	assert.Equal(t, "work_items.type::text as key_0, work_items.fields->>'system.state' as key_1, count(*) as count, "+
+		"sum(case jsonb_typeof(work_items.fields->'effort') when 'number' then (work_items.fields->>'effort')::numeric end) as sum_0, "+
+		"sum(case jsonb_typeof(work_items.fields->'story_points') when 'number' then (work_items.fields->>'story_points')::numeric end) as sum_1",
+		a.selectClause())
+	assert.Equal(t, "1, 2", a.groupClause())
+
+	// list fields are unnested
+	a, err = newAggregation([]string{SystemAssignees}, aggregationFields)
+	require.Nil(t, err)
+	require.Len(t, a.joins, 1)
+	assert.Contains(t, a.joins[0], "jsonb_array_elements_text(")
+	assert.Equal(t, []string{"group_by_0.value"}, a.keys)
+	assert.Equal(t, "1", a.groupClause())
+}
+
+func TestNewAggregationErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	for _, groupBy := range [][]string{
+		nil,
+		{SystemState, SystemAssignees, "Type"},
+		{SystemTitle},
+		{"story_points"},
+		{"no.such.field"},
+		{"ID"},
+	} {
+		_, err := newAggregation(groupBy, aggregationFields)
+		require.NotNil(t, err, "expected an error for %v", groupBy)
+		assert.IsType(t, errors.BadParameterError{}, errs.Cause(err))
+	}
+}
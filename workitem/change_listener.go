@@ -0,0 +1,54 @@
+package workitem
+
+import (
+	"sync"
+
+	"github.com/almighty/almighty-core/gormsupport"
+
+	"github.com/jinzhu/gorm"
+	"golang.org/x/net/context"
+)
+
+// ChangeListener is notified about the work items the GormWorkItemRepository creates, saves and deletes,
+// e.g. to keep a search index in sync. The notifications are queued on the transaction of the change and
+// only sent after it was committed, changes which are rolled back aren't notified.
+type ChangeListener interface {
+	// WorkItemChanged is called with the ID of a created or saved work item or of a work item whose comments
+	// changed
+	WorkItemChanged(ctx context.Context, id uint64)
+	// WorkItemDeleted is called with the ID of a deleted work item
+	WorkItemDeleted(ctx context.Context, id uint64)
+}
+
+var changeListeners []ChangeListener
+var changeListenersLock sync.RWMutex
+
+// RegisterChangeListener adds a listener which is notified about all following work item changes
+func RegisterChangeListener(listener ChangeListener) {
+	changeListenersLock.Lock()
+	defer changeListenersLock.Unlock()
+	changeListeners = append(changeListeners, listener)
+}
+
+// NotifyChanged notifies the listeners about the change of the work item with the given ID once the transaction of
+// db is committed. The comment repository calls it too, as the comments are part of the searchable text of a work item.
+func NotifyChanged(ctx context.Context, db *gorm.DB, id uint64) {
+	gormsupport.AfterCommit(db, func() {
+		changeListenersLock.RLock()
+		defer changeListenersLock.RUnlock()
+		for _, listener := range changeListeners {
+			listener.WorkItemChanged(ctx, id)
+		}
+	})
+}
+
+// notifyDeleted notifies the listeners about the deletion of the work item once the transaction of db is committed
+func notifyDeleted(ctx context.Context, db *gorm.DB, id uint64) {
+	gormsupport.AfterCommit(db, func() {
+		changeListenersLock.RLock()
+		defer changeListenersLock.RUnlock()
+		for _, listener := range changeListeners {
+			listener.WorkItemDeleted(ctx, id)
+		}
+	})
+}
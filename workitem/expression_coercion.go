@@ -0,0 +1,368 @@
+package workitem
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DateLayout is the layout of days, e.g. of date fields and of instants given as a day, which is taken in UTC
+const DateLayout = "2006-01-02"
+
+// CoerceLiterals resolves the json fields the expression compares with literal values against the given
+// field definitions and converts the literals to the representation the field values are stored in, so that
+// e.g. an instant given as "2017-01-31" is compared with the nanoseconds stored for the field. A field name
+// may be defined by several work item types, its literals have to fit one of the definitions. The literals
+// are replaced in place.
+// Returns a BadParameterError naming the field and the expected kind of value if a literal doesn't fit the
+// field or if no work item type defines the field.
+func CoerceLiterals(where criteria.Expression, fields map[string][]FieldDefinition) error {
+	var err error
+	criteria.IteratePostOrder(where, func(exp criteria.Expression) bool {
+		switch t := exp.(type) {
+		case *criteria.EqualsExpression, *criteria.NotEqualsExpression,
+			*criteria.GreaterThanExpression, *criteria.GreaterOrEqualsExpression,
+			*criteria.LessThanExpression, *criteria.LessOrEqualsExpression:
+			err = coerceComparison(t.(criteria.BinaryExpression), fields, false)
+		case *criteria.InExpression:
+			err = coerceComparison(t, fields, true)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// coerceComparison coerces the literal on the right side of a comparison with a json field. The literal
+// holds a list of values for the "in" operator.
+func coerceComparison(exp criteria.BinaryExpression, fields map[string][]FieldDefinition, in bool) error {
+	field, isField := exp.Left().(*criteria.FieldExpression)
+	literal, isLiteral := exp.Right().(*criteria.LiteralExpression)
+	if !isField || !isLiteral || !isJSONField(field.FieldName) || literal.Value == nil {
+		// the compiler reports expressions it can't handle
+		return nil
+	}
+	definitions, ok := fields[field.FieldName]
+	if !ok || len(definitions) == 0 {
+		return errors.NewBadParameterError(field.FieldName, literal.Value).Expected("a field of the work item types")
+	}
+	for _, definition := range definitions {
+		var coerced interface{}
+		var ok bool
+		if in {
+			coerced, ok = coerceList(elementType(definition.Type), literal.Value)
+		} else {
+			coerced, ok = coerceValue(definition.Type, literal.Value)
+		}
+		if ok {
+			literal.Value = coerced
+			return nil
+		}
+	}
+	expected := expectedKind(definitions[0].Type)
+	if in {
+		expected = "a list of " + expected
+	}
+	return errors.NewBadParameterError(field.FieldName, literal.Value).Expected(expected)
+}
+
+// elementType returns the type of the values a field holds, which is the component type for list fields
+func elementType(fieldType FieldType) FieldType {
+	if list, ok := fieldType.(ListType); ok {
+		return list.ComponentType
+	}
+	return fieldType
+}
+
+// coerceValue converts a literal compared with a field of the given type. Comparing a list field with a
+// list tests for containment, so the elements of the list are converted.
+func coerceValue(fieldType FieldType, value interface{}) (interface{}, bool) {
+	switch t := fieldType.(type) {
+	case ListType:
+		if isList(value) {
+			return coerceList(t.ComponentType, value)
+		}
+		return coerceValue(t.ComponentType, value)
+	case EnumType:
+		coerced, ok := coerceValue(t.BaseType, value)
+		if !ok {
+			return nil, false
+		}
+		for _, allowed := range t.Values {
+			if equalValues(allowed, coerced) {
+				return coerced, true
+			}
+		}
+		return nil, false
+	case SimpleType:
+		return coerceSimpleValue(t.Kind, value)
+	}
+	return value, true
+}
+
+// coerceList converts all elements of a list literal. []string literals stay []string as long as the
+// converted elements are strings, since the compiler embeds them in containment tests.
+func coerceList(fieldType FieldType, value interface{}) (interface{}, bool) {
+	if !isList(value) {
+		return nil, false
+	}
+	values := reflect.ValueOf(value)
+	result := make([]interface{}, values.Len())
+	allStrings := true
+	for i := range result {
+		coerced, ok := coerceValue(fieldType, values.Index(i).Interface())
+		if !ok {
+			return nil, false
+		}
+		if _, isString := coerced.(string); !isString {
+			allStrings = false
+		}
+		result[i] = coerced
+	}
+	if _, isStrings := value.([]string); isStrings && allStrings {
+		strs := make([]string, len(result))
+		for i, s := range result {
+			strs[i] = s.(string)
+		}
+		return strs, true
+	}
+	return result, true
+}
+
+func isList(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	kind := reflect.TypeOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// coerceSimpleValue converts a literal to the stored representation of the given kind of field. The values of
+// kinds without a dedicated conversion are left as they are.
+func coerceSimpleValue(kind Kind, value interface{}) (interface{}, bool) {
+	switch kind {
+	case KindInteger, KindDuration:
+		return integerValue(value)
+	case KindFloat:
+		if s, ok := value.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			return f, err == nil
+		}
+		return floatValue(value)
+	case KindInstant:
+		switch t := value.(type) {
+		case time.Time:
+			return t.UnixNano(), true
+		case string:
+			if instant, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(t)); err == nil {
+				return instant.UnixNano(), true
+			}
+			if day, err := time.Parse(DateLayout, strings.TrimSpace(t)); err == nil {
+				return day.UnixNano(), true
+			}
+		}
+		// instants are stored as nanoseconds since the epoch
+		return integerValue(value)
+	case KindUser, KindIteration, KindArea:
+		switch t := value.(type) {
+		case uuid.UUID:
+			return t.String(), true
+		case string:
+			// ids are stored in their canonical form
+			id, err := uuid.FromString(strings.TrimSpace(t))
+			return id.String(), err == nil
+		}
+		return nil, false
+	case KindBoolean:
+		switch t := value.(type) {
+		case bool:
+			return t, true
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(t))
+			return b, err == nil
+		}
+		return nil, false
+	case KindPercentage:
+		if s, ok := value.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, false
+			}
+			value = f
+		}
+		fallthrough
+	case KindDate, KindLabel:
+		// the literals are checked and normalized like the stored values
+		coerced, err := SimpleType{Kind: kind}.ConvertToModel(value)
+		return coerced, err == nil
+	}
+	return value, true
+}
+
+// integerValue converts numbers without a fractional part and strings holding such numbers to int64
+func integerValue(value interface{}) (interface{}, bool) {
+	switch t := value.(type) {
+	case int:
+		return int64(t), true
+	case int64:
+		return t, true
+	case uint:
+		return int64(t), uint64(t) <= math.MaxInt64
+	case uint64:
+		return int64(t), t <= math.MaxInt64
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(t), 10, 64)
+		return i, err == nil
+	}
+	f, ok := floatValue(value)
+	if !ok {
+		return nil, false
+	}
+	i := f.(float64)
+	if i != math.Trunc(i) || math.Abs(i) > math.MaxInt64 {
+		return nil, false
+	}
+	return int64(i), true
+}
+
+// floatValue converts numbers to float64
+func floatValue(value interface{}) (interface{}, bool) {
+	switch t := value.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case uint:
+		return float64(t), true
+	case uint64:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return nil, false
+}
+
+// equalValues compares enum values, numbers compare by value no matter what their type is,
+// since values read from the type definitions are float64
+func equalValues(a, b interface{}) bool {
+	if fa, ok := floatValue(a); ok {
+		fb, ok := floatValue(b)
+		return ok && fa == fb
+	}
+	return a == b
+}
+
+// expectedKind describes the values accepted for a field of the given type
+func expectedKind(fieldType FieldType) string {
+	switch t := fieldType.(type) {
+	case ListType:
+		return expectedKind(t.ComponentType)
+	case EnumType:
+		values := make([]string, len(t.Values))
+		for i, value := range t.Values {
+			values[i] = fmt.Sprint(value)
+		}
+		return fmt.Sprintf("%s value, one of: %s", KindEnum, strings.Join(values, ", "))
+	}
+	switch kind := fieldType.GetKind(); kind {
+	case KindInteger, KindDuration:
+		return fmt.Sprintf("%s value, a whole number", kind)
+	case KindFloat:
+		return fmt.Sprintf("%s value, a number", kind)
+	case KindInstant:
+		return fmt.Sprintf("%s value, a date like %s or an RFC 3339 date and time", kind, DateLayout)
+	case KindUser, KindIteration, KindArea:
+		return fmt.Sprintf("%s value, a UUID", kind)
+	case KindBoolean:
+		return fmt.Sprintf("%s value, true or false", kind)
+	case KindDate:
+		return fmt.Sprintf("%s value, a date like %s", kind, DateLayout)
+	case KindLabel:
+		return fmt.Sprintf("%s value, a text of 1 to %d characters", kind, MaxLabelLength)
+	case KindPercentage:
+		return fmt.Sprintf("%s value, a number from 0 to 100", kind)
+	default:
+		return fmt.Sprintf("%s value", kind)
+	}
+}
+
+// TypeRestriction returns the IDs of the work item types the expression restricts the work items to
+// with a top level condition like `Type = "..."` or `Type in (...)`, or nil if it doesn't restrict the type.
+func TypeRestriction(where criteria.Expression) []uuid.UUID {
+	switch t := where.(type) {
+	case *criteria.AndExpression:
+		if ids := TypeRestriction(t.Left()); ids != nil {
+			return ids
+		}
+		return TypeRestriction(t.Right())
+	case *criteria.EqualsExpression, *criteria.InExpression:
+		b := t.(criteria.BinaryExpression)
+		field, isField := b.Left().(*criteria.FieldExpression)
+		literal, isLiteral := b.Right().(*criteria.LiteralExpression)
+		if !isField || !isLiteral || field.FieldName != "Type" {
+			return nil
+		}
+		return typeIDs(literal.Value)
+	}
+	return nil
+}
+
+// typeIDs converts a literal compared with the work item type to the type IDs, returns nil if
+// that isn't possible
+func typeIDs(value interface{}) []uuid.UUID {
+	switch t := value.(type) {
+	case uuid.UUID:
+		return []uuid.UUID{t}
+	case string:
+		id, err := uuid.FromString(t)
+		if err != nil {
+			return nil
+		}
+		return []uuid.UUID{id}
+	}
+	if !isList(value) {
+		return nil
+	}
+	values := reflect.ValueOf(value)
+	result := []uuid.UUID{}
+	for i := 0; i < values.Len(); i++ {
+		ids := typeIDs(values.Index(i).Interface())
+		if ids == nil {
+			return nil
+		}
+		result = append(result, ids...)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// ReferencedFields returns the names of the json fields the expression refers to, each name once and in
+// sorted order
+func ReferencedFields(where criteria.Expression) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	criteria.IteratePostOrder(where, func(exp criteria.Expression) bool {
+		if field, ok := exp.(*criteria.FieldExpression); ok && isJSONField(field.FieldName) && !seen[field.FieldName] {
+			seen[field.FieldName] = true
+			result = append(result, field.FieldName)
+		}
+		return true
+	})
+	sort.Strings(result)
+	return result
+}
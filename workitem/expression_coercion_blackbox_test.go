@@ -0,0 +1,144 @@
+package workitem_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+	. "github.com/almighty/almighty-core/workitem"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var coercionFields = map[string][]FieldDefinition{
+	SystemState: {{Type: EnumType{
+		SimpleType: SimpleType{Kind: KindEnum},
+		BaseType:   SimpleType{Kind: KindString},
+		Values:     []interface{}{SystemStateNew, SystemStateClosed},
+	}}},
+	SystemAssignees: {{Type: ListType{
+		SimpleType:    SimpleType{Kind: KindList},
+		ComponentType: SimpleType{Kind: KindUser},
+	}}},
+	SystemIteration: {{Type: SimpleType{Kind: KindIteration}}},
+	SystemTitle:     {{Type: SimpleType{Kind: KindString}}},
+	"due":           {{Type: SimpleType{Kind: KindInstant}}},
+	"flagged":       {{Type: SimpleType{Kind: KindBoolean}}},
+	"start":         {{Type: SimpleType{Kind: KindDate}}},
+	"progress":      {{Type: SimpleType{Kind: KindPercentage}}},
+	"labels": {{Type: ListType{
+		SimpleType:    SimpleType{Kind: KindList},
+		ComponentType: SimpleType{Kind: KindLabel},
+	}}},
+	"priority": {
+		{Type: SimpleType{Kind: KindInteger}},
+		{Type: EnumType{
+			SimpleType: SimpleType{Kind: KindEnum},
+			BaseType:   SimpleType{Kind: KindInteger},
+			Values:     []interface{}{1.0, 2.0},
+		}},
+	},
+}
+
+// coerced returns the literal on the right side of the coerced comparison
+func coerced(t *testing.T, exp Expression) interface{} {
+	require.Nil(t, CoerceLiterals(exp, coercionFields))
+	return exp.(BinaryExpression).Right().(*LiteralExpression).Value
+}
+
+func TestCoerceLiterals(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	id := uuid.NewV4()
+	day := time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, SystemStateNew, coerced(t, Equals(Field(SystemState), Literal(SystemStateNew))))
+	assert.Equal(t, id.String(), coerced(t, Equals(Field(SystemIteration), Literal(strings.ToUpper(id.String())))))
+	assert.Equal(t, id.String(), coerced(t, Equals(Field(SystemIteration), Literal(id))))
+	assert.Equal(t, []string{id.String()}, coerced(t, Equals(Field(SystemAssignees), Literal([]string{strings.ToUpper(id.String())}))))
+	assert.Equal(t, day.UnixNano(), coerced(t, GreaterThan(Field("due"), Literal("2017-01-31"))))
+	assert.Equal(t, day.UnixNano(), coerced(t, LessOrEquals(Field("due"), Literal("2017-01-31T01:00:00+01:00"))))
+	assert.Equal(t, day.UnixNano(), coerced(t, LessOrEquals(Field("due"), Literal(day))))
+	assert.Equal(t, int64(3), coerced(t, Equals(Field("priority"), Literal(3.0))))
+	assert.Equal(t, int64(3), coerced(t, Equals(Field("priority"), Literal("3"))))
+	assert.Equal(t, []interface{}{SystemStateNew, SystemStateClosed}, coerced(t, In(Field(SystemState), Literal([]interface{}{SystemStateNew, SystemStateClosed}))))
+	assert.Equal(t, "anything", coerced(t, Equals(Field(SystemTitle), Literal("anything"))))
+	assert.Equal(t, true, coerced(t, Equals(Field("flagged"), Literal("true"))))
+	assert.Equal(t, "2017-01-31", coerced(t, GreaterThan(Field("start"), Literal(day))))
+	assert.Equal(t, 12.5, coerced(t, LessThan(Field("progress"), Literal("12.5"))))
+	assert.Equal(t, []string{"needs review"}, coerced(t, Equals(Field("labels"), Literal([]string{" needs  review"}))))
+	// columns and fields compared with null aren't coerced
+	assert.Equal(t, "abc", coerced(t, Equals(Field("ID"), Literal("abc"))))
+	assert.Nil(t, coerced(t, Equals(Field("unknown"), Literal(nil))))
+}
+
+func TestCoerceLiteralsInNestedExpressions(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	id := uuid.NewV4()
+	literal := Literal(strings.ToUpper(id.String())).(*LiteralExpression)
+	exp := And(Literal(true), Not(Or(Equals(Field(SystemState), Literal(SystemStateNew)), Equals(Field(SystemIteration), literal))))
+	require.Nil(t, CoerceLiterals(exp, coercionFields))
+	assert.Equal(t, id.String(), literal.Value)
+	// coercion is idempotent
+	require.Nil(t, CoerceLiterals(exp, coercionFields))
+	assert.Equal(t, id.String(), literal.Value)
+}
+
+func TestCoerceLiteralsErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	for _, test := range []struct {
+		exp      Expression
+		field    string
+		expected string
+	}{
+		{Equals(Field(SystemIteration), Literal("garbage")), SystemIteration, "iteration value"},
+		{Equals(Field(SystemAssignees), Literal([]string{"garbage"})), SystemAssignees, "user value"},
+		{NotEquals(Field(SystemState), Literal("no such state")), SystemState, "enum value, one of: new, closed"},
+		{In(Field(SystemState), Literal([]interface{}{SystemStateNew, "no such state"})), SystemState, "a list of enum value"},
+		{GreaterThan(Field("due"), Literal("yesterday")), "due", "instant value"},
+		{Equals(Field("priority"), Literal(1.5)), "priority", "integer value"},
+		{Equals(Field("flagged"), Literal("maybe")), "flagged", "boolean value"},
+		{Equals(Field("start"), Literal("tomorrow")), "start", "date value"},
+		{GreaterThan(Field("progress"), Literal(150)), "progress", "percentage value"},
+		{Equals(Field("labels"), Literal([]string{" "})), "labels", "label value"},
+		{Equals(Field("unknown"), Literal("value")), "unknown", "a field of the work item types"},
+	} {
+		err := CoerceLiterals(And(Literal(true), test.exp), coercionFields)
+		require.NotNil(t, err, "expected an error for %s", String(test.exp))
+		require.IsType(t, errors.BadParameterError{}, err)
+		assert.Contains(t, err.Error(), "'"+test.field+"'")
+		assert.Contains(t, err.Error(), test.expected)
+	}
+}
+
+func TestTypeRestriction(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	id := uuid.NewV4()
+	other := uuid.NewV4()
+
+	assert.Equal(t, []uuid.UUID{id}, TypeRestriction(Equals(Field("Type"), Literal(id))))
+	assert.Equal(t, []uuid.UUID{id}, TypeRestriction(And(Equals(Field(SystemState), Literal("new")), Equals(Field("Type"), Literal([]uuid.UUID{id})))))
+	assert.Equal(t, []uuid.UUID{id, other}, TypeRestriction(In(Field("Type"), Literal([]interface{}{id.String(), other.String()}))))
+	assert.Nil(t, TypeRestriction(Or(Equals(Field("Type"), Literal(id)), Literal(true))))
+	assert.Nil(t, TypeRestriction(Equals(Field("Type"), Literal("garbage"))))
+	assert.Nil(t, TypeRestriction(Literal(true)))
+}
+
+func TestReferencedFields(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	where := And(Or(Equals(Field(SystemState), Literal("new")), Not(Equals(Field("due"), Literal(nil)))), And(Equals(Field("Type"), Literal(uuid.NewV4())), In(Field(SystemState), Literal([]string{"open"}))))
+	// when
+	names := ReferencedFields(where)
+	// then
+	assert.Equal(t, []string{"due", SystemState}, names)
+	assert.Empty(t, ReferencedFields(Literal(true)))
+}
@@ -1,7 +1,9 @@
 package workitem
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -20,7 +22,9 @@ func Compile(where criteria.Expression) (whereClause string, parameters []interf
 
 	compiler := newExpressionCompiler()
 	compiled := where.Accept(&compiler)
-
+	if compiled == nil {
+		return "", compiler.parameters, compiler.err
+	}
 	return compiled.(string), compiler.parameters, compiler.err
 }
 
@@ -31,9 +35,13 @@ func bubbleUpJSONContext(exp criteria.Expression) bool {
 		if isJSONField(t.FieldName) {
 			t.SetAnnotation(jsonAnnotation, true)
 		}
-	case *criteria.EqualsExpression:
-		if t.Left().Annotation(jsonAnnotation) == true || t.Right().Annotation(jsonAnnotation) == true {
-			t.SetAnnotation(jsonAnnotation, true)
+	case *criteria.EqualsExpression, *criteria.NotEqualsExpression,
+		*criteria.GreaterThanExpression, *criteria.GreaterOrEqualsExpression,
+		*criteria.LessThanExpression, *criteria.LessOrEqualsExpression,
+		*criteria.InExpression, *criteria.SubstringExpression:
+		b := t.(criteria.BinaryExpression)
+		if b.Left().Annotation(jsonAnnotation) == true || b.Right().Annotation(jsonAnnotation) == true {
+			b.SetAnnotation(jsonAnnotation, true)
 		}
 	}
 	return true
@@ -66,14 +74,22 @@ func (c *expressionCompiler) Field(f *criteria.FieldExpression) interface{} {
 	if !isJSONField(f.FieldName) {
 		return f.FieldName
 	}
-	if strings.Contains(f.FieldName, "'") {
-		// beware of injection, it's a reasonable restriction for field names, make sure it's not allowed when creating wi types
-		c.err = append(c.err, fmt.Errorf("single quote not allowed in field name"))
+	if !c.checkFieldName(f.FieldName) {
 		return nil
 	}
 	return "Fields@>'{\"" + f.FieldName + "\""
 }
 
+// checkFieldName makes sure a json field name can be embedded in a query
+func (c *expressionCompiler) checkFieldName(fieldName string) bool {
+	if strings.Contains(fieldName, "'") {
+		// beware of injection, it's a reasonable restriction for field names, make sure it's not allowed when creating wi types
+		c.err = append(c.err, fmt.Errorf("single quote not allowed in field name"))
+		return false
+	}
+	return true
+}
+
 func (c *expressionCompiler) And(a *criteria.AndExpression) interface{} {
 	return c.binary(a, "and")
 }
@@ -99,6 +115,172 @@ func (c *expressionCompiler) Equals(e *criteria.EqualsExpression) interface{} {
 	return c.binary(e, "=")
 }
 
+func (c *expressionCompiler) NotEquals(e *criteria.NotEqualsExpression) interface{} {
+	if isInJSONContext(e.Left()) {
+		return c.negate(c.binary(e, ":"))
+	}
+	return c.binary(e, "!=")
+}
+
+func (c *expressionCompiler) GreaterThan(e *criteria.GreaterThanExpression) interface{} {
+	return c.ordering(e, ">")
+}
+
+func (c *expressionCompiler) GreaterOrEquals(e *criteria.GreaterOrEqualsExpression) interface{} {
+	return c.ordering(e, ">=")
+}
+
+func (c *expressionCompiler) LessThan(e *criteria.LessThanExpression) interface{} {
+	return c.ordering(e, "<")
+}
+
+func (c *expressionCompiler) LessOrEquals(e *criteria.LessOrEqualsExpression) interface{} {
+	return c.ordering(e, "<=")
+}
+
+// ordering compiles the comparison operators. Json fields can't use the containment operator here,
+// so the field value is extracted as jsonb and compared to the literal, which orders numbers numerically
+// and strings lexically.
+func (c *expressionCompiler) ordering(e criteria.BinaryExpression, op string) interface{} {
+	if !isInJSONContext(e.Left()) {
+		return c.binary(e, op)
+	}
+	fieldName, literal := c.jsonComparison(e)
+	if literal == nil || !c.jsonParameter(literal.Value) {
+		return nil
+	}
+	return "(Fields->'" + fieldName + "' " + op + " ?::jsonb)"
+}
+
+// In compiles the membership operator. The right hand side must be a literal slice.
+// For json fields we use the fact that a json array contains a primitive value if the value is one of its
+// elements. A list field is "in" the values if all of its elements are.
+func (c *expressionCompiler) In(e *criteria.InExpression) interface{} {
+	literal, isLiteral := e.Right().(*criteria.LiteralExpression)
+	if !isLiteral || literal.Value == nil {
+		c.err = append(c.err, fmt.Errorf("the right side of 'in' must be a list of values"))
+		return nil
+	}
+	values := reflect.ValueOf(literal.Value)
+	if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+		c.err = append(c.err, fmt.Errorf("the right side of 'in' must be a list of values, but is %T", literal.Value))
+		return nil
+	}
+	if values.Len() == 0 {
+		// nothing is a member of the empty list
+		return "false"
+	}
+	if isInJSONContext(e.Left()) {
+		fieldName, literal := c.jsonComparison(e)
+		if literal == nil || !c.jsonParameter(literal.Value) {
+			return nil
+		}
+		// like the containment test of equality the membership test is false for a missing field, so that
+		// "not in" matches it just like "!="
+		return "coalesce(Fields->'" + fieldName + "' <@ ?::jsonb, false)"
+	}
+	left := e.Left().Accept(c)
+	if left == nil {
+		return nil
+	}
+	placeholders := make([]string, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		placeholders[i] = "?"
+		c.parameters = append(c.parameters, values.Index(i).Interface())
+	}
+	return "(" + left.(string) + " in (" + strings.Join(placeholders, ",") + "))"
+}
+
+// Substring compiles a case insensitive substring test. The right hand side must be a literal string.
+func (c *expressionCompiler) Substring(e *criteria.SubstringExpression) interface{} {
+	literal, isLiteral := e.Right().(*criteria.LiteralExpression)
+	var pattern string
+	if isLiteral {
+		pattern, isLiteral = literal.Value.(string)
+	}
+	if !isLiteral {
+		c.err = append(c.err, fmt.Errorf("the right side of a substring test must be a string"))
+		return nil
+	}
+	var left string
+	if isInJSONContext(e.Left()) {
+		fieldName, _ := c.jsonComparison(e)
+		if fieldName == "" {
+			return nil
+		}
+		// ->> extracts the field value as text
+		left = "Fields->>'" + fieldName + "'"
+	} else {
+		compiled := e.Left().Accept(c)
+		if compiled == nil {
+			return nil
+		}
+		left = compiled.(string)
+	}
+	c.parameters = append(c.parameters, "%"+escapeLikePattern(pattern)+"%")
+	return "(" + left + " ilike ?)"
+}
+
+// escapeLikePattern escapes the characters with a special meaning in like patterns
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// IsNull compiles a test for a missing value. A json field is considered null if the key is missing,
+// holds a json null or an empty list.
+func (c *expressionCompiler) IsNull(e *criteria.IsNullExpression) interface{} {
+	if field, isField := e.Operand().(*criteria.FieldExpression); isField && isJSONField(field.FieldName) {
+		if !c.checkFieldName(field.FieldName) {
+			return nil
+		}
+		value := "Fields->'" + field.FieldName + "'"
+		return "(" + value + " is null or " + value + " in ('null'::jsonb, '[]'::jsonb))"
+	}
+	operand := e.Operand().Accept(c)
+	if operand == nil {
+		return nil
+	}
+	return "(" + operand.(string) + " is null)"
+}
+
+// jsonComparison returns the name of the json field on the left side of the comparison and the
+// literal on its right side. Records an error and returns an empty name and a nil literal if the
+// expression doesn't have that form.
+func (c *expressionCompiler) jsonComparison(e criteria.BinaryExpression) (string, *criteria.LiteralExpression) {
+	field, isField := e.Left().(*criteria.FieldExpression)
+	literal, isLiteral := e.Right().(*criteria.LiteralExpression)
+	if !isField || !isLiteral {
+		c.err = append(c.err, fmt.Errorf("json fields can only be compared to literal values"))
+		return "", nil
+	}
+	if !c.checkFieldName(field.FieldName) {
+		return "", nil
+	}
+	return field.FieldName, literal
+}
+
+// jsonParameter adds the json encoding of the value as a query parameter
+func (c *expressionCompiler) jsonParameter(value interface{}) bool {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		c.err = append(c.err, err)
+		return false
+	}
+	c.parameters = append(c.parameters, string(encoded))
+	return true
+}
+
+func (c *expressionCompiler) Not(e *criteria.NotExpression) interface{} {
+	return c.negate(e.Operand().Accept(c))
+}
+
+func (c *expressionCompiler) negate(compiled interface{}) interface{} {
+	if compiled == nil {
+		return nil
+	}
+	return "(not " + compiled.(string) + ")"
+}
+
 func (c *expressionCompiler) Parameter(v *criteria.ParameterExpression) interface{} {
 	c.err = append(c.err, fmt.Errorf("Parameter expression not supported"))
 	return nil
@@ -140,11 +322,17 @@ func (c *expressionCompiler) Literal(v *criteria.LiteralExpression) interface{}
 func (c *expressionCompiler) wrapStrings(value []string) string {
 	wrapped := []string{}
 	for i := 0; i < len(value); i++ {
-		wrapped = append(wrapped, "\""+value[i]+"\"")
+		wrapped = append(wrapped, quoteJSONString(value[i]))
 	}
 	return strings.Join(wrapped, ",")
 }
 
+// quoteJSONString encodes s as a json string that can be embedded in a sql string literal
+func quoteJSONString(s string) string {
+	encoded, _ := json.Marshal(s)
+	return strings.Replace(string(encoded), "'", "''", -1)
+}
+
 func (c *expressionCompiler) convertToString(value interface{}) (string, error) {
 	var result string
 	switch t := value.(type) {
@@ -159,11 +347,11 @@ func (c *expressionCompiler) convertToString(value interface{}) (string, error)
 	case uint64:
 		result = strconv.FormatUint(t, 10)
 	case string:
-		result = "\"" + t + "\""
+		result = quoteJSONString(t)
 	case bool:
 		result = strconv.FormatBool(t)
 	case uuid.UUID:
-		result = t.String()
+		result = quoteJSONString(t.String())
 	default:
 		return "", fmt.Errorf("unknown value type of %v: %T", value, value)
 	}
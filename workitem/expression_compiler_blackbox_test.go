@@ -27,6 +27,68 @@ func TestAndOr(t *testing.T) {
 	expect(t, Or(Equals(Field("foo"), Literal("abcd")), Equals(Literal(true), Literal(false))), "((Fields@>'{\"foo\" : \"abcd\"}') or (? = ?))", []interface{}{true, false})
 }
 
+func TestComparisons(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, NotEquals(Field("foo"), Literal("abcd")), "(not (Fields@>'{\"foo\" : \"abcd\"}'))", []interface{}{})
+	expect(t, NotEquals(Field("ID"), Literal(5)), "(ID != ?)", []interface{}{5})
+	expect(t, GreaterThan(Field("foo"), Literal(3)), "(Fields->'foo' > ?::jsonb)", []interface{}{"3"})
+	expect(t, GreaterOrEquals(Field("foo"), Literal("abc")), "(Fields->'foo' >= ?::jsonb)", []interface{}{"\"abc\""})
+	expect(t, LessThan(Field("Version"), Literal(2)), "(Version < ?)", []interface{}{2})
+	expect(t, LessOrEquals(Field("foo"), Literal(1.5)), "(Fields->'foo' <= ?::jsonb)", []interface{}{"1.5"})
+}
+
+func TestNot(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, Not(Equals(Field("foo"), Literal(23))), "(not (Fields@>'{\"foo\" : 23}'))", []interface{}{})
+	expect(t, Not(And(Equals(Field("Type"), Literal("abcd")), GreaterThan(Field("foo"), Literal(1)))), "(not ((Type = ?) and (Fields->'foo' > ?::jsonb)))", []interface{}{"abcd", "1"})
+}
+
+func TestIn(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, In(Field("system.state"), Literal([]string{"open", "closed"})), "coalesce(Fields->'system.state' <@ ?::jsonb, false)", []interface{}{"[\"open\",\"closed\"]"})
+	// a missing field isn't a member of the list, so "not in" matches it like "!="
+	expect(t, Not(In(Field("system.state"), Literal([]string{"open"}))), "(not coalesce(Fields->'system.state' <@ ?::jsonb, false))", []interface{}{"[\"open\"]"})
+	expect(t, In(Field("ID"), Literal([]interface{}{1, 2, 3})), "(ID in (?,?,?))", []interface{}{1, 2, 3})
+	expect(t, In(Field("ID"), Literal([]int{})), "false", []interface{}{})
+	_, _, err := Compile(In(Field("ID"), Literal(1)))
+	assert.NotEmpty(t, err)
+}
+
+func TestSubstring(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, Substring(Field("system.title"), Literal("foo")), "(Fields->>'system.title' ilike ?)", []interface{}{"%foo%"})
+	expect(t, Substring(Field("Type"), Literal("50%_off\\")), "(Type ilike ?)", []interface{}{"%50\\%\\_off\\\\%"})
+	_, _, err := Compile(Substring(Field("system.title"), Literal(5)))
+	assert.NotEmpty(t, err)
+}
+
+func TestIsNull(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, IsNull(Field("system.assignees")), "(Fields->'system.assignees' is null or Fields->'system.assignees' in ('null'::jsonb, '[]'::jsonb))", []interface{}{})
+	expect(t, Not(IsNull(Field("Version"))), "(not (Version is null))", []interface{}{})
+}
+
+func TestQuoting(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	expect(t, Equals(Field("foo"), Literal("it's \"quoted\"")), "(Fields@>'{\"foo\" : \"it''s \\\"quoted\\\"\"}')", []interface{}{})
+	expect(t, Equals(Field("system.assignees"), Literal([]string{"o'neil"})), "(Fields@>'{\"system.assignees\" : [\"o''neil\"]}')", []interface{}{})
+}
+
+func TestInvalidComparison(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	_, _, err := Compile(GreaterThan(Field("foo'"), Literal(1)))
+	assert.NotEmpty(t, err)
+	_, _, err = Compile(GreaterThan(Field("foo"), Field("bar")))
+	assert.NotEmpty(t, err)
+}
+
 func expect(t *testing.T, expr Expression, expectedClause string, expectedParameters []interface{}) {
 	clause, parameters, err := Compile(expr)
 	if len(err) > 0 {
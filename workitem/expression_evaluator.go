@@ -0,0 +1,642 @@
+package workitem
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/criteria"
+	errs "github.com/pkg/errors"
+)
+
+// Evaluate tells whether the work item matches the expression, without going to the database.
+// It is meant for places where a single work item has to be checked against a filter, like
+// subscriptions and automation rules, and as the reference for the expression compiler:
+// a work item matches an expression exactly if it is selected by the where clause Compile returns.
+// Like in SQL ordering comparisons of a missing field value are unknown, neither true nor false, so that a work
+// item without a value for a field neither matches `f > x` nor `not (f > x)`. Equality and membership are json
+// containment tests instead, which are false for a missing field: such a work item doesn't match `f = x` or
+// `f in (x)`, but it matches `f != x`, `not (f = x)` and `not (f in (x))`.
+// Returns a slice of errors if the expression can't be evaluated, the errors mirror those of Compile.
+func Evaluate(where criteria.Expression, wi WorkItem) (bool, []error) {
+	evaluator := expressionEvaluator{workItem: wi}
+	result := evaluator.condition(where)
+	if len(evaluator.err) > 0 {
+		return false, evaluator.err
+	}
+	return result == truthTrue, nil
+}
+
+// EvaluateApp tells whether the work item in its REST representation matches the expression,
+// the field values are converted to their stored form with the given type first, see Evaluate.
+func EvaluateApp(where criteria.Expression, wit WorkItemType, wi app.WorkItem) (bool, []error) {
+	converted, err := wit.ConvertToModel(wi)
+	if err != nil {
+		return false, []error{err}
+	}
+	return Evaluate(where, *converted)
+}
+
+// truth is a value of the three valued logic of SQL
+type truth int
+
+const (
+	truthUnknown truth = iota
+	truthFalse
+	truthTrue
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+// columnValue stands for the value of a work item column as opposed to a json field
+type columnValue struct {
+	value interface{}
+}
+
+// expressionEvaluator evaluates an expression for a single work item
+// implements criteria.ExpressionVisitor
+type expressionEvaluator struct {
+	workItem WorkItem
+	err      []error // record any errors found in the expression
+}
+
+// visitor implementation
+// conditions evaluate to a truth, fields to a columnValue or the normalized json value (nil if missing)
+// and literals to their value. Like in the compiler, nil is returned and an error appended to
+// the err field if the expression can't be evaluated.
+
+func (e *expressionEvaluator) Field(f *criteria.FieldExpression) interface{} {
+	switch f.FieldName {
+	case "ID":
+		return columnValue{e.workItem.ID}
+	case "Type":
+		return columnValue{e.workItem.Type}
+	case "Version":
+		return columnValue{e.workItem.Version}
+	}
+	if !e.checkFieldName(f.FieldName) {
+		return nil
+	}
+	value, _ := e.lookup(f.FieldName)
+	return value
+}
+
+// checkFieldName rejects the field names the compiler rejects
+func (e *expressionEvaluator) checkFieldName(fieldName string) bool {
+	if strings.Contains(fieldName, "'") {
+		e.err = append(e.err, fmt.Errorf("single quote not allowed in field name"))
+		return false
+	}
+	return true
+}
+
+// lookup returns the normalized value of the json field and whether the work item has a value for
+// it at all, which is what distinguishes a json null from a missing field
+func (e *expressionEvaluator) lookup(fieldName string) (interface{}, bool) {
+	value, ok := e.workItem.Fields[fieldName]
+	if !ok {
+		return nil, false
+	}
+	normalized, err := normalizeJSON(value)
+	if err != nil {
+		e.err = append(e.err, err)
+		return nil, false
+	}
+	return normalized, true
+}
+
+func (e *expressionEvaluator) And(a *criteria.AndExpression) interface{} {
+	left, right := e.condition(a.Left()), e.condition(a.Right())
+	switch {
+	case left == truthFalse || right == truthFalse:
+		return truthFalse
+	case left == truthTrue && right == truthTrue:
+		return truthTrue
+	}
+	return truthUnknown
+}
+
+func (e *expressionEvaluator) Or(o *criteria.OrExpression) interface{} {
+	left, right := e.condition(o.Left()), e.condition(o.Right())
+	switch {
+	case left == truthTrue || right == truthTrue:
+		return truthTrue
+	case left == truthFalse && right == truthFalse:
+		return truthFalse
+	}
+	return truthUnknown
+}
+
+func (e *expressionEvaluator) Not(n *criteria.NotExpression) interface{} {
+	return negate(e.condition(n.Operand()))
+}
+
+func negate(t truth) truth {
+	switch t {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	}
+	return truthUnknown
+}
+
+// condition evaluates a sub expression used as a condition. Boolean literals are conditions, too.
+func (e *expressionEvaluator) condition(exp criteria.Expression) truth {
+	switch t := exp.Accept(e).(type) {
+	case truth:
+		return t
+	case bool:
+		return truthOf(t)
+	case nil:
+		// either an error has been recorded or it's a missing field
+		return truthUnknown
+	default:
+		e.err = append(e.err, fmt.Errorf("expression is not a condition: %v", t))
+		return truthUnknown
+	}
+}
+
+func (e *expressionEvaluator) Equals(c *criteria.EqualsExpression) interface{} {
+	if isJSONComparison(c) {
+		return e.jsonEquals(c)
+	}
+	return e.columnComparison(c, func(cmp int) bool { return cmp == 0 })
+}
+
+func (e *expressionEvaluator) NotEquals(c *criteria.NotEqualsExpression) interface{} {
+	if isJSONComparison(c) {
+		result := e.jsonEquals(c)
+		if result == nil {
+			return nil
+		}
+		return negate(result.(truth))
+	}
+	return e.columnComparison(c, func(cmp int) bool { return cmp != 0 })
+}
+
+func (e *expressionEvaluator) GreaterThan(c *criteria.GreaterThanExpression) interface{} {
+	return e.ordering(c, func(cmp int) bool { return cmp > 0 })
+}
+
+func (e *expressionEvaluator) GreaterOrEquals(c *criteria.GreaterOrEqualsExpression) interface{} {
+	return e.ordering(c, func(cmp int) bool { return cmp >= 0 })
+}
+
+func (e *expressionEvaluator) LessThan(c *criteria.LessThanExpression) interface{} {
+	return e.ordering(c, func(cmp int) bool { return cmp < 0 })
+}
+
+func (e *expressionEvaluator) LessOrEquals(c *criteria.LessOrEqualsExpression) interface{} {
+	return e.ordering(c, func(cmp int) bool { return cmp <= 0 })
+}
+
+// jsonEquals evaluates the json containment test `Fields @> {"field": literal}` the compiler uses for equality
+func (e *expressionEvaluator) jsonEquals(c criteria.BinaryExpression) interface{} {
+	field, literal := e.jsonComparison(c)
+	if literal == nil {
+		return nil
+	}
+	if _, err := (&expressionCompiler{}).convertToString(literal.Value); err != nil {
+		if _, isStrings := literal.Value.([]string); !isStrings {
+			e.err = append(e.err, err)
+			return nil
+		}
+	}
+	fieldValue, ok := e.lookup(field.FieldName)
+	if !ok {
+		// the containment test is false, not unknown, if the key is missing
+		return truthFalse
+	}
+	literalValue, err := normalizeJSON(literal.Value)
+	if err != nil {
+		e.err = append(e.err, err)
+		return nil
+	}
+	return truthOf(jsonContains(fieldValue, literalValue) && jsonRank(fieldValue) == jsonRank(literalValue))
+}
+
+// ordering evaluates the comparison operators, json field values are compared like jsonb values
+func (e *expressionEvaluator) ordering(c criteria.BinaryExpression, accept func(cmp int) bool) interface{} {
+	if !isJSONComparison(c) {
+		return e.columnComparison(c, accept)
+	}
+	field, literal := e.jsonComparison(c)
+	if literal == nil {
+		return nil
+	}
+	literalValue, err := normalizeJSON(literal.Value)
+	if err != nil {
+		e.err = append(e.err, err)
+		return nil
+	}
+	fieldValue, ok := e.lookup(field.FieldName)
+	if !ok {
+		return truthUnknown
+	}
+	return truthOf(accept(compareJSON(fieldValue, literalValue)))
+}
+
+// In evaluates the membership test. A json list field is a member of the values if all of its elements are.
+func (e *expressionEvaluator) In(c *criteria.InExpression) interface{} {
+	literal, isLiteral := c.Right().(*criteria.LiteralExpression)
+	if !isLiteral || literal.Value == nil {
+		e.err = append(e.err, fmt.Errorf("the right side of 'in' must be a list of values"))
+		return nil
+	}
+	values := reflect.ValueOf(literal.Value)
+	if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+		e.err = append(e.err, fmt.Errorf("the right side of 'in' must be a list of values, but is %T", literal.Value))
+		return nil
+	}
+	if values.Len() == 0 {
+		return truthFalse
+	}
+	if isJSONComparison(c) {
+		field, literal := e.jsonComparison(c)
+		if literal == nil {
+			return nil
+		}
+		list, err := normalizeJSON(literal.Value)
+		if err != nil {
+			e.err = append(e.err, err)
+			return nil
+		}
+		fieldValue, ok := e.lookup(field.FieldName)
+		if !ok {
+			// like the containment test of equality the membership test is false if the key is missing
+			return truthFalse
+		}
+		if !isJSONContainer(fieldValue) {
+			// a json array contains a primitive value if it's one of its elements
+			fieldValue = []interface{}{fieldValue}
+		}
+		return truthOf(jsonContains(list, fieldValue))
+	}
+	left := c.Left().Accept(e)
+	result := truthFalse
+	for i := 0; i < values.Len(); i++ {
+		switch compareColumn(left, values.Index(i).Interface()) {
+		case truthTrue:
+			return truthTrue
+		case truthUnknown:
+			result = truthUnknown
+		}
+	}
+	return result
+}
+
+// Substring evaluates the case insensitive substring test on the textual representation of the value
+func (e *expressionEvaluator) Substring(c *criteria.SubstringExpression) interface{} {
+	literal, isLiteral := c.Right().(*criteria.LiteralExpression)
+	var pattern string
+	if isLiteral {
+		pattern, isLiteral = literal.Value.(string)
+	}
+	if !isLiteral {
+		e.err = append(e.err, fmt.Errorf("the right side of a substring test must be a string"))
+		return nil
+	}
+	var text string
+	if isJSONComparison(c) {
+		field, _ := e.jsonComparison(c)
+		if field == nil {
+			return nil
+		}
+		value, _ := e.lookup(field.FieldName)
+		if value == nil {
+			// ->> yields NULL for missing fields and json nulls
+			return truthUnknown
+		}
+		text = jsonText(value)
+	} else {
+		switch left := c.Left().Accept(e).(type) {
+		case columnValue:
+			text = fmt.Sprint(left.value)
+		case nil:
+			return nil
+		default:
+			text = fmt.Sprint(left)
+		}
+	}
+	return truthOf(strings.Contains(strings.ToLower(text), strings.ToLower(pattern)))
+}
+
+// IsNull evaluates the test for a missing value, a json field is null if it's missing, a json null or an empty list
+func (e *expressionEvaluator) IsNull(n *criteria.IsNullExpression) interface{} {
+	if field, isField := n.Operand().(*criteria.FieldExpression); isField && isJSONField(field.FieldName) {
+		if !e.checkFieldName(field.FieldName) {
+			return nil
+		}
+		value, _ := e.lookup(field.FieldName)
+		list, isList := value.([]interface{})
+		return truthOf(value == nil || isList && len(list) == 0)
+	}
+	switch operand := n.Operand().Accept(e).(type) {
+	case columnValue:
+		return truthOf(operand.value == nil)
+	default:
+		return truthOf(operand == nil)
+	}
+}
+
+func (e *expressionEvaluator) Parameter(v *criteria.ParameterExpression) interface{} {
+	e.err = append(e.err, fmt.Errorf("Parameter expression not supported"))
+	return nil
+}
+
+func (e *expressionEvaluator) Literal(v *criteria.LiteralExpression) interface{} {
+	return v.Value
+}
+
+// isJSONComparison tells whether the compiler turns the comparison into a json operation.
+// That's the case if either side references a json field.
+func isJSONComparison(c criteria.BinaryExpression) bool {
+	return referencesJSONField(c.Left()) || referencesJSONField(c.Right())
+}
+
+func referencesJSONField(exp criteria.Expression) bool {
+	result := false
+	criteria.IteratePostOrder(exp, func(exp criteria.Expression) bool {
+		if field, isField := exp.(*criteria.FieldExpression); isField && isJSONField(field.FieldName) {
+			result = true
+			return false
+		}
+		return true
+	})
+	return result
+}
+
+// jsonComparison returns the json field on the left side of the comparison and the literal on its right side.
+// Records an error and returns nil if the expression doesn't have that form.
+func (e *expressionEvaluator) jsonComparison(c criteria.BinaryExpression) (*criteria.FieldExpression, *criteria.LiteralExpression) {
+	field, isField := c.Left().(*criteria.FieldExpression)
+	literal, isLiteral := c.Right().(*criteria.LiteralExpression)
+	if !isField || !isLiteral {
+		e.err = append(e.err, fmt.Errorf("json fields can only be compared to literal values"))
+		return nil, nil
+	}
+	if !e.checkFieldName(field.FieldName) {
+		return nil, nil
+	}
+	return field, literal
+}
+
+// columnComparison compares the values of both sides, where at least one of them is
+// a column or a literal. Comparisons involving conditions are not supported.
+func (e *expressionEvaluator) columnComparison(c criteria.BinaryExpression, accept func(cmp int) bool) interface{} {
+	left := c.Left().Accept(e)
+	right := c.Right().Accept(e)
+	if len(e.err) > 0 {
+		return nil
+	}
+	if _, isTruth := left.(truth); isTruth {
+		e.err = append(e.err, fmt.Errorf("conditions can't be compared"))
+		return nil
+	}
+	if _, isTruth := right.(truth); isTruth {
+		e.err = append(e.err, fmt.Errorf("conditions can't be compared"))
+		return nil
+	}
+	cmp, ok := compareValues(left, right)
+	if !ok {
+		return truthUnknown
+	}
+	return truthOf(accept(cmp))
+}
+
+// compareColumn tells whether a column equals a literal value
+func compareColumn(left, right interface{}) truth {
+	cmp, ok := compareValues(left, right)
+	if !ok {
+		return truthUnknown
+	}
+	return truthOf(cmp == 0)
+}
+
+// compareValues compares columns and literals the way the database compares a column with a
+// query parameter: numerically if both are numbers, otherwise by their textual representation.
+// Returns false if either value is NULL.
+func compareValues(left, right interface{}) (int, bool) {
+	if c, isColumn := left.(columnValue); isColumn {
+		left = c.value
+	}
+	if c, isColumn := right.(columnValue); isColumn {
+		right = c.value
+	}
+	if left == nil || right == nil {
+		return 0, false
+	}
+	leftText, rightText := fmt.Sprint(left), fmt.Sprint(right)
+	leftNumber, leftIsNumber := new(big.Rat).SetString(leftText)
+	rightNumber, rightIsNumber := new(big.Rat).SetString(rightText)
+	if leftIsNumber && rightIsNumber {
+		return leftNumber.Cmp(rightNumber), true
+	}
+	if leftBool, isBool := left.(bool); isBool {
+		if rightBool, isBool := right.(bool); isBool {
+			return compareBools(leftBool, rightBool), true
+		}
+	}
+	// uuids are compared case insensitively
+	return strings.Compare(strings.ToLower(leftText), strings.ToLower(rightText)), true
+}
+
+// normalizeJSON converts the value to the generic representation of its json encoding,
+// with numbers represented as json.Number to keep their precision
+func normalizeJSON(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var result interface{}
+	if err := decoder.Decode(&result); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	return result, nil
+}
+
+func isJSONContainer(value interface{}) bool {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		return true
+	}
+	return false
+}
+
+// jsonContains implements the containment operator @> of jsonb: objects contain the pairs of the
+// other object and arrays contain all the elements of the other array, nested values must be of
+// the same kind.
+func jsonContains(container, contained interface{}) bool {
+	switch t := contained.(type) {
+	case map[string]interface{}:
+		object, isObject := container.(map[string]interface{})
+		if !isObject {
+			return false
+		}
+		for key, value := range t {
+			other, ok := object[key]
+			if !ok || jsonRank(other) != jsonRank(value) || !jsonContains(other, value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		array, isArray := container.([]interface{})
+		if !isArray {
+			return false
+		}
+		for _, value := range t {
+			found := false
+			for _, other := range array {
+				if jsonRank(other) == jsonRank(value) && jsonContains(other, value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	default:
+		return !isJSONContainer(container) && compareJSON(container, contained) == 0
+	}
+}
+
+// jsonRank returns the position of the kind of the value in the jsonb sort order
+func jsonRank(value interface{}) int {
+	switch value.(type) {
+	case nil:
+		return 0
+	case string:
+		return 1
+	case json.Number:
+		return 2
+	case bool:
+		return 3
+	case []interface{}:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// compareJSON orders normalized json values like jsonb does: null < strings < numbers < booleans < arrays < objects.
+// Arrays and objects with fewer elements come first, those of the same size are compared element by element.
+// Note that the database compares strings according to its collation, here they are compared byte wise.
+func compareJSON(left, right interface{}) int {
+	leftRank, rightRank := jsonRank(left), jsonRank(right)
+	if leftRank != rightRank {
+		return leftRank - rightRank
+	}
+	switch l := left.(type) {
+	case string:
+		return strings.Compare(l, right.(string))
+	case json.Number:
+		leftNumber, _ := new(big.Rat).SetString(l.String())
+		rightNumber, _ := new(big.Rat).SetString(right.(json.Number).String())
+		return leftNumber.Cmp(rightNumber)
+	case bool:
+		return compareBools(l, right.(bool))
+	case []interface{}:
+		r := right.([]interface{})
+		if len(l) != len(r) {
+			return len(l) - len(r)
+		}
+		for i := range l {
+			if cmp := compareJSON(l[i], r[i]); cmp != 0 {
+				return cmp
+			}
+		}
+	case map[string]interface{}:
+		r := right.(map[string]interface{})
+		if len(l) != len(r) {
+			return len(l) - len(r)
+		}
+		leftKeys, rightKeys := jsonKeys(l), jsonKeys(r)
+		for i := range leftKeys {
+			if cmp := strings.Compare(leftKeys[i], rightKeys[i]); cmp != 0 {
+				return cmp
+			}
+			if cmp := compareJSON(l[leftKeys[i]], r[rightKeys[i]]); cmp != 0 {
+				return cmp
+			}
+		}
+	}
+	return 0
+}
+
+func compareBools(left, right bool) int {
+	switch {
+	case left == right:
+		return 0
+	case left:
+		return 1
+	}
+	return -1
+}
+
+// jsonKeys returns the keys of the object in jsonb storage order, shorter keys first
+func jsonKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// jsonText returns the text the ->> operator extracts from a non null json value,
+// arrays and objects are formatted the way jsonb prints them
+func jsonText(value interface{}) string {
+	switch t := value.(type) {
+	case string:
+		return t
+	case []interface{}:
+		elements := make([]string, len(t))
+		for i, element := range t {
+			elements[i] = jsonString(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case map[string]interface{}:
+		keys := jsonKeys(t)
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			pairs[i] = jsonString(key) + ": " + jsonString(t[key])
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonString returns the json text of a value nested in an array or object
+func jsonString(value interface{}) string {
+	if s, isString := value.(string); isString {
+		var encoded bytes.Buffer
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(s)
+		return strings.TrimSuffix(encoded.String(), "\n")
+	}
+	return jsonText(value)
+}
@@ -0,0 +1,192 @@
+package workitem_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/almighty/almighty-core/app"
+	. "github.com/almighty/almighty-core/criteria"
+	"github.com/almighty/almighty-core/resource"
+	. "github.com/almighty/almighty-core/workitem"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluationItem returns a work item with fields as they are read from the database
+func evaluationItem(t *testing.T) WorkItem {
+	fields := Fields{}
+	err := json.Unmarshal([]byte(`{
+		"system.title": "Crash on start",
+		"system.state": "open",
+		"system.assignees": ["alice", "bob"],
+		"system.area": null,
+		"estimate": 3,
+		"ratio": 0.5,
+		"done": false,
+		"labels": []
+	}`), &fields)
+	require.Nil(t, err)
+	return WorkItem{ID: 42, Type: uuid.FromStringOrNil("7c9a1a4e-0d4c-4f5c-9a61-9bd3c5c8a2e1"), Version: 3, Fields: fields}
+}
+
+func expectMatch(t *testing.T, wi WorkItem, expected bool, exp Expression) {
+	result, err := Evaluate(exp, wi)
+	require.Empty(t, err, "unexpected error for %v", exp)
+	assert.Equal(t, expected, result, "unexpected result for %v", exp)
+}
+
+func TestEvaluateEquals(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	wi := evaluationItem(t)
+	expectMatch(t, wi, true, Equals(Field("system.state"), Literal("open")))
+	expectMatch(t, wi, false, Equals(Field("system.state"), Literal("closed")))
+	expectMatch(t, wi, true, Equals(Field("estimate"), Literal(3)))
+	expectMatch(t, wi, true, Equals(Field("estimate"), Literal(3.0)))
+	expectMatch(t, wi, false, Equals(Field("estimate"), Literal("3")))
+	expectMatch(t, wi, true, Equals(Field("done"), Literal(false)))
+	expectMatch(t, wi, true, NotEquals(Field("system.state"), Literal("closed")))
+	// a missing key fails the containment test, so its negation holds
+	expectMatch(t, wi, false, Equals(Field("missing"), Literal("x")))
+	expectMatch(t, wi, true, NotEquals(Field("missing"), Literal("x")))
+	expectMatch(t, wi, true, Not(Equals(Field("missing"), Literal("x"))))
+	// columns
+	expectMatch(t, wi, true, Equals(Field("ID"), Literal("42")))
+	expectMatch(t, wi, true, NotEquals(Field("Version"), Literal(2)))
+	expectMatch(t, wi, true, Equals(Field("Type"), Literal(wi.Type)))
+	expectMatch(t, wi, true, Equals(Field("Type"), Literal("7C9A1A4E-0D4C-4F5C-9A61-9BD3C5C8A2E1")))
+	expectMatch(t, wi, false, Equals(Literal(true), Literal(false)))
+}
+
+func TestEvaluateListContainment(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	wi := evaluationItem(t)
+	// equality with a list tests whether the list field contains all of the values
+	expectMatch(t, wi, true, Equals(Field("system.assignees"), Literal([]string{"alice"})))
+	expectMatch(t, wi, true, Equals(Field("system.assignees"), Literal([]string{"bob", "alice"})))
+	expectMatch(t, wi, false, Equals(Field("system.assignees"), Literal([]string{"alice", "carol"})))
+	expectMatch(t, wi, true, Equals(Field("system.assignees"), Literal([]string{})))
+	// a list doesn't contain a single value at the top level
+	expectMatch(t, wi, false, Equals(Field("system.assignees"), Literal("alice")))
+	// a list field is in the values if all of its elements are
+	expectMatch(t, wi, true, In(Field("system.assignees"), Literal([]string{"alice", "bob", "carol"})))
+	expectMatch(t, wi, false, In(Field("system.assignees"), Literal([]string{"alice"})))
+	expectMatch(t, wi, true, In(Field("system.state"), Literal([]interface{}{"new", "open"})))
+	expectMatch(t, wi, true, In(Field("estimate"), Literal([]interface{}{int64(1), 3.0})))
+	expectMatch(t, wi, false, In(Field("system.state"), Literal([]string{})))
+	expectMatch(t, wi, true, In(Field("ID"), Literal([]int{1, 42})))
+}
+
+func TestEvaluateOrdering(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	wi := evaluationItem(t)
+	expectMatch(t, wi, true, GreaterThan(Field("estimate"), Literal(2)))
+	expectMatch(t, wi, false, GreaterThan(Field("estimate"), Literal(3)))
+	expectMatch(t, wi, true, GreaterOrEquals(Field("estimate"), Literal(3)))
+	expectMatch(t, wi, true, LessThan(Field("ratio"), Literal(0.75)))
+	expectMatch(t, wi, true, LessOrEquals(Field("system.title"), Literal("D")))
+	// jsonb orders values of different kinds: strings < numbers < booleans
+	expectMatch(t, wi, true, GreaterThan(Field("estimate"), Literal("10")))
+	expectMatch(t, wi, true, LessThan(Field("estimate"), Literal(true)))
+	expectMatch(t, wi, true, LessThan(Field("Version"), Literal(10)))
+}
+
+func TestEvaluateSubstring(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	wi := evaluationItem(t)
+	expectMatch(t, wi, true, Substring(Field("system.title"), Literal("CRASH")))
+	expectMatch(t, wi, false, Substring(Field("system.title"), Literal("hang")))
+	expectMatch(t, wi, true, Substring(Field("estimate"), Literal("3")))
+	expectMatch(t, wi, true, Substring(Field("system.assignees"), Literal(`"alice", "bob"`)))
+	expectMatch(t, wi, true, Substring(Field("ID"), Literal("4")))
+}
+
+func TestEvaluateNull(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	wi := evaluationItem(t)
+	expectMatch(t, wi, true, IsNull(Field("system.area")))
+	expectMatch(t, wi, true, IsNull(Field("missing")))
+	expectMatch(t, wi, true, IsNull(Field("labels")))
+	expectMatch(t, wi, false, IsNull(Field("system.assignees")))
+	expectMatch(t, wi, false, IsNull(Field("ID")))
+	// comparisons with missing values are unknown, so neither they nor their negation match
+	for _, exp := range []func() Expression{
+		func() Expression { return GreaterThan(Field("missing"), Literal(1)) },
+		func() Expression { return Substring(Field("system.area"), Literal("a")) },
+	} {
+		expectMatch(t, wi, false, exp())
+		expectMatch(t, wi, false, Not(exp()))
+		// unknown or true is true, unknown and false is false
+		expectMatch(t, wi, true, Or(exp(), Literal(true)))
+		expectMatch(t, wi, true, Not(And(exp(), Literal(false))))
+		expectMatch(t, wi, false, Or(exp(), Literal(false)))
+	}
+	// membership tests of missing values are false like equality, so their negation matches
+	expectMatch(t, wi, false, In(Field("missing"), Literal([]string{"a"})))
+	expectMatch(t, wi, true, Not(In(Field("missing"), Literal([]string{"a"}))))
+	// a json null is a value of its own and comes before all other values
+	expectMatch(t, wi, true, LessThan(Field("system.area"), Literal("")))
+}
+
+func TestEvaluateBooleanOperators(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	wi := evaluationItem(t)
+	open := func() Expression { return Equals(Field("system.state"), Literal("open")) }
+	alice := func() Expression { return Equals(Field("system.assignees"), Literal([]string{"alice"})) }
+	carol := func() Expression { return Equals(Field("system.assignees"), Literal([]string{"carol"})) }
+	expectMatch(t, wi, true, And(open(), alice()))
+	expectMatch(t, wi, false, And(open(), carol()))
+	expectMatch(t, wi, true, Or(carol(), alice()))
+	expectMatch(t, wi, true, Not(carol()))
+	expectMatch(t, wi, true, Literal(true))
+	expectMatch(t, wi, false, Literal(false))
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	wi := evaluationItem(t)
+	for _, exp := range []Expression{
+		Equals(Field("system.state"), Field("system.area")),
+		Equals(Field("it's"), Literal("x")),
+		IsNull(Field("it's")),
+		Equals(Field("system.area"), Literal(nil)),
+		In(Field("system.state"), Literal("open")),
+		Substring(Field("system.title"), Literal(1)),
+		Equals(Field("system.state"), Parameter()),
+		Field("system.title"),
+		And(Literal(true), Literal("x")),
+	} {
+		_, err := Evaluate(exp, wi)
+		assert.NotEmpty(t, err, "expected an error for %v", exp)
+	}
+}
+
+func TestEvaluateApp(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	wit := WorkItemType{Fields: map[string]FieldDefinition{
+		SystemState:     {Type: SimpleType{Kind: KindString}},
+		SystemAssignees: {Type: ListType{SimpleType: SimpleType{Kind: KindList}, ComponentType: SimpleType{Kind: KindString}}},
+		"estimate":      {Type: SimpleType{Kind: KindFloat}},
+	}}
+	wi := app.WorkItem{ID: "7", Fields: map[string]interface{}{
+		SystemState:     "open",
+		SystemAssignees: []interface{}{"alice"},
+		"estimate":      2.5,
+	}}
+	exp := And(Equals(Field(SystemAssignees), Literal([]string{"alice"})), And(GreaterThan(Field("estimate"), Literal(2)), Equals(Field("ID"), Literal(7))))
+	result, err := EvaluateApp(exp, wit, wi)
+	require.Empty(t, err)
+	assert.True(t, result)
+
+	wi.ID = "not a number"
+	_, err = EvaluateApp(exp, wit, wi)
+	assert.NotEmpty(t, err)
+}
@@ -0,0 +1,231 @@
+package workitem
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/errors"
+)
+
+// FieldConstraints restrict the values of a field beyond its type. The constraints of a list field apply to each
+// of its values, the constraints which don't apply to the kind of a field are rejected when the field is defined.
+type FieldConstraints struct {
+	// Min and Max bound number and instant fields, instants are bounded in nanoseconds since the epoch
+	Min *float64 `json:",omitempty"`
+	Max *float64 `json:",omitempty"`
+	// MinLength and MaxLength bound the number of characters of string, label, URL and markup fields
+	MinLength *int `json:",omitempty"`
+	MaxLength *int `json:",omitempty"`
+	// Pattern is a regular expression which the whole value of a string, label or URL field must match
+	Pattern string `json:",omitempty"`
+	// MaxItems bounds the number of values of list fields
+	MaxItems *int `json:",omitempty"`
+	// Unique values differ for all work items of the type within a space. The work item repository checks them
+	// when work items are created or changed, and a field only becomes unique if the existing work items don't
+	// share a value of it.
+	Unique bool `json:",omitempty"`
+}
+
+// constrainedKind returns the kind of the values the constraints of a field apply to
+func constrainedKind(fieldType FieldType) Kind {
+	switch t := fieldType.(type) {
+	case ListType:
+		return t.ComponentType.GetKind()
+	case EnumType:
+		return t.BaseType.GetKind()
+	}
+	return fieldType.GetKind()
+}
+
+func isBoundedKind(kind Kind) bool {
+	switch kind {
+	case KindInteger, KindFloat, KindDuration, KindPercentage, KindInstant:
+		return true
+	}
+	return false
+}
+
+func isTextKind(kind Kind) bool {
+	switch kind {
+	case KindString, KindLabel, KindURL, KindMarkup:
+		return true
+	}
+	return false
+}
+
+// check returns an error if the value of a field of the given type violates the constraints, the value is in the
+// form stored in the persistence layer. Unique values are checked by the work item repository.
+func (c FieldConstraints) check(fieldType FieldType, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if _, isList := fieldType.(ListType); !isList {
+		return c.checkValue(constrainedKind(fieldType), value)
+	}
+	values := reflect.ValueOf(value)
+	if c.MaxItems != nil && values.Len() > *c.MaxItems {
+		return fmt.Errorf("value %v should have at most %d items", value, *c.MaxItems)
+	}
+	for i := 0; i < values.Len(); i++ {
+		if err := c.checkValue(constrainedKind(fieldType), values.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c FieldConstraints) checkValue(kind Kind, value interface{}) error {
+	if number, ok := floatValue(value); ok && isBoundedKind(kind) {
+		if c.Min != nil && number.(float64) < *c.Min {
+			return fmt.Errorf("value %v should be at least %v", value, *c.Min)
+		}
+		if c.Max != nil && number.(float64) > *c.Max {
+			return fmt.Errorf("value %v should be at most %v", value, *c.Max)
+		}
+		return nil
+	}
+	text, ok := value.(string)
+	if markup, isMarkup := value.(map[string]interface{}); isMarkup && kind == KindMarkup {
+		text, ok = markup["content"].(string)
+	}
+	if !ok || !isTextKind(kind) {
+		return nil
+	}
+	length := utf8.RuneCountInString(text)
+	if c.MinLength != nil && length < *c.MinLength {
+		return fmt.Errorf("value %q should have at least %d characters", text, *c.MinLength)
+	}
+	if c.MaxLength != nil && length > *c.MaxLength {
+		return fmt.Errorf("value %q should have at most %d characters", text, *c.MaxLength)
+	}
+	if c.Pattern != "" {
+		matches, err := regexp.MatchString(anchoredPattern(c.Pattern), text)
+		if err != nil || !matches {
+			return fmt.Errorf("value %q should match %s", text, c.Pattern)
+		}
+	}
+	return nil
+}
+
+// anchoredPattern makes a pattern match whole values, like the pattern attribute of HTML forms
+func anchoredPattern(pattern string) string {
+	return "^(?:" + pattern + ")$"
+}
+
+// convertFieldConstraintsToModel converts the constraints of a field of the given type from the API layer
+// returns BadParameterError if a constraint doesn't apply to the type or is invalid
+func convertFieldConstraintsToModel(fieldType FieldType, constraints *app.FieldConstraints) (*FieldConstraints, error) {
+	if constraints == nil {
+		return nil, nil
+	}
+	kind := constrainedKind(fieldType)
+	_, isList := fieldType.(ListType)
+	result := FieldConstraints{
+		MinLength: constraints.MinLength,
+		MaxLength: constraints.MaxLength,
+		MaxItems:  constraints.MaxItems,
+		Unique:    constraints.Unique != nil && *constraints.Unique,
+	}
+	if constraints.Pattern != nil {
+		result.Pattern = *constraints.Pattern
+	}
+	bounds := []struct {
+		name  string
+		value interface{}
+		bound **float64
+	}{{"min", constraints.Min, &result.Min}, {"max", constraints.Max, &result.Max}}
+	for _, bound := range bounds {
+		if bound.value == nil {
+			continue
+		}
+		if !isBoundedKind(kind) {
+			return nil, errors.NewBadParameterError(bound.name, bound.value).Expected("a number or instant field")
+		}
+		// instants are given like the literals of filters, the bounds of the other kinds may be fractional
+		boundKind := KindFloat
+		if kind == KindInstant {
+			boundKind = KindInstant
+		}
+		coerced, ok := coerceSimpleValue(boundKind, bound.value)
+		number, isNumber := floatValue(coerced)
+		if !ok || !isNumber {
+			return nil, errors.NewBadParameterError(bound.name, bound.value).Expected(expectedKind(SimpleType{Kind: kind}))
+		}
+		value := number.(float64)
+		*bound.bound = &value
+	}
+	if result.Min != nil && result.Max != nil && *result.Min > *result.Max {
+		return nil, errors.NewBadParameterError("max", constraints.Max).Expected("a maximum which is not less than the minimum")
+	}
+	// the payload is validated by the API already, the repositories may be called with other values
+	if result.MinLength != nil && *result.MinLength < 0 {
+		return nil, errors.NewBadParameterError("minLength", *result.MinLength).Expected("a minimum length which is not negative")
+	}
+	if result.MaxLength != nil && *result.MaxLength < 0 {
+		return nil, errors.NewBadParameterError("maxLength", *result.MaxLength).Expected("a maximum length which is not negative")
+	}
+	if result.MaxItems != nil && *result.MaxItems < 1 {
+		return nil, errors.NewBadParameterError("maxItems", *result.MaxItems).Expected("a maximum number of values of at least 1")
+	}
+	if result.MinLength != nil && !isTextKind(kind) {
+		return nil, errors.NewBadParameterError("minLength", *result.MinLength).Expected("a string, label, URL or markup field")
+	}
+	if result.MaxLength != nil && !isTextKind(kind) {
+		return nil, errors.NewBadParameterError("maxLength", *result.MaxLength).Expected("a string, label, URL or markup field")
+	}
+	if result.MinLength != nil && result.MaxLength != nil && *result.MinLength > *result.MaxLength {
+		return nil, errors.NewBadParameterError("maxLength", *result.MaxLength).Expected("a maximum length which is not less than the minimum length")
+	}
+	if result.Pattern != "" {
+		if kind == KindMarkup || !isTextKind(kind) {
+			return nil, errors.NewBadParameterError("pattern", result.Pattern).Expected("a string, label or URL field")
+		}
+		if _, err := regexp.Compile(anchoredPattern(result.Pattern)); err != nil {
+			return nil, errors.NewBadParameterError("pattern", result.Pattern).Expected(err.Error())
+		}
+	}
+	if result.MaxItems != nil && !isList {
+		return nil, errors.NewBadParameterError("maxItems", *result.MaxItems).Expected("a list field")
+	}
+	if result.Unique && isList {
+		return nil, errors.NewBadParameterError("unique", true).Expected("a field which is not a list")
+	}
+	return &result, nil
+}
+
+// convertFieldConstraintsFromModel converts the constraints of a field of the given type for use in the API layer,
+// the bounds of instant fields are converted to times
+func convertFieldConstraintsFromModel(fieldType FieldType, constraints *FieldConstraints) *app.FieldConstraints {
+	if constraints == nil {
+		return nil
+	}
+	result := app.FieldConstraints{
+		MinLength: constraints.MinLength,
+		MaxLength: constraints.MaxLength,
+		MaxItems:  constraints.MaxItems,
+	}
+	if constraints.Pattern != "" {
+		pattern := constraints.Pattern
+		result.Pattern = &pattern
+	}
+	if constraints.Unique {
+		unique := true
+		result.Unique = &unique
+	}
+	bound := func(value *float64) interface{} {
+		if value == nil {
+			return nil
+		}
+		if constrainedKind(fieldType) == KindInstant {
+			return time.Unix(0, int64(*value)).UTC()
+		}
+		return *value
+	}
+	result.Min = bound(constraints.Min)
+	result.Max = bound(constraints.Max)
+	return &result
+}
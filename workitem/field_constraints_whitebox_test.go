@@ -0,0 +1,91 @@
+package workitem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertFieldConstraints(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	day := time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC)
+	pattern, unique := "[a-z]+", true
+	instantType := SimpleType{Kind: KindInstant}
+	stringType := SimpleType{Kind: KindString}
+	// when
+	instant, err := convertFieldConstraintsToModel(instantType, &app.FieldConstraints{Min: "2017-01-31", Max: day.Add(time.Hour)})
+	require.Nil(t, err)
+	text, err := convertFieldConstraintsToModel(stringType, &app.FieldConstraints{Pattern: &pattern, Unique: &unique})
+	require.Nil(t, err)
+	number, err := convertFieldConstraintsToModel(SimpleType{Kind: KindInteger}, &app.FieldConstraints{Max: "2.5"})
+	require.Nil(t, err)
+	none, err := convertFieldConstraintsToModel(stringType, nil)
+	require.Nil(t, err)
+	// then
+	assert.Equal(t, float64(day.UnixNano()), *instant.Min)
+	assert.Equal(t, float64(day.Add(time.Hour).UnixNano()), *instant.Max)
+	assert.Equal(t, FieldConstraints{Pattern: pattern, Unique: true}, *text)
+	assert.Equal(t, 2.5, *number.Max)
+	assert.Nil(t, none)
+	// when converted back
+	converted := convertFieldConstraintsFromModel(instantType, instant)
+	// then
+	assert.Equal(t, day, converted.Min)
+	assert.Equal(t, &app.FieldConstraints{Pattern: &pattern, Unique: &unique}, convertFieldConstraintsFromModel(stringType, text))
+	assert.Equal(t, 2.5, convertFieldConstraintsFromModel(SimpleType{Kind: KindInteger}, number).Max)
+}
+
+func TestConvertInvalidFieldConstraints(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	zero, one, two, negative, unique, pattern, invalidPattern := 0, 1, 2, -1, true, "[a-z]+", "[a-z"
+	stringList := ListType{SimpleType{Kind: KindList}, SimpleType{Kind: KindString}}
+	for _, test := range []struct {
+		fieldType   FieldType
+		constraints app.FieldConstraints
+	}{
+		{SimpleType{Kind: KindString}, app.FieldConstraints{Min: 1}},
+		{SimpleType{Kind: KindFloat}, app.FieldConstraints{Min: "one"}},
+		{SimpleType{Kind: KindInstant}, app.FieldConstraints{Max: "yesterday"}},
+		{SimpleType{Kind: KindFloat}, app.FieldConstraints{Min: 2, Max: 1}},
+		{SimpleType{Kind: KindInteger}, app.FieldConstraints{MaxLength: &one}},
+		{SimpleType{Kind: KindString}, app.FieldConstraints{MinLength: &two, MaxLength: &one}},
+		{SimpleType{Kind: KindMarkup}, app.FieldConstraints{Pattern: &pattern}},
+		{SimpleType{Kind: KindString}, app.FieldConstraints{Pattern: &invalidPattern}},
+		{SimpleType{Kind: KindString}, app.FieldConstraints{MaxItems: &one}},
+		{stringList, app.FieldConstraints{Unique: &unique}},
+		{SimpleType{Kind: KindString}, app.FieldConstraints{MinLength: &negative}},
+		{SimpleType{Kind: KindString}, app.FieldConstraints{MaxLength: &negative}},
+		{stringList, app.FieldConstraints{MaxItems: &negative}},
+		{stringList, app.FieldConstraints{MaxItems: &zero}},
+	} {
+		// when
+		_, err := convertFieldConstraintsToModel(test.fieldType, &test.constraints)
+		// then
+		require.NotNil(t, err, "constraints %+v of %v", test.constraints, test.fieldType)
+		assert.IsType(t, errors.BadParameterError{}, errs.Cause(err), "constraints %+v of %v", test.constraints, test.fieldType)
+	}
+}
+
+func TestMigrateFieldChangeChecksConstraints(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	fields := Fields{"effort": "30"}
+	max := 8.0
+	bounded := FieldDefinition{Type: SimpleType{Kind: KindFloat}, Constraints: &FieldConstraints{Max: &max}}
+	// when
+	_, err := FieldChange{Op: FieldChangeRetype, Field: "effort", Definition: &bounded}.migrate(changeDefinitions["effort"], fields)
+	// then
+	assert.NotNil(t, err)
+	assert.Equal(t, "30", fields["effort"])
+}
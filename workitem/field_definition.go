@@ -27,6 +27,10 @@ const (
 	KindMarkup            Kind = "markup"
 	KindArea              Kind = "area"
 	KindCodebase          Kind = "codebase"
+	KindBoolean           Kind = "boolean"
+	KindDate              Kind = "date"
+	KindLabel             Kind = "label"
+	KindPercentage        Kind = "percentage"
 )
 
 // Kind is the kind of field type
@@ -54,6 +58,10 @@ type FieldDefinition struct {
 	Label       string
 	Description string
 	Type        FieldType
+	// Searchable string and markup fields are indexed for the full text search
+	Searchable bool `json:",omitempty"`
+	// Constraints restrict the values of the field beyond its type
+	Constraints *FieldConstraints `json:",omitempty"`
 }
 
 // Ensure FieldDefinition implements the Equaler interface
@@ -75,6 +83,12 @@ func (f FieldDefinition) Equal(u convert.Equaler) bool {
 	if f.Description != other.Description {
 		return false
 	}
+	if f.Searchable != other.Searchable {
+		return false
+	}
+	if !reflect.DeepEqual(f.Constraints, other.Constraints) {
+		return false
+	}
 	return f.Type.Equal(other.Type)
 }
 
@@ -83,7 +97,14 @@ func (f FieldDefinition) ConvertToModel(name string, value interface{}) (interfa
 	if f.Required && (value == nil || (f.Type.GetKind() == KindString && strings.TrimSpace(value.(string)) == "")) {
 		return nil, fmt.Errorf("Value %s is required", name)
 	}
-	return f.Type.ConvertToModel(value)
+	converted, err := f.Type.ConvertToModel(value)
+	if err != nil || f.Constraints == nil {
+		return converted, err
+	}
+	if err := f.Constraints.check(f.Type, converted); err != nil {
+		return nil, err
+	}
+	return converted, nil
 }
 
 // ConvertFromModel converts a field value for use in the REST API layer
@@ -99,6 +120,8 @@ type rawFieldDef struct {
 	Label       string
 	Description string
 	Type        *json.RawMessage
+	Searchable  bool
+	Constraints *FieldConstraints
 }
 
 // Ensure rawFieldDef implements the Equaler interface
@@ -120,6 +143,12 @@ func (f rawFieldDef) Equal(u convert.Equaler) bool {
 	if f.Description != other.Description {
 		return false
 	}
+	if f.Searchable != other.Searchable {
+		return false
+	}
+	if !reflect.DeepEqual(f.Constraints, other.Constraints) {
+		return false
+	}
 	if f.Type == nil && other.Type == nil {
 		return true
 	}
@@ -152,21 +181,21 @@ func (f *FieldDefinition) UnmarshalJSON(bytes []byte) error {
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		*f = FieldDefinition{Type: theType, Required: temp.Required, Label: temp.Label, Description: temp.Description}
+		*f = FieldDefinition{Type: theType, Required: temp.Required, Label: temp.Label, Description: temp.Description, Searchable: temp.Searchable, Constraints: temp.Constraints}
 	case KindEnum:
 		theType := EnumType{}
 		err = json.Unmarshal(*temp.Type, &theType)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		*f = FieldDefinition{Type: theType, Required: temp.Required, Label: temp.Label, Description: temp.Description}
+		*f = FieldDefinition{Type: theType, Required: temp.Required, Label: temp.Label, Description: temp.Description, Searchable: temp.Searchable, Constraints: temp.Constraints}
 	default:
 		theType := SimpleType{}
 		err = json.Unmarshal(*temp.Type, &theType)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		*f = FieldDefinition{Type: theType, Required: temp.Required, Label: temp.Label, Description: temp.Description}
+		*f = FieldDefinition{Type: theType, Required: temp.Required, Label: temp.Label, Description: temp.Description, Searchable: temp.Searchable, Constraints: temp.Constraints}
 	}
 	return nil
 }
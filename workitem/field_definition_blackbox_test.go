@@ -6,8 +6,11 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/resource"
 	. "github.com/almighty/almighty-core/workitem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestListFieldDefMarshalling(t *testing.T) {
@@ -36,3 +39,78 @@ func TestListFieldDefMarshalling(t *testing.T) {
 		t.Errorf("field should be %v, but is %v", def, unmarshalled)
 	}
 }
+
+func TestFieldDefConstraintsMarshalling(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	min, maxItems := 1.5, 3
+	def := FieldDefinition{
+		Label: "Points",
+		Type: ListType{
+			SimpleType:    SimpleType{Kind: KindList},
+			ComponentType: SimpleType{Kind: KindFloat},
+		},
+		Constraints: &FieldConstraints{Min: &min, MaxItems: &maxItems},
+	}
+	// when
+	bytes, err := json.Marshal(def)
+	require.Nil(t, err)
+	unmarshalled := FieldDefinition{}
+	err = json.Unmarshal(bytes, &unmarshalled)
+	// then
+	require.Nil(t, err)
+	assert.Equal(t, def, unmarshalled)
+	assert.True(t, def.Equal(unmarshalled))
+	assert.False(t, def.Equal(FieldDefinition{Label: def.Label, Type: def.Type}))
+}
+
+func TestFieldDefConvertToModelChecksConstraints(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	zero, hundred, two, five := 0.0, 100.0, 2, 5
+	integer := FieldDefinition{
+		Type:        SimpleType{Kind: KindInteger},
+		Constraints: &FieldConstraints{Min: &zero, Max: &hundred},
+	}
+	key := FieldDefinition{
+		Type:        SimpleType{Kind: KindString},
+		Constraints: &FieldConstraints{MinLength: &two, MaxLength: &five, Pattern: "[A-Z]+-[0-9]+"},
+	}
+	labels := FieldDefinition{
+		Type: ListType{
+			SimpleType:    SimpleType{Kind: KindList},
+			ComponentType: SimpleType{Kind: KindLabel},
+		},
+		Constraints: &FieldConstraints{MaxItems: &two, MaxLength: &five},
+	}
+	description := FieldDefinition{
+		Type:        SimpleType{Kind: KindMarkup},
+		Constraints: &FieldConstraints{MaxLength: &five},
+	}
+	for _, test := range []struct {
+		def   FieldDefinition
+		value interface{}
+		valid bool
+	}{
+		{integer, 0, true},
+		{integer, 100, true},
+		{integer, nil, true},
+		{integer, -1, false},
+		{integer, 101, false},
+		{key, "AB-1", true},
+		{key, "ab-1", false},
+		{key, "xAB-1", false},
+		{key, "A", false},
+		{labels, []string{"ui", "api"}, true},
+		{labels, []string{"ui", "api", "db"}, false},
+		{labels, []string{"backend"}, false},
+		{description, rendering.NewMarkupContent("short", rendering.SystemMarkupMarkdown), true},
+		{description, rendering.NewMarkupContent("too long", rendering.SystemMarkupMarkdown), false},
+	} {
+		// when
+		_, err := test.def.ConvertToModel("field", test.value)
+		// then
+		assert.Equal(t, test.valid, err == nil, "converting %v, error: %v", test.value, err)
+	}
+}
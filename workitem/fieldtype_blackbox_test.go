@@ -1,9 +1,10 @@
 package workitem_test
 
 import (
-	"testing"
-
 	"reflect"
+	"strings"
+	"testing"
+	"time"
 
 	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/resource"
@@ -11,15 +12,19 @@ import (
 )
 
 var (
-	stString    = SimpleType{Kind: KindString}
-	stIteration = SimpleType{Kind: KindIteration}
-	stInt       = SimpleType{Kind: KindInteger}
-	stFloat     = SimpleType{Kind: KindFloat}
-	stDuration  = SimpleType{Kind: KindDuration}
-	stURL       = SimpleType{Kind: KindURL}
-	stList      = SimpleType{Kind: KindList}
-	stMarkup    = SimpleType{Kind: KindMarkup}
-	stArea      = SimpleType{Kind: KindArea}
+	stString     = SimpleType{Kind: KindString}
+	stIteration  = SimpleType{Kind: KindIteration}
+	stInt        = SimpleType{Kind: KindInteger}
+	stFloat      = SimpleType{Kind: KindFloat}
+	stDuration   = SimpleType{Kind: KindDuration}
+	stURL        = SimpleType{Kind: KindURL}
+	stList       = SimpleType{Kind: KindList}
+	stMarkup     = SimpleType{Kind: KindMarkup}
+	stArea       = SimpleType{Kind: KindArea}
+	stBoolean    = SimpleType{Kind: KindBoolean}
+	stDate       = SimpleType{Kind: KindDate}
+	stLabel      = SimpleType{Kind: KindLabel}
+	stPercentage = SimpleType{Kind: KindPercentage}
 )
 
 type input struct {
@@ -85,6 +90,26 @@ func TestSimpleTypeConversion(t *testing.T) {
 		{stMarkup, rendering.NewMarkupContent("## description", rendering.SystemMarkupMarkdown), markupContent2, false},
 		{stMarkup, nil, nil, false},
 		{stMarkup, 1, nil, true},
+
+		{stBoolean, true, true, false},
+		{stBoolean, "true", nil, true},
+		{stBoolean, 1, nil, true},
+
+		{stDate, "2017-01-31", "2017-01-31", false},
+		{stDate, time.Date(2017, 1, 31, 12, 0, 0, 0, time.UTC), "2017-01-31", false},
+		{stDate, "31.01.2017", nil, true},
+		{stDate, 1, nil, true},
+
+		{stLabel, "  needs   review ", "needs review", false},
+		{stLabel, "  ", nil, true},
+		{stLabel, strings.Repeat("a", MaxLabelLength+1), nil, true},
+		{stLabel, 1, nil, true},
+
+		{stPercentage, 50, float64(50), false},
+		{stPercentage, 12.5, 12.5, false},
+		{stPercentage, 101, nil, true},
+		{stPercentage, -1, nil, true},
+		{stPercentage, "50", nil, true},
 	}
 	for _, inp := range test_data {
 		retVal, err := inp.t.ConvertToModel(inp.value)
@@ -0,0 +1,83 @@
+package workitem
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/almighty/almighty-core/errors"
+	errs "github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// mergeFields applies the values a client set on the base version of the fields to the current version, the
+// values which are the same as in the base version are not changes of the client and are ignored. A nil value
+// removes a field. The change of a field conflicts if the field was changed to a different value since the base
+// version.
+// returns the merged fields and the sorted names of the conflicting fields
+func mergeFields(base Fields, current Fields, values Fields) (Fields, []string) {
+	merged := Fields{}
+	for name, value := range current {
+		merged[name] = value
+	}
+	var conflicts []string
+	for name, value := range values {
+		if sameFieldValue(base[name], value) || sameFieldValue(current[name], value) {
+			continue
+		}
+		if !sameFieldValue(base[name], current[name]) {
+			conflicts = append(conflicts, name)
+			continue
+		}
+		if value == nil {
+			delete(merged, name)
+		} else {
+			merged[name] = value
+		}
+	}
+	sort.Strings(conflicts)
+	return merged, conflicts
+}
+
+// sameFieldValue returns true if the values of a field are stored the same way, e.g. the values set by a
+// client and the values loaded from the database
+func sameFieldValue(value1 interface{}, value2 interface{}) bool {
+	return reflect.DeepEqual(storedFieldValue(value1), storedFieldValue(value2))
+}
+
+// storedFieldValue returns the value like it is loaded from the JSON of the stored fields
+func storedFieldValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var stored interface{}
+	if err := json.Unmarshal(bytes, &stored); err != nil {
+		return value
+	}
+	return stored
+}
+
+// mergeConcurrentChanges merges the values a client set on the given version of the work item into the
+// current fields of the work item, see mergeFields. The fields of the version are taken from the revisions.
+// returns VersionConflictError if the version is unknown or if fields changed concurrently, InternalError
+func (r *GormWorkItemRepository) mergeConcurrentChanges(ctx context.Context, current *WorkItem, version int, values Fields) (Fields, error) {
+	base, err := r.wirr.LoadVersion(ctx, current.ID, version)
+	if err != nil {
+		if _, ok := errs.Cause(err).(errors.NotFoundError); ok {
+			return nil, errors.NewVersionConflictError("version conflict")
+		}
+		return nil, errs.WithStack(err)
+	}
+	if base.WorkItemTypeID != current.Type {
+		return nil, errors.NewVersionConflictError("version conflict")
+	}
+	merged, conflicts := mergeFields(base.WorkItemFields, current.Fields, values)
+	if len(conflicts) > 0 {
+		return nil, errors.NewFieldConflictError(conflicts)
+	}
+	return merged, nil
+}
@@ -0,0 +1,96 @@
+package workitem
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/rendering"
+	"github.com/almighty/almighty-core/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	mergeBase = Fields{
+		SystemTitle:     "title",
+		SystemState:     SystemStateNew,
+		SystemAssignees: []interface{}{"A"},
+		"points":        float64(3),
+	}
+	mergeCurrent = Fields{
+		SystemTitle:     "title",
+		SystemState:     SystemStateOpen,
+		SystemAssignees: []interface{}{"A"},
+		"points":        float64(5),
+	}
+)
+
+func TestMergeFieldsWithoutOverlap(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// when
+	merged, conflicts := mergeFields(mergeBase, mergeCurrent, Fields{
+		SystemTitle:     "new title",
+		SystemState:     SystemStateNew,
+		SystemAssignees: nil,
+	})
+	// then the unchanged state is not a change
+	assert.Nil(t, conflicts)
+	assert.Equal(t, Fields{
+		SystemTitle: "new title",
+		SystemState: SystemStateOpen,
+		"points":    float64(5),
+	}, merged)
+}
+
+func TestMergeFieldsWithSameChanges(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// when
+	merged, conflicts := mergeFields(mergeBase, mergeCurrent, Fields{
+		SystemState: SystemStateOpen,
+		// stored as a float
+		"points": 5,
+	})
+	// then
+	assert.Nil(t, conflicts)
+	assert.Equal(t, mergeCurrent, merged)
+}
+
+func TestMergeFieldsWithCollidingChanges(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// when
+	_, conflicts := mergeFields(mergeBase, mergeCurrent, Fields{
+		SystemTitle: "new title",
+		SystemState: SystemStateClosed,
+		"points":    nil,
+	})
+	// then
+	assert.Equal(t, []string{"points", SystemState}, conflicts)
+}
+
+func TestMergeFieldsWithNewField(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	description := rendering.NewMarkupContentFromLegacy("description")
+	// when
+	merged, conflicts := mergeFields(mergeBase, mergeCurrent, Fields{
+		SystemDescription: description.ToMap(),
+	})
+	// then
+	assert.Nil(t, conflicts)
+	assert.Equal(t, description.ToMap(), merged[SystemDescription])
+	assert.Equal(t, SystemStateOpen, merged[SystemState])
+}
+
+func TestSameFieldValue(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	assert.True(t, sameFieldValue(nil, nil))
+	assert.True(t, sameFieldValue(1, float64(1)))
+	assert.True(t, sameFieldValue([]string{"A"}, []interface{}{"A"}))
+	assert.True(t, sameFieldValue(map[string]string{"content": "a"}, map[string]interface{}{"content": "a"}))
+	assert.False(t, sameFieldValue("", nil))
+	assert.False(t, sameFieldValue([]string{"A", "B"}, []interface{}{"B", "A"}))
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/almighty/almighty-core/codebase"
 	"github.com/almighty/almighty-core/convert"
+	"github.com/almighty/almighty-core/label"
 	"github.com/almighty/almighty-core/rendering"
 	"github.com/asaskevich/govalidator"
 	"github.com/pkg/errors"
@@ -103,11 +105,47 @@ func (fieldType SimpleType) ConvertToModel(value interface{}) (interface{}, erro
 		default:
 			return nil, errors.Errorf("value %v should be %s, but is %s", value, "CodebaseContent", valueType)
 		}
+	case KindBoolean:
+		if valueType.Kind() != reflect.Bool {
+			return nil, fmt.Errorf("value %v should be %s, but is %s", value, "bool", valueType.Name())
+		}
+		return value, nil
+	case KindDate:
+		// dates are stored like 2017-01-31, which orders them chronologically
+		switch t := value.(type) {
+		case time.Time:
+			return t.Format(DateLayout), nil
+		case string:
+			day, err := time.Parse(DateLayout, strings.TrimSpace(t))
+			if err != nil {
+				return nil, fmt.Errorf("value %v should be %s", value, "a date like "+DateLayout)
+			}
+			return day.Format(DateLayout), nil
+		}
+		return nil, fmt.Errorf("value %v should be %s, but is %s", value, "a date", valueType.Name())
+	case KindLabel:
+		if valueType.Kind() != reflect.String {
+			return nil, fmt.Errorf("value %v should be %s, but is %s", value, "string", valueType.Name())
+		}
+		normalized, err := label.Normalize(value.(string))
+		if err != nil {
+			return nil, err
+		}
+		return normalized, nil
+	case KindPercentage:
+		number, ok := floatValue(value)
+		if !ok || number.(float64) < 0 || number.(float64) > 100 {
+			return nil, fmt.Errorf("value %v should be %s", value, "a number from 0 to 100")
+		}
+		return number, nil
 	default:
 		return nil, errors.Errorf("unexpected type constant: '%s'", fieldType.GetKind())
 	}
 }
 
+// MaxLabelLength is the maximum number of characters of a label
+const MaxLabelLength = label.MaxLength
+
 // ConvertFromModel implements the FieldType interface
 func (fieldType SimpleType) ConvertFromModel(value interface{}) (interface{}, error) {
 	if value == nil {
@@ -115,7 +153,8 @@ func (fieldType SimpleType) ConvertFromModel(value interface{}) (interface{}, er
 	}
 	valueType := reflect.TypeOf(value)
 	switch fieldType.GetKind() {
-	case KindString, KindURL, KindUser, KindInteger, KindFloat, KindDuration, KindIteration, KindArea:
+	case KindString, KindURL, KindUser, KindInteger, KindFloat, KindDuration, KindIteration, KindArea,
+		KindBoolean, KindDate, KindLabel, KindPercentage:
 		return value, nil
 	case KindInstant:
 		return time.Unix(0, value.(int64)), nil
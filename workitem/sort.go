@@ -0,0 +1,78 @@
+package workitem
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/pagination"
+	errs "github.com/pkg/errors"
+)
+
+// Names of the sort keys which refer to columns of the work item table instead of work item fields
+const (
+	SortByID        = "ID"
+	SortByVersion   = "Version"
+	SortByCreatedAt = SystemCreatedAt
+	SortByUpdatedAt = "UpdatedAt"
+)
+
+var sortColumns = map[string]string{
+	SortByID:        "id",
+	SortByVersion:   "version",
+	SortByCreatedAt: "created_at",
+	SortByUpdatedAt: "updated_at",
+}
+
+// field names end up in the order clause verbatim, so only allow what field names are made of
+var sortableFieldName = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+// SortKey describes one key of the order in which work items are listed.
+type SortKey struct {
+	// Field is either one of the SortBy* column names or the name of a work item field
+	Field      string
+	Descending bool
+}
+
+// String returns the key in the form "field" or "-field" for descending keys
+func (k SortKey) String() string {
+	if k.Descending {
+		return "-" + k.Field
+	}
+	return k.Field
+}
+
+// sortKeys converts the sort keys into the SQL expressions to order by. Work item fields are compared
+// as jsonb values, so numbers are ordered numerically and strings alphabetically. Work items without
+// a value for a field come last in ascending and first in descending order. The ID is appended as
+// tie-breaker so that the order is total and paging through the results is stable.
+// returns BadParameterError for malformed field names
+func sortKeys(order []SortKey) ([]pagination.Key, error) {
+	result := make([]pagination.Key, 0, len(order)+1)
+	for _, key := range order {
+		var expression string
+		if column, ok := sortColumns[key.Field]; ok {
+			expression = column
+		} else {
+			if !sortableFieldName.MatchString(key.Field) {
+				return nil, errors.NewBadParameterError("sort", key.Field)
+			}
+			expression = fmt.Sprintf("Fields->'%s'", key.Field)
+		}
+		result = append(result, pagination.Key{Expression: expression, Descending: key.Descending})
+		if key.Field == SortByID {
+			// the order is total already, later keys would never be consulted
+			return result, nil
+		}
+	}
+	return append(result, pagination.Key{Expression: sortColumns[SortByID]}), nil
+}
+
+// orderClause builds the SQL order clause for the given keys, see sortKeys
+func orderClause(order []SortKey) (string, error) {
+	keys, err := sortKeys(order)
+	if err != nil {
+		return "", errs.WithStack(err)
+	}
+	return pagination.Keyset{}.OrderBy(keys), nil
+}
@@ -0,0 +1,41 @@
+package workitem
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderClause(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	testData := []struct {
+		keys     []SortKey
+		expected string
+	}{
+		{nil, "id asc"},
+		{[]SortKey{{Field: SortByUpdatedAt, Descending: true}}, "updated_at desc, id asc"},
+		{[]SortKey{{Field: SystemTitle}, {Field: SortByVersion, Descending: true}}, "Fields->'system.title' asc, version desc, id asc"},
+		{[]SortKey{{Field: SortByCreatedAt}, {Field: "story_points", Descending: true}}, "created_at asc, Fields->'story_points' desc, id asc"},
+		{[]SortKey{{Field: SortByID, Descending: true}}, "id desc"},
+		// keys after the ID can never make a difference
+		{[]SortKey{{Field: SystemState}, {Field: SortByID}, {Field: SystemTitle}}, "Fields->'system.state' asc, id asc"},
+	}
+	for _, d := range testData {
+		clause, err := orderClause(d.keys)
+		require.Nil(t, err)
+		assert.Equal(t, d.expected, clause, "unexpected order for %v", d.keys)
+	}
+}
+
+func TestOrderClauseInvalidField(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	_, err := orderClause([]SortKey{{Field: "title'; drop table work_items; --"}})
+	require.NotNil(t, err)
+	assert.IsType(t, errors.BadParameterError{}, errs.Cause(err))
+}
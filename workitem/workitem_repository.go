@@ -1,7 +1,11 @@
 package workitem
 
 import (
+	"database/sql"
+	"encoding/json"
+	"sort"
 	"strconv"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -10,7 +14,9 @@ import (
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/criteria"
 	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/label"
 	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/rendering"
 
 	"github.com/goadesign/goa"
@@ -23,12 +29,15 @@ import (
 type WorkItemRepository interface {
 	Load(ctx context.Context, ID string) (*app.WorkItem, error)
 	Save(ctx context.Context, wi app.WorkItem, modifierID uuid.UUID) (*app.WorkItem, error)
+	Patch(ctx context.Context, ID string, version int, fields map[string]interface{}, modifierID uuid.UUID) (*app.WorkItem, error)
 	Delete(ctx context.Context, ID string, suppressorID uuid.UUID) error
 	Create(ctx context.Context, spaceID uuid.UUID, typeID uuid.UUID, fields map[string]interface{}, creatorID uuid.UUID) (*app.WorkItem, error)
-	List(ctx context.Context, criteria criteria.Expression, start *int, length *int) ([]*app.WorkItem, uint64, error)
+	List(ctx context.Context, criteria criteria.Expression, order []SortKey, start *int, length *int) ([]*app.WorkItem, uint64, error)
+	ListPage(ctx context.Context, criteria criteria.Expression, order []SortKey, keyset pagination.Keyset) ([]*app.WorkItem, *pagination.Page, error)
 	Fetch(ctx context.Context, criteria criteria.Expression) (*app.WorkItem, error)
 	GetCountsPerIteration(ctx context.Context, spaceID uuid.UUID) (map[string]WICountsPerIteration, error)
 	GetCountsForIteration(ctx context.Context, iterationID uuid.UUID) (map[string]WICountsPerIteration, error)
+	Aggregate(ctx context.Context, criteria criteria.Expression, groupBy []string) ([]AggregationBucket, error)
 }
 
 // NewWorkItemRepository creates a GormWorkItemRepository
@@ -112,11 +121,13 @@ func (r *GormWorkItemRepository) Delete(ctx context.Context, workitemID string,
 	if err != nil {
 		return err
 	}
+	notifyDeleted(ctx, r.db, workItem.ID)
 	log.Debug(ctx, map[string]interface{}{"wiID": workitemID}, "Work item deleted successfully!")
 	return nil
 }
 
-// Save updates the given work item in storage. Version must be the same as the one int the stored version
+// Save updates the given work item in storage. If the version is not the same as the one of the stored work item,
+// the changes made since the version are merged with the given fields, see mergeFields
 // returns NotFoundError, VersionConflictError, ConversionError or InternalError
 func (r *GormWorkItemRepository) Save(ctx context.Context, wi app.WorkItem, modifierID uuid.UUID) (*app.WorkItem, error) {
 	res := WorkItem{}
@@ -138,32 +149,47 @@ func (r *GormWorkItemRepository) Save(ctx context.Context, wi app.WorkItem, modi
 	if tx.Error != nil {
 		return nil, errors.NewInternalError(err.Error())
 	}
-	if res.Version != wi.Version {
-		return nil, errors.NewVersionConflictError("version conflict")
-	}
 
 	wiType, err := r.witr.LoadTypeFromDB(ctx, wi.Type)
 	if err != nil {
 		return nil, errors.NewBadParameterError("Type", wi.Type)
 	}
 
-	res.Version = res.Version + 1
-	res.Type = wi.Type
-	res.Fields = Fields{}
-
+	fields := Fields{}
 	for fieldName, fieldDef := range wiType.Fields {
 		if fieldName == SystemCreatedAt {
 			continue
 		}
 		fieldValue := wi.Fields[fieldName]
 		var err error
-		res.Fields[fieldName], err = fieldDef.ConvertToModel(fieldName, fieldValue)
+		fields[fieldName], err = fieldDef.ConvertToModel(fieldName, fieldValue)
 		if err != nil {
 			return nil, errors.NewBadParameterError(fieldName, fieldValue)
 		}
 	}
+	version := res.Version
+	if version != wi.Version {
+		// the work item was changed since the client loaded it, its changes are merged unless they collide
+		if res.Type != wi.Type {
+			return nil, errors.NewVersionConflictError("version conflict")
+		}
+		if fields, err = r.mergeConcurrentChanges(ctx, &res, wi.Version, fields); err != nil {
+			return nil, errs.WithStack(err)
+		}
+	}
+
+	previous := res.Fields
+	res.Version = version + 1
+	res.Type = wi.Type
+	res.Fields = fields
+	if err := r.checkUniqueFields(wiType, res, previous); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	if err := checkLabels(ctx, r.db, wiType, res, previous); err != nil {
+		return nil, errs.WithStack(err)
+	}
 
-	tx = tx.Where("Version = ?", wi.Version).Save(&res)
+	tx = tx.Where("Version = ?", version).Save(&res)
 	if err := tx.Error; err != nil {
 		log.Error(ctx, map[string]interface{}{
 			"wiID": wi.ID,
@@ -179,12 +205,82 @@ func (r *GormWorkItemRepository) Save(ctx context.Context, wi app.WorkItem, modi
 	if err != nil {
 		return nil, err
 	}
+	NotifyChanged(ctx, r.db, res.ID)
 	log.Info(ctx, map[string]interface{}{
 		"wiID": wi.ID,
 	}, "Updated work item repository")
 	return convertWorkItemModelToApp(goa.ContextRequest(ctx), wiType, &res)
 }
 
+// Patch applies the given fields to the work item with the given id like a JSON merge patch (RFC 7396): only the given
+// fields are validated and written, a nil value removes a field and the other fields are left as they are. Fields the
+// type of the work item doesn't define are ignored, like in Save. Concurrent changes are merged like in Save.
+// returns NotFoundError, VersionConflictError, BadParameterError, ConversionError or InternalError
+func (r *GormWorkItemRepository) Patch(ctx context.Context, ID string, version int, fields map[string]interface{}, modifierID uuid.UUID) (*app.WorkItem, error) {
+	res, err := r.LoadFromDB(ctx, ID)
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+	wiType, err := r.witr.LoadTypeFromDB(ctx, res.Type)
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	values := Fields{}
+	for fieldName, fieldValue := range fields {
+		fieldDef, ok := wiType.Fields[fieldName]
+		if !ok || fieldName == SystemCreatedAt {
+			continue
+		}
+		if fieldValue == nil {
+			if fieldDef.Required {
+				return nil, errors.NewBadParameterError(fieldName, fieldValue).Expected("a value of the required field")
+			}
+			values[fieldName] = nil
+			continue
+		}
+		values[fieldName], err = fieldDef.ConvertToModel(fieldName, fieldValue)
+		if err != nil {
+			return nil, errors.NewBadParameterError(fieldName, fieldValue)
+		}
+	}
+	current := res.Version
+	previous := res.Fields
+	if current == version {
+		// without concurrent changes the current fields are the base of the patch
+		res.Fields, _ = mergeFields(res.Fields, res.Fields, values)
+	} else if res.Fields, err = r.mergeConcurrentChanges(ctx, res, version, values); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	if err := r.checkUniqueFields(wiType, *res, previous); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	if err := checkLabels(ctx, r.db, wiType, *res, previous); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	res.Version = current + 1
+	tx := r.db.Where("Version = ?", current).Save(res)
+	if err := tx.Error; err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"wiID": ID,
+			"err":  err,
+		}, "unable to patch the work item")
+		return nil, errors.NewInternalError(err.Error())
+	}
+	if tx.RowsAffected == 0 {
+		return nil, errors.NewVersionConflictError("version conflict")
+	}
+	// store a revision of the modified work item
+	if err := r.wirr.Create(context.Background(), modifierID, RevisionTypeUpdate, *res); err != nil {
+		return nil, err
+	}
+	NotifyChanged(ctx, r.db, res.ID)
+	log.Info(ctx, map[string]interface{}{
+		"wiID":   ID,
+		"fields": len(fields),
+	}, "Patched work item")
+	return convertWorkItemModelToApp(goa.ContextRequest(ctx), wiType, res)
+}
+
 // Create creates a new work item in the repository
 // returns BadParameterError, ConversionError or InternalError
 func (r *GormWorkItemRepository) Create(ctx context.Context, spaceID uuid.UUID, typeID uuid.UUID, fields map[string]interface{}, creatorID uuid.UUID) (*app.WorkItem, error) {
@@ -215,6 +311,12 @@ func (r *GormWorkItemRepository) Create(ctx context.Context, spaceID uuid.UUID,
 			}
 		}
 	}
+	if err := r.checkUniqueFields(wiType, wi, nil); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	if err := checkLabels(ctx, r.db, wiType, wi, nil); err != nil {
+		return nil, errs.WithStack(err)
+	}
 	tx := r.db
 	if err = tx.Create(&wi).Error; err != nil {
 		return nil, errs.Wrapf(err, "Failed to create work item")
@@ -229,10 +331,90 @@ func (r *GormWorkItemRepository) Create(ctx context.Context, spaceID uuid.UUID,
 	if err != nil {
 		return nil, err
 	}
+	NotifyChanged(ctx, r.db, wi.ID)
 	log.Debug(ctx, map[string]interface{}{"pkg": "workitem", "wiID": wi.ID}, "Work item created successfully!")
 	return witem, nil
 }
 
+// checkUniqueFields checks the values of the unique fields of the work item which differ from the previous values.
+// Each check takes a transaction-scoped advisory lock on the field of the type in the space, so that concurrent
+// transactions storing a value of the same field wait until the transaction ends. Outside of a transaction the lock
+// is released right away.
+// returns BadParameterError if another work item of the type in the space has the value, or InternalError
+func (r *GormWorkItemRepository) checkUniqueFields(wiType *WorkItemType, wi WorkItem, previous Fields) error {
+	for name, definition := range wiType.Fields {
+		value := wi.Fields[name]
+		if definition.Constraints == nil || !definition.Constraints.Unique || value == nil || sameFieldValue(previous[name], value) {
+			continue
+		}
+		containment, err := json.Marshal(map[string]interface{}{name: value})
+		if err != nil {
+			return errors.NewInternalError(err.Error())
+		}
+		lock := fmt.Sprintf("unique:%s:%s:%s", wi.SpaceID, wi.Type, name)
+		if err := r.db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lock).Error; err != nil {
+			return errors.NewInternalError(err.Error())
+		}
+		var count int
+		db := r.db.Model(&WorkItem{}).Where("space_id = ? AND type = ? AND id != ? AND fields @> ?::jsonb", wi.SpaceID, wi.Type, wi.ID, string(containment)).Count(&count)
+		if db.Error != nil {
+			return errors.NewInternalError(db.Error.Error())
+		}
+		if count > 0 {
+			return errors.NewBadParameterError(name, value).Expected("a value which no other work item of the type in the space has")
+		}
+	}
+	return nil
+}
+
+// checkLabels checks that the values of the label fields of the work item which aren't among the previous values
+// are labels of the vocabulary of its space
+// returns BadParameterError naming the field and the first unknown label, or InternalError
+func checkLabels(ctx context.Context, db *gorm.DB, wiType *WorkItemType, wi WorkItem, previous Fields) error {
+	for name, definition := range wiType.Fields {
+		if elementType(definition.Type).GetKind() != KindLabel {
+			continue
+		}
+		known := map[string]bool{}
+		for _, l := range labelValues(previous[name]) {
+			known[l] = true
+		}
+		var added []string
+		for _, l := range labelValues(wi.Fields[name]) {
+			if !known[l] {
+				added = append(added, l)
+			}
+		}
+		missing, err := label.NewLabelRepository(db).Missing(ctx, wi.SpaceID, added)
+		if err != nil {
+			return errs.WithStack(err)
+		}
+		if len(missing) > 0 {
+			return errors.NewBadParameterError(name, missing[0]).Expected("a label of the space")
+		}
+	}
+	return nil
+}
+
+// labelValues returns the labels stored in a label field or a label list field
+func labelValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, element := range v {
+			if l, ok := element.(string); ok {
+				result = append(result, l)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
 func convertWorkItemModelToApp(request *goa.RequestData, wiType *WorkItemType, wi *WorkItem) (*app.WorkItem, error) {
 	result, err := wiType.ConvertFromModel(request, *wi)
 	if err != nil {
@@ -245,21 +427,121 @@ func convertWorkItemModelToApp(request *goa.RequestData, wiType *WorkItemType, w
 
 }
 
+// fieldDefinitions returns the definitions of the fields the expression refers to and of the given fields, the
+// same field name may be defined by several types. With numeric the definitions of all numeric fields are
+// returned as well. If the expression is restricted to some work item types only their fields are returned.
+// The types are loaded through the cache of work item types, only the IDs of the types defining the fields are
+// queried if the expression isn't restricted to known types.
+// returns InternalError
+func (r *GormWorkItemRepository) fieldDefinitions(ctx context.Context, where criteria.Expression, names []string, numeric bool) (map[string][]FieldDefinition, error) {
+	wanted := map[string]bool{}
+	for _, name := range append(ReferencedFields(where), names...) {
+		wanted[name] = true
+	}
+	fields := map[string][]FieldDefinition{}
+	if len(wanted) == 0 && !numeric {
+		return fields, nil
+	}
+	var types map[uuid.UUID]*WorkItemType
+	if ids := TypeRestriction(where); ids != nil {
+		restricted, err := r.witr.LoadTypesFromDB(ctx, ids)
+		if _, notFound := errs.Cause(err).(errors.NotFoundError); err != nil && !notFound {
+			return nil, errs.WithStack(err)
+		}
+		// unknown types don't match anything anyway, so all fields are fine then
+		if err == nil {
+			types = restricted
+		}
+	}
+	if types == nil {
+		ids, err := r.typesDefining(wanted, numeric)
+		if err != nil {
+			return nil, errs.WithStack(err)
+		}
+		if types, err = r.witr.LoadTypesFromDB(ctx, ids); err != nil {
+			return nil, errs.WithStack(err)
+		}
+	}
+	// the definitions of a field are ordered by the IDs of their types like the types are stored
+	byID := make(map[string]*WorkItemType, len(types))
+	ids := make([]string, 0, len(types))
+	for id, wit := range types {
+		byID[id.String()] = wit
+		ids = append(ids, id.String())
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		for name, definition := range byID[id].Fields {
+			if wanted[name] || (numeric && numericKinds[definition.Type.GetKind()]) {
+				fields[name] = append(fields[name], definition)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// typesDefining returns the IDs of the work item types which define one of the given fields or, with numeric,
+// a numeric field
+// returns InternalError
+func (r *GormWorkItemRepository) typesDefining(names map[string]bool, numeric bool) ([]uuid.UUID, error) {
+	// an empty list is compiled to null, which matches nothing
+	nameList := []string{}
+	for name := range names {
+		nameList = append(nameList, name)
+	}
+	kinds := []string{}
+	if numeric {
+		for kind := range numericKinds {
+			kinds = append(kinds, string(kind))
+		}
+	}
+	var types []WorkItemType
+	db := r.db.Select("id").Where("exists (select 1 from jsonb_each(fields) as f(name, definition) where f.name in (?) or f.definition->'Type'->>'Kind' in (?))", nameList, kinds).Find(&types)
+	if db.Error != nil {
+		return nil, errors.NewInternalError(db.Error.Error())
+	}
+	ids := make([]uuid.UUID, len(types))
+	for i, wit := range types {
+		ids[i] = wit.ID
+	}
+	return ids, nil
+}
+
+// coerceLiterals checks the literals of the expression against the definitions of the fields they are
+// compared with and converts them, see CoerceLiterals.
+// returns BadParameterError or InternalError
+func (r *GormWorkItemRepository) coerceLiterals(ctx context.Context, where criteria.Expression) error {
+	fields, err := r.fieldDefinitions(ctx, where, nil, false)
+	if err != nil {
+		return errs.WithStack(err)
+	}
+	return CoerceLiterals(where, fields)
+}
+
 // extracted this function from List() in order to close the rows object with "defer" for more readability
 // workaround for https://github.com/lib/pq/issues/81
-func (r *GormWorkItemRepository) listItemsFromDB(ctx context.Context, criteria criteria.Expression, start *int, limit *int) ([]WorkItem, uint64, error) {
+func (r *GormWorkItemRepository) listItemsFromDB(ctx context.Context, criteria criteria.Expression, order []SortKey, start *int, limit *int) ([]WorkItem, uint64, error) {
+	if err := r.coerceLiterals(ctx, criteria); err != nil {
+		return nil, 0, errs.WithStack(err)
+	}
 	where, parameters, compileError := Compile(criteria)
 	if compileError != nil {
 		return nil, 0, errors.NewBadParameterError("expression", criteria)
 	}
+	orderBy, err := orderClause(order)
+	if err != nil {
+		return nil, 0, errs.WithStack(err)
+	}
 
 	log.Info(ctx, map[string]interface{}{
 		"where":      where,
 		"parameters": parameters,
+		"order":      orderBy,
 	}, "Executing query : '%s' with params %v", where, parameters)
 
 	db := r.db.Model(&WorkItem{}).Where(where, parameters...)
 	orgDB := db
+	db = db.Order(orderBy)
 	if start != nil {
 		if *start < 0 {
 			return nil, 0, errors.NewBadParameterError("start", *start)
@@ -324,27 +606,108 @@ func (r *GormWorkItemRepository) listItemsFromDB(ctx context.Context, criteria c
 	return result, count, nil
 }
 
-// List returns work item selected by the given criteria.Expression, starting with start (zero-based) and returning at most limit items
-func (r *GormWorkItemRepository) List(ctx context.Context, criteria criteria.Expression, start *int, limit *int) ([]*app.WorkItem, uint64, error) {
-	result, count, err := r.listItemsFromDB(ctx, criteria, start, limit)
+// List returns work item selected by the given criteria.Expression in the given order, starting with start (zero-based)
+// and returning at most limit items. Work items are ordered by ID if order is empty.
+func (r *GormWorkItemRepository) List(ctx context.Context, criteria criteria.Expression, order []SortKey, start *int, limit *int) ([]*app.WorkItem, uint64, error) {
+	result, count, err := r.listItemsFromDB(ctx, criteria, order, start, limit)
+	if err != nil {
+		return nil, 0, errs.WithStack(err)
+	}
+	res, err := r.convertWorkItems(ctx, result)
 	if err != nil {
 		return nil, 0, errs.WithStack(err)
 	}
-	res := make([]*app.WorkItem, len(result))
-	for index, value := range result {
-		wiType, err := r.witr.LoadTypeFromDB(ctx, value.Type)
+	return res, count, nil
+}
+
+// listPageFromDB fetches the work items on the page described by the keyset
+func (r *GormWorkItemRepository) listPageFromDB(ctx context.Context, criteria criteria.Expression, order []SortKey, keyset pagination.Keyset) ([]WorkItem, *pagination.Page, error) {
+	if err := r.coerceLiterals(ctx, criteria); err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	where, parameters, compileError := Compile(criteria)
+	if compileError != nil {
+		return nil, nil, errors.NewBadParameterError("expression", criteria)
+	}
+	keys, err := sortKeys(order)
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	if err := keyset.Validate(keys); err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	var count uint64
+	if err := r.db.Model(&WorkItem{}).Where(where, parameters...).Count(&count).Error; err != nil {
+		return nil, nil, errors.NewInternalError(err.Error())
+	}
+
+	condition, conditionParameters := keyset.Condition(keys)
+	log.Info(ctx, map[string]interface{}{
+		"where":      where,
+		"parameters": parameters,
+		"condition":  condition,
+	}, "Executing query : '%s' with params %v", where, parameters)
+	db := r.db.Model(&WorkItem{}).Where(where, parameters...).Where(condition, conditionParameters...)
+	db = db.Order(keyset.OrderBy(keys)).Limit(keyset.Limit + 1).Select("*, " + pagination.Columns(keys))
+	rows, err := db.Rows()
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	defer rows.Close()
+	result := []WorkItem{}
+	page, n, err := keyset.ScanPage(rows, keys, count, func() error {
+		value := WorkItem{}
+		if err := db.ScanRows(rows, &value); err != nil {
+			return err
+		}
+		result = append(result, value)
+		return nil
+	}, func(i, j int) { result[i], result[j] = result[j], result[i] })
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	return result[:n], page, nil
+}
+
+// ListPage returns the work items selected by the given criteria.Expression on the page described by the keyset,
+// see List for the order. The page also tells the total number of selected work items.
+// returns BadParameterError if the keyset's cursor was created for another order
+func (r *GormWorkItemRepository) ListPage(ctx context.Context, criteria criteria.Expression, order []SortKey, keyset pagination.Keyset) ([]*app.WorkItem, *pagination.Page, error) {
+	result, page, err := r.listPageFromDB(ctx, criteria, order, keyset)
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	res, err := r.convertWorkItems(ctx, result)
+	if err != nil {
+		return nil, nil, errs.WithStack(err)
+	}
+	return res, page, nil
+}
+
+// convertWorkItems converts the work items, loading their types at once
+func (r *GormWorkItemRepository) convertWorkItems(ctx context.Context, workItems []WorkItem) ([]*app.WorkItem, error) {
+	typeIDs := make([]uuid.UUID, len(workItems))
+	for index, value := range workItems {
+		typeIDs[index] = value.Type
+	}
+	wiTypes, err := r.witr.LoadTypesFromDB(ctx, typeIDs)
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	res := make([]*app.WorkItem, len(workItems))
+	for index, value := range workItems {
+		res[index], err = convertWorkItemModelToApp(goa.ContextRequest(ctx), wiTypes[value.Type], &value)
 		if err != nil {
-			return nil, 0, errors.NewInternalError(err.Error())
+			return nil, errs.WithStack(err)
 		}
-		res[index], err = convertWorkItemModelToApp(goa.ContextRequest(ctx), wiType, &value)
 	}
-	return res, count, nil
+	return res, nil
 }
 
 // Fetch fetches the (first) work item matching by the given criteria.Expression.
 func (r *GormWorkItemRepository) Fetch(ctx context.Context, criteria criteria.Expression) (*app.WorkItem, error) {
 	limit := 1
-	results, count, err := r.List(ctx, criteria, nil, &limit)
+	results, count, err := r.List(ctx, criteria, nil, nil, &limit)
 	if err != nil {
 		return nil, err
 	}
@@ -404,3 +767,72 @@ func (r *GormWorkItemRepository) GetCountsForIteration(ctx context.Context, iter
 	}
 	return countsMap, nil
 }
+
+// Aggregate counts the work items matching the criteria per value of the group-by fields and sums up their
+// numeric fields. Work items are grouped by one or two fields, see MaxGroupByFields, which are either "Type" or
+// enum, user, area, iteration, boolean or label fields. A work item is counted for each value of list fields like
+// the assignees.
+// The buckets are ordered by their keys, work items without a value of a field are in the last bucket for it.
+// returns BadParameterError or InternalError
+func (r *GormWorkItemRepository) Aggregate(ctx context.Context, criteria criteria.Expression, groupBy []string) ([]AggregationBucket, error) {
+	defer goa.MeasureSince([]string{"goa", "db", "workitem", "aggregate"}, time.Now())
+	fields, err := r.fieldDefinitions(ctx, criteria, groupBy, true)
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+	if err := CoerceLiterals(criteria, fields); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	statement, err := newAggregation(groupBy, fields)
+	if err != nil {
+		return nil, errs.WithStack(err)
+	}
+	where, parameters, compileError := Compile(criteria)
+	if compileError != nil {
+		return nil, errors.NewBadParameterError("expression", criteria)
+	}
+	db := r.db.Model(&WorkItem{})
+	for _, join := range statement.joins {
+		db = db.Joins(join)
+	}
+	db = db.Where(where, parameters...).Select(statement.selectClause()).Group(statement.groupClause()).Order(statement.groupClause())
+	rows, err := db.Rows()
+	if err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	defer rows.Close()
+
+	result := []AggregationBucket{}
+	for rows.Next() {
+		keys := make([]sql.NullString, len(statement.keys))
+		sums := make([]sql.NullFloat64, len(statement.sumFields))
+		bucket := AggregationBucket{Keys: make([]*string, len(keys)), Sums: map[string]float64{}}
+		columns := []interface{}{}
+		for i := range keys {
+			columns = append(columns, &keys[i])
+		}
+		columns = append(columns, &bucket.Count)
+		for i := range sums {
+			columns = append(columns, &sums[i])
+		}
+		if err := rows.Scan(columns...); err != nil {
+			return nil, errors.NewInternalError(err.Error())
+		}
+		for i, key := range keys {
+			if key.Valid {
+				value := key.String
+				bucket.Keys[i] = &value
+			}
+		}
+		for i, name := range statement.sumFields {
+			// sums over work items without a value are null
+			bucket.Sums[name] = sums[i].Float64
+		}
+		result = append(result, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	return result, nil
+}
+
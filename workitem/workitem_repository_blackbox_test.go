@@ -3,15 +3,21 @@ package workitem_test
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/codebase"
+	config "github.com/almighty/almighty-core/configuration"
+	"github.com/almighty/almighty-core/criteria"
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/gormsupport"
 	"github.com/almighty/almighty-core/gormsupport/cleaner"
 	"github.com/almighty/almighty-core/iteration"
+	"github.com/almighty/almighty-core/label"
 	"github.com/almighty/almighty-core/migration"
 	"github.com/almighty/almighty-core/models"
+	"github.com/almighty/almighty-core/pagination"
 	"github.com/almighty/almighty-core/rendering"
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/space"
@@ -155,6 +161,143 @@ func (s *workItemRepoBlackBoxTest) TestSaveForUnchangedCreatedDate() {
 	assert.Equal(s.T(), wi.Fields[workitem.SystemCreatedAt], wiNew.Fields[workitem.SystemCreatedAt])
 }
 
+func (s *workItemRepoBlackBoxTest) TestPatchOnlyChangesGivenFields() {
+	// given
+	wi, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle:       "Title",
+			workitem.SystemDescription: rendering.NewMarkupContentFromLegacy("Description"),
+			workitem.SystemState:       workitem.SystemStateNew,
+			workitem.SystemAssignees:   []string{"A"},
+		}, s.creatorID)
+	require.Nil(s.T(), err, "Could not create workitem")
+	// when
+	patched, err := s.repo.Patch(s.ctx, wi.ID, wi.Version, map[string]interface{}{
+		workitem.SystemState:     workitem.SystemStateOpen,
+		workitem.SystemAssignees: nil,
+		"unknown":                "ignored",
+	}, s.creatorID)
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), wi.Version+1, patched.Version)
+	assert.Equal(s.T(), workitem.SystemStateOpen, patched.Fields[workitem.SystemState])
+	assert.Equal(s.T(), "Title", patched.Fields[workitem.SystemTitle])
+	assert.Equal(s.T(), rendering.NewMarkupContentFromLegacy("Description"), patched.Fields[workitem.SystemDescription])
+	assert.Nil(s.T(), patched.Fields[workitem.SystemAssignees])
+	assert.Equal(s.T(), wi.Fields[workitem.SystemCreatedAt], patched.Fields[workitem.SystemCreatedAt])
+	loaded, err := s.repo.Load(s.ctx, wi.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), patched.Fields, loaded.Fields)
+}
+
+func (s *workItemRepoBlackBoxTest) TestPatchFailures() {
+	// given
+	wi, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle: "Title",
+			workitem.SystemState: workitem.SystemStateNew,
+		}, s.creatorID)
+	require.Nil(s.T(), err, "Could not create workitem")
+	// when removing a required field
+	_, err = s.repo.Patch(s.ctx, wi.ID, wi.Version, map[string]interface{}{workitem.SystemTitle: nil}, s.creatorID)
+	// then
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+	// when setting an invalid value
+	_, err = s.repo.Patch(s.ctx, wi.ID, wi.Version, map[string]interface{}{workitem.SystemState: "invalid"}, s.creatorID)
+	// then
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+	// when patching an outdated version
+	_, err = s.repo.Patch(s.ctx, wi.ID, wi.Version+1, map[string]interface{}{workitem.SystemTitle: "New"}, s.creatorID)
+	// then
+	assert.IsType(s.T(), errors.VersionConflictError{}, errs.Cause(err))
+	// when patching a work item which doesn't exist
+	_, err = s.repo.Patch(s.ctx, "2398475203", 0, map[string]interface{}{workitem.SystemTitle: "New"}, s.creatorID)
+	// then
+	assert.IsType(s.T(), errors.NotFoundError{}, errs.Cause(err))
+}
+
+func (s *workItemRepoBlackBoxTest) TestSaveMergesConcurrentChanges() {
+	// given two clients loading the same version
+	wi, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle: "Title",
+			workitem.SystemState: workitem.SystemStateNew,
+		}, s.creatorID)
+	require.Nil(s.T(), err, "Could not create workitem")
+	first, err := s.repo.Load(s.ctx, wi.ID)
+	require.Nil(s.T(), err)
+	second, err := s.repo.Load(s.ctx, wi.ID)
+	require.Nil(s.T(), err)
+	first.Fields[workitem.SystemState] = workitem.SystemStateOpen
+	_, err = s.repo.Save(s.ctx, *first, s.creatorID)
+	require.Nil(s.T(), err)
+	// when the second client changes another field
+	second.Fields[workitem.SystemTitle] = "New Title"
+	saved, err := s.repo.Save(s.ctx, *second, s.creatorID)
+	// then both changes are kept
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), wi.Version+2, saved.Version)
+	assert.Equal(s.T(), "New Title", saved.Fields[workitem.SystemTitle])
+	assert.Equal(s.T(), workitem.SystemStateOpen, saved.Fields[workitem.SystemState])
+}
+
+func (s *workItemRepoBlackBoxTest) TestSaveReportsConflictingFields() {
+	// given two clients loading the same version
+	wi, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle: "Title",
+			workitem.SystemState: workitem.SystemStateNew,
+		}, s.creatorID)
+	require.Nil(s.T(), err, "Could not create workitem")
+	first, err := s.repo.Load(s.ctx, wi.ID)
+	require.Nil(s.T(), err)
+	second, err := s.repo.Load(s.ctx, wi.ID)
+	require.Nil(s.T(), err)
+	first.Fields[workitem.SystemState] = workitem.SystemStateOpen
+	_, err = s.repo.Save(s.ctx, *first, s.creatorID)
+	require.Nil(s.T(), err)
+	// when the second client changes the same field to another value
+	second.Fields[workitem.SystemState] = workitem.SystemStateClosed
+	second.Fields[workitem.SystemTitle] = "New Title"
+	_, err = s.repo.Save(s.ctx, *second, s.creatorID)
+	// then only the colliding field is reported
+	require.NotNil(s.T(), err)
+	require.IsType(s.T(), errors.VersionConflictError{}, errs.Cause(err))
+	assert.Equal(s.T(), []string{workitem.SystemState}, errs.Cause(err).(errors.VersionConflictError).Fields)
+	// and nothing was saved
+	loaded, err := s.repo.Load(s.ctx, wi.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "Title", loaded.Fields[workitem.SystemTitle])
+}
+
+func (s *workItemRepoBlackBoxTest) TestPatchMergesConcurrentChanges() {
+	// given
+	wi, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle: "Title",
+			workitem.SystemState: workitem.SystemStateNew,
+		}, s.creatorID)
+	require.Nil(s.T(), err, "Could not create workitem")
+	_, err = s.repo.Patch(s.ctx, wi.ID, wi.Version, map[string]interface{}{workitem.SystemState: workitem.SystemStateOpen}, s.creatorID)
+	require.Nil(s.T(), err)
+	// when patching the previous version
+	patched, err := s.repo.Patch(s.ctx, wi.ID, wi.Version, map[string]interface{}{workitem.SystemTitle: "New Title"}, s.creatorID)
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "New Title", patched.Fields[workitem.SystemTitle])
+	assert.Equal(s.T(), workitem.SystemStateOpen, patched.Fields[workitem.SystemState])
+	// when patching the same field of the previous version
+	_, err = s.repo.Patch(s.ctx, wi.ID, wi.Version, map[string]interface{}{workitem.SystemState: workitem.SystemStateClosed}, s.creatorID)
+	// then
+	require.IsType(s.T(), errors.VersionConflictError{}, errs.Cause(err))
+	assert.Equal(s.T(), []string{workitem.SystemState}, errs.Cause(err).(errors.VersionConflictError).Fields)
+}
+
 func (s *workItemRepoBlackBoxTest) TestCreateWorkItemWithDescriptionNoMarkup() {
 	// given
 	wi, err := s.repo.Create(
@@ -312,3 +455,391 @@ func (s *workItemRepoBlackBoxTest) TestCodebaseAttributes() {
 	assert.Equal(s.T(), file, cb.FileName)
 	assert.Equal(s.T(), line, cb.LineNumber)
 }
+
+func (s *workItemRepoBlackBoxTest) TestListSorted() {
+	// given
+	marker := uuid.NewV4().String()
+	for _, fields := range []struct{ title, state string }{
+		{"b", workitem.SystemStateNew},
+		{"a", workitem.SystemStateClosed},
+		{"c", workitem.SystemStateNew},
+		{"a", workitem.SystemStateNew},
+	} {
+		_, err := s.repo.Create(
+			s.ctx, s.spaceID, workitem.SystemBug,
+			map[string]interface{}{
+				workitem.SystemTitle: fields.title + " " + marker,
+				workitem.SystemState: fields.state,
+			}, s.creatorID)
+		require.Nil(s.T(), err)
+	}
+	exp := criteria.Substring(criteria.Field(workitem.SystemTitle), criteria.Literal(marker))
+	titles := func(items []*app.WorkItem) []string {
+		result := []string{}
+		for _, item := range items {
+			result = append(result, item.Fields[workitem.SystemState].(string)+":"+item.Fields[workitem.SystemTitle].(string)[:1])
+		}
+		return result
+	}
+	// when
+	items, count, err := s.repo.List(s.ctx, exp, []workitem.SortKey{
+		{Field: workitem.SystemState},
+		{Field: workitem.SystemTitle, Descending: true},
+	}, nil, nil)
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(4), count)
+	assert.Equal(s.T(), []string{"closed:a", "new:c", "new:b", "new:a"}, titles(items))
+	// when
+	start, limit := 1, 2
+	items, count, err = s.repo.List(s.ctx, exp, []workitem.SortKey{{Field: workitem.SortByID, Descending: true}}, &start, &limit)
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(4), count)
+	assert.Equal(s.T(), []string{"new:c", "closed:a"}, titles(items))
+}
+
+func (s *workItemRepoBlackBoxTest) TestListSortedByInvalidField() {
+	// when
+	_, _, err := s.repo.List(s.ctx, criteria.Literal(true), []workitem.SortKey{{Field: "title'"}}, nil, nil)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
+
+func (s *workItemRepoBlackBoxTest) TestListCoercesLiterals() {
+	// given
+	marker := uuid.NewV4().String()
+	_, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle:     "a " + marker,
+			workitem.SystemState:     workitem.SystemStateNew,
+			workitem.SystemAssignees: []interface{}{s.creatorID.String()},
+		}, s.creatorID)
+	require.Nil(s.T(), err)
+	byMarker := criteria.Substring(criteria.Field(workitem.SystemTitle), criteria.Literal(marker))
+	// when
+	upperCaseID := strings.ToUpper(s.creatorID.String())
+	_, count, err := s.repo.List(s.ctx, criteria.And(byMarker, criteria.Equals(criteria.Field(workitem.SystemAssignees), criteria.Literal([]string{upperCaseID}))), nil, nil, nil)
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), uint64(1), count)
+	for _, exp := range []criteria.Expression{
+		criteria.Equals(criteria.Field(workitem.SystemState), criteria.Literal("no such state")),
+		criteria.Equals(criteria.Field(workitem.SystemIteration), criteria.Literal("garbage")),
+		criteria.Equals(criteria.Field("no.such.field"), criteria.Literal("value")),
+	} {
+		// when
+		_, _, err = s.repo.List(s.ctx, criteria.And(byMarker, exp), nil, nil, nil)
+		// then
+		require.NotNil(s.T(), err)
+		assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+	}
+}
+
+func (s *workItemRepoBlackBoxTest) TestListNotInMatchesMissingFields() {
+	// given a work item without an area key
+	marker := uuid.NewV4().String()
+	wi, err := s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle: "a " + marker,
+			workitem.SystemState: workitem.SystemStateNew,
+		}, s.creatorID)
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), s.DB.Exec("UPDATE work_items SET fields = fields - ?::text WHERE id = ?", workitem.SystemArea, wi.ID).Error)
+	byMarker := criteria.Substring(criteria.Field(workitem.SystemTitle), criteria.Literal(marker))
+	area := uuid.NewV4().String()
+	for _, exp := range []criteria.Expression{
+		criteria.NotEquals(criteria.Field(workitem.SystemArea), criteria.Literal(area)),
+		criteria.Not(criteria.In(criteria.Field(workitem.SystemArea), criteria.Literal([]string{area}))),
+	} {
+		// when
+		_, count, err := s.repo.List(s.ctx, criteria.And(byMarker, exp), nil, nil, nil)
+		// then
+		require.Nil(s.T(), err)
+		assert.Equal(s.T(), uint64(1), count, "expression %s", exp)
+	}
+}
+
+func (s *workItemRepoBlackBoxTest) TestAggregate() {
+	// given
+	marker := uuid.NewV4().String()
+	other := uuid.NewV4().String()
+	for _, fields := range []map[string]interface{}{
+		{workitem.SystemState: workitem.SystemStateNew, workitem.SystemAssignees: []interface{}{s.creatorID.String()}},
+		{workitem.SystemState: workitem.SystemStateNew, workitem.SystemAssignees: []interface{}{s.creatorID.String(), other}},
+		{workitem.SystemState: workitem.SystemStateClosed},
+	} {
+		fields[workitem.SystemTitle] = "aggregated " + marker
+		_, err := s.repo.Create(s.ctx, s.spaceID, workitem.SystemBug, fields, s.creatorID)
+		require.Nil(s.T(), err)
+	}
+	byMarker := criteria.Substring(criteria.Field(workitem.SystemTitle), criteria.Literal(marker))
+	count := func(buckets []workitem.AggregationBucket, keys ...*string) uint64 {
+		for _, bucket := range buckets {
+			if assert.ObjectsAreEqual(keys, bucket.Keys) {
+				return bucket.Count
+			}
+		}
+		return 0
+	}
+	state := func(value string) *string { return &value }
+	// when
+	buckets, err := s.repo.Aggregate(s.ctx, byMarker, []string{workitem.SystemState})
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), buckets, 2)
+	assert.Equal(s.T(), uint64(2), count(buckets, state(workitem.SystemStateNew)))
+	assert.Equal(s.T(), uint64(1), count(buckets, state(workitem.SystemStateClosed)))
+	// when
+	buckets, err = s.repo.Aggregate(s.ctx, byMarker, []string{workitem.SystemState, workitem.SystemAssignees})
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), buckets, 3)
+	creator := s.creatorID.String()
+	assert.Equal(s.T(), uint64(2), count(buckets, state(workitem.SystemStateNew), &creator))
+	assert.Equal(s.T(), uint64(1), count(buckets, state(workitem.SystemStateNew), &other))
+	assert.Equal(s.T(), uint64(1), count(buckets, state(workitem.SystemStateClosed), nil))
+	// when
+	_, err = s.repo.Aggregate(s.ctx, byMarker, []string{workitem.SystemTitle})
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
+
+func (s *workItemRepoBlackBoxTest) TestLabelsOfTheSpace() {
+	// given
+	labelKind := string(workitem.KindLabel)
+	wit, err := workitem.NewWorkItemTypeRepository(s.DB).Create(s.ctx, s.spaceID, nil, nil, "labeled", nil, "fa-tag", map[string]app.FieldDefinition{
+		"category": {
+			Type: &app.FieldType{Kind: labelKind},
+		},
+		"labels": {
+			Type: &app.FieldType{Kind: string(workitem.KindList), ComponentType: &labelKind},
+		},
+	})
+	require.Nil(s.T(), err)
+	marker := uuid.NewV4().String()
+	labels := label.NewLabelRepository(s.DB)
+	for _, name := range []string{"bug ", "ui ", "needs review "} {
+		_, err := labels.Create(s.ctx, &label.Label{SpaceID: s.spaceID, Name: name + marker})
+		require.Nil(s.T(), err)
+	}
+	// when the work item takes labels of the space
+	wi, err := s.repo.Create(s.ctx, s.spaceID, *wit.Data.ID, map[string]interface{}{"category": "bug " + marker, "labels": []interface{}{"ui " + marker, "  needs   review " + marker}}, s.creatorID)
+	// then the labels are normalized
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []interface{}{"ui " + marker, "needs review " + marker}, wi.Fields["labels"])
+	// when the work item takes a label the space doesn't have
+	_, err = s.repo.Create(s.ctx, s.spaceID, *wit.Data.ID, map[string]interface{}{"category": "feature " + marker}, s.creatorID)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+	// when a label the space doesn't have is added to the list
+	_, err = s.repo.Patch(s.ctx, wi.ID, wi.Version, map[string]interface{}{"labels": []interface{}{"ui " + marker, "backend " + marker}}, s.creatorID)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
+
+func (s *workItemRepoBlackBoxTest) TestUniqueFields() {
+	// given a type with a unique key
+	unique, pattern := true, "[A-Z]+-[0-9]+"
+	wit, err := workitem.NewWorkItemTypeRepository(s.DB).Create(s.ctx, s.spaceID, nil, nil, "keyed", nil, "fa-key", map[string]app.FieldDefinition{
+		"key": {
+			Type:        &app.FieldType{Kind: string(workitem.KindString)},
+			Constraints: &app.FieldConstraints{Unique: &unique, Pattern: &pattern},
+		},
+	})
+	require.Nil(s.T(), err)
+	require.NotNil(s.T(), wit.Data.Attributes.Fields["key"].Constraints)
+	assert.Equal(s.T(), &unique, wit.Data.Attributes.Fields["key"].Constraints.Unique)
+	// the values are unique per type, the type is new
+	key := "KEY-1"
+	first, err := s.repo.Create(s.ctx, s.spaceID, *wit.Data.ID, map[string]interface{}{"key": key}, s.creatorID)
+	require.Nil(s.T(), err)
+	// when
+	_, err = s.repo.Create(s.ctx, s.spaceID, *wit.Data.ID, map[string]interface{}{"key": key}, s.creatorID)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+	// when
+	second, err := s.repo.Create(s.ctx, s.spaceID, *wit.Data.ID, map[string]interface{}{"key": "KEY-2"}, s.creatorID)
+	// then
+	require.Nil(s.T(), err)
+	// when the first work item is saved with its own key
+	_, err = s.repo.Save(s.ctx, *first, s.creatorID)
+	// then
+	require.Nil(s.T(), err)
+	// when
+	_, err = s.repo.Patch(s.ctx, second.ID, second.Version, map[string]interface{}{"key": key}, s.creatorID)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+	// when the value violates the pattern
+	_, err = s.repo.Patch(s.ctx, second.ID, second.Version, map[string]interface{}{"key": "key"}, s.creatorID)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
+
+func (s *workItemRepoBlackBoxTest) TestListPage() {
+	// given
+	marker := uuid.NewV4().String()
+	for _, title := range []string{"b", "e", "a", "d", "c"} {
+		_, err := s.repo.Create(
+			s.ctx, s.spaceID, workitem.SystemBug,
+			map[string]interface{}{
+				workitem.SystemTitle: title + " " + marker,
+				workitem.SystemState: workitem.SystemStateNew,
+			}, s.creatorID)
+		require.Nil(s.T(), err)
+	}
+	exp := criteria.Substring(criteria.Field(workitem.SystemTitle), criteria.Literal(marker))
+	order := []workitem.SortKey{{Field: workitem.SystemTitle}}
+	titles := func(items []*app.WorkItem) string {
+		result := ""
+		for _, item := range items {
+			result += item.Fields[workitem.SystemTitle].(string)[:1]
+		}
+		return result
+	}
+	// when
+	items, page, err := s.repo.ListPage(s.ctx, exp, order, pagination.Keyset{Limit: 2})
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "ab", titles(items))
+	assert.Equal(s.T(), uint64(5), page.TotalCount)
+	assert.False(s.T(), page.HasPrev)
+	assert.True(s.T(), page.HasNext)
+	// a work item inserted before the cursor doesn't shift the following pages
+	_, err = s.repo.Create(
+		s.ctx, s.spaceID, workitem.SystemBug,
+		map[string]interface{}{
+			workitem.SystemTitle: "a " + marker,
+			workitem.SystemState: workitem.SystemStateNew,
+		}, s.creatorID)
+	require.Nil(s.T(), err)
+	// when
+	items, page, err = s.repo.ListPage(s.ctx, exp, order, pagination.Keyset{Cursor: page.Last, Limit: 2})
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "cd", titles(items))
+	assert.Equal(s.T(), uint64(6), page.TotalCount)
+	assert.True(s.T(), page.HasPrev)
+	assert.True(s.T(), page.HasNext)
+	// when
+	items, page, err = s.repo.ListPage(s.ctx, exp, order, pagination.Keyset{Cursor: page.First, Direction: pagination.Before, Limit: 2})
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "ab", titles(items))
+	assert.True(s.T(), page.HasPrev)
+	assert.True(s.T(), page.HasNext)
+	// when
+	items, page, err = s.repo.ListPage(s.ctx, exp, order, pagination.Keyset{Direction: pagination.Before, Limit: 2})
+	// then
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "de", titles(items))
+	assert.True(s.T(), page.HasPrev)
+	assert.False(s.T(), page.HasNext)
+	// when
+	_, _, err = s.repo.ListPage(s.ctx, exp, []workitem.SortKey{{Field: workitem.SystemState}}, pagination.Keyset{Cursor: page.First, Limit: 2})
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
+
+// benchmarkTypeIDs are the types of a page of work items of the system types
+func benchmarkTypeIDs() []uuid.UUID {
+	types := []uuid.UUID{workitem.SystemPlannerItem, workitem.SystemUserStory, workitem.SystemValueProposition, workitem.SystemFundamental,
+		workitem.SystemExperience, workitem.SystemFeature, workitem.SystemScenario, workitem.SystemBug}
+	ids := make([]uuid.UUID, 100)
+	for i := range ids {
+		ids[i] = types[i%len(types)]
+	}
+	return ids
+}
+
+// openBenchmarkDB opens the database for a benchmark and populates the common types
+func openBenchmarkDB(b *testing.B) *gorm.DB {
+	resource.Require(b, resource.Database)
+	configuration, err := config.NewConfigurationData("../config.yaml")
+	require.Nil(b, err)
+	db, err := gorm.Open("postgres", configuration.GetPostgresConfigString())
+	require.Nil(b, err)
+	err = models.Transactional(db, func(tx *gorm.DB) error {
+		return migration.PopulateCommonTypes(migration.NewMigrationContext(context.Background()), tx, workitem.NewWorkItemTypeRepository(tx))
+	})
+	require.Nil(b, err)
+	return db
+}
+
+// BenchmarkLoadTypeFromDBPerRow loads the types of a page of work items one by one, the way List used to
+func BenchmarkLoadTypeFromDBPerRow(b *testing.B) {
+	db := openBenchmarkDB(b)
+	defer db.Close()
+	repo := workitem.NewWorkItemTypeRepository(db)
+	ctx := context.Background()
+	ids := benchmarkTypeIDs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		workitem.ClearGlobalWorkItemTypeCache()
+		b.StartTimer()
+		for _, id := range ids {
+			if _, err := repo.LoadTypeFromDB(ctx, id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkLoadTypesFromDB loads the types of a page of work items at once
+func BenchmarkLoadTypesFromDB(b *testing.B) {
+	db := openBenchmarkDB(b)
+	defer db.Close()
+	repo := workitem.NewWorkItemTypeRepository(db)
+	ctx := context.Background()
+	ids := benchmarkTypeIDs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		workitem.ClearGlobalWorkItemTypeCache()
+		b.StartTimer()
+		if _, err := repo.LoadTypesFromDB(ctx, ids); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkList lists a page of work items of different types
+func BenchmarkList(b *testing.B) {
+	db := openBenchmarkDB(b)
+	defer db.Close()
+	clean := cleaner.DeleteCreatedEntities(db)
+	defer clean()
+	testIdentity, err := testsupport.CreateTestIdentity(db, "jdoe", "test")
+	require.Nil(b, err)
+	repo := workitem.NewWorkItemRepository(db)
+	ctx := context.Background()
+	marker := uuid.NewV4().String()
+	for _, typeID := range benchmarkTypeIDs() {
+		_, err := repo.Create(ctx, space.SystemSpace, typeID, map[string]interface{}{
+			workitem.SystemTitle: marker,
+			workitem.SystemState: workitem.SystemStateNew,
+		}, testIdentity.ID)
+		require.Nil(b, err)
+	}
+	exp := criteria.Equals(criteria.Field(workitem.SystemTitle), criteria.Literal(marker))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		workitem.ClearGlobalWorkItemTypeCache()
+		b.StartTimer()
+		if _, _, err := repo.List(ctx, exp, nil, nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -19,6 +19,8 @@ type RevisionRepository interface {
 	Create(ctx context.Context, modifierID uuid.UUID, revisionType RevisionType, workitem WorkItem) error
 	// List retrieves all revisions for a given work item
 	List(ctx context.Context, workitemID string) ([]Revision, error)
+	// LoadVersion retrieves the revision holding the given version of a work item
+	LoadVersion(ctx context.Context, workitemID uint64, version int) (*Revision, error)
 }
 
 // NewRevisionRepository creates a GormRevisionRepository
@@ -70,3 +72,19 @@ func (r *GormRevisionRepository) List(ctx context.Context, workitemID string) ([
 	}
 	return revisions, nil
 }
+
+// LoadVersion retrieves the revision holding the given version of a work item, i.e. the revision stored
+// when the work item was created or updated to that version
+// returns NotFoundError or InternalError
+func (r *GormRevisionRepository) LoadVersion(ctx context.Context, workitemID uint64, version int) (*Revision, error) {
+	revision := Revision{}
+	db := r.db.Where("work_item_id = ? and work_item_version = ? and revision_type <> ?", workitemID, version, RevisionTypeDelete).
+		Order("revision_time desc").First(&revision)
+	if db.RecordNotFound() {
+		return nil, errors.NewNotFoundError("work item revision", fmt.Sprintf("%d@%d", workitemID, version))
+	}
+	if err := db.Error; err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("Failed to retrieve work item revision: %s", err.Error()))
+	}
+	return &revision, nil
+}
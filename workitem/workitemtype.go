@@ -188,6 +188,40 @@ func (wit WorkItemType) ConvertFromModel(request *goa.RequestData, workItem Work
 	return &result, nil
 }
 
+// ConvertToModel converts a workItem of the API layer into its form in the persistence layer, the
+// reverse of ConvertFromModel. An empty ID is left as 0 for work items which haven't been stored yet.
+func (wit WorkItemType) ConvertToModel(workItem app.WorkItem) (*WorkItem, error) {
+	result := WorkItem{
+		Type:    workItem.Type,
+		Version: workItem.Version,
+		Fields:  Fields{},
+	}
+	if workItem.ID != "" {
+		id, err := ParseWorkItemIDToUint64(workItem.ID)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		result.ID = id
+	}
+	if workItem.Relationships != nil && workItem.Relationships.Space != nil &&
+		workItem.Relationships.Space.Data != nil && workItem.Relationships.Space.Data.ID != nil {
+		result.SpaceID = *workItem.Relationships.Space.Data.ID
+	}
+
+	for name, field := range wit.Fields {
+		var err error
+		if name == SystemCreatedAt {
+			continue
+		}
+		result.Fields[name], err = field.ConvertToModel(name, workItem.Fields[name])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return &result, nil
+}
+
 // IsTypeOrSubtypeOf returns true if the work item type with the given type ID,
 // is of the same type as the current WIT or of it is a subtype; otherwise false
 // is returned.
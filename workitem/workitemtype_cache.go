@@ -2,40 +2,97 @@ package workitem
 
 import (
 	"sync"
+	"time"
 
 	"github.com/almighty/almighty-core/log"
 	uuid "github.com/satori/go.uuid"
 )
 
-type witCacheMap map[uuid.UUID]WorkItemType
+// DefaultWorkItemTypeCacheTTL is how long a work item type is cached. The cache only learns about the changes
+// made by its own process, so the changes made by other instances of the service show up after this time.
+const DefaultWorkItemTypeCacheTTL = time.Minute
+
+// witCacheEntry is a cached work item type along with the time it expires
+type witCacheEntry struct {
+	wit     WorkItemType
+	expires time.Time
+}
+
+type witCacheMap map[uuid.UUID]witCacheEntry
 
 // WorkItemTypeCache represents WorkItemType cache
 type WorkItemTypeCache struct {
 	cache   witCacheMap
 	mapLock sync.RWMutex
+	// generation is incremented whenever entries are invalidated, see PutIfCurrent
+	generation uint64
+	ttl        time.Duration
 }
 
-// NewWorkItemTypeCache constructs WorkItemTypeCache
+// NewWorkItemTypeCache constructs WorkItemTypeCache caching the work item types for DefaultWorkItemTypeCacheTTL
 func NewWorkItemTypeCache() *WorkItemTypeCache {
-	witCache := WorkItemTypeCache{}
+	return NewWorkItemTypeCacheWithTTL(DefaultWorkItemTypeCacheTTL)
+}
+
+// NewWorkItemTypeCacheWithTTL constructs WorkItemTypeCache caching the work item types for the given time
+func NewWorkItemTypeCacheWithTTL(ttl time.Duration) *WorkItemTypeCache {
+	witCache := WorkItemTypeCache{ttl: ttl}
 	witCache.cache = make(witCacheMap)
 	return &witCache
 }
 
 // Get returns WorkItemType by ID.
-// The second value (ok) is a bool that is true if the WorkItemType exists in the cache, and false if not.
+// The second value (ok) is a bool that is true if the WorkItemType exists in the cache and hasn't expired,
+// and false if not.
 func (c *WorkItemTypeCache) Get(id uuid.UUID) (WorkItemType, bool) {
 	c.mapLock.RLock()
 	defer c.mapLock.RUnlock()
-	w, ok := c.cache[id]
-	return w, ok
+	entry, ok := c.cache[id]
+	if !ok || time.Now().After(entry.expires) {
+		return WorkItemType{}, false
+	}
+	return entry.wit, true
 }
 
 // Put puts a work item type to the cache
 func (c *WorkItemTypeCache) Put(wit WorkItemType) {
 	c.mapLock.Lock()
 	defer c.mapLock.Unlock()
-	c.cache[wit.ID] = wit
+	c.put(wit)
+}
+
+// put caches the work item type until the TTL passed, the lock has to be held
+func (c *WorkItemTypeCache) put(wit WorkItemType) {
+	c.cache[wit.ID] = witCacheEntry{wit: wit, expires: time.Now().Add(c.ttl)}
+}
+
+// Generation returns the current generation of the cache. Pass it to PutIfCurrent
+// to cache a work item type loaded after the call.
+func (c *WorkItemTypeCache) Generation() uint64 {
+	c.mapLock.RLock()
+	defer c.mapLock.RUnlock()
+	return c.generation
+}
+
+// PutIfCurrent puts a work item type to the cache unless the cache was invalidated or cleared since
+// the given generation, in which case the work item type may be outdated. It returns true if the
+// work item type was put.
+func (c *WorkItemTypeCache) PutIfCurrent(wit WorkItemType, generation uint64) bool {
+	c.mapLock.Lock()
+	defer c.mapLock.Unlock()
+	if c.generation != generation {
+		return false
+	}
+	c.put(wit)
+	return true
+}
+
+// Invalidate removes the work item type with the given ID from the cache
+func (c *WorkItemTypeCache) Invalidate(id uuid.UUID) {
+	c.mapLock.Lock()
+	defer c.mapLock.Unlock()
+	delete(c.cache, id)
+	c.generation++
 }
 
 // Clear clears the cache
@@ -45,4 +102,5 @@ func (c *WorkItemTypeCache) Clear() {
 	log.Info(nil, nil, "Clearing work item cache")
 
 	c.cache = make(witCacheMap)
+	c.generation++
 }
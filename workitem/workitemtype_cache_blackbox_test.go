@@ -3,6 +3,7 @@ package workitem_test
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/workitem"
@@ -84,3 +85,62 @@ func TestNoFailuresWithConcurrentMapReadAndMapWrite(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+func TestGetReturnNotOkAfterInvalidate(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	c := workitem.NewWorkItemTypeCache()
+	invalidated := workitem.WorkItemType{ID: uuid.FromStringOrNil("5c1ad7c4-0a1b-4d6e-8c5f-1c0f9a0bd4e1"), Name: "testInvalidate"}
+	kept := workitem.WorkItemType{ID: uuid.FromStringOrNil("0e1be5f4-6f5b-4c8a-9d0e-8c0f4d6b7a2c"), Name: "testKeep"}
+	c.Put(invalidated)
+	c.Put(kept)
+
+	c.Invalidate(invalidated.ID)
+	_, ok := c.Get(invalidated.ID)
+	assert.False(t, ok)
+	_, ok = c.Get(kept.ID)
+	assert.True(t, ok)
+}
+
+func TestPutIfCurrentIgnoresOutdatedWIT(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	c := workitem.NewWorkItemTypeCache()
+	wit := workitem.WorkItemType{ID: uuid.FromStringOrNil("9a3f0d1e-2b4c-4d5e-8f6a-7b8c9d0e1f2a"), Name: "testPutIfCurrent"}
+
+	// a load started before the type was invalidated
+	generation := c.Generation()
+	c.Invalidate(wit.ID)
+	assert.False(t, c.PutIfCurrent(wit, generation))
+	_, ok := c.Get(wit.ID)
+	assert.False(t, ok)
+
+	// a load started after the type was invalidated
+	assert.True(t, c.PutIfCurrent(wit, c.Generation()))
+	cachedWit, ok := c.Get(wit.ID)
+	assert.True(t, ok)
+	assert.Equal(t, wit, cachedWit)
+
+	// clearing invalidates all types
+	generation = c.Generation()
+	c.Clear()
+	assert.False(t, c.PutIfCurrent(wit, generation))
+}
+
+func TestGetReturnsNotOkAfterTTL(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	c := workitem.NewWorkItemTypeCacheWithTTL(10 * time.Millisecond)
+	wit := workitem.WorkItemType{ID: uuid.FromStringOrNil("4b2d6e1f-8a3c-4e5d-9f0a-1b2c3d4e5f6a"), Name: "testTTL"}
+	c.Put(wit)
+	_, ok := c.Get(wit.ID)
+	assert.True(t, ok)
+
+	// the type may have been changed by another instance meanwhile
+	time.Sleep(20 * time.Millisecond)
+	_, ok = c.Get(wit.ID)
+	assert.False(t, ok)
+}
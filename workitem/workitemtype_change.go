@@ -0,0 +1,387 @@
+package workitem
+
+import (
+	"database/sql/driver"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/rendering"
+
+	"github.com/asaskevich/govalidator"
+	errs "github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+// The kinds of changes of the fields of a work item type
+const (
+	FieldChangeAdd    = "add"
+	FieldChangeRemove = "remove"
+	FieldChangeRename = "rename"
+	FieldChangeRetype = "retype"
+
+	// systemFieldPrefix is the prefix of the fields the application relies on
+	systemFieldPrefix = "system."
+)
+
+// FieldChange is a change of a field of an existing work item type along with the migration of the values of the
+// field in the work items of the type
+type FieldChange struct {
+	Op    string `json:"op"`
+	Field string `json:"field"`
+	// NewName is the new name of a renamed field
+	NewName string `json:"new_name,omitempty"`
+	// Definition is the definition of an added field or the new definition of a retyped field
+	Definition *FieldDefinition `json:"definition,omitempty"`
+	// Value is set on the work items without a value for an added field
+	Value interface{} `json:"value,omitempty"`
+}
+
+// FieldChanges is the list of changes of a version of a work item type
+type FieldChanges []FieldChange
+
+// Value implements the driver.Valuer interface
+func (j FieldChanges) Value() (driver.Value, error) {
+	return toBytes(j)
+}
+
+// Scan implements the sql.Scanner interface
+func (j *FieldChanges) Scan(src interface{}) error {
+	return fromBytes(src, j)
+}
+
+// FieldChangeReport tells how many work items a change of a work item type affects
+type FieldChangeReport struct {
+	FieldChange
+	// Affected is the number of work items whose values are migrated
+	Affected int
+	// Failed is the number of work items whose values can't be migrated
+	Failed int
+}
+
+// WorkItemTypeVersion is a version of the definition of a work item type, stored with every update of the type
+type WorkItemTypeVersion struct {
+	ID        uuid.UUID `sql:"type:uuid default uuid_generate_v4()" gorm:"primary_key"`
+	CreatedAt time.Time
+	// the identity which changed the work item type, not set for the version before the first update
+	ModifierID *uuid.UUID `sql:"type:uuid"`
+	// the id of the work item type
+	WorkItemTypeID uuid.UUID `sql:"type:uuid"`
+	// Version of the work item type
+	Version int
+	// definitions of the fields in this version
+	Fields FieldDefinitions `sql:"type:jsonb"`
+	// the changes made on the previous version
+	Changes FieldChanges `sql:"type:jsonb"`
+}
+
+// TableName implements gorm.tabler
+func (v WorkItemTypeVersion) TableName() string {
+	return "work_item_type_versions"
+}
+
+// convertFieldChangeToModel converts a field change of the API layer, the value of an added field is converted
+// with the new definition
+// returns BadParameterError
+func convertFieldChangeToModel(change app.FieldChange) (*FieldChange, error) {
+	result := FieldChange{
+		Op:    change.Op,
+		Field: change.Field,
+	}
+	if change.NewName != nil {
+		result.NewName = *change.NewName
+	}
+	if change.Definition != nil {
+		if change.Definition.Type == nil {
+			return nil, errors.NewBadParameterError("definition", change.Field).Expected("a field definition with a type")
+		}
+		ft, err := convertFieldTypeToModels(*change.Definition.Type)
+		if err != nil {
+			return nil, errors.NewBadParameterError("type", change.Definition.Type.Kind).Expected(err.Error())
+		}
+		constraints, err := convertFieldConstraintsToModel(ft, change.Definition.Constraints)
+		if err != nil {
+			return nil, errs.WithStack(err)
+		}
+		result.Definition = &FieldDefinition{
+			Required:    change.Definition.Required,
+			Label:       change.Definition.Label,
+			Description: change.Definition.Description,
+			Type:        ft,
+			Searchable:  change.Definition.Searchable != nil && *change.Definition.Searchable,
+			Constraints: constraints,
+		}
+	}
+	if change.Value != nil {
+		if result.Definition == nil {
+			return nil, errors.NewBadParameterError("value", change.Value).Expected("no value without a field definition")
+		}
+		value, err := result.Definition.ConvertToModel(change.Field, change.Value)
+		if err != nil {
+			return nil, errors.NewBadParameterError("value", change.Value).Expected(err.Error())
+		}
+		result.Value = value
+	}
+	return &result, nil
+}
+
+// apply validates the change and applies it to the given definitions
+// returns the definition of the field before the change, BadParameterError
+func (change FieldChange) apply(definitions FieldDefinitions) (*FieldDefinition, error) {
+	previous, exists := definitions[change.Field]
+	if strings.HasPrefix(change.Field, systemFieldPrefix) {
+		return nil, errors.NewBadParameterError("field", change.Field).Expected("a field which is not a system field")
+	}
+	if change.Op != FieldChangeAdd && !exists {
+		return nil, errors.NewBadParameterError("field", change.Field).Expected("a field of the work item type")
+	}
+	if change.Definition != nil && change.Definition.Searchable {
+		if kind := change.Definition.Type.GetKind(); kind != KindString && kind != KindMarkup {
+			return nil, errors.NewBadParameterError("searchable", change.Field).Expected("a string or markup field")
+		}
+	}
+	switch change.Op {
+	case FieldChangeAdd:
+		if exists {
+			return nil, errors.NewBadParameterError("field", change.Field).Expected("a field which is not yet defined by the work item type")
+		}
+		if change.Definition == nil {
+			return nil, errors.NewBadParameterError("definition", nil).Expected("the definition of the added field")
+		}
+		if change.Definition.Required && change.Value == nil {
+			return nil, errors.NewBadParameterError("value", nil).Expected("a value for the existing work items, the field is required")
+		}
+		if change.Definition.Constraints != nil && change.Definition.Constraints.Unique && change.Value != nil {
+			return nil, errors.NewBadParameterError("value", change.Value).Expected("no value for the existing work items, the field is unique")
+		}
+		definitions[change.Field] = *change.Definition
+	case FieldChangeRemove:
+		delete(definitions, change.Field)
+	case FieldChangeRename:
+		if change.NewName == "" || strings.HasPrefix(change.NewName, systemFieldPrefix) {
+			return nil, errors.NewBadParameterError("newName", change.NewName).Expected("a name which is not a system field")
+		}
+		if _, taken := definitions[change.NewName]; taken {
+			return nil, errors.NewBadParameterError("newName", change.NewName).Expected("a field which is not yet defined by the work item type")
+		}
+		delete(definitions, change.Field)
+		definitions[change.NewName] = previous
+	case FieldChangeRetype:
+		if change.Definition == nil {
+			return nil, errors.NewBadParameterError("definition", nil).Expected("the new definition of the retyped field")
+		}
+		definitions[change.Field] = *change.Definition
+	default:
+		return nil, errors.NewBadParameterError("op", change.Op).Expected("add, remove, rename or retype")
+	}
+	return &previous, nil
+}
+
+// migrate changes the stored values of a work item for the change, previous is the definition of the field
+// before the change
+// returns true if the values changed, an error if the value of the field can't be migrated
+func (change FieldChange) migrate(previous FieldDefinition, fields Fields) (bool, error) {
+	value, exists := fields[change.Field]
+	switch change.Op {
+	case FieldChangeAdd:
+		if value != nil || change.Value == nil {
+			return false, nil
+		}
+		fields[change.Field] = change.Value
+		return true, nil
+	case FieldChangeRemove:
+		delete(fields, change.Field)
+		return exists, nil
+	case FieldChangeRename:
+		delete(fields, change.Field)
+		if value != nil {
+			fields[change.NewName] = value
+		} else {
+			delete(fields, change.NewName)
+		}
+		return exists, nil
+	case FieldChangeRetype:
+		converted, err := convertStoredValue(previous.Type, change.Definition.Type, value)
+		if err != nil {
+			return false, errs.WithStack(err)
+		}
+		if converted == nil && change.Definition.Required {
+			return false, errs.Errorf("Value %s is required", change.Field)
+		}
+		if change.Definition.Constraints != nil {
+			if err := change.Definition.Constraints.check(change.Definition.Type, converted); err != nil {
+				return false, errs.WithStack(err)
+			}
+		}
+		if sameFieldValue(value, converted) {
+			return false, nil
+		}
+		if converted == nil {
+			delete(fields, change.Field)
+		} else {
+			fields[change.Field] = converted
+		}
+		return true, nil
+	}
+	return false, errs.Errorf("unknown change %s", change.Op)
+}
+
+// convertStoredValue converts a value stored for a field of the type from into the stored form of the type to.
+// A value is wrapped into a list and a list with a single element is unwrapped.
+func convertStoredValue(from FieldType, to FieldType, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch toType := to.(type) {
+	case ListType:
+		fromList, ok := from.(ListType)
+		if !ok {
+			converted, err := convertStoredValue(from, toType.ComponentType, value)
+			if err != nil || converted == nil {
+				return nil, errs.WithStack(err)
+			}
+			return []interface{}{converted}, nil
+		}
+		elements, ok := value.([]interface{})
+		if !ok {
+			return nil, errs.Errorf("value %v should be a list", value)
+		}
+		result := make([]interface{}, len(elements))
+		for index, element := range elements {
+			converted, err := convertStoredValue(fromList.ComponentType, toType.ComponentType, element)
+			if err != nil {
+				return nil, errs.WithStack(err)
+			}
+			result[index] = converted
+		}
+		return result, nil
+	case EnumType:
+		converted, err := convertStoredValue(from, toType.BaseType, value)
+		if err != nil {
+			return nil, errs.WithStack(err)
+		}
+		for _, enumValue := range toType.Values {
+			if sameFieldValue(enumValue, converted) {
+				return converted, nil
+			}
+		}
+		return nil, errs.Errorf("not an enum value: %v", value)
+	}
+	switch fromType := from.(type) {
+	case ListType:
+		elements, ok := value.([]interface{})
+		if !ok {
+			return nil, errs.Errorf("value %v should be a list", value)
+		}
+		switch len(elements) {
+		case 0:
+			return nil, nil
+		case 1:
+			return convertStoredValue(fromType.ComponentType, to, elements[0])
+		}
+		return nil, errs.Errorf("value %v has more than one element", value)
+	case EnumType:
+		return convertStoredValue(fromType.BaseType, to, value)
+	}
+	return convertStoredKind(from.GetKind(), to.GetKind(), value)
+}
+
+// convertStoredKind converts a stored value of a simple type
+func convertStoredKind(from Kind, to Kind, value interface{}) (interface{}, error) {
+	if from == to {
+		return value, nil
+	}
+	switch to {
+	case KindString:
+		switch from {
+		case KindURL, KindUser, KindIteration, KindArea, KindLabel, KindDate:
+			return value, nil
+		case KindBoolean:
+			if b, ok := value.(bool); ok {
+				return strconv.FormatBool(b), nil
+			}
+		case KindInteger, KindFloat, KindDuration, KindPercentage:
+			if number, ok := value.(float64); ok {
+				return strconv.FormatFloat(number, 'f', -1, 64), nil
+			}
+		case KindMarkup:
+			if markup, ok := value.(map[string]interface{}); ok {
+				return rendering.NewMarkupContentFromMap(markup).Content, nil
+			}
+		}
+	case KindURL:
+		if text, ok := value.(string); ok && from == KindString {
+			if !govalidator.IsURL(text) {
+				return nil, errs.Errorf("value %v should be %s", value, "URL")
+			}
+			return text, nil
+		}
+	case KindInteger, KindDuration:
+		switch from {
+		case KindInteger, KindDuration, KindFloat:
+			if number, ok := value.(float64); ok {
+				if number != math.Trunc(number) {
+					return nil, errs.Errorf("value %v should be %s", value, "a whole number")
+				}
+				return int(number), nil
+			}
+		case KindString:
+			if text, ok := value.(string); ok {
+				number, err := strconv.Atoi(strings.TrimSpace(text))
+				if err != nil {
+					return nil, errs.Errorf("value %v should be %s", value, "a whole number")
+				}
+				return number, nil
+			}
+		}
+	case KindFloat:
+		switch from {
+		case KindInteger, KindDuration, KindPercentage:
+			if number, ok := value.(float64); ok {
+				return number, nil
+			}
+		case KindString:
+			if text, ok := value.(string); ok {
+				number, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+				if err != nil {
+					return nil, errs.Errorf("value %v should be %s", value, "a number")
+				}
+				return number, nil
+			}
+		}
+	case KindMarkup:
+		if text, ok := value.(string); ok && from == KindString {
+			markup := rendering.NewMarkupContentFromLegacy(text)
+			return markup.ToMap(), nil
+		}
+	case KindDate:
+		if number, ok := value.(float64); ok && from == KindInstant {
+			return time.Unix(0, int64(number)).UTC().Format(DateLayout), nil
+		}
+		if from == KindString {
+			return coerceStoredValue(to, value)
+		}
+	case KindBoolean, KindLabel:
+		if from == KindString {
+			return coerceStoredValue(to, value)
+		}
+	case KindPercentage:
+		switch from {
+		case KindString, KindInteger, KindFloat:
+			return coerceStoredValue(to, value)
+		}
+	}
+	return nil, errs.Errorf("a %s value can't be converted to %s: %v", from, to, value)
+}
+
+// coerceStoredValue converts a stored value to the given kind like a literal of a filter, see coerceSimpleValue
+func coerceStoredValue(kind Kind, value interface{}) (interface{}, error) {
+	coerced, ok := coerceSimpleValue(kind, value)
+	if !ok {
+		return nil, errs.Errorf("value %v should be %s", value, expectedKind(SimpleType{Kind: kind}))
+	}
+	return coerced, nil
+}
@@ -0,0 +1,214 @@
+package workitem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/resource"
+
+	errs "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var changeDefinitions = FieldDefinitions{
+	SystemTitle: {Required: true, Type: SimpleType{Kind: KindString}},
+	"effort":    {Type: SimpleType{Kind: KindString}},
+	"labels":    {Type: ListType{SimpleType{Kind: KindList}, SimpleType{Kind: KindString}}},
+}
+
+func copyChangeDefinitions() FieldDefinitions {
+	result := FieldDefinitions{}
+	for name, definition := range changeDefinitions {
+		result[name] = definition
+	}
+	return result
+}
+
+func TestApplyFieldChanges(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	definitions := copyChangeDefinitions()
+	points := FieldDefinition{Type: SimpleType{Kind: KindFloat}}
+	// when
+	_, err := FieldChange{Op: FieldChangeAdd, Field: "points", Definition: &points}.apply(definitions)
+	require.Nil(t, err)
+	_, err = FieldChange{Op: FieldChangeRename, Field: "effort", NewName: "estimate"}.apply(definitions)
+	require.Nil(t, err)
+	previous, err := FieldChange{Op: FieldChangeRetype, Field: "estimate", Definition: &points}.apply(definitions)
+	require.Nil(t, err)
+	_, err = FieldChange{Op: FieldChangeRemove, Field: "labels"}.apply(definitions)
+	require.Nil(t, err)
+	// then
+	assert.Equal(t, changeDefinitions["effort"], *previous)
+	assert.Equal(t, FieldDefinitions{
+		SystemTitle: changeDefinitions[SystemTitle],
+		"points":    points,
+		"estimate":  points,
+	}, definitions)
+}
+
+func TestApplyInvalidFieldChanges(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	required := FieldDefinition{Required: true, Type: SimpleType{Kind: KindString}}
+	searchable := FieldDefinition{Searchable: true, Type: SimpleType{Kind: KindInteger}}
+	unique := FieldDefinition{Type: SimpleType{Kind: KindString}, Constraints: &FieldConstraints{Unique: true}}
+	for _, change := range []FieldChange{
+		{Op: FieldChangeRemove, Field: SystemTitle},
+		{Op: FieldChangeRemove, Field: "unknown"},
+		{Op: FieldChangeAdd, Field: "effort", Definition: &required, Value: "1h"},
+		{Op: FieldChangeAdd, Field: "points"},
+		{Op: FieldChangeAdd, Field: "points", Definition: &required},
+		{Op: FieldChangeAdd, Field: "points", Definition: &searchable},
+		{Op: FieldChangeAdd, Field: "key", Definition: &unique, Value: "KEY-1"},
+		{Op: FieldChangeRename, Field: "effort", NewName: "labels"},
+		{Op: FieldChangeRename, Field: "effort", NewName: SystemState},
+		{Op: FieldChangeRetype, Field: "effort"},
+		{Op: "move", Field: "effort"},
+	} {
+		// when
+		definitions := copyChangeDefinitions()
+		_, err := change.apply(definitions)
+		// then
+		require.NotNil(t, err, "change %+v", change)
+		assert.IsType(t, errors.BadParameterError{}, errs.Cause(err), "change %+v", change)
+		assert.Equal(t, changeDefinitions, definitions, "change %+v", change)
+	}
+}
+
+func TestMigrateFieldChanges(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	fields := Fields{SystemTitle: "title", "effort": "3", "labels": []interface{}{"a"}}
+	points := FieldDefinition{Type: SimpleType{Kind: KindFloat}}
+	// when
+	added, err := FieldChange{Op: FieldChangeAdd, Field: "points", Definition: &points, Value: float64(1)}.migrate(FieldDefinition{}, fields)
+	require.Nil(t, err)
+	renamed, err := FieldChange{Op: FieldChangeRename, Field: "effort", NewName: "estimate"}.migrate(changeDefinitions["effort"], fields)
+	require.Nil(t, err)
+	retyped, err := FieldChange{Op: FieldChangeRetype, Field: "estimate", Definition: &points}.migrate(changeDefinitions["effort"], fields)
+	require.Nil(t, err)
+	removed, err := FieldChange{Op: FieldChangeRemove, Field: "missing"}.migrate(FieldDefinition{}, fields)
+	require.Nil(t, err)
+	// then
+	assert.True(t, added)
+	assert.True(t, renamed)
+	assert.True(t, retyped)
+	assert.False(t, removed)
+	assert.Equal(t, Fields{SystemTitle: "title", "points": float64(1), "estimate": float64(3), "labels": []interface{}{"a"}}, fields)
+}
+
+func TestMigrateFieldChangeKeepsExistingValue(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	fields := Fields{"points": float64(5)}
+	points := FieldDefinition{Type: SimpleType{Kind: KindFloat}}
+	// when
+	added, err := FieldChange{Op: FieldChangeAdd, Field: "points", Definition: &points, Value: float64(1)}.migrate(FieldDefinition{}, fields)
+	// then
+	require.Nil(t, err)
+	assert.False(t, added)
+	assert.Equal(t, float64(5), fields["points"])
+}
+
+func TestMigrateFieldChangeFailsForRequiredField(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	// given
+	fields := Fields{}
+	required := FieldDefinition{Required: true, Type: SimpleType{Kind: KindString}}
+	// when
+	_, err := FieldChange{Op: FieldChangeRetype, Field: "effort", Definition: &required}.migrate(changeDefinitions["effort"], fields)
+	// then
+	assert.NotNil(t, err)
+}
+
+func TestConvertStoredValue(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	stringType := SimpleType{Kind: KindString}
+	integerType := SimpleType{Kind: KindInteger}
+	floatType := SimpleType{Kind: KindFloat}
+	markupType := SimpleType{Kind: KindMarkup}
+	stringList := ListType{SimpleType{Kind: KindList}, stringType}
+	integerList := ListType{SimpleType{Kind: KindList}, integerType}
+	enumType := EnumType{SimpleType{Kind: KindEnum}, stringType, []interface{}{"low", "high"}}
+	booleanType := SimpleType{Kind: KindBoolean}
+	dateType := SimpleType{Kind: KindDate}
+	labelType := SimpleType{Kind: KindLabel}
+	percentageType := SimpleType{Kind: KindPercentage}
+	for _, test := range []struct {
+		from     FieldType
+		to       FieldType
+		value    interface{}
+		expected interface{}
+	}{
+		{stringType, integerType, " 3", 3},
+		{stringType, floatType, "2.5", 2.5},
+		{integerType, stringType, float64(3), "3"},
+		{floatType, stringType, 2.5, "2.5"},
+		{floatType, integerType, float64(2), 2},
+		{integerType, floatType, float64(2), float64(2)},
+		{stringType, markupType, "text", map[string]interface{}{"content": "text", "markup": "PlainText"}},
+		{markupType, stringType, map[string]interface{}{"content": "text", "markup": "Markdown"}, "text"},
+		{stringType, stringList, "a", []interface{}{"a"}},
+		{stringList, stringType, []interface{}{"a"}, "a"},
+		{stringList, stringType, []interface{}{}, nil},
+		{stringList, integerList, []interface{}{"1", "2"}, []interface{}{1, 2}},
+		{stringType, enumType, "low", "low"},
+		{enumType, stringType, "high", "high"},
+		{integerType, stringType, nil, nil},
+		{stringType, booleanType, "true", true},
+		{booleanType, stringType, false, "false"},
+		{stringType, dateType, "2017-01-31", "2017-01-31"},
+		{SimpleType{Kind: KindInstant}, dateType, float64(time.Date(2017, 1, 31, 12, 0, 0, 0, time.UTC).UnixNano()), "2017-01-31"},
+		{dateType, stringType, "2017-01-31", "2017-01-31"},
+		{stringList, ListType{SimpleType{Kind: KindList}, labelType}, []interface{}{" needs  review"}, []interface{}{"needs review"}},
+		{labelType, stringType, "needs review", "needs review"},
+		{integerType, percentageType, float64(50), float64(50)},
+		{stringType, percentageType, "12.5", 12.5},
+		{percentageType, floatType, 12.5, 12.5},
+	} {
+		// when
+		converted, err := convertStoredValue(test.from, test.to, test.value)
+		// then
+		require.Nil(t, err, "converting %v from %v to %v", test.value, test.from, test.to)
+		assert.Equal(t, test.expected, converted, "converting %v from %v to %v", test.value, test.from, test.to)
+	}
+}
+
+func TestConvertStoredValueFails(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+	stringType := SimpleType{Kind: KindString}
+	integerType := SimpleType{Kind: KindInteger}
+	stringList := ListType{SimpleType{Kind: KindList}, stringType}
+	enumType := EnumType{SimpleType{Kind: KindEnum}, stringType, []interface{}{"low", "high"}}
+	for _, test := range []struct {
+		from  FieldType
+		to    FieldType
+		value interface{}
+	}{
+		{stringType, integerType, "three"},
+		{SimpleType{Kind: KindFloat}, integerType, 2.5},
+		{stringType, SimpleType{Kind: KindURL}, "not a url"},
+		{stringType, enumType, "medium"},
+		{stringList, stringType, []interface{}{"a", "b"}},
+		{SimpleType{Kind: KindUser}, SimpleType{Kind: KindIteration}, "id"},
+		{stringType, SimpleType{Kind: KindBoolean}, "maybe"},
+		{stringType, SimpleType{Kind: KindDate}, "tomorrow"},
+		{stringType, SimpleType{Kind: KindLabel}, " "},
+		{integerType, SimpleType{Kind: KindPercentage}, float64(150)},
+		{SimpleType{Kind: KindBoolean}, integerType, true},
+	} {
+		// when
+		_, err := convertStoredValue(test.from, test.to, test.value)
+		// then
+		assert.NotNil(t, err, "converting %v from %v to %v", test.value, test.from, test.to)
+	}
+}
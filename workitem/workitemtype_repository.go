@@ -8,6 +8,7 @@ import (
 
 	"github.com/almighty/almighty-core/app"
 	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/gormsupport"
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/rest"
 	"github.com/almighty/almighty-core/space"
@@ -20,11 +21,15 @@ import (
 
 var cache = NewWorkItemTypeCache()
 
+// migrationBatchSize is the number of work items loaded at once while the fields of their type change
+const migrationBatchSize = 500
+
 // WorkItemTypeRepository encapsulates storage & retrieval of work item types
 type WorkItemTypeRepository interface {
 	Load(ctx context.Context, id uuid.UUID) (*app.WorkItemTypeSingle, error)
 	Create(ctx context.Context, spaceID uuid.UUID, id *uuid.UUID, extendedTypeID *uuid.UUID, name string, description *string, icon string, fields map[string]app.FieldDefinition) (*app.WorkItemTypeSingle, error)
 	List(ctx context.Context, start *int, length *int) (*app.WorkItemTypeList, error)
+	Update(ctx context.Context, id uuid.UUID, version int, changes []*app.FieldChange, modifierID uuid.UUID, dryRun bool) (*app.WorkItemTypeUpdate, error)
 }
 
 // NewWorkItemTypeRepository creates a wi type repository based on gorm
@@ -59,6 +64,7 @@ func (r *GormWorkItemTypeRepository) LoadTypeFromDB(ctx context.Context, id uuid
 		}, "Work item type doesn't exist in the cache. Loading from DB...")
 		res = WorkItemType{}
 
+		generation := cache.Generation()
 		db := r.db.Model(&res).Where("id=?", id).First(&res)
 		if db.RecordNotFound() {
 			log.Error(ctx, map[string]interface{}{
@@ -69,11 +75,358 @@ func (r *GormWorkItemTypeRepository) LoadTypeFromDB(ctx context.Context, id uuid
 		if err := db.Error; err != nil {
 			return nil, errors.NewInternalError(err.Error())
 		}
-		cache.Put(res)
+		cache.PutIfCurrent(res, generation)
 	}
 	return &res, nil
 }
 
+// LoadTypesFromDB returns the work item types with the given ids, taking the cached ones from the cache
+// and loading the others with a single query
+// returns NotFoundError if one of the work item types doesn't exist, InternalError
+func (r *GormWorkItemTypeRepository) LoadTypesFromDB(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*WorkItemType, error) {
+	result := make(map[uuid.UUID]*WorkItemType, len(ids))
+	missing := []uuid.UUID{}
+	for _, id := range ids {
+		if _, done := result[id]; done {
+			continue
+		}
+		if wit, ok := cache.Get(id); ok {
+			result[id] = &wit
+			continue
+		}
+		// reserve the entry so that duplicate ids are loaded once
+		result[id] = nil
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	log.Info(ctx, map[string]interface{}{
+		"witIDs": missing,
+	}, "Work item types don't exist in the cache. Loading from DB...")
+	generation := cache.Generation()
+	var rows []WorkItemType
+	if err := r.db.Where("id in (?)", missing).Find(&rows).Error; err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	for index := range rows {
+		wit := &rows[index]
+		result[wit.ID] = wit
+		cache.PutIfCurrent(*wit, generation)
+	}
+	for _, id := range missing {
+		if result[id] == nil {
+			log.Error(ctx, map[string]interface{}{
+				"witID": id,
+			}, "work item type not found")
+			return nil, errors.NewNotFoundError("work item type", id.String())
+		}
+	}
+	return result, nil
+}
+
+// Save updates the given work item type in storage and removes it from the cache of work item types
+// returns InternalError
+func (r *GormWorkItemTypeRepository) Save(ctx context.Context, wit *WorkItemType) error {
+	r.invalidate(wit.ID)
+	if err := r.db.Save(wit).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{"witID": wit.ID}, "Work item type updated successfully!")
+	return nil
+}
+
+// Update applies the given changes of the fields to the work item type in the given order and migrates the values
+// of the fields in the work items of the type. The previous and the new definition are stored as versions of the
+// type. With dryRun nothing is stored, the result reports how many work items each change affects and how many
+// can't be migrated, e.g. because a value can't be converted to a new field type. Work items of subtypes are not
+// migrated, a subtype has its own copy of the fields.
+// The type is stored before the work items are migrated in batches, so that they are saved with the new definition
+// of the type. A migrated value of a label field which isn't a label of the space of the work item fails the
+// update, in a dry run as well, and so do values of a field made unique which several work items of a space share.
+// The update has to run in a transaction which is rolled back if the update fails.
+// returns NotFoundError, VersionConflictError, BadParameterError or InternalError
+func (r *GormWorkItemTypeRepository) Update(ctx context.Context, id uuid.UUID, version int, changes []*app.FieldChange, modifierID uuid.UUID, dryRun bool) (*app.WorkItemTypeUpdate, error) {
+	wit := WorkItemType{}
+	db := r.db.Where("id = ?", id).First(&wit)
+	if db.RecordNotFound() {
+		return nil, errors.NewNotFoundError("work item type", id.String())
+	}
+	if err := db.Error; err != nil {
+		return nil, errors.NewInternalError(err.Error())
+	}
+	if wit.Version != version {
+		return nil, errors.NewVersionConflictError("version conflict")
+	}
+
+	definitions := FieldDefinitions{}
+	for name, definition := range wit.Fields {
+		definitions[name] = definition
+	}
+	reports := make([]FieldChangeReport, len(changes))
+	previous := make([]FieldDefinition, len(changes))
+	for index, c := range changes {
+		change, err := convertFieldChangeToModel(*c)
+		if err != nil {
+			return nil, errs.WithStack(err)
+		}
+		definition, err := change.apply(definitions)
+		if err != nil {
+			return nil, errs.WithStack(err)
+		}
+		reports[index].FieldChange = *change
+		previous[index] = *definition
+	}
+
+	stored := wit.Fields
+	wit.Fields = definitions
+	refreshSearch := searchableChanged(stored, definitions)
+	if !dryRun {
+		if err := r.storeVersion(ctx, wit.ID, version, stored, definitions, reports, modifierID); err != nil {
+			return nil, errs.WithStack(err)
+		}
+		wit.Version = version + 1
+		r.invalidate(wit.ID)
+		db = r.db.Where("version = ?", version).Save(&wit)
+		if err := db.Error; err != nil {
+			return nil, errors.NewInternalError(err.Error())
+		}
+		if db.RowsAffected == 0 {
+			return nil, errors.NewVersionConflictError("version conflict")
+		}
+	}
+
+	failed := false
+	migrated := 0
+	var last uint64
+	for {
+		var batch []WorkItem
+		if err := r.db.Where("type = ? AND id > ?", id, last).Order("id").Limit(migrationBatchSize).Find(&batch).Error; err != nil {
+			return nil, errors.NewInternalError(err.Error())
+		}
+		if len(batch) == 0 {
+			break
+		}
+		last = batch[len(batch)-1].ID
+		var changedItems []WorkItem
+		var unchanged []uint64
+		for _, wi := range batch {
+			fields := Fields{}
+			for name, value := range wi.Fields {
+				fields[name] = value
+			}
+			changed := false
+			for index := range reports {
+				affected, err := reports[index].migrate(previous[index], fields)
+				if err != nil {
+					log.Info(ctx, map[string]interface{}{
+						"wiID":  wi.ID,
+						"field": reports[index].Field,
+						"err":   err,
+					}, "unable to migrate the work item")
+					reports[index].Failed++
+					failed = true
+				} else if affected {
+					reports[index].Affected++
+					changed = true
+				}
+			}
+			if changed {
+				migratedItem := wi
+				migratedItem.Fields = fields
+				if err := checkLabels(ctx, r.db, &wit, migratedItem, wi.Fields); err != nil {
+					return nil, errs.WithStack(err)
+				}
+				wi.Fields = fields
+				changedItems = append(changedItems, wi)
+			} else if refreshSearch {
+				unchanged = append(unchanged, wi.ID)
+			}
+		}
+		// once a work item can't be migrated the update fails, the remaining work items are only counted
+		if !dryRun && !failed {
+			if err := r.migrateWorkItems(ctx, changedItems, modifierID); err != nil {
+				return nil, errs.WithStack(err)
+			}
+			if err := r.refreshSearch(ctx, unchanged); err != nil {
+				return nil, errs.WithStack(err)
+			}
+		}
+		migrated += len(changedItems)
+	}
+
+	if !dryRun {
+		for _, report := range reports {
+			if report.Failed > 0 {
+				return nil, errors.NewBadParameterError("field", report.Field).Expected(fmt.Sprintf("a change all work items can be migrated to, %d work items can't be migrated", report.Failed))
+			}
+		}
+	}
+	// a dry run checks the stored values, which haven't been migrated
+	if err := r.checkUniqueValues(id, stored, definitions); err != nil {
+		return nil, errs.WithStack(err)
+	}
+	if !dryRun {
+		log.Info(ctx, map[string]interface{}{
+			"witID":    wit.ID,
+			"migrated": migrated,
+		}, "Work item type updated successfully!")
+	}
+
+	result := app.WorkItemTypeUpdate{
+		Data: &app.WorkItemTypeData{},
+		Meta: &app.WorkItemTypeUpdateMeta{
+			DryRun:  dryRun,
+			Changes: make([]*app.FieldChangeReport, len(reports)),
+		},
+	}
+	*result.Data = convertTypeFromModels(goa.ContextRequest(ctx), &wit)
+	for index, report := range reports {
+		result.Meta.Changes[index] = &app.FieldChangeReport{
+			Op:       report.Op,
+			Field:    report.Field,
+			Affected: report.Affected,
+			Failed:   report.Failed,
+		}
+	}
+	return &result, nil
+}
+
+// checkUniqueValues checks the migrated values of the fields which are unique after a change but weren't before,
+// the work items of the type within a space must not share a value of such a field. Like the checks of the work
+// item repository it takes the advisory lock on the field in each space, so that concurrent transactions storing
+// a value of the field wait until the transaction ends.
+// returns BadParameterError if they do, InternalError
+func (r *GormWorkItemTypeRepository) checkUniqueValues(id uuid.UUID, before FieldDefinitions, after FieldDefinitions) error {
+	for name, definition := range after {
+		if definition.Constraints == nil || !definition.Constraints.Unique {
+			continue
+		}
+		if previous, ok := before[name]; ok && previous.Constraints != nil && previous.Constraints.Unique {
+			continue
+		}
+		// the locks the checks of the work item repository take for the field in each space, in a fixed order
+		var spaceIDs []uuid.UUID
+		if err := r.db.Model(&WorkItem{}).Where("type = ?", id).Order("space_id").Pluck("distinct space_id", &spaceIDs).Error; err != nil {
+			return errors.NewInternalError(err.Error())
+		}
+		for _, spaceID := range spaceIDs {
+			lock := fmt.Sprintf("unique:%s:%s:%s", spaceID, id, name)
+			if err := r.db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lock).Error; err != nil {
+				return errors.NewInternalError(err.Error())
+			}
+		}
+		var duplicates int
+		// json values are compared like the containment check of the work item repository does, so 3 and 3.0 are the same
+		statement := "select count(*) from (select 1 from " + WorkItem{}.TableName() +
+			" where type = ? and deleted_at is null and fields->>(?::text) is not null group by space_id, fields->(?::text) having count(*) > 1) as duplicates"
+		if err := r.db.Raw(statement, id, name, name).Row().Scan(&duplicates); err != nil {
+			return errors.NewInternalError(err.Error())
+		}
+		if duplicates > 0 {
+			return errors.NewBadParameterError("field", name).Expected(fmt.Sprintf("a unique field whose values differ within each space, %d values are shared by several work items", duplicates))
+		}
+	}
+	return nil
+}
+
+// searchableChanged tells whether a field which is defined before and after a change is searchable in only one of
+// the definitions, then the texts the work items of the type are searched by change even if their values don't
+func searchableChanged(before FieldDefinitions, after FieldDefinitions) bool {
+	for name, definition := range after {
+		if previous, ok := before[name]; ok && previous.Searchable != definition.Searchable {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshSearch recomputes the search vectors of the given work items, whose values weren't migrated, from the
+// stored definition of their type and notifies their changes, so that other search indexes pick up the texts too
+// returns InternalError
+func (r *GormWorkItemTypeRepository) refreshSearch(ctx context.Context, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	// the SQL function is the one the trigger on work items uses
+	statement := "update " + WorkItem{}.TableName() + " set tsv = workitem_tsv(id, space_id, type, fields) where id in (?)"
+	if err := r.db.Exec(statement, ids).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	for _, id := range ids {
+		NotifyChanged(ctx, r.db, id)
+	}
+	return nil
+}
+
+// migrateWorkItems saves the migrated work items along with a revision of each
+// returns VersionConflictError if a work item changed concurrently, InternalError
+func (r *GormWorkItemTypeRepository) migrateWorkItems(ctx context.Context, workItems []WorkItem, modifierID uuid.UUID) error {
+	revisions := NewRevisionRepository(r.db)
+	for _, wi := range workItems {
+		version := wi.Version
+		wi.Version = version + 1
+		db := r.db.Where("version = ?", version).Save(&wi)
+		if err := db.Error; err != nil {
+			return errors.NewInternalError(err.Error())
+		}
+		if db.RowsAffected == 0 {
+			return errors.NewVersionConflictError("version conflict")
+		}
+		if err := revisions.Create(ctx, modifierID, RevisionTypeUpdate, wi); err != nil {
+			return errs.WithStack(err)
+		}
+		NotifyChanged(ctx, r.db, wi.ID)
+	}
+	return nil
+}
+
+// storeVersion stores the changed definition of the work item type with the given id and version as its next
+// version. The stored version is kept too if it isn't stored yet, i.e. when the type is updated the first time.
+// returns InternalError
+func (r *GormWorkItemTypeRepository) storeVersion(ctx context.Context, id uuid.UUID, version int, stored FieldDefinitions, changed FieldDefinitions, reports []FieldChangeReport, modifierID uuid.UUID) error {
+	var count int
+	if err := r.db.Model(&WorkItemTypeVersion{}).Where("work_item_type_id = ? AND version = ?", id, version).Count(&count).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	if count == 0 {
+		initial := WorkItemTypeVersion{
+			WorkItemTypeID: id,
+			Version:        version,
+			Fields:         stored,
+		}
+		if err := r.db.Create(&initial).Error; err != nil {
+			return errors.NewInternalError(err.Error())
+		}
+	}
+	changes := make(FieldChanges, len(reports))
+	for index, report := range reports {
+		changes[index] = report.FieldChange
+	}
+	next := WorkItemTypeVersion{
+		WorkItemTypeID: id,
+		Version:        version + 1,
+		ModifierID:     &modifierID,
+		Fields:         changed,
+		Changes:        changes,
+	}
+	if err := r.db.Create(&next).Error; err != nil {
+		return errors.NewInternalError(err.Error())
+	}
+	log.Debug(ctx, map[string]interface{}{"witID": id, "version": next.Version}, "Work item type version stored")
+	return nil
+}
+
+// invalidate removes the work item type from the cache before it is changed and again once the transaction
+// of the change ends. Otherwise another transaction could cache the type as it was before the change was
+// committed, or the transaction itself could cache a change which is rolled back.
+func (r *GormWorkItemTypeRepository) invalidate(id uuid.UUID) {
+	cache.Invalidate(id)
+	gormsupport.AfterTransaction(r.db, func() {
+		cache.Invalidate(id)
+	})
+}
+
 // ClearGlobalWorkItemTypeCache removes all work items from the global cache
 func ClearGlobalWorkItemTypeCache() {
 	cache.Clear()
@@ -118,11 +471,20 @@ func (r *GormWorkItemTypeRepository) Create(ctx context.Context, spaceID uuid.UU
 		if err != nil {
 			return nil, errs.WithStack(err)
 		}
+		constraints, err := convertFieldConstraintsToModel(ct, definition.Constraints)
+		if err != nil {
+			return nil, errs.WithStack(err)
+		}
 		converted := FieldDefinition{
 			Label:       definition.Label,
 			Description: definition.Description,
 			Required:    definition.Required,
 			Type:        ct,
+			Searchable:  definition.Searchable != nil && *definition.Searchable,
+			Constraints: constraints,
+		}
+		if converted.Searchable && ct.GetKind() != KindString && ct.GetKind() != KindMarkup {
+			return nil, errors.NewBadParameterError("searchable", field).Expected("a string or markup field")
 		}
 		if exists && !compatibleFields(existing, converted) {
 			return nil, fmt.Errorf("incompatible change for field %s", field)
@@ -141,6 +503,8 @@ func (r *GormWorkItemTypeRepository) Create(ctx context.Context, spaceID uuid.UU
 		SpaceID:     spaceID,
 	}
 
+	// a type with the same ID may have been cached before, e.g. by a transaction that was rolled back
+	r.invalidate(created.ID)
 	if err := r.db.Create(&created).Error; err != nil {
 		return nil, errors.NewInternalError(err.Error())
 	}
@@ -188,6 +552,9 @@ func compatibleFields(existing FieldDefinition, new FieldDefinition) bool {
 	if existing.Required != new.Required {
 		return false
 	}
+	if !reflect.DeepEqual(existing.Constraints, new.Constraints) {
+		return false
+	}
 	return reflect.DeepEqual(existing.Type, new.Type)
 }
 
@@ -216,6 +583,11 @@ func convertTypeFromModels(request *goa.RequestData, t *WorkItemType) app.WorkIt
 			Label:       def.Label,
 			Description: def.Description,
 			Type:        &ct,
+			Constraints: convertFieldConstraintsFromModel(def.Type, def.Constraints),
+		}
+		if def.Searchable {
+			searchable := true
+			converted.Attributes.Fields[name].Searchable = &searchable
 		}
 	}
 	return converted
@@ -250,7 +622,8 @@ func convertAnyToKind(any interface{}) (*Kind, error) {
 func convertStringToKind(k string) (*Kind, error) {
 	kind := Kind(k)
 	switch kind {
-	case KindString, KindInteger, KindFloat, KindInstant, KindDuration, KindURL, KindWorkitemReference, KindUser, KindEnum, KindList, KindIteration, KindMarkup, KindArea, KindCodebase:
+	case KindString, KindInteger, KindFloat, KindInstant, KindDuration, KindURL, KindWorkitemReference, KindUser, KindEnum, KindList, KindIteration, KindMarkup, KindArea, KindCodebase,
+		KindBoolean, KindDate, KindLabel, KindPercentage:
 		return &kind, nil
 	}
 	return nil, fmt.Errorf("Not a simple type")
@@ -302,11 +675,17 @@ func TEMPConvertFieldTypesToModel(fields map[string]app.FieldDefinition) (map[st
 		if err != nil {
 			return nil, errs.WithStack(err)
 		}
+		constraints, err := convertFieldConstraintsToModel(ct, definition.Constraints)
+		if err != nil {
+			return nil, errs.WithStack(err)
+		}
 		converted := FieldDefinition{
 			Required:    definition.Required,
 			Label:       definition.Label,
 			Description: definition.Description,
 			Type:        ct,
+			Searchable:  definition.Searchable != nil && *definition.Searchable,
+			Constraints: constraints,
 		}
 		allFields[field] = converted
 	}
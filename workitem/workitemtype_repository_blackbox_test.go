@@ -10,16 +10,19 @@ import (
 	"testing"
 
 	"github.com/almighty/almighty-core/app"
+	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/gormsupport"
 	"github.com/almighty/almighty-core/gormsupport/cleaner"
 	"github.com/almighty/almighty-core/migration"
 	"github.com/almighty/almighty-core/models"
 	"github.com/almighty/almighty-core/resource"
 	"github.com/almighty/almighty-core/space"
+	testsupport "github.com/almighty/almighty-core/test"
 	"github.com/almighty/almighty-core/workitem"
 
 	"github.com/goadesign/goa"
 	"github.com/jinzhu/gorm"
+	errs "github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -101,6 +104,36 @@ func (s *workItemTypeRepoBlackBoxTest) TestCreateLoadWIT() {
 	assert.Nil(s.T(), field.Type.Values)
 }
 
+func (s *workItemTypeRepoBlackBoxTest) TestCreateLoadSearchableField() {
+	searchable := true
+	wit, err := s.repo.Create(s.ctx, space.SystemSpace, nil, nil, "searchable", nil, "fa-bomb", map[string]app.FieldDefinition{
+		"notes": {
+			Type:       &app.FieldType{Kind: string(workitem.KindMarkup)},
+			Searchable: &searchable,
+		},
+		"points": {
+			Type: &app.FieldType{Kind: string(workitem.KindInteger)},
+		},
+	})
+	require.Nil(s.T(), err)
+
+	wit2, err := s.repo.Load(s.ctx, *wit.Data.ID)
+	require.Nil(s.T(), err)
+	require.NotNil(s.T(), wit2.Data.Attributes.Fields["notes"].Searchable)
+	assert.True(s.T(), *wit2.Data.Attributes.Fields["notes"].Searchable)
+	assert.Nil(s.T(), wit2.Data.Attributes.Fields["points"].Searchable)
+
+	// only text can be searched
+	_, err = s.repo.Create(s.ctx, space.SystemSpace, nil, nil, "searchable points", nil, "fa-bomb", map[string]app.FieldDefinition{
+		"points": {
+			Type:       &app.FieldType{Kind: string(workitem.KindInteger)},
+			Searchable: &searchable,
+		},
+	})
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}
+
 func (s *workItemTypeRepoBlackBoxTest) TestCreateLoadWITWithList() {
 	bt := "string"
 	wit, err := s.repo.Create(s.ctx, space.SystemSpace, nil, nil, "foo_bar", nil, "fa-bomb", map[string]app.FieldDefinition{
@@ -169,3 +202,229 @@ func (s *workItemTypeRepoBlackBoxTest) TestDoNotCreateWITWithMissingBaseType() {
 	require.NotNil(s.T(), err)
 	require.Nil(s.T(), extendedWit)
 }
+
+func (s *workItemTypeRepoBlackBoxTest) TestLoadTypesFromDB() {
+	// given
+	repo := workitem.NewWorkItemTypeRepository(s.DB)
+	wit, err := repo.Create(s.ctx, space.SystemSpace, nil, nil, "batched", nil, "fa-bomb", map[string]app.FieldDefinition{})
+	require.Nil(s.T(), err)
+	// cache one of the types
+	_, err = repo.LoadTypeFromDB(s.ctx, workitem.SystemBug)
+	require.Nil(s.T(), err)
+	// when
+	wits, err := repo.LoadTypesFromDB(s.ctx, []uuid.UUID{*wit.Data.ID, workitem.SystemBug, *wit.Data.ID})
+	// then
+	require.Nil(s.T(), err)
+	require.Len(s.T(), wits, 2)
+	assert.Equal(s.T(), "batched", wits[*wit.Data.ID].Name)
+	assert.Equal(s.T(), "Bug", wits[workitem.SystemBug].Name)
+}
+
+func (s *workItemTypeRepoBlackBoxTest) TestLoadTypesFromDBNotFound() {
+	// when
+	_, err := workitem.NewWorkItemTypeRepository(s.DB).LoadTypesFromDB(s.ctx, []uuid.UUID{workitem.SystemBug, uuid.NewV4()})
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.NotFoundError{}, errs.Cause(err))
+}
+
+func (s *workItemTypeRepoBlackBoxTest) TestSaveInvalidatesCache() {
+	// given
+	repo := workitem.NewWorkItemTypeRepository(s.DB)
+	created, err := repo.Create(s.ctx, space.SystemSpace, nil, nil, "cached", nil, "fa-bomb", map[string]app.FieldDefinition{})
+	require.Nil(s.T(), err)
+	wit, err := repo.LoadTypeFromDB(s.ctx, *created.Data.ID)
+	require.Nil(s.T(), err)
+	// when
+	wit.Icon = "fa-bug"
+	err = repo.Save(s.ctx, wit)
+	// then
+	require.Nil(s.T(), err)
+	loaded, err := repo.LoadTypesFromDB(s.ctx, []uuid.UUID{wit.ID})
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "fa-bug", loaded[wit.ID].Icon)
+}
+
+// createTypeWithEfforts creates a work item type with a string field "effort" and a work item with each of the
+// given efforts
+// returns the type, the ids of the work items and the identity which created them
+func (s *workItemTypeRepoBlackBoxTest) createTypeWithEfforts(efforts ...string) (*app.WorkItemTypeSingle, []string, uuid.UUID) {
+	wit, err := s.repo.Create(s.ctx, space.SystemSpace, nil, nil, "evolving", nil, "fa-bomb", map[string]app.FieldDefinition{
+		"effort": {
+			Type: &app.FieldType{Kind: string(workitem.KindString)},
+		},
+	})
+	require.Nil(s.T(), err)
+	identity, err := testsupport.CreateTestIdentity(s.DB, "jdoe", "test")
+	require.Nil(s.T(), err)
+	ids := make([]string, len(efforts))
+	for index, effort := range efforts {
+		wi, err := workitem.NewWorkItemRepository(s.DB).Create(s.ctx, space.SystemSpace, *wit.Data.ID, map[string]interface{}{"effort": effort}, identity.ID)
+		require.Nil(s.T(), err)
+		ids[index] = wi.ID
+	}
+	return wit, ids, identity.ID
+}
+
+func (s *workItemTypeRepoBlackBoxTest) TestUpdateMigratesWorkItems() {
+	// given
+	wit, ids, modifierID := s.createTypeWithEfforts("3", "5")
+	newName := "estimate"
+	changes := []*app.FieldChange{
+		{Op: workitem.FieldChangeRename, Field: "effort", NewName: &newName},
+		{Op: workitem.FieldChangeRetype, Field: "estimate", Definition: &app.FieldDefinition{
+			Label: "Estimate",
+			Type:  &app.FieldType{Kind: string(workitem.KindFloat)},
+		}},
+		{Op: workitem.FieldChangeAdd, Field: "points", Value: 1.5, Definition: &app.FieldDefinition{
+			Required: true,
+			Label:    "Points",
+			Type:     &app.FieldType{Kind: string(workitem.KindFloat)},
+		}},
+	}
+	// when
+	updated, err := s.repo.Update(s.ctx, *wit.Data.ID, 0, changes, modifierID, false)
+	// then
+	require.Nil(s.T(), err)
+	assert.False(s.T(), updated.Meta.DryRun)
+	require.Len(s.T(), updated.Meta.Changes, 3)
+	for _, report := range updated.Meta.Changes {
+		assert.Equal(s.T(), 2, report.Affected, "change of %s", report.Field)
+		assert.Equal(s.T(), 0, report.Failed, "change of %s", report.Field)
+	}
+	assert.Equal(s.T(), 1, updated.Data.Attributes.Version)
+	loaded, err := s.repo.Load(s.ctx, *wit.Data.ID)
+	require.Nil(s.T(), err)
+	assert.Nil(s.T(), loaded.Data.Attributes.Fields["effort"])
+	require.NotNil(s.T(), loaded.Data.Attributes.Fields["estimate"])
+	assert.Equal(s.T(), string(workitem.KindFloat), loaded.Data.Attributes.Fields["estimate"].Type.Kind)
+	wi, err := workitem.NewWorkItemRepository(s.DB).Load(s.ctx, ids[0])
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, wi.Version)
+	assert.Equal(s.T(), float64(3), wi.Fields["estimate"])
+	assert.Equal(s.T(), 1.5, wi.Fields["points"])
+	// and both versions of the type are stored
+	var versions []workitem.WorkItemTypeVersion
+	require.Nil(s.T(), s.DB.Where("work_item_type_id = ?", *wit.Data.ID).Order("version").Find(&versions).Error)
+	require.Len(s.T(), versions, 2)
+	assert.Nil(s.T(), versions[0].Changes)
+	assert.Contains(s.T(), versions[0].Fields, "effort")
+	assert.Len(s.T(), versions[1].Changes, 3)
+	assert.Contains(s.T(), versions[1].Fields, "estimate")
+}
+
+func (s *workItemTypeRepoBlackBoxTest) TestUpdateSearchableField() {
+	// given
+	wit, ids, modifierID := s.createTypeWithEfforts("zanzibarianeffort")
+	searchable := true
+	changes := []*app.FieldChange{
+		{Op: workitem.FieldChangeRetype, Field: "effort", Definition: &app.FieldDefinition{
+			Type:       &app.FieldType{Kind: string(workitem.KindString)},
+			Searchable: &searchable,
+		}},
+	}
+	matches := func() int {
+		var count int
+		require.Nil(s.T(), s.DB.Model(&workitem.WorkItem{}).Where("tsv @@ to_tsquery('english', ?)", "zanzibarianeffort").Count(&count).Error)
+		return count
+	}
+	require.Equal(s.T(), 0, matches())
+	// when
+	updated, err := s.repo.Update(s.ctx, *wit.Data.ID, 0, changes, modifierID, false)
+	// then the search vector includes the value although it didn't change
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, updated.Meta.Changes[0].Affected)
+	assert.Equal(s.T(), 1, matches())
+	wi, err := workitem.NewWorkItemRepository(s.DB).Load(s.ctx, ids[0])
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, wi.Version)
+}
+
+func (s *workItemTypeRepoBlackBoxTest) TestUpdateDryRun() {
+	// given
+	wit, ids, modifierID := s.createTypeWithEfforts("3", "three", "")
+	changes := []*app.FieldChange{
+		{Op: workitem.FieldChangeRetype, Field: "effort", Definition: &app.FieldDefinition{
+			Type: &app.FieldType{Kind: string(workitem.KindInteger)},
+		}},
+	}
+	// when
+	updated, err := s.repo.Update(s.ctx, *wit.Data.ID, 0, changes, modifierID, true)
+	// then the work items which can't be migrated are reported
+	require.Nil(s.T(), err)
+	assert.True(s.T(), updated.Meta.DryRun)
+	require.Len(s.T(), updated.Meta.Changes, 1)
+	assert.Equal(s.T(), 1, updated.Meta.Changes[0].Affected)
+	assert.Equal(s.T(), 2, updated.Meta.Changes[0].Failed)
+	assert.Equal(s.T(), string(workitem.KindInteger), updated.Data.Attributes.Fields["effort"].Type.Kind)
+	// and nothing is stored
+	loaded, err := s.repo.Load(s.ctx, *wit.Data.ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, loaded.Data.Attributes.Version)
+	assert.Equal(s.T(), string(workitem.KindString), loaded.Data.Attributes.Fields["effort"].Type.Kind)
+	wi, err := workitem.NewWorkItemRepository(s.DB).Load(s.ctx, ids[0])
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "3", wi.Fields["effort"])
+}
+
+func (s *workItemTypeRepoBlackBoxTest) TestUpdateFailsForUnconvertibleValues() {
+	// given
+	wit, ids, modifierID := s.createTypeWithEfforts("3", "three")
+	changes := []*app.FieldChange{
+		{Op: workitem.FieldChangeRetype, Field: "effort", Definition: &app.FieldDefinition{
+			Type: &app.FieldType{Kind: string(workitem.KindInteger)},
+		}},
+	}
+	// when
+	_, err := s.repo.Update(s.ctx, *wit.Data.ID, 0, changes, modifierID, false)
+	// then
+	require.NotNil(s.T(), err)
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+	wi, err := workitem.NewWorkItemRepository(s.DB).Load(s.ctx, ids[0])
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), "3", wi.Fields["effort"])
+	assert.Equal(s.T(), 0, wi.Version)
+}
+
+func (s *workItemTypeRepoBlackBoxTest) TestUpdateToUniqueField() {
+	// given
+	unique := true
+	changes := []*app.FieldChange{
+		{Op: workitem.FieldChangeRetype, Field: "effort", Definition: &app.FieldDefinition{
+			Type:        &app.FieldType{Kind: string(workitem.KindString)},
+			Constraints: &app.FieldConstraints{Unique: &unique},
+		}},
+	}
+	shared, _, modifierID := s.createTypeWithEfforts("3", "5", "3")
+	distinct, _, _ := s.createTypeWithEfforts("3", "5")
+	for _, dryRun := range []bool{true, false} {
+		// when the work items share a value
+		_, err := s.repo.Update(s.ctx, *shared.Data.ID, 0, changes, modifierID, dryRun)
+		// then
+		require.NotNil(s.T(), err, "dry run %t", dryRun)
+		assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err), "dry run %t", dryRun)
+	}
+	// when the values differ
+	updated, err := s.repo.Update(s.ctx, *distinct.Data.ID, 0, changes, modifierID, false)
+	// then
+	require.Nil(s.T(), err)
+	assert.True(s.T(), *updated.Data.Attributes.Fields["effort"].Constraints.Unique)
+}
+
+func (s *workItemTypeRepoBlackBoxTest) TestUpdateFailures() {
+	// given
+	wit, _, modifierID := s.createTypeWithEfforts()
+	remove := []*app.FieldChange{{Op: workitem.FieldChangeRemove, Field: "effort"}}
+	// when the version is outdated
+	_, err := s.repo.Update(s.ctx, *wit.Data.ID, 1, remove, modifierID, false)
+	// then
+	assert.IsType(s.T(), errors.VersionConflictError{}, errs.Cause(err))
+	// when the type doesn't exist
+	_, err = s.repo.Update(s.ctx, uuid.NewV4(), 0, remove, modifierID, false)
+	// then
+	assert.IsType(s.T(), errors.NotFoundError{}, errs.Cause(err))
+	// when a system field is removed
+	_, err = s.repo.Update(s.ctx, *wit.Data.ID, 0, []*app.FieldChange{{Op: workitem.FieldChangeRemove, Field: workitem.SystemTitle}}, modifierID, false)
+	// then
+	assert.IsType(s.T(), errors.BadParameterError{}, errs.Cause(err))
+}